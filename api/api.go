@@ -0,0 +1,15 @@
+// Package api embeds the API's specifications: the OpenAPI document and the
+// JSON Schema of the rates payloads it references.
+package api
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI document, openapi.json.
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// RatesSchema is the JSON Schema of the rates payloads, rates.schema.json.
+//
+//go:embed rates.schema.json
+var RatesSchema []byte
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/veswatch/api/internal/dump"
+)
+
+// runDump runs the export or import command:
+//
+//	veswatch export [-format jsonl] [-o file]
+//	veswatch import [-format jsonl] [file]
+//
+// Both default to standard output and input.
+func runDump(command string, args []string, stores dump.Stores) error {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	format := fs.String("format", dump.FormatJSONL, "dump format")
+	var output *string
+	if command == "export" {
+		output = fs.String("o", "-", "output file, - for standard output")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != dump.FormatJSONL {
+		return fmt.Errorf("unknown format %q (expected %q)", *format, dump.FormatJSONL)
+	}
+
+	switch command {
+	case "export":
+		return export(stores, *output)
+	case "import":
+		if fs.NArg() > 1 {
+			return fmt.Errorf("expected at most one file, got %d", fs.NArg())
+		}
+		return importDump(stores, fs.Arg(0))
+	default:
+		return fmt.Errorf("unknown command %q (expected export or import)", command)
+	}
+}
+
+// export writes a dump to path, or standard output for "-".
+func export(stores dump.Stores, path string) error {
+	f := os.Stdout
+	if path != "-" {
+		var err error
+		if f, err = os.Create(path); err != nil {
+			return err
+		}
+	}
+
+	counts, err := stores.Export(f)
+	if f != os.Stdout {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Dump: Exported %v", counts)
+	return nil
+}
+
+// importDump restores a dump from path, or standard input if empty or "-".
+func importDump(stores dump.Stores, path string) error {
+	var r io.Reader = os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	counts, err := stores.Import(r)
+	if err != nil && len(counts) > 0 {
+		return fmt.Errorf("%w (imported %v before failing)", err, counts)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Dump: Imported %v", counts)
+	return nil
+}
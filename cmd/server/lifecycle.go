@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// component is a part of the server whose lifetime the lifecycle manages.
+// start may be nil for components that are running once created.
+type component struct {
+	name    string
+	timeout time.Duration
+	start   func(ctx context.Context) error
+	stop    func(ctx context.Context) error
+}
+
+// lifecycle starts components in the order they were added and stops them
+// in reverse, so each component outlives the ones depending on it. Every
+// step gets its own timeout; a component that doesn't stop in time is
+// abandoned so the rest still shut down.
+type lifecycle struct {
+	pending []component
+	running []component
+}
+
+// add registers a component. Components without a start function are
+// considered running.
+func (l *lifecycle) add(c component) {
+	if c.start == nil {
+		l.running = append(l.running, c)
+		return
+	}
+	l.pending = append(l.pending, c)
+}
+
+// Start starts the pending components in order. If one fails, the
+// components started so far are left running for Stop.
+func (l *lifecycle) Start() error {
+	for len(l.pending) > 0 {
+		c := l.pending[0]
+		l.pending = l.pending[1:]
+		if err := run(c.name, c.timeout, c.start); err != nil {
+			return fmt.Errorf("failed to start %s: %w", c.name, err)
+		}
+		l.running = append(l.running, c)
+	}
+	return nil
+}
+
+// Stop stops the running components in reverse order. It is safe to call
+// more than once.
+func (l *lifecycle) Stop() {
+	for len(l.running) > 0 {
+		c := l.running[len(l.running)-1]
+		l.running = l.running[:len(l.running)-1]
+		if c.stop == nil {
+			continue
+		}
+		if err := run(c.name, c.timeout, c.stop); err != nil {
+			log.Printf("Lifecycle: Failed to stop %s: %v", c.name, err)
+			continue
+		}
+		log.Printf("Lifecycle: Stopped %s", c.name)
+	}
+}
+
+// run calls fn with a context bounded by timeout, giving up on fn once the
+// timeout passes even if it ignores the context.
+func run(name string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%s timed out after %s", name, timeout)
+	}
+}
+
+// closer adapts a Close method to a component stop function.
+func closer(close func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return close()
+	}
+}
+
+// stopper adapts a Close or Stop method without a result to a component
+// stop function.
+func stopper(stop func()) func(context.Context) error {
+	return func(context.Context) error {
+		stop()
+		return nil
+	}
+}
+
+// every returns a component calling fn when it starts and then at every
+// interval until it's stopped, for work running without a scheduler.
+// Failures are logged.
+func every(name string, interval time.Duration, fn func() error) component {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	call := func() {
+		if err := fn(); err != nil {
+			log.Printf("Lifecycle: %s failed: %v", name, err)
+		}
+	}
+
+	return component{
+		name:    name,
+		timeout: storeTimeout,
+		start: func(context.Context) error {
+			call()
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-stop:
+						return
+					case <-ticker.C:
+						call()
+					}
+				}
+			}()
+			return nil
+		},
+		stop: func(context.Context) error {
+			close(stop)
+			<-done
+			return nil
+		},
+	}
+}
@@ -8,76 +8,886 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
+	"github.com/veswatch/api/internal/abuse"
+	"github.com/veswatch/api/internal/accesslog"
+	"github.com/veswatch/api/internal/analytics"
+	"github.com/veswatch/api/internal/apikey"
+	"github.com/veswatch/api/internal/backup"
+	"github.com/veswatch/api/internal/config"
+	"github.com/veswatch/api/internal/contract"
+	"github.com/veswatch/api/internal/dump"
 	httphandlers "github.com/veswatch/api/internal/http"
+	"github.com/veswatch/api/internal/lambda"
+	"github.com/veswatch/api/internal/metrics"
+	"github.com/veswatch/api/internal/mock"
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/internal/oidc"
+	"github.com/veswatch/api/internal/push"
 	"github.com/veswatch/api/internal/rates"
 	"github.com/veswatch/api/internal/scheduler"
 	"github.com/veswatch/api/internal/scraper"
+	"github.com/veswatch/api/internal/sheets"
+	"github.com/veswatch/api/internal/sink"
+	"github.com/veswatch/api/internal/snapshot"
+	"github.com/veswatch/api/internal/store"
+	"github.com/veswatch/api/internal/systemd"
+	"github.com/veswatch/api/internal/upgrade"
+)
+
+// sinkInterval is how often buffered rates are written to time-series sinks.
+const sinkInterval = 10 * time.Second
+
+// summaryInterval is how often the weekly and monthly summaries are
+// recomputed, on top of every daily close.
+const summaryInterval = time.Hour
+
+// analyticsInterval is how often request analytics are saved.
+const analyticsInterval = time.Minute
+
+// dailyAggregatesInterval is how often the TimescaleDB daily aggregates are
+// brought up to date.
+const dailyAggregatesInterval = 5 * time.Minute
+
+// syncInterval is how often instances sharing TimescaleDB exchange their
+// state through its snapshot.
+const syncInterval = time.Minute
+
+// Component start and stop timeouts.
+const (
+	// The scheduler starts with the initial fetch of every source
+	schedulerTimeout = 5 * time.Minute
+	httpTimeout      = 30 * time.Second
+	sinkTimeout      = 15 * time.Second
+	storeTimeout     = 5 * time.Second
+	// Stopping waits for a delivery in progress, e.g. a Web Push request
+	notifyTimeout = 20 * time.Second
 )
 
 func main() {
+	cfg := config.Load()
+
+	if cfg.GenerateVAPIDKeys {
+		privateKey, publicKey, err := push.GenerateVAPIDKeys()
+		if err != nil {
+			log.Fatalf("Failed to generate VAPID keys: %v", err)
+		}
+		fmt.Printf("VESWATCH_VAPID_PRIVATE_KEY=%s\n# Public key: %s\n", privateKey, publicKey)
+		return
+	}
+
 	log.Println("Starting VESWatch API Server...")
 
-	// Initialize scrapers
-	bcvScraper := scraper.NewBCVScraper()
-	binanceFetcher := scraper.NewBinanceFetcher()
+	// Components are stopped in reverse order of registration
+	var life lifecycle
+	defer life.Stop()
 
-	// Initialize rates service
-	ratesService := rates.NewService(bcvScraper, binanceFetcher)
+	// Initialize rates service with live scrapers or mock sources
+	registry := metrics.NewRegistry()
 
-	// Initialize scheduler
-	sched := scheduler.New(ratesService)
-	sched.Start()
+	var ratesService *rates.Service
+	var sim *mock.Simulator
+	if cfg.Mode == config.ModeSimulate {
+		sim = mock.NewSimulator(cfg.SimVolatility, uint64(cfg.SimSeed))
+	}
+	switch cfg.Mode {
+	case config.ModeMock:
+		log.Println("Mock mode: serving deterministic synthetic data, no outbound requests")
+		ratesService = rates.NewService(mock.BCVSource{}, mock.BinanceSource{}, mock.INPCSource{})
+		ratesService.AddParallelSource("mock_p2p", mock.ParallelSource{Premium: 0.004})
+		ratesService.AddParallelSource("mock_cambio", mock.ParallelSource{Premium: -0.003})
+		for i, region := range cfg.Binance.Regions {
+			ratesService.AddRegion(rates.Region(region), mock.ParallelSource{Premium: 0.01 * float64(i+1)})
+		}
+		if cfg.Zelle {
+			ratesService.SetZelle(mock.ZelleSource{})
+		}
+		if cfg.CashSource != nil {
+			ratesService.SetCashSource(mock.ParallelSource{Premium: -0.02})
+		}
+	case config.ModeSimulate:
+		log.Printf("Simulation mode: serving random-walk rates at %.1f%% daily volatility, no outbound requests", cfg.SimVolatility*100)
+		ratesService = rates.NewService(sim.BCV(), sim.Binance(), mock.INPCSource{})
+		ratesService.AddParallelSource("sim_p2p", sim.Parallel(0.004))
+		ratesService.AddParallelSource("sim_cambio", sim.Parallel(-0.003))
+		for i, region := range cfg.Binance.Regions {
+			ratesService.AddRegion(rates.Region(region), sim.Parallel(0.01*float64(i+1)))
+		}
+		if cfg.Zelle {
+			ratesService.SetZelle(mock.ZelleSource{})
+		}
+		if cfg.CashSource != nil {
+			ratesService.SetCashSource(sim.Parallel(-0.02))
+		}
+	case config.ModeLive:
+		params, err := binanceParams(cfg)
+		if err != nil {
+			log.Fatalf("Invalid Binance configuration: %v", err)
+		}
+
+		// Every scraper shares one outbound limiter
+		limiter := outboundLimiter(cfg)
+		registry.Register(limiter.Collect)
+		limited := scraper.WithLimiter(limiter)
+
+		// The BCV and Binance scrapers pose as browsers
+		profiles := scraper.NewHeaderProfiles(scraper.DefaultHeaderProfiles())
+		if cfg.HeaderProfiles != "" {
+			if profiles, err = scraper.LoadHeaderProfiles(cfg.HeaderProfiles); err != nil {
+				log.Fatalf("Invalid header profiles: %v", err)
+			}
+		}
+		browser := scraper.WithHeaderProfiles(profiles)
+
+		bcvBounds := cfg.Bounds["bcv"]
+		bounded := scraper.WithBounds(bcvBounds.Min, bcvBounds.Max)
+		ratesService = rates.NewService(scraper.NewBCVScraper(limited, browser, bounded), scraper.NewBinanceFetcher(limited, browser, scraper.WithBinanceParams(params)), scraper.NewINPCScraper(limited, browser))
+		for _, m := range cfg.BCVMirrors {
+			// Mirrors without a JSON path copy the BCV home page
+			if m.Path == "" {
+				ratesService.AddBCVMirror(m.Name, scraper.NewBCVMirror(m.URL, limited, browser, bounded))
+				continue
+			}
+			ratesService.AddBCVMirror(m.Name, scraper.NewJSONFetcher(m.Name, m.URL, m.Path, limited))
+		}
+		for _, src := range cfg.ParallelSources {
+			ratesService.AddParallelSource(src.Name, scraper.NewJSONFetcher(src.Name, src.URL, src.Path, limited))
+		}
+		for _, region := range cfg.Binance.Regions {
+			rp := params
+			rp.Countries, rp.PayTypes = region.Countries, region.PayTypes
+			ratesService.AddRegion(rates.Region(region), scraper.NewBinanceFetcher(limited, browser, scraper.WithBinanceParams(rp)))
+		}
+		if cfg.Zelle {
+			// The USD price of USDT on Zelle ads converts the Binance rate
+			zp := params
+			zp.Fiat, zp.Countries, zp.PayTypes = "USD", nil, cfg.ZellePayTypes
+			ratesService.SetZelle(scraper.NewBinanceFetcher(limited, browser, scraper.WithBinanceParams(zp)))
+		}
+		if src := cfg.CashSource; src != nil {
+			ratesService.SetCashSource(scraper.NewJSONFetcher(src.Name, src.URL, src.Path, limited))
+		}
+	default:
+		log.Fatalf("Unknown VESWATCH_MODE %q (expected %q, %q or %q)", cfg.Mode, config.ModeLive, config.ModeMock, config.ModeSimulate)
+	}
+	ratesService.SetBinanceFallbacks(cfg.BinanceFallbacks)
+	breach, err := rates.ParseBreachDirection(cfg.BreachDirection)
+	if err != nil {
+		log.Fatalf("Invalid VESWATCH_BREACH_DIRECTION: %v", err)
+	}
+	ratesService.SetBreachDirection(breach)
+	ratesService.SetEscalation(cfg.EscalateBCVDays, cfg.EscalateBinanceFailures)
+	ratesService.SetMaxJump(cfg.MaxJump/100, cfg.MaxJumpConfirmations)
+	for source, b := range cfg.Bounds {
+		ratesService.SetBounds(source, rates.Bounds(b))
+	}
+
+	// Open the embedded database if configured. Mock and simulation modes
+	// seed synthetic history, which mustn't end up in a real database
+	var db *store.Bolt
+	if cfg.DB != "" && cfg.Mode != config.ModeLive {
+		log.Fatalf("VESWATCH_MODE=%s can't be used with VESWATCH_DB, as its synthetic history would be saved to the database", cfg.Mode)
+	}
+	if cfg.DB != "" {
+		var err error
+		if db, err = store.OpenBolt(cfg.DB); err != nil {
+			log.Fatalf("Failed to open database: %v", err)
+		}
+		life.add(component{name: "database", timeout: storeTimeout, stop: closer(db.Close)})
+		ratesService.SetHistory(db.History())
+	}
+	var seed []rates.DailyClose
+	switch cfg.Mode {
+	case config.ModeMock:
+		seed = mock.DailyCloses(90)
+	case config.ModeSimulate:
+		seed = sim.DailyCloses(90)
+	}
+	if seed != nil {
+		if err := ratesService.Restore(rates.Snapshot{DailyCloses: seed}); err != nil {
+			log.Fatalf("Failed to seed %s history: %v", cfg.Mode, err)
+		}
+	}
+
+	// Persist the audit log if configured
+	if cfg.AuditLog != "" {
+		auditLog, err := store.OpenAuditFile(cfg.AuditLog)
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		life.add(component{name: "audit log", timeout: storeTimeout, stop: closer(auditLog.Close)})
+		ratesService.SetAuditLog(auditLog)
+	}
+
+	// Write fetched rates to time-series databases
+	var influx *sink.Influx
+	if cfg.InfluxURL != "" {
+		influx = sink.NewInflux(cfg.InfluxURL, cfg.InfluxOrg, cfg.InfluxBucket, cfg.InfluxToken)
+		recorder := sink.NewRecorder(influx, sinkInterval)
+		life.add(component{name: "InfluxDB sink", timeout: sinkTimeout, stop: stopper(recorder.Close)})
+		ratesService.AddSink(recorder)
+	}
+	var timescale *sink.Timescale
+	if cfg.TimescaleDSN != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ts, err := sink.NewTimescale(ctx, cfg.TimescaleDSN)
+		cancel()
+		if err != nil {
+			log.Fatalf("Failed to connect to TimescaleDB: %v", err)
+		}
+		timescale = ts
+		life.add(component{name: "TimescaleDB", timeout: storeTimeout, stop: closer(timescale.Close)})
+		recorder := sink.NewRecorder(timescale, sinkInterval)
+		life.add(component{name: "TimescaleDB sink", timeout: sinkTimeout, stop: stopper(recorder.Close)})
+		ratesService.AddSink(recorder)
+		ratesService.SetSamples(timescale)
+	}
+
+	// Initialize notifiers
+	dispatcher := notify.NewDispatcher()
+	dispatcher.Register(notify.LogNotifier{})
+	var alertChannels []string
+	twilio := notify.NewTwilioClient(cfg.TwilioAccountSID, cfg.TwilioAuthToken)
+	twilioConfigured := cfg.TwilioAccountSID != "" && cfg.TwilioAuthToken != ""
+	if len(cfg.WhatsAppTo) > 0 {
+		if !twilioConfigured || cfg.WhatsAppFrom == "" {
+			log.Fatal("WhatsApp notifications require TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and VESWATCH_WHATSAPP_FROM")
+		}
+		dispatcher.Register(notify.NewWhatsAppNotifier(twilio, cfg.WhatsAppFrom, recipients(cfg.WhatsAppTo)))
+		alertChannels = append(alertChannels, "whatsapp")
+	}
+	if len(cfg.SMSTo) > 0 {
+		var provider notify.SMSProvider
+		switch cfg.SMSProvider {
+		case "twilio":
+			if !twilioConfigured || cfg.SMSFrom == "" {
+				log.Fatal("Twilio SMS requires TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and VESWATCH_SMS_FROM")
+			}
+			provider = notify.NewTwilioSMS(twilio, cfg.SMSFrom)
+		default:
+			log.Fatalf("Unknown VESWATCH_SMS_PROVIDER %q", cfg.SMSProvider)
+		}
+		dispatcher.Register(notify.NewSMSNotifier(provider, recipients(cfg.SMSTo)))
+		alertChannels = append(alertChannels, "sms")
+	}
+
+	// Web Push notifications. Subscriptions are opened even while it's
+	// disabled, so they can be exported
+	subscriptions := pushSubscriptions(cfg, db)
+	var pushService *push.Service
+	if cfg.VAPIDPrivateKey != "" {
+		if cfg.VAPIDSubject == "" {
+			log.Fatal("Web Push requires VESWATCH_VAPID_SUBJECT (mailto: or https: contact)")
+		}
+		vapid, err := push.NewVAPID(cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+		if err != nil {
+			log.Fatalf("Failed to load VAPID key: %v", err)
+		}
+
+		pushService = push.NewService(vapid, subscriptions, cfg.PushMaxSubscriptions, cfg.PushMaxPerClient)
+		dispatcher.Register(pushService)
+		alertChannels = append(alertChannels, "webpush")
+		ratesService.SetRecipientChecker("webpush", pushService)
+	}
+
+	// Google Sheets export of daily closes
+	if cfg.SheetsID != "" {
+		if cfg.SheetsCredentials == "" {
+			log.Fatal("Google Sheets export requires VESWATCH_SHEETS_CREDENTIALS (service account key file)")
+		}
+		exporter, err := sheets.NewExporter(cfg.SheetsCredentials, cfg.SheetsID, cfg.SheetsRange)
+		if err != nil {
+			log.Fatalf("Failed to configure Google Sheets export: %v", err)
+		}
+		dispatcher.Register(exporter)
+	}
+	if db != nil {
+		dispatcher.SetDeadLetters(db.DeadLetters())
+		dispatcher.SetOutbox(db.Outbox())
+		dispatcher.Resume()
+	}
+	ratesService.SetPublisher(dispatcher)
+	life.add(component{name: "notification retries", timeout: notifyTimeout, stop: stopper(dispatcher.Stop)})
+
+	// Configure threshold alerts
+	var alertRules []rates.AlertRule
+	for _, a := range cfg.Alerts {
+		condition := rates.ConditionBelow
+		if a.Above {
+			condition = rates.ConditionAbove
+		}
+		alertRules = append(alertRules, rates.AlertRule{Source: a.Source, Condition: condition, Threshold: a.Threshold})
+	}
+	ratesService.SetAlertRules(alertRules)
+
+	// User alert rules deliver through the enabled notification channels
+	ratesService.SetAlertChannels(alertChannels)
+	switch {
+	case cfg.AlertStore != "":
+		alertStore, err := store.OpenAlertFile(cfg.AlertStore)
+		if err != nil {
+			log.Fatalf("Failed to open alert rules: %v", err)
+		}
+		ratesService.SetAlertStore(alertStore)
+	case db != nil:
+		ratesService.SetAlertStore(db.Alerts())
+	}
+	keys := apiKeys(cfg, db)
+
+	// Export and import commands dump and restore the stores configured above
+	datastore := dump.Stores{
+		History:       ratesService.History(),
+		APIKeys:       keys,
+		Subscriptions: subscriptions,
+		Alerts:        ratesService.AlertStore(),
+		Rules:         ratesService,
+	}
+	if cfg.Command != "" {
+		if err := runDump(cfg.Command, cfg.CommandArgs, datastore); err != nil {
+			log.Fatalf("%s failed: %v", cfg.Command, err)
+		}
+		return
+	}
+
+	// Self-test mode: check sources, serialization and storage, and exit
+	if cfg.SelfTest {
+		var storage []storageCheck
+		if db != nil {
+			storage = append(storage, storageCheck{name: "database", run: func(context.Context) (string, error) {
+				closes, err := db.History().DailyCloses()
+				return fmt.Sprintf("%d daily closes", len(closes)), err
+			}})
+		}
+		if shared := snapshotStore(cfg, db); shared != nil {
+			storage = append(storage, snapshotCheck(shared))
+		}
+		if timescale != nil {
+			storage = append(storage, storageCheck{name: "timescaledb", run: func(ctx context.Context) (string, error) {
+				return "connected", timescale.Ping(ctx)
+			}})
+		}
+		if influx != nil {
+			storage = append(storage, storageCheck{name: "influxdb", run: func(ctx context.Context) (string, error) {
+				return "bucket " + cfg.InfluxBucket + " reachable", influx.Ping(ctx)
+			}})
+		}
+		if !runSelfTest(ratesService, storage) {
+			life.Stop()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// One-shot mode: fetch, save a snapshot and exit
+	if cfg.Once {
+		if err := runOnce(ratesService, snapshotStore(cfg, db)); err != nil {
+			log.Fatalf("One-shot fetch failed: %v", err)
+		}
+		return
+	}
+
+	// Serverless mode: serve the snapshot saved by one-shot runs
+	if lambda.Detected() {
+		if err := runLambda(ratesService, snapshotStore(cfg, db)); err != nil {
+			log.Fatalf("Lambda runtime failed: %v", err)
+		}
+		return
+	}
+
+	// Read-only instances run no scrapers or scheduler; they serve what
+	// other instances save to the shared store
+	var sched *scheduler.Scheduler
+	switch {
+	case cfg.ReadOnly:
+		var shared snapshot.Store
+		switch {
+		case timescale != nil:
+			shared = timescale.Snapshots()
+		case cfg.Snapshot != "":
+			shared = snapshotStore(cfg, nil)
+		default:
+			log.Fatal("Read-only mode requires a shared store: VESWATCH_TIMESCALE_DSN or VESWATCH_SNAPSHOT")
+		}
+		log.Println("Read-only mode: serving the shared store, no scrapers or scheduler")
+		life.add(every("snapshot reload", syncInterval, func() error {
+			return reloadSnapshot(ratesService, shared)
+		}))
+	case cfg.Scheduler == config.SchedulerOff:
+		log.Println("Scheduler: Disabled, no sources are fetched")
+	case cfg.Scheduler == config.SchedulerOnce:
+		log.Println("Scheduler: Disabled, fetching every source once at startup")
+		life.add(component{
+			name:    "initial fetch",
+			timeout: schedulerTimeout,
+			start: func(ctx context.Context) error {
+				ratesService.Initialize(ctx)
+				return nil
+			},
+		})
+	case cfg.Scheduler == config.SchedulerOn:
+		sched = newScheduler(cfg, ratesService, db, timescale, &life)
+	default:
+		log.Fatalf("Unknown VESWATCH_SCHEDULER %q (expected %q, %q or %q)", cfg.Scheduler, config.SchedulerOn, config.SchedulerOff, config.SchedulerOnce)
+	}
+
+	// Count requests for /admin/analytics if enabled
+	var tracker *analytics.Tracker
+	if cfg.Analytics {
+		tracker = analyticsTracker(db)
+		life.add(component{name: "analytics", timeout: storeTimeout, stop: closer(tracker.Flush)})
+		if sched != nil {
+			sched.Every(scheduler.JobAnalytics, analyticsInterval, tracker.Flush)
+		} else {
+			life.add(every("analytics", analyticsInterval, tracker.Flush))
+		}
+	}
+
+	// The initial fetch runs when the scheduler starts
+	if sched != nil {
+		life.add(component{
+			name:    "scheduler",
+			timeout: schedulerTimeout,
+			start: func(context.Context) error {
+				sched.Start()
+				return nil
+			},
+			stop: stopper(sched.Stop),
+		})
+	}
 
 	// Initialize HTTP handlers
 	handler := httphandlers.NewHandler(ratesService)
-
-	// Get port from environment or default to 8080
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	if sched != nil {
+		handler.SetSchedule(sched)
+		handler.SetJobs(sched)
+		registry.Register(sched.Collect)
+	}
+	handler.SetReadOnly(cfg.ReadOnly)
+	if cfg.ShedConcurrency > 0 {
+		shedder := httphandlers.NewShedder(cfg.ShedConcurrency, cfg.ShedQueue, cfg.ShedWait)
+		handler.SetShedder(shedder)
+		registry.Register(shedder.Collect)
+	}
+	if cfg.Validate {
+		validator, err := contract.New()
+		if err != nil {
+			log.Fatalf("Failed to load OpenAPI spec: %v", err)
+		}
+		handler.SetValidator(validator)
+		log.Println("HTTP: Validating responses against the OpenAPI spec")
+	}
+	registry.Register(ratesService.Collect)
+	registry.Register(dispatcher.Collect)
+	handler.SetMetrics(registry)
+	handler.SetAdminToken(cfg.AdminToken)
+	handler.SetForecast(cfg.Forecast)
+	if pushService != nil {
+		handler.SetPush(pushService)
+	}
+	handler.SetAPIKeys(keys)
+	if cfg.OIDC.Issuer != "" {
+		handler.SetIdentities(identities(cfg))
+		log.Printf("HTTP: Accepting tokens issued by %s", cfg.OIDC.Issuer)
+	}
+	handler.SetTrustedProxies(cfg.TrustedProxies)
+	handler.SetBehindFly(cfg.Fly)
+	handler.SetAdminFilter(httphandlers.IPFilter{Allow: cfg.AdminAllow, Deny: cfg.AdminDeny})
+	if cfg.AbuseThreshold > 0 {
+		bans := abuse.NewTracker(abuse.Config{
+			Threshold:   cfg.AbuseThreshold,
+			Window:      cfg.AbuseWindow,
+			BanDuration: cfg.AbuseBan,
+			MaxBan:      cfg.AbuseMaxBan,
+		})
+		handler.SetAbuse(bans)
+		registry.Register(bans.Collect)
+	}
+	if len(cfg.EndpointAccess) > 0 {
+		rules, err := httphandlers.NewAccessRules(cfg.EndpointAccess)
+		if err != nil {
+			log.Fatalf("Invalid endpoint access rules: %v", err)
+		}
+		handler.SetAccessRules(rules)
+	}
+	handler.SetDatastore(datastore)
+	handler.SetDeadLetters(dispatcher)
+	if db != nil {
+		handler.SetMaintainer(db)
+	}
+	if tracker != nil {
+		handler.SetAnalytics(tracker)
+	}
+	if cfg.AccessLog != "" {
+		accessLog, closeAccessLog := openAccessLog(cfg)
+		life.add(component{name: "access log", timeout: storeTimeout, stop: closer(closeAccessLog)})
+		handler.SetAccessLog(accessLog)
 	}
 
-	// Configure HTTP server
+	// Configure HTTP servers; with internal listeners, the admin, metrics
+	// and pprof endpoints are only served there
 	server := &http.Server{
-		Addr:         ":" + port,
 		Handler:      handler.Routes(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	var internal *http.Server
+	if len(cfg.AdminListen) > 0 {
+		server.Handler = handler.PublicRoutes()
 
-	// Start server in a goroutine
-	go func() {
-		log.Printf("Server listening on port %s", port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+		// Profiles and traces run as long as requested
+		internal = &http.Server{
+			Handler:     handler.InternalRoutes(),
+			ReadTimeout: 15 * time.Second,
+			IdleTimeout: 60 * time.Second,
 		}
-	}()
+	}
+
+	// Listen on the sockets inherited from a previous process, if any
+	listeners, err := upgrade.Listen(append(slices.Clone(cfg.Listen), cfg.AdminListen...))
+	if err != nil {
+		log.Fatalf("Server failed to listen: %v", err)
+	}
+	if internal != nil {
+		life.add(serve("internal HTTP server", internal, listeners[len(cfg.Listen):]))
+	}
+	life.add(serve("HTTP server", server, listeners[:len(cfg.Listen)]))
+	if err := life.Start(); err != nil {
+		log.Fatalf("Startup failed: %v", err)
+	}
+
+	// Tell the previous process it can drain and exit
+	if err := upgrade.Ready(); err != nil {
+		log.Printf("Failed to signal upgrade readiness: %v", err)
+	}
 
-	// Wait for interrupt signal for graceful shutdown
+	// Tell systemd we're ready; initial rates were loaded by sched.Start
+	readyState := systemd.StateReady
+	if upgrade.Inherited() {
+		readyState += "\n" + systemd.MainPID(os.Getpid())
+	}
+	if _, err := systemd.Notify(readyState); err != nil {
+		log.Printf("systemd: Failed to notify readiness: %v", err)
+	}
+
+	// Keep the systemd watchdog fed while no scheduler job is stuck
+	if interval := systemd.WatchdogInterval(); interval > 0 {
+		go watchdog(sched, interval)
+	}
+
+	// Wait for interrupt signal for graceful shutdown, or the upgrade
+	// signal to hand the listeners to a new process first
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	if upgrade.Signal != nil {
+		signal.Notify(quit, upgrade.Signal)
+	}
 
-	log.Println("Shutting down server...")
+	upgraded := false
+	for sig := range quit {
+		if sig != upgrade.Signal {
+			break
+		}
 
-	// Stop scheduler
-	sched.Stop()
+		// The new process couldn't open the database while this one holds it
+		if db != nil {
+			log.Println("Upgrade requested, but zero-downtime restarts aren't supported with VESWATCH_DB; restart the service instead")
+			continue
+		}
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+		log.Println("Upgrade requested, starting new process...")
+		if err := upgrade.Upgrade(listeners); err != nil {
+			log.Printf("Upgrade failed, continuing to serve: %v", err)
+			continue
+		}
+		upgraded = true
+		break
+	}
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	log.Println("Shutting down server...")
+
+	// After an upgrade the new process owns the systemd service
+	if !upgraded {
+		systemd.Notify(systemd.StateStopping)
 	}
 
+	// Stop accepting requests first, then the scheduler, then what they use
+	life.Stop()
+
 	log.Println("Server stopped gracefully")
 }
+
+// newScheduler creates the scheduler with the periodic jobs enabled in cfg.
+func newScheduler(cfg config.Config, service *rates.Service, db *store.Bolt, timescale *sink.Timescale, life *lifecycle) *scheduler.Scheduler {
+	sched := scheduler.New(service)
+	watchdog := scheduler.DefaultWatchdog()
+	watchdog.Min, watchdog.Factor = cfg.JobTimeout, cfg.JobTimeoutFactor
+	sched.SetWatchdog(watchdog)
+
+	loc, err := time.LoadLocation(cfg.SchedulerTimezone)
+	if err != nil {
+		log.Fatalf("Invalid VESWATCH_SCHEDULER_TIMEZONE: %v", err)
+	}
+	sched.SetLocation(loc)
+	if cfg.BinanceNight != nil {
+		sched.SetBinanceNight(scheduler.NightSchedule(*cfg.BinanceNight))
+	}
+
+	// Back up to object storage, restoring first if local history was lost
+	if cfg.BackupBucket != "" {
+		objects, err := backup.NewS3(cfg.BackupEndpoint, cfg.BackupRegion, cfg.BackupBucket, cfg.BackupAccessKey, cfg.BackupSecretKey)
+		if err != nil {
+			log.Fatalf("Invalid backup configuration: %v", err)
+		}
+		backups := backup.New(objects, cfg.BackupPrefix, service)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if err := backups.RestoreIfEmpty(ctx); err != nil {
+			log.Printf("Backup: Restore failed (starting without it): %v", err)
+		}
+		cancel()
+
+		sched.EveryContext(scheduler.JobBackup, cfg.BackupInterval, backups.Run)
+	}
+	sched.Every(scheduler.JobSummary, summaryInterval, service.RefreshSummaries)
+	var shared snapshot.Store
+	if timescale != nil {
+		sched.EveryContext(scheduler.JobDailyAggregates, dailyAggregatesInterval, timescale.RefreshDaily)
+
+		// Instances sharing the database take turns at fetching and share
+		// what they fetched
+		id := instanceID()
+		log.Printf("TimescaleDB: Sharing scheduled fetches as %s", id)
+		leases := timescale.Leases(id)
+		life.add(component{name: "leases", timeout: storeTimeout, stop: leases.Release})
+		sched.SetLeases(leases)
+		shared = timescale.Snapshots()
+	}
+	if db != nil {
+		if shared == nil {
+			shared = db.Snapshots()
+		}
+		sched.SetStateStore(db.Jobs())
+		db.SetAnalyticsRetention(cfg.AnalyticsRetention)
+		sched.Every(scheduler.JobMaintenance, cfg.DBMaintenanceInterval, func() error {
+			_, err := db.Maintain()
+			return err
+		})
+	}
+
+	// Start from the rates saved before a restart, or fetched by the other
+	// instances, so the initial fetch skips those still current
+	if shared != nil {
+		if err := reloadSnapshot(service, shared); err != nil {
+			log.Printf("Snapshot: Failed to load the saved rates, fetching them all: %v", err)
+		}
+		sched.Every(scheduler.JobSync, syncInterval, func() error {
+			return syncSnapshot(service, shared)
+		})
+	}
+	return sched
+}
+
+// instanceID identifies this process among the instances sharing a
+// database.
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "veswatch"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), rand.Text()[:8])
+}
+
+// serve returns the component serving HTTP on listeners, each in its own
+// goroutine. Stopping it drains in-flight requests.
+func serve(name string, server *http.Server, listeners []net.Listener) component {
+	return component{
+		name:    name,
+		timeout: httpTimeout,
+		start: func(context.Context) error {
+			for _, listener := range listeners {
+				go func() {
+					log.Printf("Server: %s listening on %s %s", name, listener.Addr().Network(), listener.Addr())
+					if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+						log.Fatalf("Server failed to start: %v", err)
+					}
+				}()
+			}
+			return nil
+		},
+		stop: server.Shutdown,
+	}
+}
+
+// pushSubscriptions returns the configured push subscription store.
+func pushSubscriptions(cfg config.Config, db *store.Bolt) push.Store {
+	switch {
+	case cfg.PushStore != "":
+		subscriptions, err := store.OpenPushFile(cfg.PushStore)
+		if err != nil {
+			log.Fatalf("Failed to open push subscriptions: %v", err)
+		}
+		return subscriptions
+	case db != nil:
+		return db.Subscriptions()
+	}
+	return push.NewMemoryStore()
+}
+
+// apiKeys creates the API key manager with the configured and stored keys.
+func apiKeys(cfg config.Config, db *store.Bolt) *apikey.Manager {
+	var keyStore apikey.Store = apikey.NewMemoryStore()
+	switch {
+	case cfg.APIKeyStore != "":
+		var err error
+		if keyStore, err = store.OpenKeyFile(cfg.APIKeyStore); err != nil {
+			log.Fatalf("Failed to open API keys: %v", err)
+		}
+	case db != nil:
+		keyStore = db.APIKeys()
+	}
+
+	manager, err := apikey.NewManager(keyStore, apikey.DefaultTiers())
+	if err != nil {
+		log.Fatalf("Failed to load API keys: %v", err)
+	}
+	for _, k := range cfg.APIKeys {
+		if err := manager.AddStatic(k.Name, k.Key, k.Tier, k.Role); err != nil {
+			log.Fatalf("Invalid API key for %s: %v", k.Name, err)
+		}
+	}
+	return manager
+}
+
+// identities creates the verifier of tokens from the configured identity
+// provider.
+func identities(cfg config.Config) *oidc.Verifier {
+	if _, ok := apikey.DefaultTiers()[cfg.OIDC.Tier]; !ok {
+		log.Fatalf("Invalid OIDC tier %q", cfg.OIDC.Tier)
+	}
+	for value, role := range cfg.OIDC.Roles {
+		if !apikey.ValidRole(role) {
+			log.Fatalf("Invalid OIDC role for %s: %q", value, role)
+		}
+	}
+
+	verifier, err := oidc.NewVerifier(oidc.Config{
+		Issuer:    cfg.OIDC.Issuer,
+		Audience:  cfg.OIDC.Audience,
+		JWKSURL:   cfg.OIDC.JWKSURL,
+		RoleClaim: cfg.OIDC.RoleClaim,
+		Roles:     cfg.OIDC.Roles,
+		Tier:      cfg.OIDC.Tier,
+	})
+	if err != nil {
+		log.Fatalf("Invalid OIDC configuration: %v", err)
+	}
+	return verifier
+}
+
+// analyticsTracker creates the request analytics tracker, stored in the
+// database if configured.
+func analyticsTracker(db *store.Bolt) *analytics.Tracker {
+	var analyticsStore analytics.Store = analytics.NewMemoryStore()
+	if db != nil {
+		analyticsStore = db.Analytics()
+	}
+
+	tracker, err := analytics.NewTracker(analyticsStore)
+	if err != nil {
+		log.Fatalf("Failed to load analytics: %v", err)
+	}
+	return tracker
+}
+
+// openAccessLog creates the access logger, writing to standard output or a
+// rotated file, and returns it with a function closing its output.
+func openAccessLog(cfg config.Config) (*accesslog.Logger, func() error) {
+	format, err := accesslog.ParseFormat(cfg.AccessLogFormat)
+	if err != nil {
+		log.Fatalf("Invalid access log configuration: %v", err)
+	}
+	if cfg.AccessLog == "-" {
+		return accesslog.New(os.Stdout, format), func() error { return nil }
+	}
+
+	file, err := accesslog.OpenFile(cfg.AccessLog, int64(cfg.AccessLogMaxSize)<<20, cfg.AccessLogMaxAge, cfg.AccessLogBackups)
+	if err != nil {
+		log.Fatalf("Failed to open access log: %v", err)
+	}
+	return accesslog.New(file, format), file.Close
+}
+
+// binanceParams converts the configured Binance P2P search parameters for
+// the scraper package.
+func binanceParams(cfg config.Config) (scraper.BinanceParams, error) {
+	b := cfg.Binance
+	agg, err := scraper.ParseAggregation(b.Aggregation)
+	if err != nil {
+		return scraper.BinanceParams{}, err
+	}
+	return scraper.BinanceParams{
+		Rows:              b.Rows,
+		Page:              b.Page,
+		Asset:             b.Asset,
+		TradeType:         b.TradeType,
+		Countries:         b.Countries,
+		PayTypes:          b.PayTypes,
+		MerchantsOnly:     b.MerchantsOnly,
+		ProMerchantAds:    b.ProMerchantAds,
+		ShieldMerchantAds: b.ShieldMerchantAds,
+		MinOrderCap:       b.MinOrderCap,
+		MaxOrderFloor:     b.MaxOrderFloor,
+		Aggregation:       agg,
+	}, nil
+}
+
+// outboundLimiter creates the outbound request limiter from configuration.
+func outboundLimiter(cfg config.Config) *scraper.Limiter {
+	limits := make(map[string]scraper.HostLimit)
+	for host, l := range cfg.OutboundLimits {
+		limits[host] = scraper.HostLimit{Interval: l.Interval, PerHour: l.PerHour}
+	}
+	fallback := limits[config.DefaultOutboundHost]
+	delete(limits, config.DefaultOutboundHost)
+	return scraper.NewLimiter(limits, fallback)
+}
+
+// recipients converts configured recipients for the notify package.
+func recipients(configured []config.Recipient) []notify.Recipient {
+	out := make([]notify.Recipient, len(configured))
+	for i, r := range configured {
+		out[i] = notify.Recipient{To: r.To, Events: r.Events}
+	}
+	return out
+}
+
+// watchdog pings the systemd watchdog at half its timeout. Pings stop when a
+// scheduler job has been running longer than the timeout, letting systemd
+// restart the service. Read-only instances have no scheduler to watch.
+func watchdog(sched *scheduler.Scheduler, timeout time.Duration) {
+	log.Printf("systemd: Watchdog enabled (timeout %s)", timeout)
+
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if sched != nil && sched.Stalled(timeout) {
+			log.Println("systemd: Scheduler job stalled, withholding watchdog ping")
+			continue
+		}
+		if _, err := systemd.Notify(systemd.StateWatchdog); err != nil {
+			log.Printf("systemd: Watchdog ping failed: %v", err)
+		}
+	}
+}
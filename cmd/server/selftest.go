@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/veswatch/api/internal/contract"
+	httphandlers "github.com/veswatch/api/internal/http"
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/internal/snapshot"
+)
+
+// selfTestTimeout bounds each storage check.
+const selfTestTimeout = 10 * time.Second
+
+// storageCheck checks that a configured store is reachable, returning a
+// short description of what it found.
+type storageCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// serializations are the payloads and formats the self-test renders.
+var serializations = []struct {
+	path   string
+	accept []string
+}{
+	{"/rates", []string{"application/json", "application/msgpack", "application/x-protobuf"}},
+	{"/v1/rates", []string{"application/json", "application/msgpack", "application/x-protobuf"}},
+	{"/api/v1/dollar", []string{"application/json"}},
+	{"/inflation", []string{"application/json"}},
+}
+
+// runSelfTest fetches every configured source once, renders the fetched
+// rates in every format and checks them against the OpenAPI spec, and
+// checks the configured stores. It prints a report and reports whether
+// every check passed. Nothing fetched is stored or published.
+func runSelfTest(service *rates.Service, storage []storageCheck) bool {
+	ran, failed := 0, 0
+	report := func(name, detail string, err error) {
+		ran++
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL  %-32s %v\n", name, err)
+			return
+		}
+		fmt.Printf("ok    %-32s %s\n", name, detail)
+	}
+
+	fmt.Println("Sources")
+	probes, replay := service.Probe(context.Background())
+	for _, p := range probes {
+		detail := fmt.Sprintf("%.4f", p.Sample.Rate)
+		if p.Sample.Method != "" {
+			detail += " (" + p.Sample.Method + ")"
+		}
+		if len(p.Points) > 0 {
+			latest := p.Points[len(p.Points)-1]
+			detail = fmt.Sprintf("%d months, latest %s = %.2f", len(p.Points), latest.Month.Format("2006-01"), latest.Index)
+		}
+		report(p.Source, fmt.Sprintf("%s in %s", detail, p.Took.Round(time.Millisecond)), p.Err)
+	}
+
+	fmt.Println("\nSerialization")
+	validator, err := contract.New()
+	if err != nil {
+		report("openapi spec", "", err)
+	} else {
+		checker := &selfTestValidator{validator: validator}
+		handler := httphandlers.NewHandler(replay)
+		handler.SetValidator(checker)
+		routes := handler.Routes()
+		for _, s := range serializations {
+			for _, accept := range s.accept {
+				size, err := render(routes, checker, s.path, accept)
+				report(s.path+" "+accept, fmt.Sprintf("%d bytes", size), err)
+			}
+		}
+	}
+
+	fmt.Println("\nStorage")
+	if len(storage) == 0 {
+		fmt.Println("-     none configured")
+	}
+	for _, c := range storage {
+		ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+		detail, err := c.run(ctx)
+		cancel()
+		report(c.name, detail, err)
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d checks failed\n", failed, ran)
+		return false
+	}
+	fmt.Printf("\nAll %d checks passed\n", ran)
+	return true
+}
+
+// render requests path in a media type and checks the response.
+func render(routes http.Handler, checker *selfTestValidator, path, accept string) (int, error) {
+	checker.err = nil
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept", accept)
+	rec := httptest.NewRecorder()
+	routes.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK && rec.Code != http.StatusPartialContent {
+		return 0, fmt.Errorf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if mediaType, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type")); mediaType != accept {
+		return 0, fmt.Errorf("served %q", mediaType)
+	}
+	return rec.Body.Len(), checker.err
+}
+
+// selfTestValidator keeps the contract violation of the last response.
+type selfTestValidator struct {
+	validator *contract.Validator
+	err       error
+}
+
+func (v *selfTestValidator) Check(r *http.Request, status int, header http.Header, body []byte) error {
+	v.err = v.validator.Check(r, status, header, body)
+	return v.err
+}
+
+// snapshotCheck loads the snapshot in store. A store without a snapshot
+// yet passes.
+func snapshotCheck(store snapshot.Store) storageCheck {
+	return storageCheck{name: "snapshot", run: func(ctx context.Context) (string, error) {
+		snap, err := store.Load(ctx)
+		if errors.Is(err, snapshot.ErrNotFound) {
+			return "none saved yet", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("taken %s, %d daily closes", snap.TakenAt.Format(time.RFC3339), len(snap.DailyCloses)), nil
+	}}
+}
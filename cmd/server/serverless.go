@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/config"
+	httphandlers "github.com/veswatch/api/internal/http"
+	"github.com/veswatch/api/internal/lambda"
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/internal/snapshot"
+	"github.com/veswatch/api/internal/store"
+)
+
+// snapshotReloadInterval limits how often a serverless instance reloads the snapshot.
+const snapshotReloadInterval = time.Minute
+
+// snapshotStore returns the configured snapshot store: the snapshot
+// location if set, otherwise the embedded database, or nil if neither is.
+func snapshotStore(cfg config.Config, db *store.Bolt) snapshot.Store {
+	switch {
+	case cfg.Snapshot != "":
+		s, err := snapshot.Open(cfg.Snapshot)
+		if err != nil {
+			log.Fatalf("Invalid snapshot location: %v", err)
+		}
+		return s
+	case db != nil:
+		return db.Snapshots()
+	}
+	return nil
+}
+
+// runOnce fetches every source once, merges the result into the existing
+// snapshot and saves it back.
+func runOnce(service *rates.Service, store snapshot.Store) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Start from the previous snapshot so failed sources keep their last value
+	if store != nil {
+		if err := restoreSnapshot(ctx, service, store); err != nil {
+			return err
+		}
+	}
+
+	service.Initialize(ctx)
+
+	if store == nil {
+		log.Println("One-shot: No snapshot location configured, nothing saved")
+		return nil
+	}
+
+	snap, err := service.Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to take snapshot: %w", err)
+	}
+	if err := store.Save(ctx, snap); err != nil {
+		return err
+	}
+
+	log.Println("One-shot: Snapshot saved")
+	return nil
+}
+
+// runLambda serves the API from AWS Lambda, reloading the snapshot written
+// by one-shot runs at most once per snapshotReloadInterval.
+func runLambda(service *rates.Service, store snapshot.Store) error {
+	if store == nil {
+		return fmt.Errorf("VESWATCH_SNAPSHOT must be set in serverless mode")
+	}
+
+	var mu sync.Mutex
+	var loadedAt time.Time
+	reload := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if time.Since(loadedAt) < snapshotReloadInterval {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := restoreSnapshot(ctx, service, store); err != nil {
+			log.Printf("Lambda: Snapshot reload failed (serving previous data): %v", err)
+			return
+		}
+		loadedAt = time.Now()
+	}
+
+	handler := httphandlers.NewHandler(service)
+	return lambda.Start(handler.Routes(), reload)
+}
+
+// syncSnapshot merges the shared snapshot into the service and saves the
+// result back, so instances taking turns at fetching all serve the latest
+// rates.
+func syncSnapshot(service *rates.Service, store snapshot.Store) error {
+	if err := reloadSnapshot(service, store); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	merged, err := service.Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to take snapshot: %w", err)
+	}
+	return store.Save(ctx, merged)
+}
+
+// reloadSnapshot merges the shared snapshot, if one exists, into the
+// service. Daily closes already in history aren't restored again.
+func reloadSnapshot(service *rates.Service, store snapshot.Store) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	snap, err := store.Load(ctx)
+	if errors.Is(err, snapshot.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	closes, err := service.GetDailyCloses()
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(closes))
+	for _, c := range closes {
+		known[c.Date] = true
+	}
+	snap.DailyCloses = slices.DeleteFunc(snap.DailyCloses, func(c rates.DailyClose) bool {
+		return known[c.Date]
+	})
+	if err := service.Restore(snap); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	return nil
+}
+
+// restoreSnapshot loads the stored snapshot into the service, if one exists.
+func restoreSnapshot(ctx context.Context, service *rates.Service, store snapshot.Store) error {
+	snap, err := store.Load(ctx)
+	if errors.Is(err, snapshot.ErrNotFound) {
+		log.Println("Snapshot: None saved yet, starting empty")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := service.Restore(snap); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	log.Printf("Snapshot: Restored data taken at %s", snap.TakenAt.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,114 @@
+// VESWatch scraper verification
+//
+// Runs each scraper once against the live sources (or saved fixtures),
+// prints the matched selector and parsed values, and exits non-zero if any
+// scraper fails. Intended for CI and for operators after BCV layout changes.
+//
+//	go run ./cmd/verify
+//	go run ./cmd/verify -fixtures internal/scraper/testdata/fixtures
+//	go run ./cmd/verify -source bcv
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/veswatch/api/internal/fixture"
+	"github.com/veswatch/api/internal/scraper"
+)
+
+// check runs a single scraper and returns a human-readable report.
+type check struct {
+	name string
+	run  func(ctx context.Context, opts []scraper.Option) (string, error)
+}
+
+var checks = []check{
+	{
+		name: "bcv",
+		run: func(ctx context.Context, opts []scraper.Option) (string, error) {
+			result, err := scraper.NewBCVScraper(opts...).Inspect(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("USD rate %.4f (selector %q)", result.Rate, result.Selector), nil
+		},
+	},
+	{
+		name: "binance",
+		run: func(ctx context.Context, opts []scraper.Option) (string, error) {
+			result, err := scraper.NewBinanceFetcher(opts...).Inspect(ctx)
+			if err != nil {
+				return "", err
+			}
+			prices := make([]string, len(result.Prices))
+			for i, p := range result.Prices {
+				prices[i] = fmt.Sprintf("%.2f", p)
+			}
+			return fmt.Sprintf("median %.2f from %d of %d ads [%s]",
+				result.Rate, len(result.Prices), result.Total, strings.Join(prices, " ")), nil
+		},
+	},
+	{
+		name: "inpc",
+		run: func(ctx context.Context, opts []scraper.Option) (string, error) {
+			points, err := scraper.NewINPCScraper(opts...).FetchINPC(ctx)
+			if err != nil {
+				return "", err
+			}
+			latest := points[len(points)-1]
+			return fmt.Sprintf("%d months, latest %s = %.2f",
+				len(points), latest.Month.Format("2006-01"), latest.Index), nil
+		},
+	},
+}
+
+func main() {
+	fixtures := flag.String("fixtures", "", "replay saved fixtures from this directory instead of the live sources")
+	source := flag.String("source", "all", "scraper to verify: all, bcv, binance or inpc")
+	verbose := flag.Bool("v", false, "show scraper logs")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	var opts []scraper.Option
+	target := "live sources"
+	if *fixtures != "" {
+		opts = append(opts, scraper.WithTransport(fixture.Replay{Dir: *fixtures}))
+		target = "fixtures in " + *fixtures
+	}
+
+	fmt.Printf("Verifying scrapers against %s\n\n", target)
+
+	ran, failed := 0, 0
+	for _, c := range checks {
+		if *source != "all" && *source != c.name {
+			continue
+		}
+		ran++
+
+		report, err := c.run(context.Background(), opts)
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL  %-8s %v\n", c.name, err)
+			continue
+		}
+		fmt.Printf("ok    %-8s %s\n", c.name, report)
+	}
+
+	if ran == 0 {
+		fmt.Fprintf(os.Stderr, "unknown source %q\n", *source)
+		os.Exit(2)
+	}
+	if failed > 0 {
+		fmt.Printf("\n%d of %d scrapers failed\n", failed, ran)
+		os.Exit(1)
+	}
+}
@@ -2,7 +2,14 @@ module github.com/veswatch/api
 
 go 1.24.0
 
-require github.com/gocolly/colly/v2 v2.3.0
+require (
+	github.com/gocolly/colly/v2 v2.3.0
+	github.com/lib/pq v1.9.0
+	go.etcd.io/bbolt v1.4.3
+	golang.org/x/image v0.25.0
+	golang.org/x/sys v0.38.0
+	google.golang.org/protobuf v1.36.10
+)
 
 require (
 	github.com/PuerkitoBio/goquery v1.11.0 // indirect
@@ -21,5 +28,4 @@ require (
 	golang.org/x/net v0.47.0 // indirect
 	golang.org/x/text v0.31.0 // indirect
 	google.golang.org/appengine v1.6.8 // indirect
-	google.golang.org/protobuf v1.36.10 // indirect
 )
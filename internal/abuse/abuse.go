@@ -0,0 +1,227 @@
+// Package abuse detects clients that keep exceeding their rate limit or
+// probing for endpoints that don't exist, and bans them for a while.
+//
+// A client that commits Threshold offenses within Window is banned for
+// BanDuration, doubled on every ban after that, up to MaxBan. Clients that
+// go a day without a ban start over. Bans are kept in memory only.
+package abuse
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/metrics"
+)
+
+// Offense is a kind of request that counts towards a ban.
+type Offense string
+
+// Offenses.
+const (
+	RateLimited Offense = "rate_limited" // answered 429
+	NotFound    Offense = "not_found"    // for a path no endpoint serves
+)
+
+const (
+	// forgetAfter is how long after a ban ends the client's ban count is
+	// reset, so the next ban is short again.
+	forgetAfter = 24 * time.Hour
+
+	// pruneInterval is how often clients with nothing left to remember
+	// are dropped.
+	pruneInterval = time.Minute
+
+	// maxClients bounds the clients tracked at once. New clients aren't
+	// tracked past it until some are pruned.
+	maxClients = 100000
+)
+
+// Config configures when and for how long clients are banned.
+type Config struct {
+	// Threshold is the number of offenses within Window that gets a
+	// client banned.
+	Threshold int
+	Window    time.Duration
+
+	// BanDuration is the length of a client's first ban; each ban after
+	// that is twice as long as the previous one, up to MaxBan.
+	BanDuration time.Duration
+	MaxBan      time.Duration
+}
+
+// Ban is a client's temporary ban.
+type Ban struct {
+	Client   string    `json:"client"`
+	Reason   Offense   `json:"reason"`
+	Offenses int       `json:"offenses"`
+	Count    int       `json:"count"`
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until"`
+}
+
+// client is the offense history of a client.
+type client struct {
+	offenses []time.Time // within the window, oldest first
+	bans     int         // since the client was last forgiven
+	ban      Ban         // the last ban, if any
+}
+
+// Tracker counts offenses per client and bans repeat offenders. It's safe
+// for concurrent use.
+type Tracker struct {
+	cfg Config
+
+	mu      sync.Mutex
+	clients map[string]*client
+	pruned  time.Time
+	total   map[Offense]int // offenses since startup
+	banned  int             // bans since startup
+}
+
+// NewTracker creates a tracker banning clients as configured.
+func NewTracker(cfg Config) *Tracker {
+	cfg.Threshold = max(cfg.Threshold, 1)
+	cfg.MaxBan = max(cfg.MaxBan, cfg.BanDuration)
+	return &Tracker{
+		cfg:     cfg,
+		clients: make(map[string]*client),
+		total:   make(map[Offense]int),
+	}
+}
+
+// Banned reports whether a client is banned at now, and until when.
+func (t *Tracker) Banned(name string, now time.Time) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.clients[name]
+	if !ok || !now.Before(c.ban.Until) {
+		return time.Time{}, false
+	}
+	return c.ban.Until, true
+}
+
+// Record counts an offense by a client at now. It returns the ban if the
+// offense got the client banned.
+func (t *Tracker) Record(name string, offense Offense, now time.Time) (Ban, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total[offense]++
+	if now.Sub(t.pruned) >= pruneInterval {
+		t.prune(now)
+	}
+
+	c, ok := t.clients[name]
+	if !ok {
+		if len(t.clients) >= maxClients {
+			return Ban{}, false
+		}
+		c = &client{}
+		t.clients[name] = c
+	}
+	if now.Before(c.ban.Until) {
+		return Ban{}, false
+	}
+	if c.bans > 0 && now.Sub(c.ban.Until) >= forgetAfter {
+		c.bans = 0
+	}
+
+	c.offenses = append(recent(c.offenses, now.Add(-t.cfg.Window)), now)
+	if len(c.offenses) < t.cfg.Threshold {
+		return Ban{}, false
+	}
+
+	duration := t.cfg.MaxBan
+	if c.bans < 32 {
+		duration = min(t.cfg.BanDuration<<c.bans, t.cfg.MaxBan)
+	}
+	c.bans++
+	c.ban = Ban{
+		Client:   name,
+		Reason:   offense,
+		Offenses: len(c.offenses),
+		Count:    c.bans,
+		Since:    now,
+		Until:    now.Add(duration),
+	}
+	c.offenses = nil
+	t.banned++
+	return c.ban, true
+}
+
+// Bans returns the bans in effect at now, latest first.
+func (t *Tracker) Bans(now time.Time) []Ban {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bans := []Ban{}
+	for _, c := range t.clients {
+		if now.Before(c.ban.Until) {
+			bans = append(bans, c.ban)
+		}
+	}
+	slices.SortFunc(bans, func(a, b Ban) int {
+		return b.Since.Compare(a.Since)
+	})
+	return bans
+}
+
+// Lift ends a client's ban at now and forgets its offenses, returning the
+// ban. It reports false if the client isn't banned.
+func (t *Tracker) Lift(name string, now time.Time) (Ban, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.clients[name]
+	if !ok || !now.Before(c.ban.Until) {
+		return Ban{}, false
+	}
+	delete(t.clients, name)
+	return c.ban, true
+}
+
+// prune drops clients without a ban in effect, offenses within the window
+// or a ban count to remember. Callers must hold the lock.
+func (t *Tracker) prune(now time.Time) {
+	t.pruned = now
+	since := now.Add(-t.cfg.Window)
+	for name, c := range t.clients {
+		c.offenses = recent(c.offenses, since)
+		if len(c.offenses) == 0 && !now.Before(c.ban.Until.Add(forgetAfter)) {
+			delete(t.clients, name)
+		}
+	}
+}
+
+// recent returns the offense times after since.
+func recent(offenses []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(offenses) && !offenses[i].After(since) {
+		i++
+	}
+	return offenses[i:]
+}
+
+// Collect writes the tracker's metrics.
+func (t *Tracker) Collect(w *metrics.Writer) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	active := 0
+	for _, c := range t.clients {
+		if now.Before(c.ban.Until) {
+			active++
+		}
+	}
+	w.Family("veswatch_http_offenses_total", metrics.Counter, "Requests counted towards a client ban, by offense.")
+	for _, offense := range []Offense{RateLimited, NotFound} {
+		w.Sample("veswatch_http_offenses_total", float64(t.total[offense]), "offense", string(offense))
+	}
+	w.Family("veswatch_http_bans_total", metrics.Counter, "Clients banned for repeated offenses.")
+	w.Sample("veswatch_http_bans_total", float64(t.banned))
+	w.Family("veswatch_http_bans_active", metrics.Gauge, "Client bans in effect.")
+	w.Sample("veswatch_http_bans_active", float64(active))
+}
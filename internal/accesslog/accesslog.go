@@ -0,0 +1,145 @@
+// Package accesslog writes HTTP access logs, separate from application logs,
+// in the combined log format or as JSON Lines.
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is an access log line format.
+type Format string
+
+// Access log formats.
+const (
+	// FormatCombined is the Apache/NGINX combined log format, readable by
+	// tools like GoAccess.
+	FormatCombined Format = "combined"
+
+	// FormatJSON writes one JSON object per line.
+	FormatJSON Format = "json"
+)
+
+// ParseFormat parses a format name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case FormatCombined, FormatJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown access log format %q (expected %q or %q)", name, FormatCombined, FormatJSON)
+}
+
+// Entry is a served request.
+type Entry struct {
+	Time      time.Time     `json:"time"`
+	Client    string        `json:"client"`
+	Method    string        `json:"method"`
+	URI       string        `json:"uri"`
+	Proto     string        `json:"proto"`
+	Status    int           `json:"status"`
+	Bytes     int64         `json:"bytes"`
+	Referer   string        `json:"referer,omitempty"`
+	UserAgent string        `json:"user_agent,omitempty"`
+	Duration  time.Duration `json:"-"`
+}
+
+// Logger writes access log entries.
+type Logger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	format Format
+}
+
+// New creates a logger writing entries to out in the given format.
+func New(out io.Writer, format Format) *Logger {
+	return &Logger{out: out, format: format}
+}
+
+// Log writes an entry. Write failures are reported to the application log
+// and otherwise ignored.
+func (l *Logger) Log(e Entry) {
+	var line []byte
+	switch l.format {
+	case FormatJSON:
+		line = jsonLine(e)
+	default:
+		line = combinedLine(e)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.out.Write(line); err != nil {
+		log.Printf("Access log: Failed to write entry: %v", err)
+	}
+}
+
+// combinedLine formats an entry in the combined log format.
+func combinedLine(e Entry) []byte {
+	var b strings.Builder
+	b.WriteString(field(e.Client))
+	b.WriteString(" - - [")
+	b.WriteString(e.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	b.WriteString("] ")
+	b.WriteString(quote(e.Method + " " + e.URI + " " + e.Proto))
+	b.WriteString(" ")
+	b.WriteString(strconv.Itoa(e.Status))
+	b.WriteString(" ")
+	if e.Bytes > 0 {
+		b.WriteString(strconv.FormatInt(e.Bytes, 10))
+	} else {
+		b.WriteString("-")
+	}
+	b.WriteString(" ")
+	b.WriteString(quote(field(e.Referer)))
+	b.WriteString(" ")
+	b.WriteString(quote(field(e.UserAgent)))
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+// jsonLine formats an entry as a JSON object on its own line.
+func jsonLine(e Entry) []byte {
+	line, err := json.Marshal(struct {
+		Entry
+		DurationMS float64 `json:"duration_ms"`
+	}{e, float64(e.Duration.Microseconds()) / 1000})
+	if err != nil {
+		// Entries hold only strings and numbers
+		panic(err)
+	}
+	return append(line, '\n')
+}
+
+// field returns "-" for empty values, as the combined format expects.
+func field(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// quote wraps s in double quotes, escaping quotes, backslashes and control
+// characters so a request cannot forge log lines.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range []byte(s) {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
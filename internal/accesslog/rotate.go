@@ -0,0 +1,155 @@
+package accesslog
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupLayout names rotated files, e.g. access.log.20260115-130405.000.
+const backupLayout = "20060102-150405.000"
+
+// File is an append-only log file rotated once it reaches a size or age.
+// Rotated files are renamed with a timestamp suffix; the oldest are removed
+// beyond the backup count.
+type File struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+	backups int
+
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenFile opens (creating if needed) a log file for appending. It rotates
+// before a write would grow the file past maxSize bytes, or once the file is
+// older than maxAge; zero disables either limit. backups is the number of
+// rotated files kept, zero keeping them all.
+func OpenFile(path string, maxSize int64, maxAge time.Duration, backups int) (*File, error) {
+	f := &File{
+		path:    path,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		backups: backups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the current file, taking its age from its modification time
+// so restarts do not postpone time-based rotation.
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open access log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat access log: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = time.Now()
+	if f.size > 0 {
+		f.opened = info.ModTime()
+	}
+	return nil
+}
+
+// Write appends p, rotating the file first if needed.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.due(int64(len(p)), time.Now()) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due reports whether the file must be rotated before writing n bytes.
+// Empty files are never rotated.
+func (f *File) due(n int64, now time.Time) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.maxSize > 0 && f.size+n > f.maxSize {
+		return true
+	}
+	return f.maxAge > 0 && now.Sub(f.opened) >= f.maxAge
+}
+
+// rotate renames the current file and opens a new one.
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close access log: %w", err)
+	}
+
+	backup := f.path + "." + time.Now().Format(backupLayout)
+	if err := os.Rename(f.path, backup); err != nil {
+		// Keep appending to the current file until the next rotation
+		if err := f.open(); err != nil {
+			return err
+		}
+		f.opened = time.Now()
+		log.Printf("Access log: Failed to rotate: %v", err)
+		return nil
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.prune()
+	return nil
+}
+
+// prune removes the oldest rotated files beyond the backup count.
+func (f *File) prune() {
+	if f.backups <= 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return
+	}
+	var backups []string
+	for _, m := range matches {
+		if _, err := time.Parse(backupLayout, strings.TrimPrefix(m, f.path+".")); err == nil {
+			backups = append(backups, m)
+		}
+	}
+
+	// Timestamps sort chronologically
+	sort.Strings(backups)
+	for len(backups) > f.backups {
+		if err := os.Remove(backups[0]); err != nil {
+			log.Printf("Access log: Failed to remove %s: %v", backups[0], err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// Close closes the underlying file.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
@@ -0,0 +1,11 @@
+// Package adminui holds the operator web page served at /admin/ui. The page
+// holds no data: it asks for the admin token, or an API key or single
+// sign-on token with a role, and calls the admin API with it.
+package adminui
+
+import _ "embed"
+
+// Index is the admin page.
+//
+//go:embed index.html
+var Index []byte
@@ -0,0 +1,298 @@
+// Package analytics keeps anonymous, aggregated request statistics: daily
+// request counts, requests per endpoint and distinct clients per day.
+//
+// Clients are counted by a salted hash of their address. The salt and the
+// hashes are kept only while the day is open; once it closes, only the
+// counts remain, so past days can't be linked back to clients.
+package analytics
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// venezuelaTZ is the timezone days are counted in.
+var venezuelaTZ = rates.Venezuela
+
+// MaxDays is the longest period a summary covers.
+const MaxDays = 366
+
+// topEndpoints is the number of endpoints listed in a summary.
+const topEndpoints = 10
+
+// Day is a day's request statistics.
+type Day struct {
+	Date      string         `json:"date"`
+	Requests  int            `json:"requests"`
+	Clients   int            `json:"clients"`
+	Endpoints map[string]int `json:"endpoints"`
+}
+
+// Record is a day as stored. Salt and Seen are only set while the day is
+// open.
+type Record struct {
+	Day
+	Salt string   `json:"salt,omitempty"`
+	Seen []string `json:"seen,omitempty"`
+}
+
+// Store persists daily statistics, keyed by date.
+type Store interface {
+	SaveDay(rec Record) error
+	Days() ([]Record, error)
+}
+
+// EndpointCount is an endpoint's request count.
+type EndpointCount struct {
+	Endpoint string `json:"endpoint"`
+	Requests int    `json:"requests"`
+}
+
+// Summary is the statistics of a period, oldest day first. Clients are
+// counted per day; the same client on two days counts on both.
+type Summary struct {
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	Requests     int             `json:"requests"`
+	TopEndpoints []EndpointCount `json:"topEndpoints"`
+	Days         []Day           `json:"days"`
+}
+
+// Tracker counts requests into the open day and writes days to a store on
+// Flush.
+type Tracker struct {
+	mu     sync.Mutex
+	store  Store
+	closed map[string]Day
+	open   Record
+	seen   map[string]bool
+
+	// pending are closed days not yet saved
+	pending []Record
+	dirty   bool
+}
+
+// NewTracker creates a tracker over the days in store. Days left open by a
+// previous run are resumed if still current, or closed.
+func NewTracker(store Store) (*Tracker, error) {
+	records, err := store.Days()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load analytics: %w", err)
+	}
+
+	t := &Tracker{
+		store:  store,
+		closed: make(map[string]Day),
+	}
+	today := dayKey(time.Now())
+	for _, rec := range records {
+		switch {
+		case rec.Date == today && rec.Salt != "":
+			t.open = rec
+		case rec.Salt != "":
+			t.close(rec)
+		default:
+			t.closed[rec.Date] = rec.Day
+		}
+	}
+	if t.open.Date == "" {
+		t.open = newRecord(today)
+	}
+	if t.open.Endpoints == nil {
+		t.open.Endpoints = make(map[string]int)
+	}
+	t.seen = make(map[string]bool, len(t.open.Seen))
+	for _, h := range t.open.Seen {
+		t.seen[h] = true
+	}
+
+	if err := t.Flush(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Record counts a request to endpoint from client. Requests that matched no
+// endpoint are counted with an empty endpoint.
+func (t *Tracker) Record(endpoint, client string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if today := dayKey(time.Now()); today != t.open.Date {
+		t.close(t.open)
+		t.open = newRecord(today)
+		t.seen = make(map[string]bool)
+	}
+
+	t.open.Requests++
+	if endpoint != "" {
+		t.open.Endpoints[endpoint]++
+	}
+	if h := hashClient(t.open.Salt, client); !t.seen[h] {
+		t.seen[h] = true
+		t.open.Seen = append(t.open.Seen, h)
+		t.open.Clients++
+	}
+	t.dirty = true
+}
+
+// close drops the salt and hashes of a day and queues it for saving.
+func (t *Tracker) close(rec Record) {
+	rec.Salt = ""
+	rec.Seen = nil
+	t.closed[rec.Date] = rec.Day
+	t.pending = append(t.pending, rec)
+}
+
+// Flush saves the closed days and the open day's changes.
+func (t *Tracker) Flush() error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = nil
+	var open *Record
+	if t.dirty {
+		rec := t.open
+		rec.Endpoints = maps.Clone(rec.Endpoints)
+		rec.Seen = slices.Clone(rec.Seen)
+		open = &rec
+		t.dirty = false
+	}
+	t.mu.Unlock()
+
+	for i, rec := range pending {
+		if err := t.store.SaveDay(rec); err != nil {
+			t.requeue(pending[i:], open)
+			return fmt.Errorf("failed to save analytics: %w", err)
+		}
+	}
+	if open != nil {
+		if err := t.store.SaveDay(*open); err != nil {
+			t.requeue(nil, open)
+			return fmt.Errorf("failed to save analytics: %w", err)
+		}
+	}
+	return nil
+}
+
+// requeue restores unsaved changes after a failed flush.
+func (t *Tracker) requeue(pending []Record, open *Record) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(pending, t.pending...)
+	if open != nil {
+		t.dirty = true
+	}
+}
+
+// Summary returns the statistics of the last days, including today.
+func (t *Tracker) Summary(days int) Summary {
+	days = min(max(days, 1), MaxDays)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().In(venezuelaTZ)
+	s := Summary{
+		From: now.AddDate(0, 0, -(days - 1)).Format("2006-01-02"),
+		To:   now.Format("2006-01-02"),
+		Days: []Day{},
+	}
+
+	totals := make(map[string]int)
+	add := func(d Day) {
+		s.Requests += d.Requests
+		for endpoint, n := range d.Endpoints {
+			totals[endpoint] += n
+		}
+		s.Days = append(s.Days, Day{
+			Date:      d.Date,
+			Requests:  d.Requests,
+			Clients:   d.Clients,
+			Endpoints: maps.Clone(d.Endpoints),
+		})
+	}
+	for _, date := range slices.Sorted(maps.Keys(t.closed)) {
+		if date >= s.From && date <= s.To && date != t.open.Date {
+			add(t.closed[date])
+		}
+	}
+	if t.open.Date >= s.From && t.open.Date <= s.To {
+		add(t.open.Day)
+	}
+
+	s.TopEndpoints = []EndpointCount{}
+	for endpoint, n := range totals {
+		s.TopEndpoints = append(s.TopEndpoints, EndpointCount{Endpoint: endpoint, Requests: n})
+	}
+	sort.Slice(s.TopEndpoints, func(i, j int) bool {
+		a, b := s.TopEndpoints[i], s.TopEndpoints[j]
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		return a.Endpoint < b.Endpoint
+	})
+	if len(s.TopEndpoints) > topEndpoints {
+		s.TopEndpoints = s.TopEndpoints[:topEndpoints]
+	}
+	return s
+}
+
+// newRecord opens a day with a fresh salt.
+func newRecord(date string) Record {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	return Record{
+		Day:  Day{Date: date, Endpoints: make(map[string]int)},
+		Salt: hex.EncodeToString(salt),
+	}
+}
+
+// hashClient returns the salted hash identifying a client for a day.
+func hashClient(salt, client string) string {
+	sum := sha256.Sum256([]byte(salt + "|" + client))
+	return hex.EncodeToString(sum[:8])
+}
+
+// dayKey formats a time as the Venezuelan calendar date (YYYY-MM-DD).
+func dayKey(t time.Time) string {
+	return t.In(venezuelaTZ).Format("2006-01-02")
+}
+
+// MemoryStore keeps daily statistics in memory.
+type MemoryStore struct {
+	mu   sync.Mutex
+	days map[string]Record
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{days: make(map[string]Record)}
+}
+
+// SaveDay adds or replaces a day.
+func (m *MemoryStore) SaveDay(rec Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.days[rec.Date] = rec
+	return nil
+}
+
+// Days returns all days ordered by date.
+func (m *MemoryStore) Days() ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	days := make([]Record, 0, len(m.days))
+	for _, date := range slices.Sorted(maps.Keys(m.days)) {
+		days = append(days, m.days[date])
+	}
+	return days, nil
+}
@@ -0,0 +1,454 @@
+// Package apikey authenticates API clients by key and enforces their quota
+// tier.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key errors.
+var (
+	ErrKeyNotFound = errors.New("API key not found")
+	ErrInvalidKey  = errors.New("invalid API key")
+	ErrUnknownTier = errors.New("unknown tier")
+	ErrUnknownRole = errors.New("unknown role")
+	ErrStaticKey   = errors.New("API key is set in configuration")
+	ErrNameTaken   = errors.New("name is taken")
+)
+
+// IdentityPrefix starts the names of identities signed in through OIDC.
+// Names own resources such as alert rules, so keys can't take one.
+const IdentityPrefix = "oidc:"
+
+// Key is an API key issued to a client. Name identifies the client and owns
+// the resources it creates. Only a hash of the secret is kept. Keys with a
+// Role can also call the admin API.
+type Key struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Tier      string    `json:"tier"`
+	Role      string    `json:"role,omitempty"`
+	Hash      string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	RevokedAt time.Time `json:"revokedAt,omitzero"`
+
+	// Static keys come from configuration and can't be revoked or rotated.
+	Static bool `json:"static,omitempty"`
+}
+
+// Active reports whether the key hasn't been revoked.
+func (k Key) Active() bool {
+	return k.RevokedAt.IsZero()
+}
+
+// Store persists managed API keys.
+type Store interface {
+	SaveKey(key Key) error
+	Keys() ([]Key, error)
+}
+
+// MemoryStore keeps API keys in memory.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	keys map[string]Key
+}
+
+// NewMemoryStore creates an empty in-memory key store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]Key)}
+}
+
+// SaveKey adds or replaces a key.
+func (m *MemoryStore) SaveKey(key Key) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key.ID] = key
+	return nil
+}
+
+// Keys returns all keys ordered by creation time.
+func (m *MemoryStore) Keys() ([]Key, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return SortKeys(m.keys), nil
+}
+
+// SortKeys returns the keys ordered by creation time, then name.
+func SortKeys(keys map[string]Key) []Key {
+	sorted := make([]Key, 0, len(keys))
+	for _, k := range keys {
+		sorted = append(sorted, k)
+	}
+	slices.SortFunc(sorted, func(a, b Key) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return sorted
+}
+
+// Usage summarizes a key's requests since startup.
+type Usage struct {
+	Requests int64     `json:"requests"`
+	Limited  int64     `json:"limited"`
+	LastUsed time.Time `json:"lastUsed,omitzero"`
+}
+
+// Summary describes a key for the admin API.
+type Summary struct {
+	Key
+	Usage Usage `json:"usage"`
+}
+
+// usage tracks a key's counters and its current rate limit window.
+type usage struct {
+	Usage
+	window time.Time
+	count  int
+}
+
+// Manager authenticates keys, enforces tier limits and manages the key
+// lifecycle.
+type Manager struct {
+	mu     sync.Mutex
+	store  Store
+	tiers  map[string]Tier
+	keys   map[string]Key    // by ID
+	hashes map[string]string // hash to ID
+	usage  map[string]*usage // by ID
+}
+
+// NewManager creates a manager backed by store, loading its keys.
+func NewManager(store Store, tiers map[string]Tier) (*Manager, error) {
+	m := &Manager{
+		store:  store,
+		tiers:  tiers,
+		keys:   make(map[string]Key),
+		hashes: make(map[string]string),
+		usage:  make(map[string]*usage),
+	}
+
+	keys, err := store.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API keys: %w", err)
+	}
+	for _, k := range keys {
+		m.index(k)
+	}
+	return m, nil
+}
+
+// AddStatic registers a key from configuration. Its name must be unique
+// and can't start with IdentityPrefix.
+func (m *Manager) AddStatic(name, secret, tier, role string) error {
+	if _, ok := m.tiers[tier]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownTier, tier)
+	}
+	if !ValidRole(role) {
+		return fmt.Errorf("%w %q", ErrUnknownRole, role)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkName("", name); err != nil {
+		return err
+	}
+	m.index(Key{
+		ID:     "static-" + name,
+		Name:   name,
+		Tier:   tier,
+		Role:   role,
+		Hash:   hash(secret),
+		Static: true,
+	})
+	return nil
+}
+
+// checkName checks that a key's name isn't reserved for identities, nor
+// taken by a key other than the one with the given ID, even a revoked one,
+// since the name owns the key's resources. Callers must hold the lock.
+func (m *Manager) checkName(id, name string) error {
+	if strings.HasPrefix(name, IdentityPrefix) {
+		return fmt.Errorf("%w: names starting with %q are reserved", ErrInvalidKey, IdentityPrefix)
+	}
+	for _, k := range m.keys {
+		if k.Name == name && k.ID != id {
+			return fmt.Errorf("%w: %w", ErrInvalidKey, ErrNameTaken)
+		}
+	}
+	return nil
+}
+
+// index adds a key to the lookup tables. Callers must hold the lock.
+func (m *Manager) index(k Key) {
+	if old, ok := m.keys[k.ID]; ok {
+		delete(m.hashes, old.Hash)
+	}
+	m.keys[k.ID] = k
+	if k.Active() {
+		m.hashes[k.Hash] = k.ID
+	}
+}
+
+// Lookup returns the active key matching the secret.
+func (m *Manager) Lookup(secret string) (Key, bool) {
+	if secret == "" {
+		return Key{}, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id, ok := m.hashes[hash(secret)]
+	if !ok {
+		return Key{}, false
+	}
+	return m.keys[id], true
+}
+
+// Tier returns the tier of a key.
+func (m *Manager) Tier(k Key) Tier {
+	return m.tiers[k.Tier]
+}
+
+// Tiers returns the available tiers ordered by name.
+func (m *Manager) Tiers() []Tier {
+	tiers := make([]Tier, 0, len(m.tiers))
+	for _, t := range m.tiers {
+		tiers = append(tiers, t)
+	}
+	slices.SortFunc(tiers, func(a, b Tier) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return tiers
+}
+
+// Allow records a request by the key and reports whether it's within the
+// tier's rate limit, along with the requests remaining in the current window
+// and when the window resets.
+func (m *Manager) Allow(k Key, now time.Time) (bool, int, time.Time) {
+	tier := m.tiers[k.Tier]
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := m.usage[k.ID]
+	if u == nil {
+		u = &usage{}
+		m.usage[k.ID] = u
+	}
+	u.Requests++
+	u.LastUsed = now
+
+	window := now.Truncate(time.Minute)
+	if !u.window.Equal(window) {
+		u.window = window
+		u.count = 0
+	}
+	reset := window.Add(time.Minute)
+
+	if tier.RequestsPerMinute <= 0 {
+		return true, -1, reset
+	}
+	if u.count >= tier.RequestsPerMinute {
+		u.Limited++
+		return false, 0, reset
+	}
+	u.count++
+	return true, tier.RequestsPerMinute - u.count, reset
+}
+
+// Create issues a new key and returns it with its secret. The secret can't
+// be recovered later. An empty role creates a key for the data endpoints
+// only. Names must be unique and can't start with IdentityPrefix.
+func (m *Manager) Create(name, tier, role string) (Key, string, error) {
+	if name == "" {
+		return Key{}, "", fmt.Errorf("%w: name is required", ErrInvalidKey)
+	}
+	if _, ok := m.tiers[tier]; !ok {
+		return Key{}, "", fmt.Errorf("%w: %w %q", ErrInvalidKey, ErrUnknownTier, tier)
+	}
+	if !ValidRole(role) {
+		return Key{}, "", fmt.Errorf("%w: %w %q", ErrInvalidKey, ErrUnknownRole, role)
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return Key{}, "", err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	k := Key{
+		ID:        id,
+		Name:      name,
+		Tier:      tier,
+		Role:      role,
+		Hash:      hash(secret),
+		CreatedAt: time.Now(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkName(k.ID, name); err != nil {
+		return Key{}, "", err
+	}
+	if err := m.store.SaveKey(k); err != nil {
+		return Key{}, "", err
+	}
+	m.index(k)
+	return k, secret, nil
+}
+
+// Revoke disables a key. Revoking a revoked key is a no-op.
+func (m *Manager) Revoke(id string) (Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, err := m.managed(id)
+	if err != nil || !k.Active() {
+		return k, err
+	}
+
+	k.RevokedAt = time.Now()
+	if err := m.store.SaveKey(k); err != nil {
+		return Key{}, err
+	}
+	m.index(k)
+	return k, nil
+}
+
+// Rotate replaces a key's secret, keeping its ID, owner, tier and role, and
+// returns the new secret. The old secret stops working immediately.
+func (m *Manager) Rotate(id string) (Key, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, err := m.managed(id)
+	if err != nil {
+		return Key{}, "", err
+	}
+	if !k.Active() {
+		return Key{}, "", fmt.Errorf("%w: key is revoked", ErrInvalidKey)
+	}
+
+	k.Hash = hash(secret)
+	if err := m.store.SaveKey(k); err != nil {
+		return Key{}, "", err
+	}
+	m.index(k)
+	return k, secret, nil
+}
+
+// managed returns a key that can be changed through the admin API.
+// Callers must hold the lock.
+func (m *Manager) managed(id string) (Key, error) {
+	k, ok := m.keys[id]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	if k.Static {
+		return Key{}, ErrStaticKey
+	}
+	return k, nil
+}
+
+// Keys returns the managed keys with their hashes, ordered by creation
+// time. Static keys come from configuration and aren't included.
+func (m *Manager) Keys() ([]Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	managed := make(map[string]Key, len(m.keys))
+	for id, k := range m.keys {
+		if !k.Static {
+			managed[id] = k
+		}
+	}
+	return SortKeys(managed), nil
+}
+
+// SaveKey stores a managed key with its hash, e.g. from an import,
+// replacing any key with the same ID. As with Create, its name must be
+// unique and can't start with IdentityPrefix.
+func (m *Manager) SaveKey(k Key) error {
+	if k.ID == "" || k.Hash == "" {
+		return fmt.Errorf("%w: id and hash are required", ErrInvalidKey)
+	}
+	if _, ok := m.tiers[k.Tier]; !ok {
+		return fmt.Errorf("%w: %w %q", ErrInvalidKey, ErrUnknownTier, k.Tier)
+	}
+	if !ValidRole(k.Role) {
+		return fmt.Errorf("%w: %w %q", ErrInvalidKey, ErrUnknownRole, k.Role)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.keys[k.ID]; ok && old.Static {
+		return ErrStaticKey
+	}
+	if err := m.checkName(k.ID, k.Name); err != nil {
+		return err
+	}
+	k.Static = false
+	if err := m.store.SaveKey(k); err != nil {
+		return err
+	}
+	m.index(k)
+	return nil
+}
+
+// List returns every key with its usage, ordered by creation time.
+func (m *Manager) List() []Summary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summaries := make([]Summary, 0, len(m.keys))
+	for _, k := range SortKeys(m.keys) {
+		s := Summary{Key: k}
+		if u := m.usage[k.ID]; u != nil {
+			s.Usage = u.Usage
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+// hash returns the hex SHA-256 of a secret.
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSecret generates a key secret.
+func newSecret() (string, error) {
+	s, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+	return "vw_" + s, nil
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,42 @@
+package apikey
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyNames(t *testing.T) {
+	m, err := NewManager(NewMemoryStore(), DefaultTiers())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if err := m.AddStatic("static", "secret", TierFree, ""); err != nil {
+		t.Fatalf("AddStatic: %v", err)
+	}
+	created, _, err := m.Create("acme", TierFree, "")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := m.Revoke(created.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	// Names own alert rules, so none may be shared or pass for an identity
+	for _, name := range []string{"acme", "static", IdentityPrefix + "user"} {
+		if _, _, err := m.Create(name, TierFree, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Create(%q): got %v, want invalid", name, err)
+		}
+		if err := m.AddStatic(name, "other", TierFree, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("AddStatic(%q): got %v, want invalid", name, err)
+		}
+		if err := m.SaveKey(Key{ID: "imported", Name: name, Tier: TierFree, Hash: "hash"}); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("SaveKey(%q): got %v, want invalid", name, err)
+		}
+	}
+
+	// A key can still be saved again under its own name
+	created.Tier = TierPartner
+	if err := m.SaveKey(created); err != nil {
+		t.Errorf("SaveKey of an existing key: %v", err)
+	}
+}
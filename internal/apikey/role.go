@@ -0,0 +1,35 @@
+package apikey
+
+// Admin roles, from least to most privileged. A key with a role can call
+// the admin API besides the data endpoints; keys without one can't.
+const (
+	// RoleViewer reads the admin API: audit log, analytics, keys and
+	// undelivered notifications.
+	RoleViewer = "viewer"
+
+	// RoleOperator also changes what's served: cash rates, overrides,
+	// maintenance, jobs and notification redelivery.
+	RoleOperator = "operator"
+
+	// RoleAdmin also manages API keys, exports and imports data, and reads
+	// the pprof endpoints.
+	RoleAdmin = "admin"
+)
+
+// roleRanks orders the roles by privilege.
+var roleRanks = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ValidRole reports whether role is a known role, or empty for none.
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok || role == ""
+}
+
+// Allows reports whether a key's role includes the required one.
+func (k Key) Allows(role string) bool {
+	return k.Role != "" && roleRanks[k.Role] >= roleRanks[role]
+}
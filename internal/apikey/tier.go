@@ -0,0 +1,27 @@
+package apikey
+
+// Quota tiers.
+const (
+	TierFree    = "free"
+	TierPartner = "partner"
+)
+
+// Tier defines the quota of the keys assigned to it.
+type Tier struct {
+	Name string `json:"name"`
+
+	// RequestsPerMinute limits authenticated requests; 0 means unlimited.
+	RequestsPerMinute int `json:"requestsPerMinute"`
+
+	// HistoryDays is how many days back /rates/history serves; 0 means
+	// the full history.
+	HistoryDays int `json:"historyDays"`
+}
+
+// DefaultTiers are the built-in quota tiers.
+func DefaultTiers() map[string]Tier {
+	return map[string]Tier{
+		TierFree:    {Name: TierFree, RequestsPerMinute: 60, HistoryDays: 90},
+		TierPartner: {Name: TierPartner, RequestsPerMinute: 1200},
+	}
+}
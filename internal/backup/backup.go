@@ -0,0 +1,124 @@
+// Package backup uploads compressed snapshots to object storage and restores
+// them after disk loss.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// Objects stores backup objects.
+type Objects interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Service is the part of the rate service backups read and restore.
+type Service interface {
+	Snapshot() (rates.Snapshot, error)
+	Restore(snap rates.Snapshot) error
+	GetDailyCloses() ([]rates.DailyClose, error)
+}
+
+// latestKey is the name of the most recent backup under the prefix.
+const latestKey = "latest.json.gz"
+
+// Backup writes gzipped JSON snapshots under a key prefix: a timestamped
+// copy for each run (expire old ones with a bucket lifecycle rule) and
+// latest.json.gz, which restores read.
+type Backup struct {
+	objects Objects
+	prefix  string
+	service Service
+}
+
+// New creates a backup of service into objects under prefix.
+func New(objects Objects, prefix string, service Service) *Backup {
+	return &Backup{
+		objects: objects,
+		prefix:  prefix,
+		service: service,
+	}
+}
+
+// Run uploads a snapshot of the current state.
+func (b *Backup) Run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	snap, err := b.service.Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to take snapshot: %w", err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(snap); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+
+	key := path.Join(b.prefix, "snapshots", snap.TakenAt.UTC().Format("20060102T150405Z")+".json.gz")
+	for _, k := range []string{key, path.Join(b.prefix, latestKey)} {
+		if err := b.objects.Put(ctx, k, buf.Bytes(), "application/gzip"); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", k, err)
+		}
+	}
+
+	log.Printf("Backup: Uploaded %s (%d daily closes, %d bytes)", key, len(snap.DailyCloses), buf.Len())
+	return nil
+}
+
+// RestoreIfEmpty restores the latest backup when the service has no history,
+// as after losing its disk. Existing history is never overwritten.
+func (b *Backup) RestoreIfEmpty(ctx context.Context) error {
+	closes, err := b.service.GetDailyCloses()
+	if err != nil {
+		return err
+	}
+	if len(closes) > 0 {
+		log.Printf("Backup: Local history present (%d daily closes), not restoring", len(closes))
+		return nil
+	}
+
+	data, err := b.objects.Get(ctx, path.Join(b.prefix, latestKey))
+	if errors.Is(err, ErrNotFound) {
+		log.Println("Backup: None uploaded yet, starting empty")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decompress backup: %w", err)
+	}
+	raw, err := io.ReadAll(gz)
+	if err != nil {
+		return fmt.Errorf("failed to decompress backup: %w", err)
+	}
+
+	var snap rates.Snapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return fmt.Errorf("failed to parse backup: %w", err)
+	}
+	if err := b.service.Restore(snap); err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	log.Printf("Backup: Restored %d daily closes from backup taken at %s", len(snap.DailyCloses), snap.TakenAt.Format(time.RFC3339))
+	return nil
+}
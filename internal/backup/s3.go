@@ -0,0 +1,199 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an object doesn't exist.
+var ErrNotFound = errors.New("object not found")
+
+// S3 is a minimal client for S3-compatible object storage (AWS S3, GCS in
+// interoperability mode, MinIO, R2...), using path-style URLs and AWS
+// Signature Version 4.
+type S3 struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewS3 creates a client for a bucket at an endpoint such as
+// https://s3.us-east-1.amazonaws.com or https://storage.googleapis.com.
+func NewS3(endpoint, region, bucket, accessKey, secretKey string) (*S3, error) {
+	u, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("invalid object storage endpoint %q", endpoint)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("object storage bucket is required")
+	}
+
+	return &S3{
+		endpoint:  u,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+// Put uploads an object.
+func (s *S3) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, body, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("object storage request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// Get downloads an object, returning ErrNotFound if it doesn't exist.
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, nil, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("object storage request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// request builds a path-style request for an object.
+func (s *S3) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	u := *s.endpoint
+	u.Path = u.Path + "/" + s.bucket + "/" + key
+	u.RawPath = s.endpoint.EscapedPath() + "/" + uriEncode(s.bucket) + "/" + uriEncodePath(key)
+
+	return http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+}
+
+// sign adds AWS Signature Version 4 headers to a request. The signed headers
+// are Host and every Content-Type, Range and X-Amz-* header.
+func (s *S3) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// responseError describes a failed object storage response.
+func responseError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("object storage returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// uriEncodePath encodes an object key, keeping its slashes.
+func uriEncodePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode percent-encodes everything but RFC 3986 unreserved characters,
+// as Signature Version 4 requires.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,993 @@
+// Package config loads server configuration from flags and environment variables.
+package config
+
+import (
+	"flag"
+	"log"
+	"math"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Data modes.
+const (
+	ModeLive = "live"
+	ModeMock = "mock"
+	// ModeSimulate serves random-walk rates.
+	ModeSimulate = "simulate"
+)
+
+// Scheduler modes.
+const (
+	SchedulerOn   = "on"
+	SchedulerOff  = "off"
+	SchedulerOnce = "once"
+)
+
+// Config holds the server configuration.
+type Config struct {
+	// Port is the HTTP server port.
+	Port string
+
+	// Listen are the addresses the server listens on: TCP host:port pairs
+	// and Unix sockets as unix:/path. It defaults to all interfaces on Port.
+	Listen []string
+
+	// AdminListen are the addresses of the internal listener serving the
+	// admin, metrics and pprof endpoints, which are then not served on
+	// Listen; empty serves everything but pprof on Listen.
+	AdminListen []string
+
+	// Mode selects live scraping, deterministic mock data or simulated
+	// random-walk rates.
+	Mode string
+
+	// BreachDirection measures breaches as the premium of other rates over
+	// the BCV rate ("premium") or the BCV rate's discount to them
+	// ("discount").
+	BreachDirection string
+
+	// EscalateBCVDays and EscalateBinanceFailures escalate missed updates:
+	// a BCV rate unchanged for more business days, or a Binance job failing
+	// more consecutive runs. Zero disables a check.
+	EscalateBCVDays         int
+	EscalateBinanceFailures int
+
+	// MaxJump is the largest accepted change, in percent, between
+	// consecutive updates of a source; 0 disables the check. A larger
+	// change in fetched rates is accepted once MaxJumpConfirmations
+	// consecutive samples agree on it; 0 always rejects it.
+	MaxJump              float64
+	MaxJumpConfirmations int
+
+	// SimVolatility is the standard deviation of the simulated parallel
+	// premium's daily log change, and SimSeed seeds the simulation; zero
+	// picks a random seed.
+	SimVolatility float64
+	SimSeed       int
+
+	// Once fetches all sources, saves a snapshot and exits.
+	Once bool
+
+	// SelfTest fetches every source once, checks serialization and
+	// storage, prints a report and exits.
+	SelfTest bool
+
+	// ReadOnly runs no scrapers or scheduler, serving the rates other
+	// instances save to the shared store (TimescaleDB or Snapshot).
+	ReadOnly bool
+
+	// Scheduler runs the scheduled jobs ("on"), nothing ("off"), or a
+	// single fetch of every source at startup ("once"), for integration
+	// tests and preview deploys.
+	Scheduler string
+
+	// SchedulerTimezone is the IANA zone the daily jobs' times of day and
+	// weekends are in.
+	SchedulerTimezone string
+
+	// Command is the subcommand following the flags ("export" or
+	// "import"), with its arguments; empty runs the server.
+	Command     string
+	CommandArgs []string
+
+	// ParallelSources are additional parallel-market JSON sources.
+	ParallelSources []ParallelSource
+
+	// BCVMirrors are tried in order while the BCV site can't be scraped:
+	// JSON sources, or copies of the BCV home page where Path is empty.
+	BCVMirrors []ParallelSource
+
+	// Zelle enables the Zelle rate, derived from the USD price of USDT on
+	// ads paid with ZellePayTypes.
+	Zelle         bool
+	ZellePayTypes []string
+
+	// CashSource is the JSON endpoint of the street cash-dollar
+	// ("efectivo") rate, if any.
+	CashSource *ParallelSource
+
+	// BinanceFallbacks are the parallel sources, in order, that supply the
+	// Binance rate while Binance fails.
+	BinanceFallbacks []string
+
+	// Binance selects which Binance P2P ads are sampled.
+	Binance Binance
+
+	// BinanceNight slows the Binance job overnight; nil keeps it at every
+	// 5 minutes.
+	BinanceNight *NightSchedule
+
+	// HeaderProfiles is the path of a JSON file of browser header profiles
+	// the scrapers rotate through; empty uses the built-in profile.
+	HeaderProfiles string
+
+	// Bounds are the rates accepted from each source.
+	Bounds map[string]Bounds
+
+	// OutboundLimits are the per-host outbound request limits; the "*"
+	// entry applies to hosts without their own.
+	OutboundLimits map[string]OutboundLimit
+
+	// AdminToken is the bearer token for admin endpoints; empty disables them.
+	AdminToken string
+
+	// OIDC accepts JWTs from an identity provider besides API keys; an
+	// empty issuer disables it.
+	OIDC OIDC
+
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and
+	// Fly-Client-IP headers give the client address.
+	TrustedProxies []netip.Prefix
+
+	// Fly is set when running on Fly.io (FLY_APP_NAME is set), where the
+	// public listeners are behind Fly's proxy and Fly-Client-IP is
+	// believed without trusted proxies.
+	Fly bool
+
+	// AdminAllow and AdminDeny restrict the metrics, admin and pprof
+	// endpoints to client addresses; an empty AdminAllow allows any.
+	AdminAllow []netip.Prefix
+	AdminDeny  []netip.Prefix
+
+	// Abuse detection: a client answered 429, or 404 for a path no
+	// endpoint serves, AbuseThreshold times within AbuseWindow is banned
+	// for AbuseBan, doubled on each repeat up to AbuseMaxBan. An
+	// AbuseThreshold of 0 disables bans.
+	AbuseThreshold int
+	AbuseWindow    time.Duration
+	AbuseBan       time.Duration
+	AbuseMaxBan    time.Duration
+
+	// EndpointAccess maps route patterns, e.g. "GET /rates/history", to
+	// the access they require: "public", "key" or an admin role.
+	EndpointAccess map[string]string
+
+	// AuditLog is the path of the append-only audit log file; empty keeps
+	// the log in memory.
+	AuditLog string
+
+	// AccessLog is the path of the HTTP access log, or "-" for standard
+	// output; empty disables it.
+	AccessLog string
+
+	// AccessLogFormat is the access log line format, "combined" or "json".
+	AccessLogFormat string
+
+	// Access log rotation: the file is rotated once it would exceed
+	// AccessLogMaxSize megabytes or is older than AccessLogMaxAge, keeping
+	// AccessLogBackups rotated files (0 keeps all).
+	AccessLogMaxSize int
+	AccessLogMaxAge  time.Duration
+	AccessLogBackups int
+
+	// Analytics enables anonymous request statistics at /admin/analytics.
+	Analytics bool
+
+	// Load shedding: up to ShedConcurrency low-priority requests run at
+	// once and up to ShedQueue more wait at most ShedWait for a slot; the
+	// rest are answered 503. A ShedConcurrency of 0 disables shedding.
+	ShedConcurrency int
+	ShedQueue       int
+	ShedWait        time.Duration
+
+	// Validate checks every response against the OpenAPI spec, logging
+	// violations. It's meant for debugging and CI.
+	Validate bool
+
+	// Alerts are threshold alert rules.
+	Alerts []Alert
+
+	// APIKeys are API keys set in configuration.
+	APIKeys []APIKey
+
+	// APIKeyStore is the path of the file holding keys created through the
+	// admin API; empty keeps them in memory.
+	APIKeyStore string
+
+	// AlertStore is the path of the user alert rules file; empty keeps
+	// rules in memory.
+	AlertStore string
+
+	// Twilio credentials for WhatsApp and SMS notifications.
+	TwilioAccountSID string
+	TwilioAuthToken  string
+
+	// WhatsAppFrom is the Twilio WhatsApp sender number.
+	WhatsAppFrom string
+
+	// WhatsAppTo are the WhatsApp recipients and their event subscriptions.
+	WhatsAppTo []Recipient
+
+	// SMSProvider selects the SMS gateway; only "twilio" is supported.
+	SMSProvider string
+
+	// SMSFrom is the SMS sender number.
+	SMSFrom string
+
+	// SMSTo are the SMS recipients and their event subscriptions.
+	SMSTo []Recipient
+
+	// VAPIDPrivateKey is the base64url Web Push application server key;
+	// empty disables Web Push.
+	VAPIDPrivateKey string
+
+	// VAPIDSubject is the contact (mailto: or https: URL) sent to push services.
+	VAPIDSubject string
+
+	// PushStore is the path of the push subscriptions file; empty keeps
+	// subscriptions in memory.
+	PushStore string
+
+	// PushMaxSubscriptions bounds the push subscriptions kept, and
+	// PushMaxPerClient those made from any one client address; 0 means no
+	// limit.
+	PushMaxSubscriptions int
+	PushMaxPerClient     int
+
+	// GenerateVAPIDKeys prints a new VAPID key pair and exits.
+	GenerateVAPIDKeys bool
+
+	// InfluxDB connection for the time-series sink; an empty URL disables it.
+	InfluxURL    string
+	InfluxToken  string
+	InfluxOrg    string
+	InfluxBucket string
+
+	// TimescaleDSN is the PostgreSQL connection string for the TimescaleDB
+	// sink; empty disables it.
+	TimescaleDSN string
+
+	// Google Sheets export of daily closes; an empty spreadsheet ID
+	// disables it.
+	SheetsCredentials string
+	SheetsID          string
+	SheetsRange       string
+
+	// Object storage backups; an empty bucket disables them.
+	BackupEndpoint  string
+	BackupRegion    string
+	BackupBucket    string
+	BackupPrefix    string
+	BackupInterval  time.Duration
+	BackupAccessKey string
+	BackupSecretKey string
+
+	// Forecast enables the experimental /rates/forecast endpoint.
+	Forecast bool
+
+	// DB is the path of the embedded database holding history, snapshots,
+	// API keys, push subscriptions and alert rules; empty keeps them in
+	// memory or their own files.
+	DB string
+
+	// DBMaintenanceInterval is how often the database is compacted, after
+	// deleting request analytics older than AnalyticsRetention days (0 keeps
+	// all).
+	DBMaintenanceInterval time.Duration
+	AnalyticsRetention    int
+
+	// Scheduled job runs are abandoned after JobTimeoutFactor times their
+	// average run time, and no sooner than JobTimeout.
+	JobTimeout       time.Duration
+	JobTimeoutFactor float64
+
+	// Snapshot is the location (file path or http(s) URL) where rate
+	// snapshots are saved and restored from.
+	Snapshot string
+}
+
+// ParallelSource is a JSON endpoint publishing a parallel-market rate.
+type ParallelSource struct {
+	Name string
+	URL  string
+	Path string
+}
+
+// Binance holds the Binance P2P search parameters.
+type Binance struct {
+	Rows              int
+	Page              int
+	Asset             string
+	TradeType         string
+	Countries         []string
+	PayTypes          []string
+	MerchantsOnly     bool
+	ProMerchantAds    bool
+	ShieldMerchantAds bool
+
+	// MinOrderCap and MaxOrderFloor, in units of Asset, skip ads whose
+	// minimum order is above the cap or whose largest possible order is
+	// below the floor; 0 disables each.
+	MinOrderCap   float64
+	MaxOrderFloor float64
+
+	// Aggregation is the statistic reducing the sampled ads to the rate,
+	// e.g. "median" or "trimmed_mean:20".
+	Aggregation string
+
+	// Regions are additional ad filters sampled alongside the headline one.
+	Regions []Region
+}
+
+// Region is a named Binance P2P ad filter, replacing the countries and
+// payment methods of the headline search.
+type Region struct {
+	Name      string
+	Countries []string
+	PayTypes  []string
+}
+
+// OutboundLimit spaces requests to a host at least Interval apart, with at
+// most PerHour requests per hour (0 for no budget).
+type OutboundLimit struct {
+	Interval time.Duration
+	PerHour  int
+}
+
+// NightSchedule runs a job every Interval between the times of day Start
+// and End, given as durations since midnight.
+type NightSchedule struct {
+	Start    time.Duration
+	End      time.Duration
+	Interval time.Duration
+}
+
+// Bounds is the range of rates accepted from a source; a zero Min or Max
+// leaves that side open.
+type Bounds struct {
+	Min float64
+	Max float64
+}
+
+// DefaultOutboundHost is the OutboundLimits key for hosts without a limit
+// of their own.
+const DefaultOutboundHost = "*"
+
+// Alert is a threshold alert rule, e.g. binance>60.
+type Alert struct {
+	Source    string
+	Above     bool
+	Threshold float64
+}
+
+// APIKey is an API key issued to a named client in a quota tier, with an
+// optional admin role.
+type APIKey struct {
+	Name string
+	Key  string
+	Tier string
+	Role string
+}
+
+// OIDC identifies the identity provider whose JWTs are accepted, and how
+// its claims map to admin roles.
+type OIDC struct {
+	Issuer    string
+	Audience  string
+	JWKSURL   string
+	RoleClaim string
+
+	// Roles maps role claim values, e.g. group names, to admin roles; nil
+	// takes claim values as role names.
+	Roles map[string]string
+
+	// Tier is the API key tier of authenticated identities.
+	Tier string
+}
+
+// Recipient is a notification destination subscribed to some event types.
+// No event types means all events.
+type Recipient struct {
+	To     string
+	Events []string
+}
+
+// Load parses command-line flags and environment variables.
+func Load() Config {
+	cfg := Config{
+		Port:      getEnv("PORT", "8080"),
+		Mode:      getEnv("VESWATCH_MODE", ModeLive),
+		ReadOnly:  getBool("VESWATCH_READ_ONLY"),
+		Scheduler: getEnv("VESWATCH_SCHEDULER", SchedulerOn),
+		Snapshot:  os.Getenv("VESWATCH_SNAPSHOT"),
+		DB:        os.Getenv("VESWATCH_DB"),
+		Forecast:  getBool("VESWATCH_FORECAST"),
+
+		BreachDirection:   getEnv("VESWATCH_BREACH_DIRECTION", "premium"),
+		SchedulerTimezone: getEnv("VESWATCH_SCHEDULER_TIMEZONE", "America/Caracas"),
+
+		EscalateBCVDays:         getInt("VESWATCH_ESCALATE_BCV_DAYS", 2, 0, 30),
+		EscalateBinanceFailures: getInt("VESWATCH_ESCALATE_BINANCE_FAILURES", 6, 0, 1000),
+
+		MaxJump:              getFloat("VESWATCH_MAX_JUMP", 50, 0, 100000),
+		MaxJumpConfirmations: getInt("VESWATCH_MAX_JUMP_CONFIRMATIONS", 3, 0, 1000),
+
+		SimVolatility: getFloat("VESWATCH_SIM_VOLATILITY", 0.03, 0, 1),
+		SimSeed:       getInt("VESWATCH_SIM_SEED", 0, 0, math.MaxInt),
+
+		DBMaintenanceInterval: getDuration("VESWATCH_DB_MAINTENANCE_INTERVAL", 24*time.Hour),
+		AnalyticsRetention:    getInt("VESWATCH_ANALYTICS_RETENTION", 366, 0, 100000),
+		JobTimeout:            getDuration("VESWATCH_JOB_TIMEOUT", 5*time.Minute),
+		JobTimeoutFactor:      getFloat("VESWATCH_JOB_TIMEOUT_FACTOR", 10, 1, 1000),
+
+		InfluxURL:    os.Getenv("VESWATCH_INFLUX_URL"),
+		InfluxToken:  os.Getenv("VESWATCH_INFLUX_TOKEN"),
+		InfluxOrg:    os.Getenv("VESWATCH_INFLUX_ORG"),
+		InfluxBucket: getEnv("VESWATCH_INFLUX_BUCKET", "veswatch"),
+		TimescaleDSN: os.Getenv("VESWATCH_TIMESCALE_DSN"),
+
+		SheetsCredentials: os.Getenv("VESWATCH_SHEETS_CREDENTIALS"),
+		SheetsID:          os.Getenv("VESWATCH_SHEETS_ID"),
+		SheetsRange:       getEnv("VESWATCH_SHEETS_RANGE", "A:G"),
+
+		BackupEndpoint:  getEnv("VESWATCH_BACKUP_ENDPOINT", "https://s3.amazonaws.com"),
+		BackupRegion:    getEnv("VESWATCH_BACKUP_REGION", "us-east-1"),
+		BackupBucket:    os.Getenv("VESWATCH_BACKUP_BUCKET"),
+		BackupPrefix:    getEnv("VESWATCH_BACKUP_PREFIX", "veswatch"),
+		BackupInterval:  getDuration("VESWATCH_BACKUP_INTERVAL", 6*time.Hour),
+		BackupAccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		BackupSecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+
+		AdminToken: os.Getenv("VESWATCH_ADMIN_TOKEN"),
+		AuditLog:   os.Getenv("VESWATCH_AUDIT_LOG"),
+		OIDC: OIDC{
+			Issuer:    os.Getenv("VESWATCH_OIDC_ISSUER"),
+			Audience:  os.Getenv("VESWATCH_OIDC_AUDIENCE"),
+			JWKSURL:   os.Getenv("VESWATCH_OIDC_JWKS_URL"),
+			RoleClaim: getEnv("VESWATCH_OIDC_ROLE_CLAIM", "roles"),
+			Roles:     parseRoleMap(os.Getenv("VESWATCH_OIDC_ROLES")),
+			Tier:      getEnv("VESWATCH_OIDC_TIER", "free"),
+		},
+		EndpointAccess: parseEndpointAccess(os.Getenv("VESWATCH_ENDPOINT_ACCESS")),
+		TrustedProxies: parsePrefixes(os.Getenv("VESWATCH_TRUSTED_PROXIES")),
+		Fly:            os.Getenv("FLY_APP_NAME") != "",
+		AdminAllow:     parsePrefixes(os.Getenv("VESWATCH_ADMIN_ALLOW")),
+		AdminDeny:      parsePrefixes(os.Getenv("VESWATCH_ADMIN_DENY")),
+		AbuseThreshold: getInt("VESWATCH_ABUSE_THRESHOLD", 0, 0, 100000),
+		AbuseWindow:    getDuration("VESWATCH_ABUSE_WINDOW", time.Minute),
+		AbuseBan:       getDuration("VESWATCH_ABUSE_BAN", 5*time.Minute),
+		AbuseMaxBan:    getDuration("VESWATCH_ABUSE_MAX_BAN", 24*time.Hour),
+
+		AccessLog:        os.Getenv("VESWATCH_ACCESS_LOG"),
+		AccessLogFormat:  getEnv("VESWATCH_ACCESS_LOG_FORMAT", "combined"),
+		AccessLogMaxSize: getInt("VESWATCH_ACCESS_LOG_MAX_SIZE", 100, 1, 100000),
+		AccessLogMaxAge:  getDuration("VESWATCH_ACCESS_LOG_MAX_AGE", 24*time.Hour),
+		AccessLogBackups: getInt("VESWATCH_ACCESS_LOG_BACKUPS", 7, 0, 10000),
+		Analytics:        getBool("VESWATCH_ANALYTICS"),
+		ShedConcurrency:  getInt("VESWATCH_SHED_CONCURRENCY", 64, 0, 100000),
+		ShedQueue:        getInt("VESWATCH_SHED_QUEUE", 128, 0, 100000),
+		ShedWait:         getDuration("VESWATCH_SHED_WAIT", 2*time.Second),
+		Validate:         getBool("VESWATCH_VALIDATE"),
+
+		ParallelSources:  parseParallelSources(os.Getenv("VESWATCH_PARALLEL_SOURCES")),
+		BCVMirrors:       parseSources(os.Getenv("VESWATCH_BCV_MIRRORS"), "BCV mirror"),
+		BinanceFallbacks: parseList(os.Getenv("VESWATCH_BINANCE_FALLBACKS")),
+		Zelle:            getBool("VESWATCH_ZELLE"),
+		CashSource:       parseSource("efectivo", os.Getenv("VESWATCH_CASH_SOURCE")),
+		ZellePayTypes:    parseList(getEnv("VESWATCH_ZELLE_PAY_TYPES", "Zelle,BANK")),
+		OutboundLimits:   parseOutboundLimits(os.Getenv("VESWATCH_OUTBOUND_LIMITS")),
+		Bounds:           parseBounds(os.Getenv("VESWATCH_BOUNDS")),
+		HeaderProfiles:   os.Getenv("VESWATCH_HEADER_PROFILES"),
+		BinanceNight:     parseNightSchedule(os.Getenv("VESWATCH_BINANCE_NIGHT")),
+		Binance: Binance{
+			Rows:              getInt("VESWATCH_BINANCE_ROWS", 10, 1, 20),
+			Page:              getInt("VESWATCH_BINANCE_PAGE", 1, 1, 100),
+			Asset:             strings.ToUpper(getEnv("VESWATCH_BINANCE_ASSET", "USDT")),
+			TradeType:         parseTradeType(os.Getenv("VESWATCH_BINANCE_TRADE_TYPE")),
+			Countries:         parseList(os.Getenv("VESWATCH_BINANCE_COUNTRIES")),
+			PayTypes:          parseList(os.Getenv("VESWATCH_BINANCE_PAY_TYPES")),
+			MerchantsOnly:     getBool("VESWATCH_BINANCE_MERCHANTS_ONLY"),
+			ProMerchantAds:    getBool("VESWATCH_BINANCE_PRO_MERCHANT_ADS"),
+			ShieldMerchantAds: getBool("VESWATCH_BINANCE_SHIELD_MERCHANT_ADS"),
+			MinOrderCap:       getFloat("VESWATCH_BINANCE_MIN_ORDER_CAP", 0, 0, math.MaxFloat64),
+			MaxOrderFloor:     getFloat("VESWATCH_BINANCE_MAX_ORDER_FLOOR", 0, 0, math.MaxFloat64),
+			Aggregation:       getEnv("VESWATCH_BINANCE_AGGREGATION", "median"),
+			Regions:           parseRegions(os.Getenv("VESWATCH_BINANCE_REGIONS")),
+		},
+		Alerts: parseAlerts(os.Getenv("VESWATCH_ALERTS")),
+
+		APIKeys:     parseAPIKeys(os.Getenv("VESWATCH_API_KEYS")),
+		APIKeyStore: os.Getenv("VESWATCH_API_KEY_STORE"),
+		AlertStore:  os.Getenv("VESWATCH_ALERT_STORE"),
+
+		TwilioAccountSID: os.Getenv("TWILIO_ACCOUNT_SID"),
+		TwilioAuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+		WhatsAppFrom:     os.Getenv("VESWATCH_WHATSAPP_FROM"),
+		WhatsAppTo:       parseRecipients(os.Getenv("VESWATCH_WHATSAPP_TO")),
+		SMSProvider:      getEnv("VESWATCH_SMS_PROVIDER", "twilio"),
+		SMSFrom:          os.Getenv("VESWATCH_SMS_FROM"),
+		SMSTo:            parseRecipients(os.Getenv("VESWATCH_SMS_TO")),
+
+		VAPIDPrivateKey: os.Getenv("VESWATCH_VAPID_PRIVATE_KEY"),
+		VAPIDSubject:    os.Getenv("VESWATCH_VAPID_SUBJECT"),
+		PushStore:       os.Getenv("VESWATCH_PUSH_STORE"),
+
+		PushMaxSubscriptions: getInt("VESWATCH_PUSH_MAX_SUBSCRIPTIONS", 10000, 0, 10000000),
+		PushMaxPerClient:     getInt("VESWATCH_PUSH_MAX_PER_CLIENT", 5, 0, 100000),
+	}
+
+	cfg.Listen = parseList(os.Getenv("VESWATCH_LISTEN"))
+	if len(cfg.Listen) == 0 {
+		cfg.Listen = []string{":" + cfg.Port}
+	}
+	cfg.AdminListen = parseList(os.Getenv("VESWATCH_ADMIN_LISTEN"))
+
+	flag.BoolVar(&cfg.Once, "once", false, "fetch all sources once, save a snapshot and exit")
+	flag.BoolVar(&cfg.SelfTest, "selftest", false, "check every source, serialization and storage once, print a report and exit")
+	flag.StringVar(&cfg.Snapshot, "snapshot", cfg.Snapshot, "snapshot location (file path or http(s) URL)")
+	flag.StringVar(&cfg.Scheduler, "scheduler", cfg.Scheduler, `scheduled jobs: "on", "off" or "once" (fetch at startup only)`)
+	flag.BoolVar(&cfg.GenerateVAPIDKeys, "vapid-keygen", false, "print a new Web Push VAPID key pair and exit")
+	flag.Parse()
+	if args := flag.Args(); len(args) > 0 {
+		cfg.Command, cfg.CommandArgs = args[0], args[1:]
+	}
+
+	return cfg
+}
+
+// parseParallelSources parses a comma-separated list of name=url#path entries,
+// e.g. "yadio=https://api.yadio.io/exrates/USD#USD.VES".
+func parseParallelSources(v string) []ParallelSource {
+	return parseSources(v, "parallel source")
+}
+
+// parseSources parses a comma-separated list of name=url#path sources of
+// the given kind.
+func parseSources(v, kind string) []ParallelSource {
+	var sources []ParallelSource
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, target, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || target == "" {
+			log.Printf("Config: Ignoring invalid %s %q (expected name=url#path)", kind, entry)
+			continue
+		}
+
+		url, path, _ := strings.Cut(target, "#")
+		sources = append(sources, ParallelSource{
+			Name: strings.TrimSpace(name),
+			URL:  url,
+			Path: path,
+		})
+	}
+	return sources
+}
+
+// parseSource parses a url#path JSON source, or returns nil if v is empty.
+func parseSource(name, v string) *ParallelSource {
+	if v = strings.TrimSpace(v); v == "" {
+		return nil
+	}
+	url, path, _ := strings.Cut(v, "#")
+	return &ParallelSource{Name: name, URL: url, Path: path}
+}
+
+// parseRegions parses a comma-separated list of name=countries/payTypes
+// entries, with countries and payment methods separated by "|", e.g.
+// "ve=VE/PagoMovil|Banesco,intl=/Zelle|Wise".
+func parseRegions(v string) []Region {
+	var regions []Region
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, filter, ok := strings.Cut(entry, "=")
+		countries, payTypes, ok2 := strings.Cut(filter, "/")
+		name = strings.TrimSpace(name)
+		if !ok || !ok2 || name == "" {
+			log.Printf("Config: Ignoring invalid Binance region %q (expected name=countries/payTypes)", entry)
+			continue
+		}
+
+		regions = append(regions, Region{
+			Name:      name,
+			Countries: parseList(strings.ReplaceAll(strings.ToUpper(countries), "|", ",")),
+			PayTypes:  parseList(strings.ReplaceAll(payTypes, "|", ",")),
+		})
+	}
+	return regions
+}
+
+// parseAlerts parses a comma-separated list of source>threshold and
+// source<threshold rules, e.g. "binance>60,breach>25".
+func parseAlerts(v string) []Alert {
+	var alerts []Alert
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.IndexAny(entry, "<>")
+		if i <= 0 {
+			log.Printf("Config: Ignoring invalid alert %q (expected source>threshold or source<threshold)", entry)
+			continue
+		}
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(entry[i+1:]), 64)
+		if err != nil {
+			log.Printf("Config: Ignoring invalid alert %q: %v", entry, err)
+			continue
+		}
+
+		alerts = append(alerts, Alert{
+			Source:    strings.TrimSpace(entry[:i]),
+			Above:     entry[i] == '>',
+			Threshold: threshold,
+		})
+	}
+	return alerts
+}
+
+// parseAPIKeys parses a comma-separated list of name:key entries, each
+// optionally followed by :tier and :role, e.g.
+// "acme:3f9c2a...:partner,grafana:77ab01...:free:viewer". Keys without a
+// tier are in the free tier.
+func parseAPIKeys(v string) []APIKey {
+	var keys []APIKey
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, rest, ok := strings.Cut(entry, ":")
+		key, rest, _ := strings.Cut(rest, ":")
+		tier, role, _ := strings.Cut(rest, ":")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		tier, role = strings.TrimSpace(tier), strings.TrimSpace(role)
+		if !ok || name == "" || key == "" {
+			log.Printf("Config: Ignoring invalid API key entry for %q (expected name:key[:tier[:role]])", name)
+			continue
+		}
+		if tier == "" {
+			tier = "free"
+		}
+		keys = append(keys, APIKey{Name: name, Key: key, Tier: tier, Role: role})
+	}
+	return keys
+}
+
+// parseRoleMap parses comma-separated value=role entries, e.g.
+// "veswatch-admins=admin,sre=operator".
+func parseRoleMap(v string) map[string]string {
+	var roles map[string]string
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		value, role, ok := strings.Cut(entry, "=")
+		value, role = strings.TrimSpace(value), strings.TrimSpace(role)
+		if !ok || value == "" || role == "" {
+			log.Printf("Config: Ignoring invalid OIDC role mapping %q (expected value=role)", entry)
+			continue
+		}
+		if roles == nil {
+			roles = make(map[string]string)
+		}
+		roles[value] = role
+	}
+	return roles
+}
+
+// parseEndpointAccess parses comma-separated pattern=level entries, e.g.
+// "GET /rates/history=key,/metrics=viewer". Patterns use the syntax of
+// route patterns, so they may contain spaces but no "=".
+func parseEndpointAccess(v string) map[string]string {
+	var access map[string]string
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pattern, level, ok := strings.Cut(entry, "=")
+		pattern, level = strings.TrimSpace(pattern), strings.TrimSpace(level)
+		if !ok || pattern == "" || level == "" {
+			log.Printf("Config: Ignoring invalid endpoint access rule %q (expected pattern=level)", entry)
+			continue
+		}
+		if access == nil {
+			access = make(map[string]string)
+		}
+		access[pattern] = level
+	}
+	return access
+}
+
+// parsePrefixes parses a comma-separated list of CIDR prefixes or single
+// addresses, e.g. "10.0.0.0/8,fd00::/8,192.0.2.7".
+func parsePrefixes(v string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, entry := range parseList(v) {
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				log.Printf("Config: Ignoring invalid address %q", entry)
+				continue
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			log.Printf("Config: Ignoring invalid CIDR prefix %q", entry)
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+// parseRecipients parses a comma-separated list of recipients, each
+// optionally followed by =event|event to limit the events it receives,
+// e.g. "+584121234567,+584241234567=alert".
+func parseRecipients(v string) []Recipient {
+	var recipients []Recipient
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		to, events, _ := strings.Cut(entry, "=")
+		r := Recipient{To: strings.TrimSpace(to)}
+		for _, event := range strings.Split(events, "|") {
+			if event = strings.TrimSpace(event); event != "" {
+				r.Events = append(r.Events, event)
+			}
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients
+}
+
+// parseOutboundLimits parses comma-separated host=interval/perHour entries,
+// e.g. "bcv.org.ve=5s/30", over the defaults. A perHour of 0 disables the
+// budget.
+func parseOutboundLimits(v string) map[string]OutboundLimit {
+	limits := map[string]OutboundLimit{
+		"bcv.org.ve":        {Interval: 2 * time.Second, PerHour: 60},
+		"p2p.binance.com":   {Interval: time.Second, PerHour: 120},
+		DefaultOutboundHost: {Interval: time.Second, PerHour: 240},
+	}
+
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, spec, ok := strings.Cut(entry, "=")
+		interval, perHour, ok2 := strings.Cut(spec, "/")
+		if !ok || !ok2 || host == "" {
+			log.Printf("Config: Ignoring invalid outbound limit %q (expected host=interval/perHour)", entry)
+			continue
+		}
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < 0 {
+			log.Printf("Config: Ignoring invalid outbound limit %q: bad interval", entry)
+			continue
+		}
+		n, err := strconv.Atoi(perHour)
+		if err != nil || n < 0 {
+			log.Printf("Config: Ignoring invalid outbound limit %q: bad budget", entry)
+			continue
+		}
+
+		limits[strings.ToLower(host)] = OutboundLimit{Interval: d, PerHour: n}
+	}
+	return limits
+}
+
+// parseBounds parses comma-separated source=min:max entries, e.g.
+// "bcv=20:2000,binance=:5000", over the defaults. Either side may be left
+// empty to leave it open; "source=:" removes a source's bounds.
+func parseBounds(v string) map[string]Bounds {
+	bounds := map[string]Bounds{
+		"bcv": {Min: 20, Max: 2000},
+	}
+
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		source, spec, ok := strings.Cut(entry, "=")
+		lo, hi, ok2 := strings.Cut(spec, ":")
+		if !ok || !ok2 || source == "" {
+			log.Printf("Config: Ignoring invalid bounds %q (expected source=min:max)", entry)
+			continue
+		}
+		minRate, ok := parseBound(lo)
+		if !ok {
+			log.Printf("Config: Ignoring invalid bounds %q: bad minimum", entry)
+			continue
+		}
+		maxRate, ok := parseBound(hi)
+		if !ok || (maxRate > 0 && maxRate < minRate) {
+			log.Printf("Config: Ignoring invalid bounds %q: bad maximum", entry)
+			continue
+		}
+
+		if minRate == 0 && maxRate == 0 {
+			delete(bounds, source)
+			continue
+		}
+		bounds[source] = Bounds{Min: minRate, Max: maxRate}
+	}
+	return bounds
+}
+
+// parseBound parses one side of a bounds entry; empty is 0 (open).
+func parseBound(v string) (float64, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, true
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || !(f >= 0) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+// parseNightSchedule parses a start-end/interval night schedule, e.g.
+// "22:00-06:00/30m", or returns nil if v is empty or invalid.
+func parseNightSchedule(v string) *NightSchedule {
+	if v = strings.TrimSpace(v); v == "" {
+		return nil
+	}
+
+	window, every, ok := strings.Cut(v, "/")
+	from, to, ok2 := strings.Cut(window, "-")
+	if !ok || !ok2 {
+		log.Printf("Config: Ignoring invalid VESWATCH_BINANCE_NIGHT %q (expected start-end/interval)", v)
+		return nil
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		log.Printf("Config: Ignoring invalid VESWATCH_BINANCE_NIGHT %q: bad start", v)
+		return nil
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil || end.Equal(start) {
+		log.Printf("Config: Ignoring invalid VESWATCH_BINANCE_NIGHT %q: bad end", v)
+		return nil
+	}
+	interval, err := time.ParseDuration(strings.TrimSpace(every))
+	if err != nil || interval < time.Minute {
+		log.Printf("Config: Ignoring invalid VESWATCH_BINANCE_NIGHT %q: bad interval", v)
+		return nil
+	}
+
+	midnight := time.Date(0, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return &NightSchedule{Start: start.Sub(midnight), End: end.Sub(midnight), Interval: interval}
+}
+
+// parseTradeType parses a Binance P2P trade type, BUY or SELL, defaulting
+// to BUY.
+func parseTradeType(v string) string {
+	switch t := strings.ToUpper(strings.TrimSpace(v)); t {
+	case "":
+		return "BUY"
+	case "BUY", "SELL":
+		return t
+	default:
+		log.Printf("Config: Ignoring invalid VESWATCH_BINANCE_TRADE_TYPE %q, using BUY", v)
+		return "BUY"
+	}
+}
+
+// parseList parses a comma-separated list, skipping empty entries.
+func parseList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getInt returns the environment variable parsed as an integer within
+// [lo, hi], or a default if it is unset or invalid.
+func getInt(key string, fallback, lo, hi int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < lo || n > hi {
+		log.Printf("Config: Ignoring invalid %s %q, using %d", key, v, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getFloat returns the environment variable parsed as a number within
+// [lo, hi], or a default if it is unset or invalid.
+func getFloat(key string, fallback, lo, hi float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < lo || f > hi {
+		log.Printf("Config: Ignoring invalid %s %q, using %g", key, v, fallback)
+		return fallback
+	}
+	return f
+}
+
+// getDuration returns the environment variable parsed as a duration, or a
+// default if it is unset or invalid.
+func getDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Config: Ignoring invalid %s %q, using %s", key, v, fallback)
+		return fallback
+	}
+	return d
+}
+
+// getBool returns the environment variable parsed as a boolean, false if it
+// is unset or invalid.
+func getBool(key string) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("Config: Ignoring invalid %s %q", key, v)
+		return false
+	}
+	return b
+}
+
+// getEnv returns the environment variable value or a default.
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,395 @@
+// Package contract checks requests and responses against the OpenAPI
+// document in api/openapi.json, so the implementation and the spec can't
+// drift apart unnoticed. It understands the subset of JSON Schema the spec
+// uses: type, enum, const, required, properties, items, minimum, maximum,
+// the date and date-time formats, and $ref within the spec and to
+// rates.schema.json.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/veswatch/api/api"
+)
+
+// Documents referenced by $ref.
+const (
+	specDocument   = ""
+	schemaDocument = "rates.schema.json"
+)
+
+// genericStatuses are answered by middleware on any operation, so the spec
+// doesn't list them per operation. Access rules can require a key or an
+// admin role on any endpoint.
+var genericStatuses = []int{
+	http.StatusUnauthorized,
+	http.StatusForbidden,
+	http.StatusRequestTimeout,
+	http.StatusRequestEntityTooLarge,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusServiceUnavailable,
+}
+
+// Validator checks exchanges against the spec.
+type Validator struct {
+	docs   map[string]map[string]any
+	routes []route
+}
+
+// route is an operation path of the spec, split into segments.
+type route struct {
+	template string
+	segments []string
+	item     map[string]any
+}
+
+// New loads the embedded spec.
+func New() (*Validator, error) {
+	v := &Validator{docs: make(map[string]map[string]any)}
+	for name, data := range map[string][]byte{specDocument: api.OpenAPI, schemaDocument: api.RatesSchema} {
+		var doc map[string]any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse spec %q: %w", name, err)
+		}
+		v.docs[name] = doc
+	}
+
+	paths, _ := v.docs[specDocument]["paths"].(map[string]any)
+	for template, item := range paths {
+		item, _ := item.(map[string]any)
+		v.routes = append(v.routes, route{
+			template: template,
+			segments: strings.Split(strings.Trim(template, "/"), "/"),
+			item:     item,
+		})
+	}
+	// Literal segments win over parameters, e.g. /keys/new over /keys/{id}
+	slices.SortFunc(v.routes, func(a, b route) int {
+		return strings.Count(a.template, "{") - strings.Count(b.template, "{")
+	})
+	return v, nil
+}
+
+// Check checks a request and the response it got: the operation must be
+// in the spec and document the status, a successful response's query
+// parameters must be valid, and JSON bodies must match the documented
+// schema. HEAD is checked as GET, and OPTIONS isn't checked.
+func (v *Validator) Check(r *http.Request, status int, header http.Header, body []byte) error {
+	method := r.Method
+	switch method {
+	case http.MethodOptions:
+		return nil
+	case http.MethodHead:
+		method, body = http.MethodGet, nil
+	}
+
+	op, params, ok := v.operation(method, r.URL.Path)
+	if !ok {
+		// Unknown routes are the mux's 404 and 405, unless an access rule
+		// turned the request away first
+		switch status {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusUnauthorized, http.StatusForbidden:
+			return nil
+		}
+		return fmt.Errorf("%s %s answered %d but isn't documented", r.Method, r.URL.Path, status)
+	}
+
+	if status >= 200 && status < 300 {
+		if err := v.checkParameters(op, params, r.URL.Query()); err != nil {
+			return fmt.Errorf("%s %s answered %d to an invalid request: %v", r.Method, r.URL.Path, status, err)
+		}
+	}
+
+	responses, _ := op["responses"].(map[string]any)
+	response, ok := responses[strconv.Itoa(status)].(map[string]any)
+	if !ok {
+		response, ok = responses["default"].(map[string]any)
+	}
+	if !ok {
+		if slices.Contains(genericStatuses, status) {
+			return nil
+		}
+		return fmt.Errorf("%s %s answered undocumented status %d", r.Method, r.URL.Path, status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if mediaType != "application/json" || body == nil {
+		return nil
+	}
+	content, _ := response["content"].(map[string]any)
+	media, _ := content["application/json"].(map[string]any)
+	schema, ok := media["schema"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("%s %s answered %d with invalid JSON: %v", r.Method, r.URL.Path, status, err)
+	}
+	// Sparse fieldsets leave out required properties
+	partial := r.URL.Query().Has("fields")
+	if err := v.validate(specDocument, schema, value, "$", partial); err != nil {
+		return fmt.Errorf("%s %s answered %d with %v", r.Method, r.URL.Path, status, err)
+	}
+	return nil
+}
+
+// operation returns the spec's operation for a request, with its path
+// parameters.
+func (v *Validator) operation(method, path string) (map[string]any, map[string]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, rt := range v.routes {
+		if len(rt.segments) != len(segments) {
+			continue
+		}
+		params := make(map[string]string)
+		matched := true
+		for i, seg := range rt.segments {
+			if name, ok := strings.CutPrefix(seg, "{"); ok {
+				params[strings.TrimSuffix(name, "}")] = segments[i]
+				continue
+			}
+			if seg != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		op, ok := rt.item[strings.ToLower(method)].(map[string]any)
+		if !ok {
+			return nil, nil, false
+		}
+		return op, params, true
+	}
+	return nil, nil, false
+}
+
+// checkParameters validates the documented path and query parameters of a
+// request. Undocumented query parameters are ignored.
+func (v *Validator) checkParameters(op map[string]any, path map[string]string, query url.Values) error {
+	list, _ := op["parameters"].([]any)
+	for _, p := range list {
+		_, param := v.resolve(specDocument, p.(map[string]any))
+		name, _ := param["name"].(string)
+		schema, _ := param["schema"].(map[string]any)
+
+		var value string
+		var present bool
+		switch param["in"] {
+		case "path":
+			value, present = path[name]
+		case "query":
+			present = query.Has(name)
+			value = query.Get(name)
+		default:
+			continue
+		}
+
+		if !present {
+			if required, _ := param["required"].(bool); required {
+				return fmt.Errorf("missing parameter %s", name)
+			}
+			continue
+		}
+		if schema != nil {
+			if err := v.validate(specDocument, schema, parameterValue(value, schema), name, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parameterValue converts a parameter to the JSON type its schema expects.
+// Enum values are matched ignoring case, as the handlers parse them.
+func parameterValue(s string, schema map[string]any) any {
+	enum, _ := schema["enum"].([]any)
+	for _, e := range enum {
+		if e, ok := e.(string); ok && strings.EqualFold(e, s) {
+			return e
+		}
+	}
+
+	switch schema["type"] {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
+
+// resolve follows a schema's $ref, returning the document the target lives
+// in and the target.
+func (v *Validator) resolve(doc string, schema map[string]any) (string, map[string]any) {
+	for {
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			return doc, schema
+		}
+		file, pointer, _ := strings.Cut(ref, "#")
+		if file != "" {
+			doc = file
+		}
+
+		var target any = v.docs[doc]
+		for _, key := range strings.Split(strings.Trim(pointer, "/"), "/") {
+			if key == "" {
+				continue
+			}
+			m, _ := target.(map[string]any)
+			target = m[key]
+		}
+		next, ok := target.(map[string]any)
+		if !ok {
+			return doc, map[string]any{"not": "resolvable " + ref}
+		}
+		schema = next
+	}
+}
+
+// validate checks value against schema, found in doc. path locates value
+// in error messages. Partial values may lack required properties.
+func (v *Validator) validate(doc string, schema map[string]any, value any, path string, partial bool) error {
+	doc, schema = v.resolve(doc, schema)
+	if reason, ok := schema["not"].(string); ok {
+		return fmt.Errorf("%s: %s", path, reason)
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+	}
+	if c, ok := schema["const"]; ok && c != value {
+		return fmt.Errorf("%s: %v is not %v", path, value, c)
+	}
+	if err := checkType(schema["type"], value); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch value := value.(type) {
+	case float64:
+		if minimum, ok := schema["minimum"].(float64); ok && value < minimum {
+			return fmt.Errorf("%s: %v is below the minimum %v", path, value, minimum)
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && value > maximum {
+			return fmt.Errorf("%s: %v is above the maximum %v", path, value, maximum)
+		}
+	case string:
+		if err := checkFormat(schema["format"], value); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case map[string]any:
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok && !partial {
+				return fmt.Errorf("%s: missing required %s", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, prop := range properties {
+			field, ok := value[name]
+			if !ok {
+				continue
+			}
+			if err := v.validate(doc, prop.(map[string]any), field, path+"."+name, partial); err != nil {
+				return err
+			}
+		}
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			break
+		}
+		for i, item := range value {
+			if err := v.validate(doc, items, item, fmt.Sprintf("%s[%d]", path, i), partial); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkType checks value against a schema type, a name or a list of names.
+func checkType(typ any, value any) error {
+	var types []string
+	switch t := typ.(type) {
+	case nil:
+		return nil
+	case string:
+		types = []string{t}
+	case []any:
+		for _, name := range t {
+			types = append(types, name.(string))
+		}
+	}
+
+	for _, t := range types {
+		if hasType(t, value) {
+			return nil
+		}
+	}
+	return fmt.Errorf("expected %s, got %s", strings.Join(types, " or "), typeName(value))
+}
+
+// hasType reports whether a decoded JSON value is of a schema type.
+func hasType(typ string, value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case float64:
+		return typ == "number" || (typ == "integer" && value == math.Trunc(value))
+	case string:
+		return typ == "string"
+	case []any:
+		return typ == "array"
+	case map[string]any:
+		return typ == "object"
+	}
+	return false
+}
+
+// typeName returns the schema type name of a decoded JSON value.
+func typeName(value any) string {
+	for _, t := range []string{"null", "boolean", "integer", "number", "string", "array", "object"} {
+		if hasType(t, value) {
+			return t
+		}
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// checkFormat checks a string against the date and date-time formats.
+func checkFormat(format any, s string) error {
+	var layout string
+	switch format {
+	case "date":
+		layout = time.DateOnly
+	case "date-time":
+		layout = time.RFC3339
+	default:
+		return nil
+	}
+	if _, err := time.Parse(layout, s); err != nil {
+		return fmt.Errorf("%q is not a %s", s, format)
+	}
+	return nil
+}
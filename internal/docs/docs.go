@@ -0,0 +1,13 @@
+// Package docs holds the human-readable API documentation page served at /.
+// The page is generated from api/openapi.json by gen.go; run go generate
+// after changing the spec.
+package docs
+
+import _ "embed"
+
+//go:generate go run gen.go
+
+// Index is the documentation page.
+//
+//go:embed index.html
+var Index []byte
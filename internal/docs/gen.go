@@ -0,0 +1,359 @@
+//go:build ignore
+
+// gen renders index.html from the OpenAPI spec.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	specPath   = "../../api/openapi.json"
+	outputPath = "index.html"
+)
+
+// generated marks the output; html/template strips comments written in the
+// template itself.
+const generated = "<!-- Code generated by gen.go from api/openapi.json. DO NOT EDIT. -->"
+
+// methods lists the HTTP methods in display order.
+var methods = []string{"get", "post", "put", "delete"}
+
+type spec struct {
+	Info struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+		Disclaimer  string `json:"x-disclaimer"`
+	} `json:"info"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Tags []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"tags"`
+	Paths      json.RawMessage `json:"paths"`
+	Components struct {
+		Parameters map[string]parameter `json:"parameters"`
+	} `json:"components"`
+}
+
+type operation struct {
+	Tags        []string                   `json:"tags"`
+	Summary     string                     `json:"summary"`
+	Description string                     `json:"description"`
+	Parameters  []parameter                `json:"parameters"`
+	RequestBody *requestBody               `json:"requestBody"`
+	Responses   map[string]json.RawMessage `json:"responses"`
+	Security    []map[string][]string      `json:"security"`
+}
+
+type parameter struct {
+	Ref         string `json:"$ref"`
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Example     string `json:"example"`
+	Schema      struct {
+		Default any `json:"default"`
+	} `json:"schema"`
+}
+
+type requestBody struct {
+	Description string `json:"description"`
+	Content     map[string]struct {
+		Example json.RawMessage `json:"example"`
+	} `json:"content"`
+}
+
+// page is the template data.
+type page struct {
+	Generated   template.HTML
+	Title       string
+	Version     string
+	Description string
+	Disclaimer  string
+	Sections    []section
+}
+
+type section struct {
+	Name        string
+	Description string
+	Endpoints   []endpoint
+}
+
+type endpoint struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Parameters  []parameter
+	Body        string
+	Responses   []response
+	Curl        string
+}
+
+type response struct {
+	Status      string
+	Description string
+}
+
+func main() {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var s spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Fatalf("failed to parse spec: %v", err)
+	}
+
+	server := ""
+	if len(s.Servers) > 0 {
+		server = s.Servers[0].URL
+	}
+
+	p := page{
+		Generated:   generated,
+		Title:       s.Info.Title,
+		Version:     s.Info.Version,
+		Description: s.Info.Description,
+		Disclaimer:  s.Info.Disclaimer,
+	}
+	sections := make(map[string]*section)
+	for _, tag := range s.Tags {
+		p.Sections = append(p.Sections, section{Name: tag.Name, Description: tag.Description})
+	}
+	for i := range p.Sections {
+		sections[p.Sections[i].Name] = &p.Sections[i]
+	}
+
+	paths, err := orderedObject(s.Paths)
+	if err != nil {
+		log.Fatalf("failed to parse paths: %v", err)
+	}
+	for _, path := range paths {
+		var ops map[string]operation
+		if err := json.Unmarshal(path.value, &ops); err != nil {
+			log.Fatalf("failed to parse %s: %v", path.key, err)
+		}
+		for _, method := range methods {
+			op, ok := ops[method]
+			if !ok {
+				continue
+			}
+			if len(op.Tags) == 0 || sections[op.Tags[0]] == nil {
+				log.Fatalf("%s %s: missing or unknown tag", method, path.key)
+			}
+			sec := sections[op.Tags[0]]
+			sec.Endpoints = append(sec.Endpoints, newEndpoint(s, server, method, path.key, op))
+		}
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, p); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(outputPath, out.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newEndpoint builds the template data of an operation.
+func newEndpoint(s spec, server, method, path string, op operation) endpoint {
+	e := endpoint{
+		ID:          strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(method+path, "-"), "-"),
+		Method:      strings.ToUpper(method),
+		Path:        path,
+		Summary:     op.Summary,
+		Description: op.Description,
+	}
+
+	target := path
+	query := url.Values{}
+	var queryOrder []string
+	for _, param := range op.Parameters {
+		// Shared parameters are documented but left out of examples
+		shared := param.Ref != ""
+		if shared {
+			name := strings.TrimPrefix(param.Ref, "#/components/parameters/")
+			param = s.Components.Parameters[name]
+		}
+		e.Parameters = append(e.Parameters, param)
+
+		if param.Example == "" || (shared && !param.Required) {
+			continue
+		}
+		switch param.In {
+		case "path":
+			target = strings.ReplaceAll(target, "{"+param.Name+"}", param.Example)
+		case "query":
+			query.Set(param.Name, param.Example)
+			queryOrder = append(queryOrder, param.Name)
+		}
+	}
+
+	var qs []string
+	for _, name := range queryOrder {
+		qs = append(qs, url.QueryEscape(name)+"="+url.QueryEscape(query.Get(name)))
+	}
+	if len(qs) > 0 {
+		target += "?" + strings.Join(qs, "&")
+	}
+
+	curl := []string{"curl"}
+	if method != "get" {
+		curl = append(curl, "-X", strings.ToUpper(method))
+	}
+	curl = append(curl, fmt.Sprintf("%q", server+target))
+	for _, sec := range op.Security {
+		if _, ok := sec["apiKey"]; ok {
+			curl = append(curl, "\\\n  -H \"Authorization: Bearer $API_KEY\"")
+		}
+		if _, ok := sec["adminToken"]; ok {
+			curl = append(curl, "\\\n  -H \"Authorization: Bearer $ADMIN_TOKEN\"")
+		}
+	}
+	if op.RequestBody != nil {
+		if c, ok := op.RequestBody.Content["application/json"]; ok && c.Example != nil {
+			var compact bytes.Buffer
+			json.Compact(&compact, c.Example)
+			e.Body = op.RequestBody.Description
+			curl = append(curl, "\\\n  -H \"Content-Type: application/json\"", "\\\n  -d '"+compact.String()+"'")
+		}
+	}
+	e.Curl = strings.Join(curl, " ")
+
+	statuses, err := orderedObject(mustMarshal(op.Responses))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, status := range statuses {
+		var r struct {
+			Description string `json:"description"`
+		}
+		json.Unmarshal(status.value, &r)
+		e.Responses = append(e.Responses, response{Status: status.key, Description: r.Description})
+	}
+	return e
+}
+
+// member is a key and value of a JSON object.
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// orderedObject returns the members of a JSON object in document order.
+func orderedObject(data json.RawMessage) ([]member, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var members []member
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, member{key: tok.(string), value: value})
+	}
+	return members, nil
+}
+
+func mustMarshal(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return data
+}
+
+// codeSpan matches Markdown inline code.
+var codeSpan = regexp.MustCompile("`([^`]+)`")
+
+// inline renders Markdown inline code in escaped text.
+func inline(s string) template.HTML {
+	return template.HTML(codeSpan.ReplaceAllString(template.HTMLEscapeString(s), "<code>$1</code>"))
+}
+
+var tmpl = template.Must(template.New("index").Funcs(template.FuncMap{"inline": inline}).Parse(`<!DOCTYPE html>
+{{.Generated}}
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font: 16px/1.5 system-ui, sans-serif; color: #1d2430; max-width: 860px; margin: 0 auto; padding: 1.5rem; }
+h1 { margin-bottom: 0; }
+h2 { margin-top: 2.5rem; border-bottom: 1px solid #d8dee6; }
+h3 { margin: 2rem 0 .25rem; font-size: 1.05rem; }
+code, pre { font-family: ui-monospace, monospace; font-size: .9em; background: #f2f4f7; border-radius: 4px; }
+code { padding: 0 .25em; }
+pre { padding: .75rem 1rem; overflow-x: auto; }
+table { border-collapse: collapse; width: 100%; font-size: .95em; }
+th, td { text-align: left; vertical-align: top; padding: .3rem .5rem; border-bottom: 1px solid #e5e9ef; }
+.method { display: inline-block; min-width: 4em; font-weight: 600; color: #0b6b3a; }
+.version { color: #5c6675; }
+.disclaimer { border-left: 4px solid #d9a300; background: #fff8e1; padding: .5rem 1rem; }
+nav ul { columns: 2; padding-left: 1.2rem; }
+a { color: #0b5cad; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="version">Version {{.Version}} · <a href="/?format=json">JSON</a></p>
+<p>{{inline .Description}}</p>
+<p class="disclaimer">{{.Disclaimer}}</p>
+<nav>
+<ul>
+{{- range .Sections}}{{range .Endpoints}}
+<li><a href="#{{.ID}}"><span class="method">{{.Method}}</span> {{.Path}}</a></li>
+{{- end}}{{end}}
+</ul>
+</nav>
+{{- range .Sections}}
+<h2>{{.Name}}</h2>
+<p>{{.Description}}</p>
+{{- range .Endpoints}}
+<h3 id="{{.ID}}"><span class="method">{{.Method}}</span> <code>{{.Path}}</code></h3>
+<p><strong>{{.Summary}}.</strong> {{inline .Description}}</p>
+{{- if .Parameters}}
+<table>
+<tr><th>Parameter</th><th>Default</th><th>Description</th></tr>
+{{- range .Parameters}}
+<tr><td><code>{{.Name}}</code>{{if .Required}} (required){{end}}</td><td>{{with .Schema.Default}}<code>{{.}}</code>{{end}}</td><td>{{inline .Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- with .Body}}
+<p>Body: {{inline .}}</p>
+{{- end}}
+<pre>{{.Curl}}</pre>
+<table>
+<tr><th>Status</th><th>Response</th></tr>
+{{- range .Responses}}
+<tr><td>{{.Status}}</td><td>{{inline .Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
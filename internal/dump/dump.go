@@ -0,0 +1,303 @@
+// Package dump exports the datastore to JSON Lines and imports it back,
+// for backups and for moving between storage backends.
+//
+// A dump starts with a header line, followed by one line per record:
+//
+//	{"type":"veswatch_dump","data":{"version":1,"createdAt":"..."}}
+//	{"type":"daily_close","data":{"date":"2026-01-14","bcv":45.82,...}}
+package dump
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/veswatch/api/internal/apikey"
+	"github.com/veswatch/api/internal/push"
+	"github.com/veswatch/api/internal/rates"
+)
+
+// FormatJSONL is the only dump format.
+const FormatJSONL = "jsonl"
+
+// Version is the dump format version written in the header.
+const Version = 1
+
+// Record types.
+const (
+	TypeHeader       = "veswatch_dump"
+	TypeDailyClose   = "daily_close"
+	TypeAPIKey       = "api_key"
+	TypeSubscription = "push_subscription"
+	TypeAlert        = "alert"
+)
+
+// maxLine bounds the length of a dump line.
+const maxLine = 1 << 20
+
+// ErrInvalidDump is returned for input that isn't a valid dump.
+var ErrInvalidDump = errors.New("invalid dump")
+
+// Stores are the stores a dump covers.
+type Stores struct {
+	History       rates.History
+	APIKeys       apikey.Store
+	Subscriptions push.Store
+	Alerts        rates.AlertStore
+
+	// Rules checks imported alert rules. Without it, any rule is taken.
+	Rules RuleChecker
+}
+
+// RuleChecker checks a user alert rule, as rates.Service does.
+type RuleChecker interface {
+	CheckAlert(rule rates.AlertRule) error
+}
+
+// tierLister lists the tiers an API key store accepts, as apikey.Manager
+// does.
+type tierLister interface {
+	Tiers() []apikey.Tier
+}
+
+// Counts are the number of records exported or imported, by type.
+type Counts map[string]int
+
+// header is the data of the first line.
+type header struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// line is a dump line.
+type line struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// keyRecord is an API key with its secret hash, which isn't part of the
+// key's API representation.
+type keyRecord struct {
+	Hash string `json:"hash"`
+	apikey.Key
+}
+
+// alertRecord is a user alert rule with its owner, which isn't part of the
+// rule's API representation.
+type alertRecord struct {
+	Owner string `json:"owner"`
+	rates.AlertRule
+}
+
+// Export writes every record of the stores to w.
+func (s Stores) Export(w io.Writer) (Counts, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	counts := make(Counts)
+
+	encode := func(typ string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", typ, err)
+		}
+		if err := enc.Encode(line{Type: typ, Data: data}); err != nil {
+			return fmt.Errorf("failed to write dump: %w", err)
+		}
+		return nil
+	}
+	write := func(typ string, v any) error {
+		counts[typ]++
+		return encode(typ, v)
+	}
+
+	if err := encode(TypeHeader, header{Version: Version, CreatedAt: time.Now().UTC()}); err != nil {
+		return nil, err
+	}
+
+	closes, err := s.History.DailyCloses()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range closes {
+		if err := write(TypeDailyClose, c); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := s.APIKeys.Keys()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if err := write(TypeAPIKey, keyRecord{Hash: k.Hash, Key: k}); err != nil {
+			return nil, err
+		}
+	}
+
+	subs, err := s.Subscriptions.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		if err := write(TypeSubscription, sub); err != nil {
+			return nil, err
+		}
+	}
+
+	rules, err := s.Alerts.Alerts("")
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if err := write(TypeAlert, alertRecord{Owner: rule.Owner, AlertRule: rule}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write dump: %w", err)
+	}
+	return counts, nil
+}
+
+// Import reads a dump and saves its records into the stores, replacing
+// records with the same key and keeping the rest. The whole dump is read and
+// checked before anything is saved, so a malformed dump changes nothing.
+func (s Stores) Import(r io.Reader) (Counts, error) {
+	var (
+		closes []rates.DailyClose
+		keys   []apikey.Key
+		subs   []push.Subscription
+		rules  []rates.AlertRule
+	)
+
+	// Keys and rules are checked as the stores would, so one they'd refuse
+	// fails the import before the records ahead of it are saved
+	var tiers map[string]bool
+	if l, ok := s.APIKeys.(tierLister); ok {
+		tiers = make(map[string]bool)
+		for _, t := range l.Tiers() {
+			tiers[t.Name] = true
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLine)
+	n := 0
+	started := false
+	for scanner.Scan() {
+		n++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var l line
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidDump, n, err)
+		}
+		if !started {
+			if err := checkHeader(l); err != nil {
+				return nil, err
+			}
+			started = true
+			continue
+		}
+
+		var err error
+		switch l.Type {
+		case TypeDailyClose:
+			var c rates.DailyClose
+			if err = json.Unmarshal(l.Data, &c); err == nil {
+				if _, perr := time.Parse("2006-01-02", c.Date); perr != nil {
+					err = fmt.Errorf("invalid date %q", c.Date)
+				}
+			}
+			closes = append(closes, c)
+		case TypeAPIKey:
+			var rec keyRecord
+			err = json.Unmarshal(l.Data, &rec)
+			switch {
+			case err != nil:
+			case rec.ID == "" || rec.Hash == "":
+				err = errors.New("id and hash are required")
+			case tiers != nil && !tiers[rec.Tier]:
+				err = fmt.Errorf("%w %q", apikey.ErrUnknownTier, rec.Tier)
+			case !apikey.ValidRole(rec.Role):
+				err = fmt.Errorf("%w %q", apikey.ErrUnknownRole, rec.Role)
+			}
+			rec.Key.Hash = rec.Hash
+			keys = append(keys, rec.Key)
+		case TypeSubscription:
+			var sub push.Subscription
+			if err = json.Unmarshal(l.Data, &sub); err == nil {
+				err = sub.Validate()
+			}
+			subs = append(subs, sub)
+		case TypeAlert:
+			var rec alertRecord
+			err = json.Unmarshal(l.Data, &rec)
+			switch {
+			case err != nil:
+			case rec.ID == "" || rec.Owner == "":
+				err = errors.New("id and owner are required")
+			case s.Rules != nil:
+				err = s.Rules.CheckAlert(rec.AlertRule)
+			}
+			rec.AlertRule.Owner = rec.Owner
+			rules = append(rules, rec.AlertRule)
+		default:
+			err = fmt.Errorf("unknown record type %q", l.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidDump, n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dump: %w", err)
+	}
+	if !started {
+		return nil, fmt.Errorf("%w: missing %s header", ErrInvalidDump, TypeHeader)
+	}
+
+	counts := make(Counts)
+	for _, c := range closes {
+		if err := s.History.SaveDailyClose(c); err != nil {
+			return counts, err
+		}
+		counts[TypeDailyClose]++
+	}
+	for _, k := range keys {
+		if err := s.APIKeys.SaveKey(k); err != nil {
+			return counts, err
+		}
+		counts[TypeAPIKey]++
+	}
+	for _, sub := range subs {
+		if err := s.Subscriptions.Save(sub); err != nil {
+			return counts, err
+		}
+		counts[TypeSubscription]++
+	}
+	for _, rule := range rules {
+		if err := s.Alerts.SaveAlert(rule); err != nil {
+			return counts, err
+		}
+		counts[TypeAlert]++
+	}
+	return counts, nil
+}
+
+// checkHeader verifies the first line of a dump.
+func checkHeader(l line) error {
+	var h header
+	if l.Type != TypeHeader || json.Unmarshal(l.Data, &h) != nil {
+		return fmt.Errorf("%w: missing %s header", ErrInvalidDump, TypeHeader)
+	}
+	if h.Version != Version {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidDump, h.Version)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package dump
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/veswatch/api/internal/apikey"
+	"github.com/veswatch/api/internal/push"
+	"github.com/veswatch/api/internal/rates"
+)
+
+func TestImportRefusesBeforeSaving(t *testing.T) {
+	const (
+		header = `{"type":"veswatch_dump","data":{"version":1,"createdAt":"2026-01-14T00:00:00Z"}}`
+		daily  = `{"type":"daily_close","data":{"date":"2026-01-14","bcv":45.82}}`
+	)
+	tests := []struct {
+		name, record string
+	}{
+		{"unknown tier", `{"type":"api_key","data":{"id":"k1","name":"acme","tier":"platinum","hash":"h"}}`},
+		{"unknown role", `{"type":"api_key","data":{"id":"k1","name":"acme","tier":"free","role":"root","hash":"h"}}`},
+		{"unknown alert source", `{"type":"alert","data":{"id":"a1","owner":"acme","source":"euro","condition":"above","threshold":50,"channel":"sms","to":"+584121234567"}}`},
+		{"disabled alert channel", `{"type":"alert","data":{"id":"a1","owner":"acme","source":"bcv","condition":"above","threshold":50,"channel":"telegram","to":"123"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := apikey.NewManager(apikey.NewMemoryStore(), apikey.DefaultTiers())
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			service := rates.NewService(nil, nil, nil)
+			service.SetAlertChannels([]string{"sms"})
+			history := rates.NewMemoryHistory()
+			s := Stores{
+				History:       history,
+				APIKeys:       keys,
+				Subscriptions: push.NewMemoryStore(),
+				Alerts:        rates.NewMemoryAlertStore(),
+				Rules:         service,
+			}
+
+			dump := strings.Join([]string{header, daily, tt.record}, "\n")
+			if _, err := s.Import(strings.NewReader(dump)); !errors.Is(err, ErrInvalidDump) {
+				t.Fatalf("got %v, want an invalid dump", err)
+			}
+			if closes, _ := history.DailyCloses(); len(closes) != 0 {
+				t.Errorf("saved %d closes from a dump that was refused", len(closes))
+			}
+		})
+	}
+}
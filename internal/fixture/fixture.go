@@ -0,0 +1,102 @@
+// Package fixture records and replays HTTP responses so scrapers can run
+// against saved snapshots of BCV and Binance instead of the live sites.
+//
+// Fixtures are stored one file per endpoint as raw HTTP responses, under
+// <dir>/<host>/<path>.http, where the path has "/" replaced by "_" and the
+// site root is named "index".
+package fixture
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Path returns the fixture file for a request URL.
+func Path(dir string, req *http.Request) string {
+	name := strings.Trim(req.URL.Path, "/")
+	if name == "" {
+		name = "index"
+	}
+	name = strings.ReplaceAll(name, "/", "_")
+	return filepath.Join(dir, req.URL.Hostname(), name+".http")
+}
+
+// Replay is an http.RoundTripper serving responses from fixture files.
+// Requests without a matching fixture fail instead of reaching the network.
+type Replay struct {
+	Dir string
+}
+
+// RoundTrip returns the recorded response for the request.
+func (r Replay) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	path := Path(r.Dir, req)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fixture: no recording for %s %s: %w", req.Method, req.URL, err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil, fmt.Errorf("fixture: invalid recording %s: %w", path, err)
+	}
+	return resp, nil
+}
+
+// Recorder is an http.RoundTripper that saves every response it proxies.
+type Recorder struct {
+	Dir       string
+	Transport http.RoundTripper
+}
+
+// RoundTrip performs the request and records the response.
+func (r Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("fixture: failed to read response: %w", err)
+	}
+
+	// Store the decoded body with an accurate length
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.TransferEncoding = nil
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Transfer-Encoding")
+
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return nil, fmt.Errorf("fixture: failed to dump response: %w", err)
+	}
+
+	path := Path(r.Dir, req)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("fixture: failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, dump, 0o644); err != nil {
+		return nil, fmt.Errorf("fixture: failed to write %s: %w", path, err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
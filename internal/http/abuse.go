@@ -0,0 +1,121 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/veswatch/api/internal/abuse"
+)
+
+// Abuse tracks client offenses and bans repeat offenders.
+type Abuse interface {
+	Banned(client string, now time.Time) (time.Time, bool)
+	Record(client string, offense abuse.Offense, now time.Time) (abuse.Ban, bool)
+	Bans(now time.Time) []abuse.Ban
+	Lift(client string, now time.Time) (abuse.Ban, bool)
+}
+
+// SetAbuse enables temporary bans of clients that keep exceeding their
+// rate limit or requesting paths no endpoint serves. Without it, the
+// /admin/bans endpoints return 404.
+func (h *Handler) SetAbuse(a Abuse) {
+	h.abuse = a
+}
+
+// banKey returns the name bans of a client address are kept under: the
+// address itself, or its /64 for IPv6, as that's what a single host is
+// usually given. Clients without an address aren't banned.
+func banKey(addr netip.Addr) string {
+	switch {
+	case !addr.IsValid():
+		return ""
+	case addr.Is4():
+		return addr.String()
+	}
+	return netip.PrefixFrom(addr, 64).Masked().String()
+}
+
+// guard turns away banned clients with 403, and serves everyone else,
+// counting rate limited requests and requests for unrouted paths as
+// offenses.
+func (h *Handler) guard(mux *http.ServeMux, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	client := ""
+	if h.abuse != nil {
+		client = banKey(h.clientAddr(r))
+	}
+	if client == "" {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	if until, banned := h.abuse.Banned(client, time.Now()); banned {
+		retry := max(int(time.Until(until).Seconds()+0.5), 1)
+		w.Header().Set("Retry-After", strconv.Itoa(retry))
+		writeError(w, r, http.StatusForbidden, "client is temporarily banned")
+		return
+	}
+
+	rec := &accessRecorder{ResponseWriter: w}
+	next.ServeHTTP(rec, r)
+
+	var offense abuse.Offense
+	switch rec.status {
+	case http.StatusTooManyRequests:
+		offense = abuse.RateLimited
+	case http.StatusNotFound:
+		if _, pattern := mux.Handler(r); pattern != "" {
+			return
+		}
+		offense = abuse.NotFound
+	default:
+		return
+	}
+	if ban, banned := h.abuse.Record(client, offense, time.Now()); banned {
+		log.Printf("HTTP: Banned %s until %s after %d offenses (%s)",
+			client, ban.Until.Format(time.RFC3339), ban.Offenses, ban.Reason)
+	}
+}
+
+// abuseEnabled wraps an admin handler so it returns 404 without abuse
+// detection.
+func (h *Handler) abuseEnabled(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.abuse == nil {
+			writeError(w, r, http.StatusNotFound, "abuse detection is not enabled")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleListBans returns the bans in effect, latest first.
+func (h *Handler) handleListBans(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bans": h.abuse.Bans(time.Now()),
+	})
+}
+
+// handleLiftBan lifts the ban of a client address, or of the /64 an IPv6
+// address is in.
+func (h *Handler) handleLiftBan(w http.ResponseWriter, r *http.Request) {
+	addr, err := netip.ParseAddr(r.PathValue("client"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid client address")
+		return
+	}
+
+	client := banKey(addr.Unmap())
+	ban, ok := h.abuse.Lift(client, time.Now())
+	if !ok {
+		writeError(w, r, http.StatusNotFound, fmt.Sprintf("client %s is not banned", client))
+		return
+	}
+	log.Printf("HTTP: Lifted the ban of %s", client)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ban": ban,
+	})
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/veswatch/api/internal/apikey"
+)
+
+// Access levels of endpoints besides the admin roles, which require admin
+// credentials with that role.
+const (
+	// AccessPublic serves an endpoint as it's registered, e.g. to exempt
+	// it from a broader rule.
+	AccessPublic = "public"
+
+	// AccessKey requires an API key or identity provider token.
+	AccessKey = "key"
+)
+
+// AccessRules sets the access level of the endpoints matching each
+// pattern, on top of what every endpoint requires by itself: a rule can
+// make /rates/history need a key, but can't open the admin API.
+type AccessRules struct {
+	patterns *http.ServeMux
+	levels   map[string]string // by pattern
+}
+
+// NewAccessRules compiles rules mapping ServeMux patterns, e.g.
+// "GET /rates/history" or "/v1/", to access levels. As with routes, the
+// most specific pattern matching a request applies.
+func NewAccessRules(rules map[string]string) (a *AccessRules, err error) {
+	a = &AccessRules{
+		patterns: http.NewServeMux(),
+		levels:   make(map[string]string, len(rules)),
+	}
+	for pattern, level := range rules {
+		if level != AccessPublic && level != AccessKey && (level == "" || !apikey.ValidRole(level)) {
+			return nil, fmt.Errorf("unknown access level %q for %s", level, pattern)
+		}
+		if err := a.add(pattern); err != nil {
+			return nil, err
+		}
+		a.levels[pattern] = level
+	}
+	return a, nil
+}
+
+// add registers a pattern, turning the panics of invalid or conflicting
+// patterns into errors.
+func (a *AccessRules) add(pattern string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("invalid access rule pattern: %v", p)
+		}
+	}()
+	a.patterns.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
+
+// level returns the access level of the rule matching a request, or empty
+// if none does.
+func (a *AccessRules) level(r *http.Request) string {
+	_, pattern := a.patterns.Handler(r)
+	return a.levels[pattern]
+}
+
+// SetAccessRules enables per-endpoint access rules.
+func (h *Handler) SetAccessRules(rules *AccessRules) {
+	h.access = rules
+}
+
+// checkAccess serves a request after enforcing the access rule of its
+// endpoint, if any.
+func (h *Handler) checkAccess(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	level := ""
+	if h.access != nil {
+		level = h.access.level(r)
+	}
+
+	switch level {
+	case "", AccessPublic:
+		next(w, r)
+	case AccessKey:
+		h.authenticated(next)(w, r)
+	default:
+		h.admin(level, next)(w, r)
+	}
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/veswatch/api/internal/accesslog"
+)
+
+// AccessLogger records served requests.
+type AccessLogger interface {
+	Log(entry accesslog.Entry)
+}
+
+// SetAccessLog enables the access log. Without a logger, requests are only
+// logged to the application log.
+func (h *Handler) SetAccessLog(logger AccessLogger) {
+	h.accessLog = logger
+}
+
+// logAccess serves the request and writes its access log entry.
+func (h *Handler) logAccess(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if h.accessLog == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	start := time.Now()
+	rec := &accessRecorder{ResponseWriter: w}
+	next.ServeHTTP(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	h.accessLog.Log(accesslog.Entry{
+		Time:      start,
+		Client:    h.clientIP(r),
+		Method:    r.Method,
+		URI:       r.URL.RequestURI(),
+		Proto:     r.Proto,
+		Status:    rec.status,
+		Bytes:     rec.bytes,
+		Referer:   r.Referer(),
+		UserAgent: r.UserAgent(),
+		Duration:  time.Since(start),
+	})
+}
+
+// accessRecorder captures the status and body size of a response.
+type accessRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *accessRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
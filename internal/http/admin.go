@@ -0,0 +1,116 @@
+package http
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/veswatch/api/internal/adminui"
+	"github.com/veswatch/api/internal/apikey"
+)
+
+// adminUIPolicy is the Content-Security-Policy of the admin page: its own
+// inline script and style, calls to this origin only, and no framing.
+const adminUIPolicy = "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'; form-action 'none'; frame-ancestors 'none'"
+
+// SetAdminToken sets the bearer token required by admin endpoints.
+// Without a token, admin endpoints are disabled.
+func (h *Handler) SetAdminToken(token string) {
+	h.adminToken = token
+}
+
+// adminEnabled reports whether the admin API is served: with an admin
+// token, or with an identity provider whose identities may have roles.
+func (h *Handler) adminEnabled() bool {
+	return h.adminToken != "" || h.identities != nil
+}
+
+// admin wraps a handler so it requires the admin bearer token, or an API
+// key or identity provider token whose role includes the given one. The
+// admin token has every role.
+func (h *Handler) admin(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.adminEnabled() {
+			writeError(w, r, http.StatusNotFound, "admin API is disabled")
+			return
+		}
+
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if h.adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1 {
+			next(w, r)
+			return
+		}
+
+		key, err := h.lookupClient(r.Context(), token)
+		if err == nil && key.Role == "" {
+			err = errUnknownKey
+		}
+		if err != nil {
+			writeAuthError(w, r, err, "veswatch-admin", "invalid or missing admin token")
+			return
+		}
+		if !key.Allows(role) {
+			writeError(w, r, http.StatusForbidden, fmt.Sprintf("this endpoint requires the %s role", role))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// debug wraps a pprof handler so it requires the admin role when the admin
+// API is enabled. Otherwise, pprof is only protected by being served on the
+// internal listener.
+func (h *Handler) debug(next http.HandlerFunc) http.HandlerFunc {
+	protected := h.admin(apikey.RoleAdmin, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.adminEnabled() {
+			next(w, r)
+			return
+		}
+		protected(w, r)
+	}
+}
+
+// handleAdminUI serves the admin web page. The page holds no data, so it's
+// served without the token, which it asks for and sends with every call
+// to the admin API; it's still disabled along with the admin API.
+func (h *Handler) handleAdminUI(w http.ResponseWriter, r *http.Request) {
+	if !h.adminEnabled() {
+		writeError(w, r, http.StatusNotFound, "admin API is disabled")
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Content-Security-Policy", adminUIPolicy)
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(adminui.Index)
+}
+
+// handleAudit returns recent rate update audit entries.
+func (h *Handler) handleAudit(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.rateProvider.GetAuditLog(r.URL.Query().Get("source"), limit)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed to read audit log")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"entries": entries,
+	})
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// handleListAlerts returns the client's alert rules.
+func (h *Handler) handleListAlerts(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.rateProvider.ListAlerts(clientFromContext(r.Context()).Name)
+	if err != nil {
+		log.Printf("HTTP: Failed to list alerts: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"alerts": rules,
+	})
+}
+
+// handleCreateAlert creates an alert rule for the client.
+func (h *Handler) handleCreateAlert(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source    string  `json:"source"`
+		Condition string  `json:"condition"`
+		Threshold float64 `json:"threshold"`
+		Channel   string  `json:"channel"`
+		To        string  `json:"to"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	rule, err := h.rateProvider.CreateAlert(clientFromContext(r.Context()).Name, rates.AlertRule{
+		Source:    req.Source,
+		Condition: req.Condition,
+		Threshold: req.Threshold,
+		Channel:   req.Channel,
+		To:        req.To,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, rates.ErrInvalidAlert):
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, rates.ErrTooManyAlerts):
+			writeError(w, r, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		log.Printf("HTTP: Failed to create alert: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+// handleDeleteAlert deletes one of the client's alert rules.
+func (h *Handler) handleDeleteAlert(w http.ResponseWriter, r *http.Request) {
+	err := h.rateProvider.DeleteAlert(clientFromContext(r.Context()).Name, r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, rates.ErrAlertNotFound) {
+			writeError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("HTTP: Failed to delete alert: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/veswatch/api/internal/analytics"
+)
+
+// defaultAnalyticsDays is the period /admin/analytics covers by default.
+const defaultAnalyticsDays = 30
+
+// Analytics counts requests and summarizes them.
+type Analytics interface {
+	Record(endpoint, client string)
+	Summary(days int) analytics.Summary
+}
+
+// SetAnalytics enables request analytics. Without them, /admin/analytics
+// returns 404.
+func (h *Handler) SetAnalytics(a Analytics) {
+	h.analytics = a
+}
+
+// recordRequest counts a request under the route it matches. Preflight
+// requests aren't counted.
+func (h *Handler) recordRequest(mux *http.ServeMux, r *http.Request) {
+	if h.analytics == nil || r.Method == http.MethodOptions {
+		return
+	}
+
+	// Patterns keep the endpoint list bounded, e.g. "DELETE /alerts/{id}"
+	_, pattern := mux.Handler(r)
+	h.analytics.Record(strings.TrimSuffix(pattern, "{$}"), h.clientIP(r))
+}
+
+// handleAnalytics returns request statistics for the last days.
+func (h *Handler) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	if h.analytics == nil {
+		writeError(w, r, http.StatusNotFound, "analytics are not enabled")
+		return
+	}
+
+	days := defaultAnalyticsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > analytics.MaxDays {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("days must be between 1 and %d", analytics.MaxDays))
+			return
+		}
+		days = n
+	}
+
+	writeJSON(w, http.StatusOK, h.analytics.Summary(days))
+}
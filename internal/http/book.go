@@ -0,0 +1,22 @@
+package http
+
+import (
+	"net/http"
+)
+
+// handleBook returns anonymized statistics about the Binance ads sampled on
+// the latest fetch.
+func (h *Handler) handleBook(w http.ResponseWriter, r *http.Request) {
+	loc, err := location(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	book, err := h.rateProvider.GetBook()
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, book.In(loc))
+}
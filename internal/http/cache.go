@@ -0,0 +1,336 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/scheduler"
+)
+
+// Schedule reports when scheduled jobs next refresh their source data, and
+// the state of every job.
+type Schedule interface {
+	NextRun(job string) time.Time
+	Status() []scheduler.JobStatus
+}
+
+// maxStale is how long past its expiry a revalidated entry may still be
+// served while its replacement renders.
+const maxStale = time.Minute
+
+// maxCacheEntries bounds the number of cached responses. When the cache
+// fills up, expired entries are swept, and it's emptied if none were.
+const maxCacheEntries = 1024
+
+// cacheEntry is a stored response.
+type cacheEntry struct {
+	status   int
+	header   http.Header
+	body     []byte
+	storedAt time.Time
+	expires  time.Time
+}
+
+// responseCache is an in-process cache of encoded GET responses.
+type responseCache struct {
+	mu           sync.Mutex
+	entries      map[string]*cacheEntry
+	revalidating map[string]bool
+}
+
+// newResponseCache creates an empty response cache.
+func newResponseCache() *responseCache {
+	return &responseCache{
+		entries:      make(map[string]*cacheEntry),
+		revalidating: make(map[string]bool),
+	}
+}
+
+// get returns a fresh entry for key, evicting it if expired.
+func (c *responseCache) get(key string, now time.Time) (*cacheEntry, bool) {
+	entry, fresh := c.lookup(key, now, 0)
+	return entry, entry != nil && fresh
+}
+
+// lookup returns the entry for key and whether it's fresh. Expired entries
+// are returned for up to stale past their expiry, and evicted after.
+func (c *responseCache) lookup(key string, now time.Time, stale time.Duration) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if now.Before(entry.expires) {
+		return entry, true
+	}
+	if now.Before(entry.expires.Add(stale)) {
+		return entry, false
+	}
+	delete(c.entries, key)
+	return nil, false
+}
+
+// set stores an entry under key, making room if the cache is full.
+func (c *responseCache) set(key string, entry *cacheEntry, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCacheEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires.Add(maxStale)) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = entry
+}
+
+// claim marks key as being revalidated, reporting false if it already is.
+func (c *responseCache) claim(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.revalidating[key] {
+		return false
+	}
+	c.revalidating[key] = true
+	return true
+}
+
+// release marks the revalidation of key as finished.
+func (c *responseCache) release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.revalidating, key)
+}
+
+// clear removes every entry.
+func (c *responseCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// captureWriter records a response while passing it through.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// discardWriter is where background revalidations write, their response
+// only being cached.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header       { return w.header }
+func (discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (discardWriter) WriteHeader(int)             {}
+
+// cached wraps a handler with the response cache. Responses are keyed on
+// the query parameters in params, the ones the handler reads, so others
+// don't fill the cache. Entries expire at the next scheduled run of the
+// given jobs, so neither this cache nor downstream CDN/proxy caches serve
+// data past the next source update.
+func (h *Handler) cached(next http.HandlerFunc, params []string, jobs ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		expires := h.nextRefresh(now, jobs)
+		scope, key := cacheScope(r, params)
+		r = withParams(r, params)
+
+		// Refresh in progress or no schedule known: don't cache
+		if !expires.After(now) {
+			w.Header().Set("Cache-Control", scope+", max-age=0, must-revalidate")
+			next(w, r)
+			return
+		}
+
+		if entry, ok := h.cache.get(key, now); ok {
+			serveEntry(w, entry, now, "")
+			return
+		}
+
+		cacheControl := fmt.Sprintf("%s, max-age=%d", scope, int(expires.Sub(now).Seconds()))
+		h.render(w, r, next, key, now, expires, cacheControl)
+	}
+}
+
+// revalidated wraps a handler with the response cache like cached, except
+// that once an entry expires it's still served, for up to maxStale, while a
+// background request renders its replacement. Requests arriving as sources
+// refresh are then answered from memory instead of waiting on the handler.
+// While a refresh is running, rendered entries are stale right away.
+func (h *Handler) revalidated(next http.HandlerFunc, params []string, jobs ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, key := cacheScope(r, params)
+		r = withParams(r, params)
+		if h.schedule == nil {
+			w.Header().Set("Cache-Control", scope+", max-age=0, must-revalidate")
+			next(w, r)
+			return
+		}
+
+		now := time.Now()
+		entry, fresh := h.cache.lookup(key, now, maxStale)
+		if fresh {
+			serveEntry(w, entry, now, "")
+			return
+		}
+
+		expires := h.nextRefresh(now, jobs)
+		if expires.Before(now) {
+			expires = now
+		}
+		cacheControl := fmt.Sprintf("%s, max-age=%d, stale-while-revalidate=%d", scope, int(expires.Sub(now).Seconds()), int(maxStale.Seconds()))
+
+		if entry != nil {
+			if h.cache.claim(key) {
+				go func() {
+					defer h.cache.release(key)
+					bg := r.Clone(context.WithoutCancel(r.Context()))
+					h.render(discardWriter{header: make(http.Header)}, bg, next, key, time.Now(), expires, cacheControl)
+				}()
+			}
+			serveEntry(w, entry, now, fmt.Sprintf("%s, max-age=0, stale-while-revalidate=%d", scope, int(maxStale.Seconds())))
+			return
+		}
+		h.render(w, r, next, key, now, expires, cacheControl)
+	}
+}
+
+// cacheScope returns the Cache-Control scope of a request's response and
+// its cache key, made of the given query parameters. Responses to API key
+// clients depend on their tier, so shared caches must not store them.
+func cacheScope(r *http.Request, params []string) (scope, key string) {
+	scope = "public"
+	tier := clientFromContext(r.Context()).Tier
+	if tier != "" {
+		scope = "private"
+	}
+	query := ""
+	if r.URL.RawQuery != "" {
+		query = keptQuery(r, params)
+	}
+	key = tier + " " + negotiate(r) + " " + string(langFromContext(r.Context())) + " " + r.URL.Path + "?" + query
+	return scope, key
+}
+
+// keptQuery returns the request's query with only the given parameters.
+func keptQuery(r *http.Request, params []string) string {
+	all := r.URL.Query()
+	kept := make(url.Values, len(params))
+	for _, p := range params {
+		if v, ok := all[p]; ok {
+			kept[p] = v
+		}
+	}
+	return kept.Encode()
+}
+
+// withParams returns the request with only the given query parameters, so
+// a cached response, e.g. its next page link, can't depend on the others.
+func withParams(r *http.Request, params []string) *http.Request {
+	if r.URL.RawQuery == "" {
+		return r
+	}
+	r = r.Clone(r.Context())
+	r.URL.RawQuery = keptQuery(r, params)
+	return r
+}
+
+// render calls next, storing a successful response under key until expires.
+func (h *Handler) render(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, key string, now, expires time.Time, cacheControl string) {
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Age", "0")
+
+	cw := &captureWriter{ResponseWriter: w}
+	next(cw, r)
+
+	// Only successful responses are cached
+	if cw.status == http.StatusOK {
+		h.cache.set(key, &cacheEntry{
+			status:   cw.status,
+			header:   w.Header().Clone(),
+			body:     cw.buf.Bytes(),
+			storedAt: now,
+			expires:  expires,
+		}, now)
+	}
+}
+
+// serveEntry writes a cached response, with its Age and X-Data-Age brought
+// up to date. A non-empty cacheControl replaces the stored Cache-Control.
+func serveEntry(w http.ResponseWriter, entry *cacheEntry, now time.Time, cacheControl string) {
+	for k, v := range entry.header {
+		// Rate limit headers belong to the current request
+		if strings.HasPrefix(k, "X-Ratelimit-") {
+			continue
+		}
+		w.Header()[k] = v
+	}
+	age := int(now.Sub(entry.storedAt).Seconds())
+	w.Header().Set("Age", strconv.Itoa(age))
+	if dataAge, err := strconv.Atoi(entry.header.Get("X-Data-Age")); err == nil {
+		w.Header().Set("X-Data-Age", strconv.Itoa(dataAge+age))
+	}
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+	w.WriteHeader(entry.status)
+	w.Write(entry.body)
+}
+
+// setDataAge sets the X-Data-Age header to the seconds since the response's
+// data was updated.
+func setDataAge(w http.ResponseWriter, updatedAt time.Time) {
+	if updatedAt.IsZero() {
+		return
+	}
+	w.Header().Set("X-Data-Age", strconv.Itoa(int(time.Since(updatedAt).Seconds())))
+}
+
+// nextRefresh returns the earliest upcoming run among the given jobs, or the
+// zero time if no schedule is configured or any of the jobs is running.
+func (h *Handler) nextRefresh(now time.Time, jobs []string) time.Time {
+	if h.schedule == nil {
+		return time.Time{}
+	}
+
+	var earliest time.Time
+	for _, job := range jobs {
+		next := h.schedule.NextRun(job)
+		if !next.After(now) {
+			return time.Time{}
+		}
+		if earliest.IsZero() || next.Before(earliest) {
+			earliest = next
+		}
+	}
+	return earliest
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/veswatch/api/internal/scheduler"
+)
+
+// hourly is a schedule running every job an hour from now.
+type hourly struct{}
+
+func (hourly) NextRun(string) time.Time      { return time.Now().Add(time.Hour) }
+func (hourly) Status() []scheduler.JobStatus { return nil }
+
+func TestCachedIgnoresUnreadParams(t *testing.T) {
+	h := &Handler{cache: newResponseCache(), schedule: hourly{}}
+	calls := 0
+	var seen []string
+	handler := h.cached(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		seen = append(seen, r.URL.RawQuery)
+		w.Write([]byte("ok"))
+	}, []string{"tz"}, scheduler.JobBinance)
+
+	for i := range 50 {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("GET", fmt.Sprintf("/v1/rates?tz=UTC&x=%d", i), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, rec.Code)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+	if len(seen) > 0 && seen[0] != "tz=UTC" {
+		t.Errorf("handler got query %q, want only tz", seen[0])
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/v1/rates?tz=America/New_York", nil))
+	if calls != 2 {
+		t.Errorf("a different tz was served from the cache")
+	}
+}
+
+func TestResponseCacheBounded(t *testing.T) {
+	c := newResponseCache()
+	now := time.Now()
+
+	// Expired entries are swept once the cache is full
+	for i := range maxCacheEntries {
+		c.set(fmt.Sprint("expired", i), &cacheEntry{expires: now.Add(-maxStale - time.Second)}, now)
+	}
+	c.set("fresh", &cacheEntry{expires: now.Add(time.Hour)}, now)
+	if len(c.entries) != 1 {
+		t.Errorf("got %d entries after the sweep, want 1", len(c.entries))
+	}
+
+	// Without any expired, it's emptied
+	for i := range maxCacheEntries - 1 {
+		c.set(fmt.Sprint("live", i), &cacheEntry{expires: now.Add(time.Hour)}, now)
+	}
+	c.set("another", &cacheEntry{expires: now.Add(time.Hour)}, now)
+	if len(c.entries) > maxCacheEntries {
+		t.Errorf("got %d entries, want at most %d", len(c.entries), maxCacheEntries)
+	}
+	if _, ok := c.get("another", now); !ok {
+		t.Error("the entry set last isn't cached")
+	}
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// handleSetCash records a manually entered street cash-dollar rate.
+func (h *Handler) handleSetCash(w http.ResponseWriter, r *http.Request) {
+	loc, err := location(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req struct {
+		Rate float64 `json:"rate"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	cash, err := h.rateProvider.SetCash(req.Rate)
+	if errors.Is(err, rates.ErrRateRejected) {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("HTTP: Failed to set cash rate: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	// Cached rates no longer reflect the new value
+	h.cache.clear()
+	writeJSON(w, http.StatusOK, cash.In(loc))
+}
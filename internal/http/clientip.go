@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// SetTrustedProxies sets the reverse proxies whose forwarding headers are
+// believed. Only their X-Forwarded-For is read; Fly-Client-IP is only
+// believed on Fly, where clients can't set it.
+func (h *Handler) SetTrustedProxies(proxies []netip.Prefix) {
+	h.proxies = proxies
+}
+
+// SetBehindFly reports that the public listeners are only reachable through
+// Fly's proxy, which sets Fly-Client-IP on every request it forwards, so
+// the header is believed there even without trusted proxies. It isn't on
+// internal listeners, which Fly's proxy doesn't front.
+func (h *Handler) SetBehindFly(fly bool) {
+	h.fly = fly
+}
+
+// internalKey marks the context of requests to an internal listener.
+type internalKey struct{}
+
+// withInternal marks a request as received on an internal listener.
+func withInternal(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), internalKey{}, true))
+}
+
+// IPFilter admits the client addresses in Allow, or any if it's empty,
+// except those in Deny.
+type IPFilter struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+// admits reports whether the filter lets a client address through.
+// Addresses that can't be told, e.g. over Unix sockets, are only admitted
+// without an allow list.
+func (f IPFilter) admits(addr netip.Addr) bool {
+	if addr.IsValid() && contains(f.Deny, addr) {
+		return false
+	}
+	return len(f.Allow) == 0 || addr.IsValid() && contains(f.Allow, addr)
+}
+
+// SetAdminFilter restricts the metrics, admin and pprof endpoints to the
+// client addresses the filter admits, wherever they're served.
+func (h *Handler) SetAdminFilter(filter IPFilter) {
+	h.adminFilter = filter
+}
+
+// restricted wraps an internal endpoint so it answers 403 to clients the
+// admin filter doesn't admit.
+func (h *Handler) restricted(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.adminFilter.admits(h.clientAddr(r)) {
+			writeError(w, r, http.StatusForbidden, "client address not allowed")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clientIP returns the address of the client for logs and analytics.
+func (h *Handler) clientIP(r *http.Request) string {
+	if addr := h.clientAddr(r); addr.IsValid() {
+		return addr.String()
+	}
+	return r.RemoteAddr
+}
+
+// clientAddr returns the address of the client. On Fly's public listeners,
+// it's Fly-Client-IP, which Fly's proxy sets on every request. Behind
+// trusted proxies, it's the last address in X-Forwarded-For that isn't a
+// trusted proxy itself, as every proxy appends the address it got the
+// request from. Connections over Unix sockets come from a local proxy, so
+// they're trusted. Otherwise it's the connection's peer.
+func (h *Handler) clientAddr(r *http.Request) netip.Addr {
+	if h.fly && r.Context().Value(internalKey{}) == nil {
+		if addr, err := netip.ParseAddr(r.Header.Get("Fly-Client-IP")); err == nil {
+			return addr.Unmap()
+		}
+	}
+
+	peer := remoteAddr(r)
+	if len(h.proxies) == 0 || peer.IsValid() && !contains(h.proxies, peer) {
+		return peer
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := peer
+	for _, entry := range slices.Backward(forwarded) {
+		addr, err := netip.ParseAddr(strings.TrimSpace(entry))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !contains(h.proxies, client) {
+			break
+		}
+	}
+	return client
+}
+
+// remoteAddr returns the address of the connection's peer, or the zero
+// address over Unix sockets.
+func remoteAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// contains reports whether any of the prefixes contains addr.
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
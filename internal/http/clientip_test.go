@@ -0,0 +1,101 @@
+package http
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientAddr(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name     string
+		proxies  []netip.Prefix
+		fly      bool
+		internal bool
+		peer     string
+		header   map[string]string
+		want     string
+	}{
+		{
+			name: "direct",
+			peer: "203.0.113.7:4000",
+			want: "203.0.113.7",
+		},
+		{
+			name:   "direct with spoofed headers",
+			peer:   "203.0.113.7:4000",
+			header: map[string]string{"Fly-Client-IP": "127.0.0.1", "X-Forwarded-For": "127.0.0.1"},
+			want:   "203.0.113.7",
+		},
+		{
+			name:    "behind proxy",
+			proxies: proxies,
+			peer:    "10.0.0.2:4000",
+			header:  map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.0.0.3"},
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "behind proxy with spoofed Fly-Client-IP",
+			proxies: proxies,
+			peer:    "10.0.0.2:4000",
+			header:  map[string]string{"Fly-Client-IP": "127.0.0.1", "X-Forwarded-For": "203.0.113.7"},
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "untrusted peer with spoofed headers",
+			proxies: proxies,
+			peer:    "203.0.113.7:4000",
+			header:  map[string]string{"Fly-Client-IP": "127.0.0.1", "X-Forwarded-For": "10.0.0.3"},
+			want:    "203.0.113.7",
+		},
+		{
+			name:   "fly",
+			fly:    true,
+			peer:   "172.16.0.2:4000",
+			header: map[string]string{"Fly-Client-IP": "203.0.113.7", "X-Forwarded-For": "127.0.0.1"},
+			want:   "203.0.113.7",
+		},
+		{
+			name:    "fly with trusted proxies",
+			proxies: proxies,
+			fly:     true,
+			peer:    "10.0.0.2:4000",
+			header:  map[string]string{"Fly-Client-IP": "203.0.113.7", "X-Forwarded-For": "127.0.0.1"},
+			want:    "203.0.113.7",
+		},
+		{
+			name:     "fly internal listener",
+			fly:      true,
+			internal: true,
+			peer:     "172.16.0.2:4000",
+			header:   map[string]string{"Fly-Client-IP": "203.0.113.7"},
+			want:     "172.16.0.2",
+		},
+		{
+			name: "unix socket",
+			peer: "@",
+			want: "invalid IP",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{}
+			h.SetTrustedProxies(tt.proxies)
+			h.SetBehindFly(tt.fly)
+
+			r := httptest.NewRequest("GET", "/rates", nil)
+			r.RemoteAddr = tt.peer
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			if tt.internal {
+				r = withInternal(r)
+			}
+			if got := h.clientAddr(r).String(); got != tt.want {
+				t.Errorf("clientAddr = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
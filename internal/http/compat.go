@@ -0,0 +1,186 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// pyMonitor is a rate in the pydolarvenezuela API's monitor shape.
+type pyMonitor struct {
+	Change     float64 `json:"change"`
+	Color      string  `json:"color"`
+	Image      string  `json:"image"`
+	LastUpdate any     `json:"last_update"`
+	Percent    float64 `json:"percent"`
+	Price      float64 `json:"price"`
+	PriceOld   float64 `json:"price_old"`
+	Symbol     string  `json:"symbol"`
+	Title      string  `json:"title"`
+}
+
+// pyParallelMonitor is the pydolarvenezuela monitor that clients commonly
+// read as "the" parallel rate. It serves the parallel consensus.
+const pyParallelMonitor = "enparalelovzla"
+
+// Spanish day and month names for pydolarvenezuela's date strings.
+var (
+	spanishDays   = [...]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"}
+	spanishMonths = [...]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"}
+)
+
+// handleDollarCompat serves rates in the response shape of the
+// pydolarvenezuela API (GET /api/v1/dollar), so apps built against it can
+// switch to VESWatch by changing the base URL.
+//
+// Query parameters: page ("bcv" limits the response to BCV; any other page
+// returns every monitor), monitor (return a single monitor), format_date
+// ("default", "iso" or "timestamp") and rounded_price ("true" or "false").
+func (h *Handler) handleDollarCompat(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	formatDate := q.Get("format_date")
+	switch formatDate {
+	case "":
+		formatDate = "default"
+	case "default", "iso", "timestamp":
+	default:
+		writeError(w, r, http.StatusBadRequest, `format_date must be "default", "iso" or "timestamp"`)
+		return
+	}
+	rounded := q.Get("rounded_price") != "false"
+
+	closes, err := h.rateProvider.GetDailyCloses()
+	if err != nil {
+		log.Printf("HTTP: Failed to load history: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	now := time.Now().In(defaultLocation)
+	v := h.rateProvider.GetRatesV1()
+	previous := previousClose(closes, now.Format("2006-01-02"))
+
+	monitors := make(map[string]pyMonitor)
+	add := func(key, title string, rate rates.SourceRate, old float64) {
+		if rate.Rate <= 0 {
+			return
+		}
+		monitors[key] = newPyMonitor(title, rate.Rate, old, rate.UpdatedAt.In(defaultLocation), formatDate, rounded)
+	}
+
+	add("bcv", "Banco Central de Venezuela", v.BCV, previous.BCV)
+	if q.Get("page") != "bcv" {
+		add(pyParallelMonitor, "Dólar paralelo (consenso VESWatch)", rates.SourceRate{Rate: v.Parallel.Rate, UpdatedAt: v.UpdatedAt}, 0)
+		for _, src := range v.Parallel.Sources {
+			old := 0.0
+			if src.Name == "binance" {
+				old = previous.Binance
+			}
+			add(src.Name, pyTitle(src.Name), src, old)
+		}
+	}
+
+	if name := q.Get("monitor"); name != "" {
+		m, ok := monitors[strings.ToLower(name)]
+		if !ok {
+			writeError(w, r, http.StatusNotFound, fmt.Sprintf("monitor %q not found", name))
+			return
+		}
+		writeJSON(w, http.StatusOK, m)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"datetime": map[string]string{
+			"date": spanishDate(now),
+			"time": spanishTime(now),
+		},
+		"monitors": monitors,
+	})
+}
+
+// previousClose returns the most recent daily close before today, or the
+// zero close if there is none.
+func previousClose(closes []rates.DailyClose, today string) rates.DailyClose {
+	var previous rates.DailyClose
+	for _, c := range closes {
+		if c.Date < today && c.Date > previous.Date {
+			previous = c
+		}
+	}
+	return previous
+}
+
+// newPyMonitor builds a monitor. Without a previous price the change is
+// reported as neutral.
+func newPyMonitor(title string, price, old float64, updated time.Time, formatDate string, rounded bool) pyMonitor {
+	if old <= 0 {
+		old = price
+	}
+
+	m := pyMonitor{
+		Title:    title,
+		Price:    price,
+		PriceOld: old,
+		Change:   math.Abs(price - old),
+		Percent:  math.Abs(price/old-1) * 100,
+		Color:    "neutral",
+	}
+	if rounded {
+		m.Price = round2(m.Price)
+		m.PriceOld = round2(m.PriceOld)
+		m.Change = round2(m.Change)
+		m.Percent = round2(m.Percent)
+	}
+
+	// pydolarvenezuela marks a rising dollar red, as a loss for the bolívar
+	switch {
+	case price > old:
+		m.Color, m.Symbol = "red", "▲"
+	case price < old:
+		m.Color, m.Symbol = "green", "▼"
+	}
+
+	switch formatDate {
+	case "iso":
+		m.LastUpdate = updated.Format(time.RFC3339)
+	case "timestamp":
+		m.LastUpdate = updated.Unix()
+	default:
+		m.LastUpdate = updated.Format("02/01/2006, 03:04 PM")
+	}
+	return m
+}
+
+// pyTitle returns a display title for a parallel source.
+func pyTitle(name string) string {
+	if name == "binance" {
+		return "Binance"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// spanishDate formats a date like "jueves, 15 de febrero de 2024".
+func spanishDate(t time.Time) string {
+	return fmt.Sprintf("%s, %d de %s de %d", spanishDays[t.Weekday()], t.Day(), spanishMonths[t.Month()-1], t.Year())
+}
+
+// spanishTime formats a time like "05:36:02 p.m.".
+func spanishTime(t time.Time) string {
+	suffix := "a.m."
+	if t.Hour() >= 12 {
+		suffix = "p.m."
+	}
+	return t.Format("03:04:05") + " " + suffix
+}
+
+// round2 rounds to two decimals.
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
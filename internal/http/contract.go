@@ -0,0 +1,82 @@
+package http
+
+import (
+	"bytes"
+	"log"
+	"mime"
+	"net/http"
+)
+
+// maxValidatedBody bounds the response body buffered for validation. Larger
+// bodies have their status checked but not their content.
+const maxValidatedBody = 1 << 20
+
+// Validator checks a served request and its response against the API
+// contract.
+type Validator interface {
+	Check(r *http.Request, status int, header http.Header, body []byte) error
+}
+
+// SetValidator enables contract validation, logging every response the
+// validator rejects. It buffers JSON responses, so it's meant for
+// debugging and CI rather than production.
+func (h *Handler) SetValidator(v Validator) {
+	h.validator = v
+}
+
+// validate serves the request and checks the exchange with the validator.
+func (h *Handler) validate(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if h.validator == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	rec := &contractRecorder{ResponseWriter: w}
+	next.ServeHTTP(rec, r)
+	rec.WriteHeader(http.StatusOK)
+
+	var body []byte
+	if rec.json && !rec.truncated {
+		body = rec.body.Bytes()
+	}
+	if err := h.validator.Check(r, rec.status, w.Header(), body); err != nil {
+		log.Printf("HTTP: Contract violation: %v", err)
+	}
+}
+
+// contractRecorder captures the status of a response and a copy of its
+// body, if JSON.
+type contractRecorder struct {
+	http.ResponseWriter
+	status    int
+	json      bool
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *contractRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		w.json = mediaType == "application/json"
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *contractRecorder) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.json && !w.truncated {
+		if w.body.Len()+len(b) > maxValidatedBody {
+			w.truncated = true
+			w.body = bytes.Buffer{}
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *contractRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/veswatch/api/internal/notify"
+)
+
+// DeadLetters lists and re-drives the events notifiers failed to deliver.
+type DeadLetters interface {
+	DeadLetters() ([]notify.DeadLetter, error)
+	Redeliver(id string) (notify.DeadLetter, error)
+	Discard(id string) (notify.DeadLetter, error)
+}
+
+// SetDeadLetters enables the /admin/deadletters endpoints. Without dead
+// letters, they return 404.
+func (h *Handler) SetDeadLetters(d DeadLetters) {
+	h.deadLetters = d
+}
+
+// deadLettersEnabled wraps an admin handler so it returns 404 without dead
+// letters.
+func (h *Handler) deadLettersEnabled(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.deadLetters == nil {
+			writeError(w, r, http.StatusNotFound, "notifications are not enabled")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleListDeadLetters returns the dead letters, oldest first, optionally
+// only those of one notifier.
+func (h *Handler) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters, err := h.filteredDeadLetters(r.URL.Query().Get("notifier"))
+	if err != nil {
+		log.Printf("HTTP: Failed to list dead letters: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"deadLetters": letters,
+	})
+}
+
+// handleRedeliver redelivers a dead letter. A failed redelivery keeps the
+// dead letter and returns 502 with the notifier's error.
+func (h *Handler) handleRedeliver(w http.ResponseWriter, r *http.Request) {
+	letter, err := h.deadLetters.Redeliver(r.PathValue("id"))
+	if err != nil {
+		writeDeadLetterError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"deadLetter": letter,
+	})
+}
+
+// handleRedeliverAll redelivers every dead letter, or those of one
+// notifier, and reports how many were delivered and how many failed again.
+func (h *Handler) handleRedeliverAll(w http.ResponseWriter, r *http.Request) {
+	letters, err := h.filteredDeadLetters(r.URL.Query().Get("notifier"))
+	if err != nil {
+		log.Printf("HTTP: Failed to list dead letters: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	delivered, failed := 0, 0
+	for _, letter := range letters {
+		if _, err := h.deadLetters.Redeliver(letter.ID); err != nil {
+			failed++
+			continue
+		}
+		delivered++
+	}
+	log.Printf("HTTP: Redelivered %d dead letters, %d failed again", delivered, failed)
+	writeJSON(w, http.StatusOK, map[string]int{
+		"delivered": delivered,
+		"failed":    failed,
+	})
+}
+
+// handleDiscardDeadLetter deletes a dead letter without delivering it.
+func (h *Handler) handleDiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	letter, err := h.deadLetters.Discard(r.PathValue("id"))
+	if err != nil {
+		writeDeadLetterError(w, r, err)
+		return
+	}
+
+	log.Printf("HTTP: Discarded dead letter %s (%s event for %s)", letter.ID, letter.Event.Type, letter.Notifier)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// filteredDeadLetters returns the dead letters of a notifier, or all of
+// them if notifier is empty.
+func (h *Handler) filteredDeadLetters(notifier string) ([]notify.DeadLetter, error) {
+	letters, err := h.deadLetters.DeadLetters()
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]notify.DeadLetter, 0, len(letters))
+	for _, letter := range letters {
+		if notifier == "" || letter.Notifier == notifier {
+			filtered = append(filtered, letter)
+		}
+	}
+	return filtered, nil
+}
+
+// writeDeadLetterError writes the response for a failed dead letter
+// operation.
+func writeDeadLetterError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, notify.ErrDeadLetterNotFound):
+		writeError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, notify.ErrNotifierMissing):
+		writeError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, notify.ErrRedeliveryFailed):
+		writeError(w, r, http.StatusBadGateway, err.Error())
+	default:
+		log.Printf("HTTP: Failed to update dead letter: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+	}
+}
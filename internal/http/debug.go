@@ -0,0 +1,19 @@
+package http
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// debugRoutes registers the pprof endpoints. They run without a request
+// timeout, since CPU profiles and traces last as long as requested. Like
+// the admin endpoints, they're restricted by the admin filter and require
+// the admin role when the admin API is enabled.
+func (h *Handler) debugRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /debug/pprof/", h.restricted(h.debug(pprof.Index)))
+	mux.HandleFunc("GET /debug/pprof/cmdline", h.restricted(h.debug(pprof.Cmdline)))
+	mux.HandleFunc("GET /debug/pprof/profile", h.restricted(h.debug(pprof.Profile)))
+	mux.HandleFunc("GET /debug/pprof/symbol", h.restricted(h.debug(pprof.Symbol)))
+	mux.HandleFunc("POST /debug/pprof/symbol", h.restricted(h.debug(pprof.Symbol)))
+	mux.HandleFunc("GET /debug/pprof/trace", h.restricted(h.debug(pprof.Trace)))
+}
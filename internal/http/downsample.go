@@ -0,0 +1,87 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// handleDownsampled returns the rates between from and to aggregated into
+// buckets (?interval=5m|1h|1d&agg=avg|last|ohlc, default avg), one page at
+// a time. It serves /rates/history requests with an interval.
+func (h *Handler) handleDownsampled(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from := h.clampFrom(r, q.Get("from"))
+	to := q.Get("to")
+	interval := q.Get("interval")
+	agg := q.Get("agg")
+	if agg == "" {
+		agg = rates.AggAvg
+	}
+
+	pg, err := parsePage(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ts, _, _ := strings.Cut(pg.After, "/"); pg.After != "" {
+		if _, err := time.Parse(time.RFC3339, ts); err != nil {
+			writeError(w, r, http.StatusBadRequest, errInvalidCursor.Error())
+			return
+		}
+	}
+
+	buckets, err := h.rateProvider.Downsample(r.Context(), from, to, interval, agg)
+	switch {
+	case errors.Is(err, rates.ErrUnknownInterval), errors.Is(err, rates.ErrUnknownAggregation),
+		errors.Is(err, rates.ErrInvalidDate), errors.Is(err, rates.ErrNoSamples):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		log.Printf("HTTP: Failed to downsample history: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	// Buckets are ordered by time and source, as are their keys.
+	start := sort.Search(len(buckets), func(i int) bool {
+		return bucketKey(buckets[i]) > pg.After
+	})
+	buckets = buckets[start:]
+	more := len(buckets) > pg.Limit
+	if more {
+		buckets = buckets[:pg.Limit]
+	}
+
+	fields := parseFields(r)
+
+	filtered := make([]any, 0, len(buckets))
+	for _, b := range buckets {
+		entry, err := sparse(b, fields)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		filtered = append(filtered, entry)
+	}
+
+	body := map[string]any{
+		"interval": interval,
+		"agg":      agg,
+		"buckets":  filtered,
+	}
+	if more {
+		body["next"] = nextLink(r, bucketKey(buckets[len(buckets)-1]))
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// bucketKey is the pagination key of a bucket.
+func bucketKey(b rates.Bucket) string {
+	return b.Time.UTC().Format(time.RFC3339) + "/" + b.Source
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/veswatch/api/internal/dump"
+)
+
+// Datastore exports and imports every stored record as JSON Lines.
+type Datastore interface {
+	Export(w io.Writer) (dump.Counts, error)
+	Import(r io.Reader) (dump.Counts, error)
+}
+
+// SetDatastore enables GET /admin/export and POST /admin/import. Without
+// it, they return 404.
+func (h *Handler) SetDatastore(d Datastore) {
+	h.datastore = d
+}
+
+// handleExport returns a dump of the datastore.
+func (h *Handler) handleExport(w http.ResponseWriter, r *http.Request) {
+	if h.datastore == nil {
+		writeError(w, r, http.StatusNotFound, "datastore export is not enabled")
+		return
+	}
+	if f := r.URL.Query().Get("format"); f != "" && f != dump.FormatJSONL {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("unknown format %q (expected %q)", f, dump.FormatJSONL))
+		return
+	}
+
+	// Buffered so a failed export can still be reported with a status
+	var buf bytes.Buffer
+	if _, err := h.datastore.Export(&buf); err != nil {
+		log.Printf("HTTP: Datastore export failed: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	filename := "veswatch-" + time.Now().UTC().Format("20060102-150405") + ".jsonl"
+	w.Header().Set("Content-Type", "application/jsonl")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Write(buf.Bytes())
+}
+
+// handleImport restores a dump into the datastore and reports the records
+// imported by type.
+func (h *Handler) handleImport(w http.ResponseWriter, r *http.Request) {
+	if h.datastore == nil {
+		writeError(w, r, http.StatusNotFound, "datastore export is not enabled")
+		return
+	}
+
+	counts, err := h.datastore.Import(r.Body)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+		return
+	case errors.Is(err, dump.ErrInvalidDump):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		log.Printf("HTTP: Datastore import failed after %v: %v", counts, err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"imported": counts,
+	})
+}
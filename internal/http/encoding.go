@@ -0,0 +1,102 @@
+package http
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/veswatch/api/internal/wire"
+)
+
+// Response media types.
+const (
+	mediaJSON     = "application/json"
+	mediaMsgpack  = "application/msgpack"
+	mediaProtobuf = "application/x-protobuf"
+)
+
+// mediaAliases maps accepted media types to the one served.
+var mediaAliases = map[string]string{
+	"application/json":                mediaJSON,
+	"application/msgpack":             mediaMsgpack,
+	"application/x-msgpack":           mediaMsgpack,
+	"application/vnd.msgpack":         mediaMsgpack,
+	"application/x-protobuf":          mediaProtobuf,
+	"application/protobuf":            mediaProtobuf,
+	"application/vnd.google.protobuf": mediaProtobuf,
+}
+
+// negotiate picks the response media type from the Accept header, preferring
+// higher q values and earlier entries. JSON is served when nothing matches.
+func negotiate(r *http.Request) string {
+	type candidate struct {
+		media string
+		q     float64
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" || accept == "*/*" {
+		return mediaJSON
+	}
+
+	var candidates []candidate
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		media, ok := mediaAliases[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{media, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return mediaJSON
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].media
+}
+
+// writeNegotiated writes v with status code in the negotiated encoding.
+// protobuf encodes v as its message from api/rates.proto.
+func writeNegotiated(w http.ResponseWriter, r *http.Request, code int, v any, protobuf func() []byte) {
+	w.Header().Add("Vary", "Accept")
+
+	switch negotiate(r) {
+	case mediaProtobuf:
+		writeBinary(w, code, mediaProtobuf, protobuf())
+	case mediaMsgpack:
+		data, err := wire.MarshalMsgpack(v)
+		if err != nil {
+			log.Printf("HTTP: Failed to encode MessagePack response: %v", err)
+			writeError(w, r, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		writeBinary(w, code, mediaMsgpack, data)
+	default:
+		writeJSON(w, code, v)
+	}
+}
+
+// writeBinary writes a successful binary response.
+func writeBinary(w http.ResponseWriter, code int, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(code)
+	w.Write(data)
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// parseFields returns the field paths requested with ?fields=, or nil to
+// return every field. Nested fields use dots (e.g. parallel.rate).
+func parseFields(r *http.Request) []string {
+	raw := queryValue(r, "fields")
+	if raw == "" {
+		return nil
+	}
+
+	var fields []string
+	for _, f := range strings.Split(raw, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// sparse returns v reduced to the given fields, keyed by their JSON names.
+// v is returned unchanged when no fields are requested.
+func sparse(v any, fields []string) (any, error) {
+	if fields == nil {
+		return v, nil
+	}
+
+	out := make(map[string]any)
+	for _, path := range fields {
+		if err := selectField(out, reflect.ValueOf(v), strings.Split(path, ".")); err != nil {
+			return nil, fmt.Errorf("unknown field %q", path)
+		}
+	}
+	return out, nil
+}
+
+// selectField copies the field at path from v into out, creating nested maps
+// for intermediate structs.
+func selectField(out map[string]any, v reflect.Value, path []string) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("not an object")
+	}
+
+	field, omitted, ok := fieldByJSONName(v, path[0])
+	if !ok {
+		return fmt.Errorf("no field %s", path[0])
+	}
+	if omitted {
+		return nil
+	}
+	if len(path) == 1 {
+		out[path[0]] = field.Interface()
+		return nil
+	}
+
+	nested, ok := out[path[0]].(map[string]any)
+	if !ok {
+		nested = make(map[string]any)
+		out[path[0]] = nested
+	}
+	return selectField(nested, field, path[1:])
+}
+
+// fieldByJSONName finds a struct field by the name it has in JSON output,
+// and reports whether JSON output omits it, as omitempty and omitzero do.
+func fieldByJSONName(v reflect.Value, name string) (field reflect.Value, omitted, ok bool) {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = sf.Name
+		}
+		if tag == name {
+			field = v.Field(i)
+			opts = "," + opts + ","
+			omitted = strings.Contains(opts, ",omitzero,") && field.IsZero() ||
+				strings.Contains(opts, ",omitempty,") && isEmptyValue(field)
+			return field, omitted, true
+		}
+	}
+	return reflect.Value{}, false, false
+}
+
+// isEmptyValue reports whether omitempty omits v.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
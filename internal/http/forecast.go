@@ -0,0 +1,61 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// defaultForecastDays is the forecast horizon when days isn't given.
+const defaultForecastDays = 3
+
+// SetForecast enables the experimental /rates/forecast endpoint. Disabled,
+// it returns 404.
+func (h *Handler) SetForecast(enabled bool) {
+	h.forecast = enabled
+}
+
+// handleForecast projects the parallel rate a few days ahead
+// (?model=linear|ewma, default linear; ?days=1-7, default 3). The response
+// is marked experimental and carries a Warning header.
+func (h *Handler) handleForecast(w http.ResponseWriter, r *http.Request) {
+	if !h.forecast {
+		writeError(w, r, http.StatusNotFound, "forecast is not enabled")
+		return
+	}
+
+	q := r.URL.Query()
+	model := q.Get("model")
+	if model == "" {
+		model = rates.ModelLinear
+	}
+	days := defaultForecastDays
+	if v := q.Get("days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "days must be a number")
+			return
+		}
+		days = d
+	}
+
+	forecast, err := h.rateProvider.Forecast(model, days)
+	switch {
+	case errors.Is(err, rates.ErrUnknownModel), errors.Is(err, rates.ErrInvalidHorizon):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, rates.ErrNotEnoughHistory):
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		log.Printf("HTTP: Failed to forecast: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.Header().Set("Warning", `299 - "Experimental forecast, not financial advice"`)
+	writeJSON(w, http.StatusOK, forecast)
+}
@@ -6,104 +6,707 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/veswatch/api/internal/apikey"
+	"github.com/veswatch/api/internal/docs"
 	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/internal/scheduler"
+	"github.com/veswatch/api/internal/wire"
+	"github.com/veswatch/api/pkg/format"
 )
 
 // RateProvider defines the interface for getting rate data.
 type RateProvider interface {
 	GetRates() rates.RateData
+	Changed() <-chan struct{}
+	GetRatesV1() rates.RatesV1
+	GetRegionRates(region string) (rates.RateData, error)
+	GetRegionRatesV1(region string) (rates.RatesV1, error)
+	GetBook() (rates.Book, error)
+	SetCash(rate float64) (rates.SourceRate, error)
+	SetOverride(source string, rate float64, until time.Time) (rates.SourceRate, error)
+	GetInflation() rates.InflationData
+	Convert(req rates.ConversionRequest) (rates.Conversion, error)
+	GetDailyCloses() ([]rates.DailyClose, error)
+	QueryDailyCloses(from, to string) ([]rates.DailyClose, error)
+	CloseOn(date string) (rates.DailyClose, error)
+	Downsample(ctx context.Context, from, to, interval, agg string) ([]rates.Bucket, error)
+	GetSummary(period string) (rates.Summary, error)
+	Forecast(model string, days int) (rates.Forecast, error)
+	Correlation(from, to string) (rates.Correlation, error)
+	Status() []rates.SourceStatus
+	Fetches(source string, limit int) ([]rates.FetchRecord, error)
+	Escalations() []rates.Escalation
+	GetAuditLog(source string, limit int) ([]rates.AuditEntry, error)
+	ListAlerts(owner string) ([]rates.AlertRule, error)
+	CreateAlert(owner string, rule rates.AlertRule) (rates.AlertRule, error)
+	DeleteAlert(owner, id string) error
 }
 
 // Handler handles HTTP requests for the API.
 type Handler struct {
 	rateProvider RateProvider
+	schedule     Schedule
+	cache        *responseCache
+	adminToken   string
+	push         PushRegistry
+	apiKeys      APIKeys
+	identities   Identities
+	access       *AccessRules
+	proxies      []netip.Prefix
+	fly          bool
+	adminFilter  IPFilter
+	forecast     bool
+	metrics      http.Handler
+	accessLog    AccessLogger
+	analytics    Analytics
+	abuse        Abuse
+	maintainer   Maintainer
+	datastore    Datastore
+	readOnly     bool
+	shedder      *Shedder
+	validator    Validator
+	deadLetters  DeadLetters
+	jobs         Jobs
 }
 
 // NewHandler creates a new HTTP handler.
 func NewHandler(provider RateProvider) *Handler {
 	return &Handler{
 		rateProvider: provider,
+		cache:        newResponseCache(),
 	}
 }
 
-// Routes returns the HTTP router with all endpoints registered.
+// SetSchedule sets the refresh schedule used to derive cache lifetimes.
+// Without a schedule, responses are not cached.
+func (h *Handler) SetSchedule(schedule Schedule) {
+	h.schedule = schedule
+}
+
+// Routes returns the HTTP router with the public, status, metrics and admin
+// endpoints, for a server with a single listener.
 func (h *Handler) Routes() http.Handler {
 	mux := http.NewServeMux()
+	h.publicRoutes(mux)
+	h.statusRoutes(mux)
+	h.internalRoutes(mux)
+	return h.withMiddleware(mux, true)
+}
 
-	// Health check endpoint
-	mux.HandleFunc("GET /health", h.handleHealth)
+// PublicRoutes returns the HTTP router with the public and status
+// endpoints, for the public listener when internal endpoints are served
+// apart.
+func (h *Handler) PublicRoutes() http.Handler {
+	mux := http.NewServeMux()
+	h.publicRoutes(mux)
+	h.statusRoutes(mux)
+	return h.withMiddleware(mux, true)
+}
 
+// InternalRoutes returns the HTTP router with the status, metrics, admin
+// and pprof endpoints, for a listener bound to an internal interface.
+// Client bans don't apply there, so operators can't lock themselves out.
+func (h *Handler) InternalRoutes() http.Handler {
+	mux := http.NewServeMux()
+	h.statusRoutes(mux)
+	h.internalRoutes(mux)
+	h.debugRoutes(mux)
+	return h.withMiddleware(mux, false)
+}
+
+// publicRoutes registers the rates API. Everything but the current rates
+// is low priority, shed under overload.
+func (h *Handler) publicRoutes(mux *http.ServeMux) {
 	// Main rates endpoint
-	mux.HandleFunc("GET /rates", h.handleRates)
+	mux.HandleFunc("GET /rates", h.limit(defaultLimits, h.metered(h.revalidated(h.handleRates, []string{"decimals", "fields", "region", "tz"}, scheduler.JobBinance, scheduler.JobParallel, scheduler.JobBCV))))
+
+	// Long-polling rates endpoint
+	mux.HandleFunc("GET /rates/poll", h.limit(pollLimits, h.metered(h.handlePoll)))
+
+	// Detailed rates endpoint with per-source parallel data
+	mux.HandleFunc("GET /v1/rates", h.limit(defaultLimits, h.metered(h.cached(h.handleRatesV1, []string{"decimals", "fields", "region", "tz"}, scheduler.JobBinance, scheduler.JobParallel, scheduler.JobBCV))))
+
+	// Daily close history endpoint. Downsampled history (?interval=) is
+	// aggregated from every fetched rate, so it's cached until the next fetch
+	history := h.cached(h.handleHistory, []string{"cursor", "fields", "from", "limit", "to"}, scheduler.JobDailyClose)
+	downsampled := h.cached(h.handleDownsampled, []string{"agg", "cursor", "fields", "from", "interval", "limit", "to"}, scheduler.JobBinance, scheduler.JobParallel, scheduler.JobBCV, scheduler.JobDailyClose)
+	mux.HandleFunc("GET /rates/history", h.shed(h.limit(historyLimits, h.metered(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("interval") {
+			downsampled(w, r)
+			return
+		}
+		history(w, r)
+	}))))
+
+	// Permalinks to a day's close, which never changes once recorded
+	mux.HandleFunc("GET /rates/at/{date}", h.shed(h.limit(defaultLimits, h.metered(h.handleRatesAt))))
+
+	// Binance ad book statistics endpoint
+	mux.HandleFunc("GET /rates/binance/book", h.shed(h.limit(defaultLimits, h.metered(h.cached(h.handleBook, []string{"tz"}, scheduler.JobBinance)))))
+
+	// Weekly and monthly summary endpoint
+	mux.HandleFunc("GET /rates/summary", h.shed(h.limit(defaultLimits, h.metered(h.cached(h.handleSummary, []string{"period"}, scheduler.JobSummary, scheduler.JobDailyClose)))))
+
+	// BCV and parallel rate correlation endpoint
+	mux.HandleFunc("GET /rates/correlation", h.shed(h.limit(historyLimits, h.metered(h.cached(h.handleCorrelation, []string{"from", "to"}, scheduler.JobDailyClose)))))
+
+	// Experimental rate forecast endpoint
+	mux.HandleFunc("GET /rates/forecast", h.shed(h.limit(defaultLimits, h.metered(h.cached(h.handleForecast, []string{"days", "model"}, scheduler.JobDailyClose)))))
+
+	// Inflation (INPC) endpoint
+	mux.HandleFunc("GET /inflation", h.shed(h.limit(defaultLimits, h.metered(h.cached(h.handleInflation, []string{"tz"}, scheduler.JobInflation)))))
+
+	// Currency conversion endpoint
+	mux.HandleFunc("GET /convert", h.shed(h.limit(defaultLimits, h.metered(h.handleConvert))))
+
+	// Money formatting helper endpoint
+	mux.HandleFunc("GET /format", h.shed(h.limit(defaultLimits, h.metered(h.handleFormat))))
+
+	// Open Graph image for link previews
+	mux.HandleFunc("GET /og/rates.png", h.shed(h.limit(defaultLimits, h.metered(h.cached(h.handleOGImage, nil, scheduler.JobBinance, scheduler.JobParallel, scheduler.JobBCV)))))
+
+	// pydolarvenezuela-compatible endpoint
+	mux.HandleFunc("GET /api/v1/dollar", h.shed(h.limit(defaultLimits, h.metered(h.cached(h.handleDollarCompat, []string{"format_date", "monitor", "page", "rounded_price"}, scheduler.JobBinance, scheduler.JobParallel, scheduler.JobBCV, scheduler.JobDailyClose)))))
 
-	// Root endpoint (redirect to rates)
-	mux.HandleFunc("GET /", h.handleRoot)
+	// Web Push subscription endpoints
+	mux.HandleFunc("GET /push/key", h.shed(h.limit(defaultLimits, h.handlePushKey)))
+	mux.HandleFunc("POST /push/subscriptions", h.shed(h.limit(pushLimits, h.writable(h.handlePushSubscribe))))
+	mux.HandleFunc("DELETE /push/subscriptions", h.shed(h.limit(pushLimits, h.handlePushUnsubscribe)))
 
-	// Apply middleware
-	return h.withMiddleware(mux)
+	// User alert rule endpoints
+	mux.HandleFunc("GET /alerts", h.shed(h.limit(defaultLimits, h.authenticated(h.handleListAlerts))))
+	mux.HandleFunc("POST /alerts", h.shed(h.limit(pushLimits, h.authenticated(h.writable(h.handleCreateAlert)))))
+	mux.HandleFunc("DELETE /alerts/{id}", h.shed(h.limit(defaultLimits, h.authenticated(h.handleDeleteAlert))))
+
+	// Root endpoint (documentation page)
+	mux.HandleFunc("GET /{$}", h.shed(h.limit(defaultLimits, h.handleRoot)))
 }
 
-// withMiddleware applies common middleware to all routes.
-func (h *Handler) withMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+// statusRoutes registers the health and status endpoints, served on every
+// listener.
+func (h *Handler) statusRoutes(mux *http.ServeMux) {
+	// Health check endpoint
+	mux.HandleFunc("GET /health", h.limit(defaultLimits, h.handleHealth))
+
+	// Operational status endpoints
+	mux.HandleFunc("GET /status", h.limit(defaultLimits, h.handleStatus))
+	mux.HandleFunc("GET /status/scheduler", h.limit(defaultLimits, h.handleSchedulerStatus))
+	mux.HandleFunc("GET /status/fetches", h.limit(defaultLimits, h.handleFetches))
+}
+
+// internalRoutes registers the metrics and admin endpoints, which only
+// serve the clients the admin filter admits.
+func (h *Handler) internalRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /metrics", h.restricted(h.limit(defaultLimits, h.handleMetrics)))
+
+	// Admin endpoints
+	mux.HandleFunc("GET /admin/ui", h.restricted(h.limit(defaultLimits, h.handleAdminUI)))
+	mux.HandleFunc("GET /admin/audit", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleViewer, h.handleAudit))))
+	mux.HandleFunc("GET /admin/analytics", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleViewer, h.handleAnalytics))))
+	mux.HandleFunc("PUT /admin/cash", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleOperator, h.writable(h.handleSetCash)))))
+	mux.HandleFunc("PUT /admin/rates/{source}", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleOperator, h.writable(h.handleOverride)))))
+	mux.HandleFunc("POST /admin/maintenance", h.restricted(h.limit(historyLimits, h.admin(apikey.RoleOperator, h.handleMaintenance))))
+	mux.HandleFunc("GET /admin/export", h.restricted(h.limit(historyLimits, h.admin(apikey.RoleAdmin, h.handleExport))))
+	mux.HandleFunc("POST /admin/import", h.restricted(h.limit(importLimits, h.admin(apikey.RoleAdmin, h.writable(h.handleImport)))))
+	mux.HandleFunc("GET /admin/keys", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleViewer, h.keysEnabled(h.handleListKeys)))))
+	mux.HandleFunc("POST /admin/keys", h.restricted(h.limit(pushLimits, h.admin(apikey.RoleAdmin, h.writable(h.keysEnabled(h.handleCreateKey))))))
+	mux.HandleFunc("POST /admin/keys/{id}/rotate", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleAdmin, h.keysEnabled(h.handleRotateKey)))))
+	mux.HandleFunc("DELETE /admin/keys/{id}", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleAdmin, h.keysEnabled(h.handleRevokeKey)))))
+	mux.HandleFunc("GET /admin/bans", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleViewer, h.abuseEnabled(h.handleListBans)))))
+	mux.HandleFunc("DELETE /admin/bans/{client}", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleOperator, h.abuseEnabled(h.handleLiftBan)))))
+	mux.HandleFunc("GET /admin/deadletters", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleViewer, h.deadLettersEnabled(h.handleListDeadLetters)))))
+	mux.HandleFunc("POST /admin/deadletters/redeliver", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleOperator, h.deadLettersEnabled(h.handleRedeliverAll)))))
+	mux.HandleFunc("POST /admin/deadletters/{id}/redeliver", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleOperator, h.deadLettersEnabled(h.handleRedeliver)))))
+	mux.HandleFunc("DELETE /admin/deadletters/{id}", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleOperator, h.deadLettersEnabled(h.handleDiscardDeadLetter)))))
+	mux.HandleFunc("POST /admin/jobs/{job}/pause", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleOperator, h.jobsEnabled(h.handlePauseJob)))))
+	mux.HandleFunc("POST /admin/jobs/{job}/resume", h.restricted(h.limit(defaultLimits, h.admin(apikey.RoleOperator, h.jobsEnabled(h.handleResumeJob)))))
+	mux.HandleFunc("POST /admin/jobs/{job}/run", h.restricted(h.limit(historyLimits, h.admin(apikey.RoleOperator, h.jobsEnabled(h.handleRunJob)))))
+}
+
+// withMiddleware applies common middleware to all routes. Public listeners
+// turn away banned clients; requests to internal ones are marked as such.
+func (h *Handler) withMiddleware(mux *http.ServeMux, public bool) http.Handler {
+	route := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Serve HEAD like GET, without the body
+		if r.Method == http.MethodHead {
+			hw := &headWriter{ResponseWriter: w}
+			mux.ServeHTTP(hw, r)
+			hw.finish()
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+
+	// Enforce the endpoint's configured access rule
+	access := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.checkAccess(w, r, route)
+	})
+
+	serve := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// CORS headers for frontend access
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		header := w.Header()
+		header["Access-Control-Allow-Origin"] = corsOrigin
+		header["Access-Control-Allow-Methods"] = corsMethods
+		header["Access-Control-Allow-Headers"] = corsHeaders
 
 		// Handle preflight requests
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodOptions {
+			handleOptions(mux, w, r)
 			return
 		}
 
 		// Log request
 		log.Printf("HTTP: %s %s", r.Method, r.URL.Path)
 
-		next.ServeHTTP(w, r)
+		// Pick the language of human-readable messages
+		r, ok := withLanguage(w, r)
+		if !ok {
+			return
+		}
+
+		// Turn away banned clients
+		if public {
+			h.guard(mux, w, r, access)
+			return
+		}
+		access(w, r)
+	})
+
+	checked := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.validate(w, r, serve)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !public {
+			r = withInternal(r)
+		}
+		h.recordRequest(mux, r)
+		h.logAccess(w, r, checked)
 	})
 }
 
+// Header values set on every response, shared so setting them doesn't
+// allocate. Code adding values to these headers must append, never modify
+// the shared slices in place.
+var (
+	corsOrigin  = []string{"*"}
+	corsMethods = []string{"GET, HEAD, POST, DELETE, OPTIONS"}
+	corsHeaders = []string{"Content-Type, Authorization, X-API-Key"}
+	contentJSON = []string{"application/json"}
+)
+
+// health is the body of every health check response, shared so checks
+// don't allocate one.
+var health = &struct {
+	Status string `json:"status"`
+}{Status: "ok"}
+
 // handleHealth returns a simple health check response.
 func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "ok",
-	})
+	writeJSON(w, http.StatusOK, health)
 }
 
 // handleRates returns the current exchange rates.
 func (h *Handler) handleRates(w http.ResponseWriter, r *http.Request) {
+	loc, err := location(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p, err := precision(r, unrounded)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	rateData := h.rateProvider.GetRates()
+	if region := queryValue(r, "region"); region != "" {
+		if rateData, err = h.rateProvider.GetRegionRates(region); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	rateData = rateData.In(loc).Round(p)
+	setDataAge(w, rateData.UpdatedAt)
+
+	body, err := sparse(rateData, parseFields(r))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeNegotiated(w, r, dataStatus(rateData.Status), body, func() []byte {
+		return wire.MarshalRates(rateData)
+	})
+}
+
+// handleRatesV1 returns the detailed rates, including the parallel consensus
+// and each source's deviation from it.
+func (h *Handler) handleRatesV1(w http.ResponseWriter, r *http.Request) {
+	loc, err := location(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p, err := precision(r, unrounded)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v1 := h.rateProvider.GetRatesV1()
+	if region := r.URL.Query().Get("region"); region != "" {
+		if v1, err = h.rateProvider.GetRegionRatesV1(region); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	v1 = v1.In(loc).Round(p)
+	for i := range v1.Warnings {
+		v1.Warnings[i].Message = translate(r, v1.Warnings[i].Message)
+	}
+
+	body, err := sparse(v1, parseFields(r))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeNegotiated(w, r, dataStatus(v1.Status), body, func() []byte {
+		return wire.MarshalRatesV1(v1)
+	})
+}
+
+// handleHistory returns recorded daily closes, optionally filtered by date
+// range, one page at a time.
+func (h *Handler) handleHistory(w http.ResponseWriter, r *http.Request) {
+	// The client's tier may limit how far back history goes
+	from := h.clampFrom(r, r.URL.Query().Get("from"))
+	to := r.URL.Query().Get("to")
+
+	pg, err := parsePage(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := time.Parse("2006-01-02", pg.After); pg.After != "" && err != nil {
+		writeError(w, r, http.StatusBadRequest, errInvalidCursor.Error())
+		return
+	}
+
+	closes, err := h.rateProvider.QueryDailyCloses(from, to)
+	if err != nil {
+		log.Printf("HTTP: Failed to load history: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	// Closes are ordered by date, so the page starts after the cursor date.
+	start := sort.Search(len(closes), func(i int) bool {
+		return closes[i].Date > pg.After
+	})
+	closes = closes[start:]
+	more := len(closes) > pg.Limit
+	if more {
+		closes = closes[:pg.Limit]
+	}
+
+	fields := parseFields(r)
+
+	filtered := make([]any, 0, len(closes))
+	for _, c := range closes {
+		entry, err := sparse(c, fields)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		filtered = append(filtered, entry)
+	}
+
+	body := map[string]any{
+		"closes": filtered,
+	}
+	if more {
+		body["next"] = nextLink(r, closes[len(closes)-1].Date)
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// handleSummary returns the precomputed weekly or monthly summary of the
+// daily closes (?period=week|month, default week).
+func (h *Handler) handleSummary(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = rates.PeriodWeek
+	}
+
+	summary, err := h.rateProvider.GetSummary(period)
+	switch {
+	case errors.Is(err, rates.ErrUnknownPeriod):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, rates.ErrNoSummary):
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		log.Printf("HTTP: Failed to load summary: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// handleCorrelation returns statistics on how the BCV rate tracks the
+// parallel rate over the daily closes, optionally filtered with from and to.
+func (h *Handler) handleCorrelation(w http.ResponseWriter, r *http.Request) {
+	from := h.clampFrom(r, r.URL.Query().Get("from"))
+	to := r.URL.Query().Get("to")
+
+	correlation, err := h.rateProvider.Correlation(from, to)
+	switch {
+	case errors.Is(err, rates.ErrNotEnoughHistory):
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		log.Printf("HTTP: Failed to compute correlation: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, correlation)
+}
+
+// handleInflation returns the INPC series with monthly and year-over-year figures.
+func (h *Handler) handleInflation(w http.ResponseWriter, r *http.Request) {
+	loc, err := location(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	inflationData := h.rateProvider.GetInflation().In(loc)
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(dataStatus(inflationData.Status))
 
-	if err := json.NewEncoder(w).Encode(rateData); err != nil {
+	if err := json.NewEncoder(w).Encode(inflationData); err != nil {
 		log.Printf("HTTP: Failed to encode response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
-// handleRoot redirects to the rates endpoint.
+// handleConvert converts an amount between USD and VES, optionally at a historical date.
+func (h *Handler) handleConvert(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	amount, err := strconv.ParseFloat(q.Get("amount"), 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "amount must be a number")
+		return
+	}
+
+	loc, err := location(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p, err := precision(r, rates.ConversionPrecision)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conversion, err := h.rateProvider.Convert(rates.ConversionRequest{
+		Amount:    amount,
+		From:      q.Get("from"),
+		Source:    q.Get("source"),
+		Date:      q.Get("date"),
+		Precision: p,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, rates.ErrNoHistoricalRate), errors.Is(err, rates.ErrRateUnavailable):
+			writeError(w, r, http.StatusNotFound, err.Error())
+		case errors.Is(err, rates.ErrInvalidAmount), errors.Is(err, rates.ErrInvalidDate),
+			errors.Is(err, rates.ErrUnknownCurrency), errors.Is(err, rates.ErrUnknownSource):
+			writeError(w, r, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("HTTP: Conversion failed: %v", err)
+			writeError(w, r, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conversion.In(loc))
+}
+
+// handleFormat formats an amount using locale-aware money conventions.
+func (h *Handler) handleFormat(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	amount, err := strconv.ParseFloat(q.Get("amount"), 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "amount must be a number")
+		return
+	}
+
+	currency := q.Get("currency")
+	if currency == "" {
+		currency = "VES"
+	}
+	locale := q.Get("locale")
+	if locale == "" {
+		locale = format.DefaultLocale
+	}
+
+	formatted, err := format.Money(amount, currency, locale)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c, _ := format.LookupCurrency(currency)
+	number, _ := format.Number(amount, c.Decimals, locale)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"amount":    amount,
+		"currency":  c.Code,
+		"locale":    locale,
+		"symbol":    c.Symbol,
+		"number":    number,
+		"formatted": formatted,
+	})
+}
+
+// disclaimer is the legal notice returned with API information.
+const disclaimer = "VESWatch provides reference exchange rates obtained from public sources. This information is not official financial advice."
+
+// handleRoot serves the API documentation page, or the service summary as
+// JSON with ?format=json or an Accept header asking for JSON and not HTML.
 func (h *Handler) handleRoot(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
+	w.Header().Add("Vary", "Accept")
+
+	accept := r.Header.Get("Accept")
+	if r.URL.Query().Get("format") == "json" ||
+		(strings.Contains(accept, mediaJSON) && !strings.Contains(accept, "text/html")) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"name":       "VESWatch API",
+			"version":    "1.0.0",
+			"endpoints":  "/rates, /rates/poll, /v1/rates, /rates/history, /rates/at/{date}, /rates/summary, /rates/correlation, /inflation, /convert, /format, /og/rates.png, /api/v1/dollar, /push/key, /alerts",
+			"disclaimer": translate(r, disclaimer),
+		})
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"name":       "VESWatch API",
-		"version":    "1.0.0",
-		"endpoints":  "/rates",
-		"disclaimer": "VESWatch provides reference exchange rates obtained from public sources. This information is not official financial advice.",
+	w.Write(docs.Index)
+}
+
+// writeJSON writes a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	buf := encodeBuffers.Get().(*encodeBuffer)
+	defer putEncodeBuffer(buf)
+
+	if err := buf.enc.Encode(v); err != nil {
+		log.Printf("HTTP: Failed to encode response: %v", err)
+		buf.Reset()
+		buf.WriteString(`{"error":"internal server error"}` + "\n")
+		status = http.StatusInternalServerError
+	}
+
+	w.Header()["Content-Type"] = contentJSON
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// encodeBuffer is a buffer JSON responses are encoded into, with an
+// encoder writing to it.
+type encodeBuffer struct {
+	bytes.Buffer
+	enc *json.Encoder
+}
+
+// encodeBuffers holds encode buffers, reused across requests.
+var encodeBuffers = sync.Pool{
+	New: func() any {
+		buf := new(encodeBuffer)
+		buf.enc = json.NewEncoder(&buf.Buffer)
+		return buf
+	},
+}
+
+// maxPooledBuffer is the largest buffer returned to a pool, so a single
+// large history response doesn't keep its memory alive.
+const maxPooledBuffer = 64 << 10
+
+// putEncodeBuffer returns buf to the pool.
+func putEncodeBuffer(buf *encodeBuffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	encodeBuffers.Put(buf)
+}
+
+// dataStatus returns the status code of a response whose data has the
+// given availability: 206 Partial Content while some of it has never been
+// fetched, so clients and caches don't mistake it for complete data.
+func dataStatus(availability string) int {
+	if availability == rates.StatusOK {
+		return http.StatusOK
+	}
+	return http.StatusPartialContent
+}
+
+// queryValue returns the first value of a query parameter. Unlike
+// r.URL.Query, it doesn't parse the query of requests without one, the
+// common case on hot endpoints.
+func queryValue(r *http.Request, key string) string {
+	if r.URL.RawQuery == "" {
+		return ""
+	}
+	return r.URL.Query().Get(key)
+}
+
+// decodeJSON decodes a JSON request body into v, writing a 400 or 413 error
+// response and returning false if it can't.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+			return false
+		}
+		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+// writeError writes a JSON error response in the request's language.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"error": translate(r, message),
 	})
 }
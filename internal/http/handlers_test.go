@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// fixedRate is a rate source always returning the same rate.
+type fixedRate float64
+
+func (f fixedRate) Fetch(context.Context) (float64, error) {
+	return float64(f), nil
+}
+
+// noInflation is an INPC source without data.
+type noInflation struct{}
+
+func (noInflation) FetchINPC(context.Context) ([]rates.IndexPoint, error) {
+	return nil, nil
+}
+
+// newBenchService returns a rates service fetched once from fixed sources,
+// with logging discarded until the benchmark ends.
+func newBenchService(b *testing.B) *rates.Service {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(out) })
+
+	svc := rates.NewService(fixedRate(36.5), fixedRate(38.2), noInflation{})
+	svc.Initialize(b.Context())
+	return svc
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	svc := newBenchService(b)
+	responses := []struct {
+		name string
+		v    any
+	}{
+		{"rates", svc.GetRates()},
+		{"v1", svc.GetRatesV1()},
+	}
+
+	for _, resp := range responses {
+		b.Run(resp.name, func(b *testing.B) {
+			w := discardWriter{header: make(http.Header)}
+			b.ReportAllocs()
+			for b.Loop() {
+				writeJSON(w, http.StatusOK, resp.v)
+			}
+		})
+	}
+}
+
+func BenchmarkMiddleware(b *testing.B) {
+	routes := NewHandler(newBenchService(b)).Routes()
+
+	endpoints := []struct {
+		name string
+		path string
+	}{
+		{"rates", "/rates"},
+		{"v1", "/v1/rates"},
+		{"health", "/health"},
+	}
+
+	for _, e := range endpoints {
+		b.Run(e.name, func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodGet, e.path, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+
+			rec := httptest.NewRecorder()
+			routes.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				b.Fatalf("GET %s returned %d: %s", e.path, rec.Code, rec.Body)
+			}
+
+			w := discardWriter{header: make(http.Header)}
+			b.ReportAllocs()
+			for b.Loop() {
+				clear(w.header)
+				routes.ServeHTTP(w, req)
+			}
+		})
+	}
+}
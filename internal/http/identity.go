@@ -0,0 +1,57 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/veswatch/api/internal/apikey"
+	"github.com/veswatch/api/internal/oidc"
+)
+
+// errUnknownKey is returned for secrets that match no API key.
+var errUnknownKey = errors.New("unknown API key")
+
+// Identities verifies JWTs issued by an identity provider, returning the
+// API client each identifies.
+type Identities interface {
+	Verify(ctx context.Context, token string) (apikey.Key, error)
+}
+
+// SetIdentities accepts JWTs from an identity provider wherever an API key
+// is, and enables the admin API for identities with a role.
+func (h *Handler) SetIdentities(identities Identities) {
+	h.identities = identities
+}
+
+// lookupClient returns the API client a bearer secret identifies: an API
+// key or, with an identity provider, a JWT it issued.
+func (h *Handler) lookupClient(ctx context.Context, secret string) (apikey.Key, error) {
+	if h.apiKeys != nil {
+		if key, ok := h.apiKeys.Lookup(secret); ok {
+			return key, nil
+		}
+	}
+	if h.identities != nil && strings.Count(secret, ".") == 2 {
+		return h.identities.Verify(ctx, secret)
+	}
+	return apikey.Key{}, errUnknownKey
+}
+
+// writeAuthError writes the response for credentials lookupClient didn't
+// accept: 503 while the identity provider's keys can't be fetched, the
+// reason a JWT was rejected, or message for anything else.
+func writeAuthError(w http.ResponseWriter, r *http.Request, err error, realm, message string) {
+	switch {
+	case errors.Is(err, oidc.ErrUnavailable):
+		writeError(w, r, http.StatusServiceUnavailable, oidc.ErrUnavailable.Error())
+	case errors.Is(err, oidc.ErrInvalidToken):
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q, error="invalid_token"`, realm))
+		writeError(w, r, http.StatusUnauthorized, err.Error())
+	default:
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q`, realm))
+		writeError(w, r, http.StatusUnauthorized, message)
+	}
+}
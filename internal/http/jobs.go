@@ -0,0 +1,114 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/veswatch/api/internal/scheduler"
+)
+
+// runWait is how long a job run on demand is waited for before answering
+// that it's still running, within the endpoint's request timeout.
+const runWait = 8 * time.Second
+
+// Jobs pauses, resumes and runs scheduled jobs on demand.
+type Jobs interface {
+	Pause(job string) error
+	Resume(job string) error
+	RunNow(job string) error
+}
+
+// SetJobs enables the /admin/jobs endpoints. Without jobs, e.g. when the
+// scheduler isn't running, they return 404.
+func (h *Handler) SetJobs(jobs Jobs) {
+	h.jobs = jobs
+}
+
+// jobsEnabled wraps an admin handler so it returns 404 without jobs.
+func (h *Handler) jobsEnabled(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.jobs == nil {
+			writeError(w, r, http.StatusNotFound, "scheduler is not running")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handlePauseJob pauses a job's scheduled runs.
+func (h *Handler) handlePauseJob(w http.ResponseWriter, r *http.Request) {
+	if err := h.jobs.Pause(r.PathValue("job")); err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+	h.writeJobStatus(w, http.StatusOK, r.PathValue("job"))
+}
+
+// handleResumeJob resumes a paused job's scheduled runs.
+func (h *Handler) handleResumeJob(w http.ResponseWriter, r *http.Request) {
+	if err := h.jobs.Resume(r.PathValue("job")); err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+	h.writeJobStatus(w, http.StatusOK, r.PathValue("job"))
+}
+
+// handleRunJob runs a job right away, e.g. to refresh a source after
+// fixing its configuration. If it finishes within runWait, its status is
+// returned, or 502 with its error; otherwise 202 while it goes on.
+func (h *Handler) handleRunJob(w http.ResponseWriter, r *http.Request) {
+	job := r.PathValue("job")
+	done := make(chan error, 1)
+	go func() {
+		err := h.jobs.RunNow(job)
+		if err == nil {
+			// Cached responses expire at the job's next scheduled run,
+			// so they'd outlive the refreshed data
+			h.cache.clear()
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		switch {
+		case err == nil:
+			h.writeJobStatus(w, http.StatusOK, job)
+		case errors.Is(err, scheduler.ErrUnknownJob):
+			writeError(w, r, http.StatusNotFound, err.Error())
+		case errors.Is(err, scheduler.ErrBusy):
+			writeError(w, r, http.StatusConflict, err.Error())
+		default:
+			writeError(w, r, http.StatusBadGateway, err.Error())
+		}
+	case <-time.After(runWait):
+		h.writeJobStatus(w, http.StatusAccepted, job)
+	}
+}
+
+// writeJobStatus writes the scheduler status of a job.
+func (h *Handler) writeJobStatus(w http.ResponseWriter, status int, job string) {
+	var js scheduler.JobStatus
+	if h.schedule != nil {
+		for _, st := range h.schedule.Status() {
+			if st.Name == job {
+				js = st
+			}
+		}
+	}
+	writeJSON(w, status, map[string]any{
+		"job": js,
+	})
+}
+
+// writeJobError writes the response for a failed pause or resume.
+func writeJobError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, scheduler.ErrUnknownJob) {
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	log.Printf("HTTP: Failed to update job: %v", err)
+	writeError(w, r, http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,225 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/veswatch/api/internal/apikey"
+)
+
+// APIKeys authenticates API keys, enforces their tier and manages them for
+// the admin API.
+type APIKeys interface {
+	Lookup(secret string) (apikey.Key, bool)
+	Tier(key apikey.Key) apikey.Tier
+	Tiers() []apikey.Tier
+	Allow(key apikey.Key, now time.Time) (bool, int, time.Time)
+	Create(name, tier, role string) (apikey.Key, string, error)
+	Revoke(id string) (apikey.Key, error)
+	Rotate(id string) (apikey.Key, string, error)
+	List() []apikey.Summary
+}
+
+// SetAPIKeys enables API keys. Without keys, the endpoints that require one
+// return 404 and every other endpoint is served anonymously.
+func (h *Handler) SetAPIKeys(keys APIKeys) {
+	h.apiKeys = keys
+}
+
+// clientKey is the context key for the authenticated API client.
+type clientKey struct{}
+
+// clientFromContext returns the authenticated API client, or the zero key
+// for anonymous requests.
+func clientFromContext(ctx context.Context) apikey.Key {
+	k, _ := ctx.Value(clientKey{}).(apikey.Key)
+	return k
+}
+
+// apiKey returns the key sent as a bearer token or in the X-API-Key header.
+func apiKey(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// authenticated wraps a handler so it requires an API key.
+func (h *Handler) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.apiKeys == nil {
+			writeError(w, r, http.StatusNotFound, "API keys are not configured")
+			return
+		}
+		h.withClient(w, r, next)
+	}
+}
+
+// metered wraps a public handler so requests sending an API key are counted
+// against its tier. Anonymous requests pass through.
+func (h *Handler) metered(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.apiKeys == nil || apiKey(r) == "" {
+			next(w, r)
+			return
+		}
+		h.withClient(w, r, next)
+	}
+}
+
+// withClient authenticates the request's API key or identity provider
+// token, applies its rate limit and calls next with the key in the request
+// context.
+func (h *Handler) withClient(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if clientFromContext(r.Context()).ID != "" {
+		// Already authenticated and counted by an access rule
+		next(w, r)
+		return
+	}
+
+	key, err := h.lookupClient(r.Context(), apiKey(r))
+	if err != nil {
+		writeAuthError(w, r, err, "veswatch", "invalid or missing API key")
+		return
+	}
+
+	allowed, remaining, reset := h.apiKeys.Allow(key, time.Now())
+	if limit := h.apiKeys.Tier(key).RequestsPerMinute; limit > 0 {
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	}
+	if !allowed {
+		retry := max(int(time.Until(reset).Seconds()+0.5), 1)
+		w.Header().Set("Retry-After", strconv.Itoa(retry))
+		writeError(w, r, http.StatusTooManyRequests, "rate limit exceeded for tier "+key.Tier)
+		return
+	}
+
+	next(w, r.WithContext(context.WithValue(r.Context(), clientKey{}, key)))
+}
+
+// historyDays returns how many days of history the client may read; 0 means
+// the full history.
+func (h *Handler) historyDays(r *http.Request) int {
+	key := clientFromContext(r.Context())
+	if key.ID == "" {
+		return 0
+	}
+	return h.apiKeys.Tier(key).HistoryDays
+}
+
+// clampFrom limits the start date of a history query to the client's tier.
+func (h *Handler) clampFrom(r *http.Request, from string) string {
+	if days := h.historyDays(r); days > 0 {
+		earliest := time.Now().In(defaultLocation).AddDate(0, 0, -days).Format("2006-01-02")
+		if from < earliest {
+			return earliest
+		}
+	}
+	return from
+}
+
+// keysEnabled wraps an admin handler so it returns 404 without API keys.
+func (h *Handler) keysEnabled(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.apiKeys == nil {
+			writeError(w, r, http.StatusNotFound, "API keys are not configured")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleListKeys returns every API key with its usage since startup, and
+// the available tiers.
+func (h *Handler) handleListKeys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys":  h.apiKeys.List(),
+		"tiers": h.apiKeys.Tiers(),
+	})
+}
+
+// handleCreateKey issues an API key, with an admin role if requested. The
+// secret is only returned here.
+func (h *Handler) handleCreateKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+		Tier string `json:"tier"`
+		Role string `json:"role"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Tier == "" {
+		req.Tier = apikey.TierFree
+	}
+
+	key, secret, err := h.apiKeys.Create(req.Name, req.Tier, req.Role)
+	if err != nil {
+		if errors.Is(err, apikey.ErrInvalidKey) {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("HTTP: Failed to create API key: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if key.Role != "" {
+		log.Printf("HTTP: Created API key %s for %s (%s, %s role)", key.ID, key.Name, key.Tier, key.Role)
+	} else {
+		log.Printf("HTTP: Created API key %s for %s (%s)", key.ID, key.Name, key.Tier)
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"key":    key,
+		"secret": secret,
+	})
+}
+
+// handleRotateKey replaces an API key's secret.
+func (h *Handler) handleRotateKey(w http.ResponseWriter, r *http.Request) {
+	key, secret, err := h.apiKeys.Rotate(r.PathValue("id"))
+	if err != nil {
+		writeKeyError(w, r, err)
+		return
+	}
+
+	log.Printf("HTTP: Rotated API key %s", key.ID)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"key":    key,
+		"secret": secret,
+	})
+}
+
+// handleRevokeKey revokes an API key.
+func (h *Handler) handleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.apiKeys.Revoke(r.PathValue("id"))
+	if err != nil {
+		writeKeyError(w, r, err)
+		return
+	}
+
+	log.Printf("HTTP: Revoked API key %s", key.ID)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"key": key,
+	})
+}
+
+// writeKeyError writes the response for a failed key change.
+func writeKeyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, apikey.ErrKeyNotFound):
+		writeError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, apikey.ErrStaticKey), errors.Is(err, apikey.ErrInvalidKey):
+		writeError(w, r, http.StatusConflict, err.Error())
+	default:
+		log.Printf("HTTP: Failed to update API key: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+	}
+}
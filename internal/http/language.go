@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/veswatch/api/internal/i18n"
+)
+
+// langKey is the context key for the response language.
+type langKey struct{}
+
+// language returns the language requested with the lang query parameter,
+// or negotiated from Accept-Language.
+func language(r *http.Request) (i18n.Lang, error) {
+	if tag := queryValue(r, "lang"); tag != "" {
+		return i18n.Parse(tag)
+	}
+	return i18n.Negotiate(r.Header.Get("Accept-Language")), nil
+}
+
+// langFromContext returns the response language, or the default language
+// before it has been picked.
+func langFromContext(ctx context.Context) i18n.Lang {
+	if lang, ok := ctx.Value(langKey{}).(i18n.Lang); ok {
+		return lang
+	}
+	return i18n.Default
+}
+
+// withLanguage picks the response language and stores it in the request
+// context. Unsupported lang parameters are answered with 400.
+func withLanguage(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	lang, err := language(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return r, false
+	}
+
+	w.Header().Set("Content-Language", string(lang))
+	w.Header().Add("Vary", "Accept-Language")
+	return r.WithContext(context.WithValue(r.Context(), langKey{}, lang)), true
+}
+
+// translate returns msg in the request's language.
+func translate(r *http.Request, msg string) string {
+	return i18n.Translate(langFromContext(r.Context()), msg)
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// limits bounds the time and request body size a route may use.
+type limits struct {
+	Timeout time.Duration
+	MaxBody int64
+}
+
+// Route limits. Timeouts stay below the server's WriteTimeout so clients
+// get a structured error instead of a dropped connection.
+var (
+	defaultLimits = limits{Timeout: 5 * time.Second, MaxBody: 64 << 10}
+	historyLimits = limits{Timeout: 10 * time.Second, MaxBody: 64 << 10}
+
+	// Subscription and alert rule bodies are small JSON documents
+	pushLimits = limits{Timeout: 5 * time.Second, MaxBody: 8 << 10}
+
+	// Datastore imports carry every stored record
+	importLimits = limits{Timeout: 10 * time.Second, MaxBody: 32 << 20}
+
+	// Long polls bound their own wait and extend the write deadline
+	pollLimits = limits{MaxBody: 64 << 10}
+)
+
+// limit wraps a handler with a request timeout and body size limit. Requests
+// whose declared body is too large are rejected with 413 up front; bodies
+// without a declared length fail on read once the limit is exceeded. Handlers
+// running past the timeout are answered with 408 and their output discarded.
+func (h *Handler) limit(l limits, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if l.MaxBody > 0 && r.Body != http.NoBody {
+			if r.ContentLength > l.MaxBody {
+				writeError(w, r, http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("request body exceeds %d bytes", l.MaxBody))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, l.MaxBody)
+		}
+
+		if l.Timeout <= 0 {
+			next(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), l.Timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := timeoutWriters.Get().(*timeoutWriter)
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			for k, v := range tw.header {
+				// Vary lists accumulate across middleware
+				if k == "Vary" {
+					w.Header()[k] = append(w.Header()[k], v...)
+					continue
+				}
+				w.Header()[k] = v
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.buf.Bytes())
+			tw.mu.Unlock()
+			putTimeoutWriter(tw)
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			writeError(w, r, http.StatusRequestTimeout,
+				fmt.Sprintf("request timed out after %s", l.Timeout))
+		}
+	}
+}
+
+// timeoutWriter buffers a response until the handler finishes in time.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	status   int
+	buf      bytes.Buffer
+	timedOut bool
+}
+
+// timeoutWriters holds writers of handlers that finished in time, reused
+// across requests. Writers of timed out handlers aren't returned, as the
+// handler may still be writing.
+var timeoutWriters = sync.Pool{
+	New: func() any { return &timeoutWriter{header: make(http.Header)} },
+}
+
+// putTimeoutWriter resets tw and returns it to the pool.
+func putTimeoutWriter(tw *timeoutWriter) {
+	if tw.buf.Cap() > maxPooledBuffer {
+		return
+	}
+	clear(tw.header)
+	tw.status = 0
+	tw.buf.Reset()
+	timeoutWriters.Put(tw)
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 && !w.timedOut {
+		w.status = status
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/veswatch/api/internal/store"
+)
+
+// Maintainer applies retention to and compacts the database.
+type Maintainer interface {
+	Maintain() (store.Maintenance, error)
+}
+
+// SetMaintainer enables POST /admin/maintenance. Without it, the endpoint
+// returns 404.
+func (h *Handler) SetMaintainer(m Maintainer) {
+	h.maintainer = m
+}
+
+// handleMaintenance runs database maintenance and reports what it deleted
+// and reclaimed.
+func (h *Handler) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if h.maintainer == nil {
+		writeError(w, r, http.StatusNotFound, "database maintenance is not enabled")
+		return
+	}
+
+	m, err := h.maintainer.Maintain()
+	if err != nil {
+		log.Printf("HTTP: Database maintenance failed: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
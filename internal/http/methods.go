@@ -0,0 +1,78 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// probeMethods are the methods checked when answering OPTIONS requests.
+var probeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// allowedMethods returns the methods routed for the request's path,
+// including OPTIONS, or nil if no route matches the path.
+func allowedMethods(mux *http.ServeMux, r *http.Request) []string {
+	var methods []string
+	for _, method := range probeMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := mux.Handler(probe); pattern != "" {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return nil
+	}
+	return append(methods, http.MethodOptions)
+}
+
+// handleOptions answers an OPTIONS request with the methods allowed on the
+// requested path.
+func handleOptions(mux *http.ServeMux, w http.ResponseWriter, r *http.Request) {
+	methods := allowedMethods(mux, r)
+	if methods == nil {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	allow := strings.Join(methods, ", ")
+	w.Header().Set("Allow", allow)
+	w.Header().Set("Access-Control-Allow-Methods", allow)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// headWriter discards the body of a HEAD response while measuring it, so the
+// response carries the Content-Length a GET would have.
+type headWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+func (w *headWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *headWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	w.length += len(b)
+	return len(b), nil
+}
+
+// finish sends the buffered status with the measured Content-Length.
+func (w *headWriter) finish() {
+	w.WriteHeader(http.StatusOK)
+	if w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.length))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
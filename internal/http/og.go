@@ -0,0 +1,49 @@
+package http
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/veswatch/api/internal/og"
+	"github.com/veswatch/api/pkg/format"
+)
+
+// handleOGImage renders the shareable Open Graph image with the current rates.
+func (h *Handler) handleOGImage(w http.ResponseWriter, r *http.Request) {
+	v1 := h.rateProvider.GetRatesV1()
+
+	card := og.Card{
+		BCV:      formatOGRate(v1.BCV.Rate),
+		Parallel: formatOGRate(v1.Parallel.Rate),
+		Breach:   "—",
+	}
+	if v1.Breach != nil {
+		if n, err := format.Number(*v1.Breach, 2, format.DefaultLocale); err == nil {
+			card.Breach = n + " %"
+		}
+	}
+	if !v1.UpdatedAt.IsZero() {
+		card.Date = v1.UpdatedAt.In(defaultLocation).Format("02/01/2006 15:04")
+	}
+
+	image, err := og.Render(card)
+	if err != nil {
+		log.Printf("HTTP: Failed to render OG image: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	writeBinary(w, http.StatusOK, "image/png", image)
+}
+
+// formatOGRate formats a rate in bolívares, or a dash when unavailable.
+func formatOGRate(rate float64) string {
+	if rate <= 0 {
+		return "—"
+	}
+	s, err := format.Money(rate, "VES", format.DefaultLocale)
+	if err != nil {
+		return "—"
+	}
+	return s
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// handleOverride pins a source's rate until an expiry, while its scraper is
+// broken.
+func (h *Handler) handleOverride(w http.ResponseWriter, r *http.Request) {
+	loc, err := location(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req struct {
+		Rate      float64   `json:"rate"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.ExpiresAt.IsZero() {
+		writeError(w, r, http.StatusBadRequest, "expiresAt is required")
+		return
+	}
+
+	src, err := h.rateProvider.SetOverride(r.PathValue("source"), req.Rate, req.ExpiresAt)
+	switch {
+	case errors.Is(err, rates.ErrUnknownSource):
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, rates.ErrInvalidOverride), errors.Is(err, rates.ErrRateRejected):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		log.Printf("HTTP: Failed to override rate: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	// Cached rates no longer reflect the new value
+	h.cache.clear()
+	writeJSON(w, http.StatusOK, src.In(loc))
+}
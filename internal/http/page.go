@@ -0,0 +1,56 @@
+package http
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Page size limits of paginated endpoints.
+const (
+	defaultPageLimit = 366
+	maxPageLimit     = 1000
+)
+
+// errInvalidCursor is returned for cursors not issued by a next link.
+var errInvalidCursor = errors.New("invalid cursor")
+
+// page is the position and size of a paginated request.
+type page struct {
+	Limit int
+
+	// After is the sort key of the last item of the previous page, or
+	// empty for the first page.
+	After string
+}
+
+// parsePage reads the limit and cursor query parameters.
+func parsePage(r *http.Request) (page, error) {
+	p := page{Limit: defaultPageLimit}
+
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return page{}, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		p.Limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		after, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil || len(after) == 0 {
+			return page{}, errInvalidCursor
+		}
+		p.After = string(after)
+	}
+	return p, nil
+}
+
+// nextLink returns the request's URL continuing after the given sort key.
+func nextLink(r *http.Request, after string) string {
+	q := r.URL.Query()
+	q.Set("cursor", base64.RawURLEncoding.EncodeToString([]byte(after)))
+	return r.URL.Path + "?" + q.Encode()
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// permalinkMaxAge is how long caches may keep a day's close, in seconds: a
+// year, as it doesn't change once recorded.
+const permalinkMaxAge = 365 * 24 * 60 * 60
+
+// handleRatesAt returns the daily close of the date in the path, at a URL
+// invoices and articles can link to. Days without a close, including today
+// before it's recorded, return 404 without being cached.
+func (h *Handler) handleRatesAt(w http.ResponseWriter, r *http.Request) {
+	date := r.PathValue("date")
+	dailyClose, err := h.rateProvider.CloseOn(date)
+	if err != nil {
+		switch {
+		case errors.Is(err, rates.ErrInvalidDate):
+			writeError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, rates.ErrNoHistoricalRate):
+			w.Header().Set("Cache-Control", "no-store")
+			writeError(w, r, http.StatusNotFound, err.Error())
+		default:
+			log.Printf("HTTP: Failed to load the close of %s: %v", date, err)
+			writeError(w, r, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	scope, _ := cacheScope(r, nil)
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d, immutable", scope, permalinkMaxAge))
+	writeJSON(w, http.StatusOK, dailyClose)
+}
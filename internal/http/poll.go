@@ -0,0 +1,148 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/pkg/events"
+)
+
+const (
+	// defaultPollWait is how long a long poll waits for a change by default.
+	defaultPollWait = 30 * time.Second
+	// maxPollWait caps the wait requested with the timeout parameter.
+	maxPollWait = 60 * time.Second
+)
+
+// handlePoll holds the request until the rates change after since, or the
+// wait elapses. Changed data is returned as in /rates; an elapsed wait is
+// answered with 204 so the client can poll again. With sources or
+// minChange, a held request only returns once a selected rate moves enough
+// from its value when the request arrived.
+func (h *Handler) handlePoll(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	since, precision, err := parseSince(q.Get("since"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "since must be an RFC 3339 timestamp or Unix epoch seconds")
+		return
+	}
+
+	wait := defaultPollWait
+	if v := q.Get("timeout"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			writeError(w, r, http.StatusBadRequest, "timeout must be a non-negative number of seconds")
+			return
+		}
+		wait = min(time.Duration(seconds)*time.Second, maxPollWait)
+	}
+
+	filter, err := parsePollFilter(q.Get("sources"), q.Get("minChange"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	filtered := filter.Sources != nil || filter.MinChange > 0
+
+	loc, err := location(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Outlive the server's WriteTimeout while waiting; not every writer
+	// (e.g. Lambda) supports deadlines
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	var baseline *rates.RateData
+	for {
+		// Subscribe before reading so no update is missed in between
+		changed := h.rateProvider.Changed()
+		data := h.rateProvider.GetRates()
+		var updated bool
+		switch {
+		case baseline != nil:
+			updated = moved(filter, *baseline, data)
+		case data.UpdatedAt.Truncate(precision).After(since):
+			updated = true
+		case filtered:
+			baseline = &data
+		}
+		if updated {
+			w.Header().Set("Cache-Control", "no-store")
+			writeJSON(w, dataStatus(data.Status), data.In(loc))
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// parseSince parses the since parameter and returns the precision to compare
+// at, so epoch seconds don't match the sub-second part of the same update.
+// An empty value returns the zero time, matching any data.
+func parseSince(v string) (time.Time, time.Duration, error) {
+	if v == "" {
+		return time.Time{}, 0, nil
+	}
+	if epoch, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(epoch, 0), time.Second, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return t, 0, nil
+}
+
+// parsePollFilter parses the sources and minChange parameters. sources is
+// a comma-separated list of bcv and binance.
+func parsePollFilter(sources, minChange string) (notify.Filter, error) {
+	var filter notify.Filter
+	if sources != "" {
+		filter.Sources = strings.Split(sources, ",")
+		for _, source := range filter.Sources {
+			if source != "bcv" && source != "binance" {
+				return notify.Filter{}, errors.New("unknown rate source: use bcv or binance")
+			}
+		}
+	}
+	if minChange != "" {
+		v, err := strconv.ParseFloat(minChange, 64)
+		if err != nil {
+			return notify.Filter{}, errors.New("minChange must be a non-negative number")
+		}
+		filter.MinChange = v
+	}
+	return filter, filter.Validate()
+}
+
+// moved reports whether a rate the filter selects changed from baseline
+// to data by at least the filter's minimum.
+func moved(filter notify.Filter, baseline, data rates.RateData) bool {
+	updates := []events.RateUpdated{
+		{Source: "bcv", Rate: data.BCV, Previous: baseline.BCV},
+		{Source: "binance", Rate: data.Binance, Previous: baseline.Binance},
+	}
+	return slices.ContainsFunc(updates, func(u events.RateUpdated) bool {
+		return u.Rate != u.Previous && filter.MatchesUpdate(u)
+	})
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// maxDecimals is the most decimals values may be rounded to.
+const maxDecimals = 8
+
+// unrounded leaves rates as their sources publish them unless decimals is
+// requested.
+var unrounded = rates.Precision{Decimals: -1}
+
+// precision returns the rounding requested with the decimals and rounding
+// query parameters. Without decimals, fallback's decimals apply; without
+// rounding, values are rounded half up.
+func precision(r *http.Request, fallback rates.Precision) (rates.Precision, error) {
+	p := fallback
+	if raw := queryValue(r, "decimals"); raw != "" {
+		decimals, err := strconv.Atoi(raw)
+		if err != nil || decimals < 0 || decimals > maxDecimals {
+			return rates.Precision{}, fmt.Errorf("decimals must be an integer between 0 and %d", maxDecimals)
+		}
+		p.Decimals = decimals
+	}
+
+	switch rounding := rates.Rounding(queryValue(r, "rounding")); rounding {
+	case "":
+		p.Rounding = rates.RoundHalfUp
+	case rates.RoundHalfUp, rates.RoundTruncate:
+		p.Rounding = rounding
+	default:
+		return rates.Precision{}, fmt.Errorf("rounding must be %q or %q", rates.RoundHalfUp, rates.RoundTruncate)
+	}
+	return p, nil
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/veswatch/api/internal/push"
+)
+
+// PushRegistry manages browser push subscriptions.
+type PushRegistry interface {
+	PublicKey() string
+	Subscribe(sub push.Subscription) error
+	Unsubscribe(endpoint string) error
+}
+
+// SetPush enables the Web Push endpoints. Without a registry they return 404.
+func (h *Handler) SetPush(registry PushRegistry) {
+	h.push = registry
+}
+
+// handlePushKey returns the VAPID public key browsers subscribe with.
+func (h *Handler) handlePushKey(w http.ResponseWriter, r *http.Request) {
+	if h.push == nil {
+		writeError(w, r, http.StatusNotFound, "push notifications are disabled")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"publicKey": h.push.PublicKey(),
+		"events":    push.Events,
+	})
+}
+
+// handlePushSubscribe registers a browser push subscription.
+func (h *Handler) handlePushSubscribe(w http.ResponseWriter, r *http.Request) {
+	if h.push == nil {
+		writeError(w, r, http.StatusNotFound, "push notifications are disabled")
+		return
+	}
+
+	var sub push.Subscription
+	if !decodeJSON(w, r, &sub) {
+		return
+	}
+	sub.CreatedAt = time.Time{}
+	sub.Client = banKey(h.clientAddr(r))
+
+	if err := h.push.Subscribe(sub); err != nil {
+		switch {
+		case errors.Is(err, push.ErrInvalidSubscription):
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, push.ErrTooManySubscriptions):
+			writeError(w, r, http.StatusTooManyRequests, err.Error())
+			return
+		case errors.Is(err, push.ErrSubscriptionsFull):
+			writeError(w, r, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		log.Printf("HTTP: Failed to save push subscription: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handlePushUnsubscribe removes a browser push subscription.
+func (h *Handler) handlePushUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if h.push == nil {
+		writeError(w, r, http.StatusNotFound, "push notifications are disabled")
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Endpoint == "" {
+		writeError(w, r, http.StatusBadRequest, "endpoint is required")
+		return
+	}
+
+	if err := h.push.Unsubscribe(req.Endpoint); err != nil {
+		log.Printf("HTTP: Failed to remove push subscription: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,190 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/veswatch/api/api"
+	"github.com/veswatch/api/internal/apikey"
+	"github.com/veswatch/api/internal/contract"
+	"github.com/veswatch/api/internal/testutil"
+)
+
+// pathValues are the values documented path parameters are given.
+var pathValues = map[string]string{
+	"date":   "2026-01-15",
+	"id":     "unknown",
+	"source": "bcv",
+	"client": "203.0.113.7",
+	"job":    "binance",
+}
+
+// queryValues are the values required query parameters are given.
+var queryValues = map[string]string{
+	"amount": "100",
+}
+
+// operation is a documented operation of the spec.
+type operation struct {
+	method, path string
+	body         []byte
+	contentType  string
+	query        []string
+}
+
+// documented returns every operation in the spec, with the example request
+// body where there's one.
+func documented(t *testing.T) []operation {
+	t.Helper()
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(api.OpenAPI, &spec); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+
+	var ops []operation
+	for path, item := range spec.Paths {
+		for method, raw := range item {
+			if method == "parameters" {
+				continue
+			}
+			var op struct {
+				Parameters []struct {
+					Name     string `json:"name"`
+					In       string `json:"in"`
+					Required bool   `json:"required"`
+				} `json:"parameters"`
+				RequestBody struct {
+					Content map[string]struct {
+						Example json.RawMessage `json:"example"`
+					} `json:"content"`
+				} `json:"requestBody"`
+			}
+			if err := json.Unmarshal(raw, &op); err != nil {
+				t.Fatalf("invalid operation %s %s: %v", method, path, err)
+			}
+
+			o := operation{method: strings.ToUpper(method), path: path}
+			for _, p := range op.Parameters {
+				if p.In == "query" && p.Required {
+					o.query = append(o.query, p.Name)
+				}
+			}
+			for contentType, media := range op.RequestBody.Content {
+				o.contentType = contentType
+				if media.Example != nil {
+					o.body = media.Example
+				}
+			}
+			ops = append(ops, o)
+		}
+	}
+	slices.SortFunc(ops, func(a, b operation) int {
+		return strings.Compare(a.path+" "+a.method, b.path+" "+b.method)
+	})
+	return ops
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// target returns the request URL of an operation.
+func (o operation) target(t *testing.T) string {
+	var missing []string
+	target := pathParam.ReplaceAllStringFunc(o.path, func(param string) string {
+		name := param[1 : len(param)-1]
+		v, ok := pathValues[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		t.Fatalf("no value for path parameters %v of %s", missing, o.path)
+	}
+
+	for i, name := range o.query {
+		v, ok := queryValues[name]
+		if !ok {
+			t.Fatalf("no value for required query parameter %s of %s", name, o.path)
+		}
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		target += sep + name + "=" + v
+	}
+	return target
+}
+
+func TestDocumentedRoutes(t *testing.T) {
+	// API keys are always enabled by the server
+	keys, err := apikey.NewManager(apikey.NewMemoryStore(), apikey.DefaultTiers())
+	if err != nil {
+		t.Fatalf("failed to create API keys: %v", err)
+	}
+	srv := testutil.New(t, testutil.WithSetup(func(s *testutil.Server) {
+		s.Handler.SetAPIKeys(keys)
+	}))
+	_, clientKey, err := keys.Create("routes", apikey.TierPartner, "")
+	if err != nil {
+		t.Fatalf("failed to create API key: %v", err)
+	}
+	srv.Fetch()
+	routes := srv.Handler.Routes()
+
+	validator, err := contract.New()
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+
+	// serve sends a request straight to the router, as an admin for the
+	// admin API and as a client otherwise
+	serve := func(method, target, contentType string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader(body))
+		token := clientKey
+		if strings.HasPrefix(target, "/admin/") || strings.HasPrefix(target, "/undocumented/admin/") {
+			token = testutil.AdminToken
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+		return rec
+	}
+
+	ops := documented(t)
+	if len(ops) == 0 {
+		t.Fatal("no operations documented")
+	}
+	for _, o := range ops {
+		t.Run(o.method+" "+o.path, func(t *testing.T) {
+			target := o.target(t)
+			rec := serve(o.method, target, o.contentType, o.body)
+			t.Logf("%s %s: %d", o.method, target, rec.Code)
+
+			// A documented route must be routed, unlike an unknown one
+			unrouted := serve(o.method, "/undocumented"+target, o.contentType, o.body)
+			if rec.Code == unrouted.Code && rec.Body.String() == unrouted.Body.String() {
+				t.Fatalf("%s %s isn't routed: %d %s", o.method, target, rec.Code, rec.Body)
+			}
+
+			req := httptest.NewRequest(o.method, target, nil)
+			var body []byte
+			if rec.Body.Len() > 0 {
+				body = rec.Body.Bytes()
+			}
+			if err := validator.Check(req, rec.Code, rec.Header(), body); err != nil {
+				t.Errorf("contract violation: %v", err)
+			}
+		})
+	}
+}
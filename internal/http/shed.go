@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/veswatch/api/internal/metrics"
+)
+
+// Shedder bounds how many low-priority requests run at once. Requests past
+// the limit wait in a bounded queue for a slot; those arriving to a full
+// queue, or still waiting after the queue timeout, are shed with 503 and
+// Retry-After. The current rates, health, status and admin endpoints bypass
+// it, so they stay responsive while history and conversion traffic spikes.
+type Shedder struct {
+	slots    chan struct{}
+	maxQueue int64
+	wait     time.Duration
+
+	queued atomic.Int64
+	shed   atomic.Int64
+}
+
+// NewShedder creates a shedder running up to concurrency requests at once
+// and queueing up to queue more for at most wait.
+func NewShedder(concurrency, queue int, wait time.Duration) *Shedder {
+	return &Shedder{
+		slots:    make(chan struct{}, concurrency),
+		maxQueue: int64(queue),
+		wait:     wait,
+	}
+}
+
+// acquire takes a slot, waiting in the queue if there's room. It reports
+// false if the request is shed or its client went away.
+func (s *Shedder) acquire(ctx context.Context) bool {
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if s.queued.Add(1) > s.maxQueue {
+		s.queued.Add(-1)
+		s.shed.Add(1)
+		return false
+	}
+	defer s.queued.Add(-1)
+
+	timer := time.NewTimer(s.wait)
+	defer timer.Stop()
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		s.shed.Add(1)
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees a slot taken by acquire.
+func (s *Shedder) release() {
+	<-s.slots
+}
+
+// retryAfter is the Retry-After of shed requests, in seconds: the queue
+// timeout, by when queued requests have been served or shed.
+func (s *Shedder) retryAfter() int {
+	return max(1, int(math.Ceil(s.wait.Seconds())))
+}
+
+// Collect writes the shedder's metrics.
+func (s *Shedder) Collect(w *metrics.Writer) {
+	w.Family("veswatch_http_low_priority_in_flight", metrics.Gauge, "Low-priority requests being served.")
+	w.Sample("veswatch_http_low_priority_in_flight", float64(len(s.slots)))
+	w.Family("veswatch_http_low_priority_queued", metrics.Gauge, "Low-priority requests waiting for a slot.")
+	w.Sample("veswatch_http_low_priority_queued", float64(s.queued.Load()))
+	w.Family("veswatch_http_shed_total", metrics.Counter, "Low-priority requests shed with 503 under overload.")
+	w.Sample("veswatch_http_shed_total", float64(s.shed.Load()))
+}
+
+// SetShedder enables load shedding of low-priority endpoints. Without one,
+// every request is served.
+func (h *Handler) SetShedder(s *Shedder) {
+	h.shedder = s
+}
+
+// shed wraps a low-priority handler with the shedder.
+func (h *Handler) shed(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := h.shedder
+		if s == nil {
+			next(w, r)
+			return
+		}
+
+		if !s.acquire(r.Context()) {
+			w.Header().Set("Retry-After", strconv.Itoa(s.retryAfter()))
+			writeError(w, r, http.StatusServiceUnavailable, "server is overloaded, retry later")
+			return
+		}
+		defer s.release()
+		next(w, r)
+	}
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/internal/scheduler"
+)
+
+// SetMetrics sets the handler serving /metrics. Without one, /metrics
+// returns 404.
+func (h *Handler) SetMetrics(metrics http.Handler) {
+	h.metrics = metrics
+}
+
+// SetReadOnly marks the instance as a read-only replica, which reports it
+// in /status and refuses writes to rates, keys, alerts and subscriptions.
+func (h *Handler) SetReadOnly(readOnly bool) {
+	h.readOnly = readOnly
+}
+
+// writable wraps a handler writing rates or records so it returns 403 on
+// read-only instances, which only serve what other instances fetch.
+func (h *Handler) writable(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.readOnly {
+			writeError(w, r, http.StatusForbidden, "instance is read-only")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleStatus returns each rate source's fetch status, including anti-bot
+// challenges and backoff, whether the instance is read-only, and whether
+// it's degraded by missed updates.
+func (h *Handler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	escalations := h.rateProvider.Escalations()
+	if escalations == nil {
+		escalations = []rates.Escalation{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"readOnly":    h.readOnly,
+		"degraded":    len(escalations) > 0,
+		"escalations": escalations,
+		"sources":     h.rateProvider.Status(),
+	})
+}
+
+// defaultFetchLimit is the number of fetches /status/fetches returns by
+// default.
+const defaultFetchLimit = 100
+
+// handleFetches returns the most recent fetches of a source, or of every
+// source, newest first, with their duration, HTTP status, bytes received
+// and sample size.
+func (h *Handler) handleFetches(w http.ResponseWriter, r *http.Request) {
+	limit := defaultFetchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = n
+	}
+
+	fetches, err := h.rateProvider.Fetches(r.URL.Query().Get("source"), limit)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"fetches": fetches,
+	})
+}
+
+// handleSchedulerStatus returns each scheduled job's next run and the
+// outcome of its last run.
+func (h *Handler) handleSchedulerStatus(w http.ResponseWriter, r *http.Request) {
+	if h.schedule == nil {
+		writeError(w, r, http.StatusNotFound, "scheduler is not running")
+		return
+	}
+
+	jobs := h.schedule.Status()
+	if jobs == nil {
+		jobs = []scheduler.JobStatus{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"jobs": jobs,
+	})
+}
+
+// handleMetrics serves operational metrics in the Prometheus text format.
+func (h *Handler) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if h.metrics == nil {
+		writeError(w, r, http.StatusNotFound, "metrics are not enabled")
+		return
+	}
+	h.metrics.ServeHTTP(w, r)
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// defaultLocation is the zone response timestamps are expressed in.
+var defaultLocation = rates.Venezuela
+
+// location returns the timezone requested with the tz query parameter, or
+// the default timezone.
+func location(r *http.Request) (*time.Location, error) {
+	tz := queryValue(r, "tz")
+	if tz == "" {
+		return defaultLocation, nil
+	}
+
+	// "Local" would leak the server's own zone
+	if tz == "Local" {
+		return nil, fmt.Errorf("unknown timezone %q", tz)
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", tz)
+	}
+	return loc, nil
+}
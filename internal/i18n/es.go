@@ -0,0 +1,147 @@
+package i18n
+
+// spanish holds the Spanish translations.
+var spanish = map[string]string{
+	// Disclaimer and notices
+	"VESWatch provides reference exchange rates obtained from public sources. This information is not official financial advice.": "VESWatch ofrece tasas de cambio referenciales obtenidas de fuentes públicas. Esta información no constituye asesoría financiera oficial.",
+	"%s data may be outdated: the latest update failed (%s)":                                                                      "Los datos de %s podrían estar desactualizados: la última actualización falló (%s)",
+
+	// Request errors
+	"internal server error":                       "error interno del servidor",
+	"not found":                                   "no encontrado",
+	"invalid JSON body":                           "cuerpo JSON inválido",
+	"request body exceeds %d bytes":               "el cuerpo de la solicitud excede %d bytes",
+	"request timed out after %s":                  "la solicitud excedió el tiempo límite de %s",
+	"rate limit exceeded for tier %s":             "límite de solicitudes excedido para el nivel %s",
+	"invalid or missing API key":                  "clave de API inválida o ausente",
+	"invalid or missing admin token":              "token de administración inválido o ausente",
+	"this endpoint requires the %s role":          "este endpoint requiere el rol %s",
+	"client address not allowed":                  "dirección del cliente no permitida",
+	"client is temporarily banned":                "cliente bloqueado temporalmente",
+	"client %s is not banned":                     "el cliente %s no está bloqueado",
+	"invalid client address":                      "dirección de cliente inválida",
+	"unsupported language %q (expected %q or %q)": "idioma no soportado %q (se esperaba %q o %q)",
+	"unknown timezone %q":                         "zona horaria desconocida %q",
+	"unknown region %q":                           "región desconocida %q",
+	"no ad book sampled yet":                      "aún no se ha muestreado el libro de anuncios",
+	"rate update rejected: %s: %s":                "actualización de tasa rechazada: %s: %s",
+	"unknown rate source %q":                      "fuente de tasa desconocida %q",
+	"invalid override: %s":                        "anulación inválida: %s",
+	"expiry must be in the future":                "el vencimiento debe estar en el futuro",
+	"expiry must be within %d days":               "el vencimiento debe estar dentro de %d días",
+	"expiresAt is required":                       "expiresAt es obligatorio",
+	"unknown format %q (expected %q)":             "formato desconocido %q (se esperaba %q)",
+	"invalid dump: line %d: %s":                   "volcado inválido: línea %d: %s",
+	"invalid dump: missing %s header":             "volcado inválido: falta el encabezado %s",
+	"invalid dump: unsupported version %d":        "volcado inválido: versión %d no soportada",
+	"unknown field %q":                            "campo desconocido %q",
+	"no field %s":                                 "no existe el campo %s",
+	"not an object":                               "no es un objeto",
+
+	// Query parameters
+	"amount must be a number":                                   "amount debe ser un número",
+	"days must be a number":                                     "days debe ser un número",
+	"days must be between 1 and %d":                             "days debe estar entre 1 y %d",
+	"limit must be a non-negative integer":                      "limit debe ser un entero no negativo",
+	"limit must be between 1 and %d":                            "limit debe estar entre 1 y %d",
+	"invalid cursor":                                            "cursor inválido",
+	"timeout must be a non-negative number of seconds":          "timeout debe ser un número de segundos no negativo",
+	"since must be an RFC 3339 timestamp or Unix epoch seconds": "since debe ser una marca de tiempo RFC 3339 o segundos Unix",
+	`format_date must be "default", "iso" or "timestamp"`:       `format_date debe ser "default", "iso" o "timestamp"`,
+	"endpoint is required":                                      "endpoint es obligatorio",
+	"decimals must be an integer between 0 and %d":              "decimals debe ser un entero entre 0 y %d",
+	`rounding must be %q or %q`:                                 `rounding debe ser %q o %q`,
+	"minChange must be a non-negative number":                   "minChange debe ser un número no negativo",
+
+	// Disabled features
+	"admin API is disabled":               "la API de administración está desactivada",
+	"API keys are not configured":         "las claves de API no están configuradas",
+	"push notifications are disabled":     "las notificaciones push están desactivadas",
+	"forecast is not enabled":             "el pronóstico no está habilitado",
+	"metrics are not enabled":             "las métricas no están habilitadas",
+	"datastore export is not enabled":     "la exportación de datos no está habilitada",
+	"database maintenance is not enabled": "el mantenimiento de la base de datos no está habilitado",
+	"analytics are not enabled":           "las analíticas no están habilitadas",
+	"notifications are not enabled":       "las notificaciones no están habilitadas",
+	"abuse detection is not enabled":      "la detección de abuso no está habilitada",
+	"scheduler is not running":            "el planificador no está en ejecución",
+	"instance is read-only":               "la instancia es de solo lectura",
+	"server is overloaded, retry later":   "el servidor está sobrecargado, reintente más tarde",
+	"failed to read audit log":            "no se pudo leer el registro de auditoría",
+	"monitor %q not found":                "monitor %q no encontrado",
+
+	// Rates
+	"unknown currency: %s":                                    "moneda desconocida: %s",
+	"unknown rate source: use bcv or binance":                 "fuente de tasa desconocida: use bcv o binance",
+	"rate not available: %s":                                  "tasa no disponible: %s",
+	"invalid date: %s (expected YYYY-MM-DD)":                  "fecha inválida: %s (se esperaba AAAA-MM-DD)",
+	"invalid date: %s is in the future":                       "fecha inválida: %s está en el futuro",
+	"no historical rate for date: %s":                         "no hay tasa histórica para la fecha: %s",
+	"invalid amount":                                          "monto inválido",
+	"unknown locale: %s":                                      "configuración regional desconocida: %s",
+	"unknown period %q (expected %q or %q)":                   "período desconocido %q (se esperaba %q o %q)",
+	"no history for period":                                   "no hay historial para el período",
+	"unknown forecast model %q (expected %q or %q)":           "modelo de pronóstico desconocido %q (se esperaba %q o %q)",
+	"invalid forecast horizon: days must be between 1 and %d": "horizonte de pronóstico inválido: days debe estar entre 1 y %d",
+	"not enough history: %d closes, need %d":                  "historial insuficiente: %d cierres, se necesitan %d",
+	"not enough history: %d closes with both rates, need %d":  "historial insuficiente: %d cierres con ambas tasas, se necesitan %d",
+	"unknown interval %q (expected 5m, 1h or 1d)":             "intervalo desconocido %q (se esperaba 5m, 1h o 1d)",
+	"unknown aggregation %q (expected %q, %q or %q)":          "agregación desconocida %q (se esperaba %q, %q o %q)",
+	"intraday samples are not recorded: only interval 1d with agg avg or last is available": "no se registran muestras intradía: solo está disponible el intervalo 1d con agg avg o last",
+
+	// Alert rules
+	"invalid alert rule: %s":           "regla de alerta inválida: %s",
+	"alert rule not found":             "regla de alerta no encontrada",
+	"unknown source %q":                "fuente desconocida %q",
+	"condition must be %q or %q":       "condition debe ser %q o %q",
+	"threshold must be positive":       "threshold debe ser positivo",
+	"channel must be one of %v":        "channel debe ser uno de %v",
+	"to is required":                   "to es obligatorio",
+	"to must be an E.164 phone number": "to debe ser un número telefónico E.164",
+	"to isn't subscribed to %s":        "to no está suscrito a %s",
+	"too many alert rules":             "demasiadas reglas de alerta",
+
+	// Jobs
+	"unknown job: %s":                "trabajo desconocido: %s",
+	"previous run still in progress": "la ejecución anterior sigue en curso",
+
+	// Dead letters
+	"dead letter not found":             "mensaje fallido no encontrado",
+	"notifier no longer configured: %s": "el canal ya no está configurado: %s",
+	"redelivery failed: %s":             "la reentrega falló: %s",
+
+	// API keys
+	"API key not found":                   "clave de API no encontrada",
+	"API key is set in configuration":     "la clave de API está definida en la configuración",
+	"invalid API key: %s":                 "clave de API inválida: %s",
+	"name is required":                    "name es obligatorio",
+	"name is taken":                       "el nombre ya está en uso",
+	"names starting with %q are reserved": "los nombres que empiezan con %q están reservados",
+	"key is revoked":                      "la clave está revocada",
+	"unknown tier %q":                     "nivel desconocido %q",
+	"unknown role %q":                     "rol desconocido %q",
+
+	// Identity provider
+	"identity provider unavailable":      "proveedor de identidad no disponible",
+	"invalid token: %s":                  "token inválido: %s",
+	"malformed token":                    "token mal formado",
+	"unknown signing key":                "clave de firma desconocida",
+	"invalid signature":                  "firma inválida",
+	"unsupported algorithm %q":           "algoritmo no soportado %q",
+	"algorithm %s doesn't match the key": "el algoritmo %s no corresponde a la clave",
+	"wrong issuer":                       "emisor incorrecto",
+	"wrong audience":                     "audiencia incorrecta",
+	"token expired":                      "token vencido",
+	"token not yet valid":                "token aún no válido",
+	"missing subject":                    "falta el sujeto",
+
+	// Push subscriptions
+	"invalid push subscription: %s":                    "suscripción push inválida: %s",
+	"endpoint must be an https URL":                    "endpoint debe ser una URL https",
+	"keys.p256dh must be a base64url P-256 public key": "keys.p256dh debe ser una clave pública P-256 en base64url",
+	"keys.auth must be a base64url 16-byte secret":     "keys.auth debe ser un secreto de 16 bytes en base64url",
+	"unknown event %q":                                 "evento desconocido %q",
+	"endpoint must be a public host":                   "endpoint debe ser un host público",
+	"too many push subscriptions from this client":     "demasiadas suscripciones push desde este cliente",
+	"push subscriptions are full":                      "no se admiten más suscripciones push",
+}
@@ -0,0 +1,184 @@
+// Package i18n translates the API's human-readable messages. Messages are
+// written in English and looked up in a per-language catalog keyed by the
+// English text.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang is a supported response language.
+type Lang string
+
+// Supported languages.
+const (
+	English Lang = "en"
+	Spanish Lang = "es"
+)
+
+// Default is the language used when a request doesn't ask for one.
+const Default = English
+
+// ErrUnsupported is returned for a language without a catalog.
+var ErrUnsupported = errors.New("unsupported language")
+
+// catalogs maps each language to its translations, keyed by the English
+// message. Keys may be fmt formats; translations must keep their verbs in
+// the same order.
+var catalogs = map[Lang]map[string]string{
+	Spanish: spanish,
+}
+
+// Parse returns the language of a tag such as "es", "es-VE" or "en_US".
+func Parse(tag string) (Lang, error) {
+	if lang, ok := lookup(tag); ok {
+		return lang, nil
+	}
+	return "", fmt.Errorf("%w %q (expected %q or %q)", ErrUnsupported, tag, English, Spanish)
+}
+
+// lookup returns the supported language of a tag. Unlike Parse, it doesn't
+// allocate for the common tags, as it runs on every request.
+func lookup(tag string) (Lang, bool) {
+	base, _, _ := strings.Cut(tag, "-")
+	base, _, _ = strings.Cut(base, "_")
+	base = strings.TrimSpace(base)
+	switch {
+	case strings.EqualFold(base, string(English)):
+		return English, true
+	case strings.EqualFold(base, string(Spanish)):
+		return Spanish, true
+	}
+	return "", false
+}
+
+// Negotiate returns the supported language an Accept-Language header
+// prefers most, or Default.
+func Negotiate(header string) Lang {
+	if header == "" {
+		return Default
+	}
+
+	best, bestQ := Default, 0.0
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		lang, ok := lookup(tag)
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// Translate returns msg in lang. Messages built from a catalog format are
+// matched against it, and their %s and %v arguments translated in turn;
+// anything not in the catalog is returned unchanged.
+func Translate(lang Lang, msg string) string {
+	catalog := catalogs[lang]
+	if catalog == nil || msg == "" {
+		return msg
+	}
+	if translated, ok := catalog[msg]; ok {
+		return translated
+	}
+
+	for _, p := range patterns[lang] {
+		m := p.re.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		args := make([]any, len(p.verbs))
+		for i, verb := range p.verbs {
+			arg := m[i+1]
+			if verb == 's' || verb == 'v' {
+				arg = Translate(lang, arg)
+			}
+			args[i] = arg
+		}
+		return fmt.Sprintf(p.translation, args...)
+	}
+	return msg
+}
+
+// pattern matches messages built from a catalog format.
+type pattern struct {
+	re          *regexp.Regexp
+	verbs       []byte
+	translation string // with every verb replaced by %s
+	literal     int    // length of the format without verbs
+}
+
+// verbPattern matches the fmt verbs catalog formats may use.
+var verbPattern = regexp.MustCompile(`%[sqdvg]`)
+
+// patterns holds each language's formats, most specific first.
+var patterns = compilePatterns()
+
+// compilePatterns builds the patterns of every catalog format.
+func compilePatterns() map[Lang][]pattern {
+	all := make(map[Lang][]pattern)
+	for lang, catalog := range catalogs {
+		var list []pattern
+		for format, translation := range catalog {
+			verbs := verbPattern.FindAllString(format, -1)
+			if len(verbs) == 0 {
+				continue
+			}
+
+			p := pattern{
+				translation: verbPattern.ReplaceAllString(translation, "%s"),
+				literal:     len(verbPattern.ReplaceAllString(format, "")),
+			}
+			var expr strings.Builder
+			expr.WriteString("^")
+			for i, literal := range verbPattern.Split(format, -1) {
+				expr.WriteString(regexp.QuoteMeta(literal))
+				if i < len(verbs) {
+					verb := verbs[i][1]
+					p.verbs = append(p.verbs, verb)
+					expr.WriteString(verbExpr(verb))
+				}
+			}
+			expr.WriteString("$")
+			p.re = regexp.MustCompile(expr.String())
+			list = append(list, p)
+		}
+
+		// Prefer the formats with the most literal text
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].literal != list[j].literal {
+				return list[i].literal > list[j].literal
+			}
+			return list[i].re.String() < list[j].re.String()
+		})
+		all[lang] = list
+	}
+	return all
+}
+
+// verbExpr returns the expression matching a verb's output.
+func verbExpr(verb byte) string {
+	switch verb {
+	case 'q':
+		return `("(?:[^"\\]|\\.)*")`
+	case 'd':
+		return `(-?\d+)`
+	default:
+		return `(.+?)`
+	}
+}
@@ -0,0 +1,239 @@
+// Package lambda runs an http.Handler on AWS Lambda using the Lambda Runtime
+// API directly, translating API Gateway (REST and HTTP API) and Function URL
+// events into HTTP requests.
+package lambda
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const runtimeAPIVersion = "2018-06-01"
+
+// Detected reports whether the process is running inside AWS Lambda.
+func Detected() bool {
+	return os.Getenv("AWS_LAMBDA_RUNTIME_API") != ""
+}
+
+// event is the subset of API Gateway v1/v2 and Function URL payloads we use.
+type event struct {
+	Version string `json:"version"`
+
+	// Payload format 2.0 (HTTP API, Function URLs)
+	RawPath        string `json:"rawPath"`
+	RawQueryString string `json:"rawQueryString"`
+	RequestContext struct {
+		HTTP struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+		} `json:"http"`
+		Identity struct {
+			SourceIP string `json:"sourceIp"`
+		} `json:"identity"`
+	} `json:"requestContext"`
+
+	// Payload format 1.0 (REST API)
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// response is the API Gateway proxy response payload.
+type response struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// Start processes invocations until the runtime shuts the process down.
+// before, if non-nil, runs ahead of each invocation (e.g. to refresh data).
+func Start(handler http.Handler, before func()) error {
+	base := "http://" + os.Getenv("AWS_LAMBDA_RUNTIME_API") + "/" + runtimeAPIVersion + "/runtime/invocation/"
+	client := &http.Client{}
+
+	log.Println("Lambda: Waiting for invocations")
+
+	for {
+		resp, err := client.Get(base + "next")
+		if err != nil {
+			return fmt.Errorf("lambda: failed to get next invocation: %w", err)
+		}
+		requestID := resp.Header.Get("Lambda-Runtime-Aws-Request-Id")
+		payload, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("lambda: failed to read invocation: %w", err)
+		}
+
+		if before != nil {
+			before()
+		}
+
+		result, err := invoke(handler, payload)
+		if err != nil {
+			log.Printf("Lambda: Invocation %s failed: %v", requestID, err)
+			postJSON(client, base+requestID+"/error", map[string]string{
+				"errorMessage": err.Error(),
+				"errorType":    "InvalidEvent",
+			})
+			continue
+		}
+
+		if err := postJSON(client, base+requestID+"/response", result); err != nil {
+			log.Printf("Lambda: Failed to post response for %s: %v", requestID, err)
+		}
+	}
+}
+
+// invoke converts an event into an HTTP request and serves it.
+func invoke(handler http.Handler, payload []byte) (response, error) {
+	var ev event
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return response{}, fmt.Errorf("failed to decode event: %w", err)
+	}
+
+	req, err := ev.request()
+	if err != nil {
+		return response{}, err
+	}
+
+	w := newResponseWriter()
+	handler.ServeHTTP(w, req)
+	return w.result(), nil
+}
+
+// request builds the HTTP request described by the event.
+func (ev event) request() (*http.Request, error) {
+	method, path := ev.RequestContext.HTTP.Method, ev.RawPath
+	query := ev.RawQueryString
+	sourceIP := ev.RequestContext.HTTP.SourceIP
+
+	if ev.Version != "2.0" {
+		method, path = ev.HTTPMethod, ev.Path
+		sourceIP = ev.RequestContext.Identity.SourceIP
+
+		values := url.Values{}
+		for k, vs := range ev.MultiValueQueryStringParameters {
+			values[k] = vs
+		}
+		if len(values) == 0 {
+			for k, v := range ev.QueryStringParameters {
+				values.Set(k, v)
+			}
+		}
+		query = values.Encode()
+	}
+
+	if method == "" || path == "" {
+		return nil, fmt.Errorf("event is not an HTTP request")
+	}
+
+	body := []byte(ev.Body)
+	if ev.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(ev.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode body: %w", err)
+		}
+		body = decoded
+	}
+
+	target := path
+	if query != "" {
+		target += "?" + query
+	}
+
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	for k, v := range ev.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Host = req.Header.Get("Host")
+	req.RemoteAddr = net.JoinHostPort(sourceIP, "0")
+
+	return req, nil
+}
+
+// responseWriter buffers a handler's response.
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{header: make(http.Header)}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// result converts the buffered response into the proxy response payload.
+func (w *responseWriter) result() response {
+	w.WriteHeader(http.StatusOK)
+
+	headers := make(map[string]string, len(w.header))
+	for k, vs := range w.header {
+		headers[k] = strings.Join(vs, ", ")
+	}
+
+	res := response{
+		StatusCode: w.status,
+		Headers:    headers,
+	}
+	if utf8.Valid(w.body.Bytes()) {
+		res.Body = w.body.String()
+	} else {
+		res.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		res.IsBase64Encoded = true
+	}
+	return res
+}
+
+// postJSON posts a JSON payload to the runtime API.
+func postJSON(client *http.Client, url string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("runtime API returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Package metrics exposes operational metrics in the Prometheus text
+// exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types.
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+)
+
+// Collector writes its current metrics when the registry is scraped.
+type Collector func(w *Writer)
+
+// Registry holds the collectors served on the metrics endpoint.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a collector.
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// ServeHTTP writes every collector's metrics.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	collectors := r.collectors
+	r.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	mw := &Writer{w: bufio.NewWriter(w)}
+	for _, c := range collectors {
+		c(mw)
+	}
+	if err := mw.w.Flush(); err != nil {
+		log.Printf("Metrics: Failed to write response: %v", err)
+	}
+}
+
+// Writer writes metric families and their samples.
+type Writer struct {
+	w *bufio.Writer
+}
+
+// Family starts a metric family. Its samples must follow.
+func (w *Writer) Family(name, typ, help string) {
+	fmt.Fprintf(w.w, "# HELP %s %s\n# TYPE %s %s\n", name, helpEscaper.Replace(help), name, typ)
+}
+
+// Sample writes a sample, with labels given as name/value pairs.
+func (w *Writer) Sample(name string, value float64, labels ...string) {
+	w.w.WriteString(name)
+	if len(labels) > 0 {
+		w.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.w.WriteByte(',')
+			}
+			fmt.Fprintf(w.w, `%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		w.w.WriteByte('}')
+	}
+	w.w.WriteByte(' ')
+	w.w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.w.WriteByte('\n')
+}
+
+// Escapers for help strings and label values.
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
@@ -0,0 +1,141 @@
+// Package mock provides deterministic synthetic rate sources for offline
+// development and CI. No outbound requests are made.
+//
+// Values are pure functions of time: the same instant always yields the
+// same rate, so responses are reproducible across runs and machines. The
+// Simulator is the exception, generating random-walk rates for demos and
+// load tests.
+package mock
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// epoch anchors the synthetic series; rates at epoch equal baseBCV.
+var epoch = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	baseBCV        = 52.0
+	dailyDrift     = 0.0018 // ~0.18% official depreciation per day
+	baseBreach     = 0.22   // parallel premium over BCV
+	breachSwing    = 0.04
+	intradaySwing  = 0.006
+	baseINPC       = 1000.0
+	monthlyInflate = 0.035
+	zellePrice     = 1.03 // USD per USDT on Zelle ads
+)
+
+// BCVSource returns the synthetic official rate.
+type BCVSource struct{}
+
+// Fetch returns the BCV rate for the current day.
+func (BCVSource) Fetch(context.Context) (float64, error) {
+	return BCVAt(time.Now()), nil
+}
+
+// BinanceSource returns the synthetic parallel rate.
+type BinanceSource struct{}
+
+// Fetch returns the parallel rate for the current 5-minute slot.
+func (BinanceSource) Fetch(context.Context) (float64, error) {
+	return BinanceAt(time.Now()), nil
+}
+
+// ParallelSource returns the synthetic parallel rate shifted by a fixed
+// premium, standing in for additional parallel-market sources.
+type ParallelSource struct {
+	Premium float64
+}
+
+// Fetch returns the shifted parallel rate for the current 5-minute slot.
+func (p ParallelSource) Fetch(context.Context) (float64, error) {
+	return round2(BinanceAt(time.Now()) * (1 + p.Premium)), nil
+}
+
+// ZelleSource returns the synthetic USD price of USDT on Zelle ads.
+type ZelleSource struct{}
+
+// Fetch returns the USD price of USDT.
+func (ZelleSource) Fetch(context.Context) (float64, error) {
+	return zellePrice, nil
+}
+
+// INPCSource returns a synthetic INPC series.
+type INPCSource struct{}
+
+// FetchINPC returns 24 months of index values ending last month.
+func (INPCSource) FetchINPC(context.Context) ([]rates.IndexPoint, error) {
+	now := time.Now().UTC()
+	last := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+
+	points := make([]rates.IndexPoint, 0, 24)
+	for i := 23; i >= 0; i-- {
+		month := last.AddDate(0, -i, 0)
+		months := float64((month.Year()-epoch.Year())*12 + int(month.Month()-epoch.Month()))
+		points = append(points, rates.IndexPoint{
+			Month: month,
+			Index: round2(baseINPC * math.Pow(1+monthlyInflate, months)),
+		})
+	}
+	return points, nil
+}
+
+// BCVAt returns the synthetic BCV rate in effect at t.
+func BCVAt(t time.Time) float64 {
+	return round2(baseBCV * math.Pow(1+dailyDrift, days(t)))
+}
+
+// BinanceAt returns the synthetic parallel rate at t, varying per 5-minute slot.
+func BinanceAt(t time.Time) float64 {
+	d := days(t)
+	slot := float64(t.Unix() / 300)
+	premium := baseBreach + breachSwing*math.Sin(d/7)
+	intraday := intradaySwing * math.Sin(slot/6)
+	return round2(BCVAt(t) * (1 + premium) * (1 + intraday))
+}
+
+// DailyCloses returns synthetic daily closes for the n days before today.
+func DailyCloses(n int) []rates.DailyClose {
+	today := time.Now().In(rates.Venezuela)
+	start := time.Date(today.Year(), today.Month(), today.Day(), 23, 55, 0, 0, rates.Venezuela)
+
+	closes := make([]rates.DailyClose, 0, n)
+	for i := n; i >= 1; i-- {
+		closedAt := start.AddDate(0, 0, -i)
+		bcv, binance := BCVAt(closedAt), BinanceAt(closedAt)
+
+		// Intraday extremes over the day's 5-minute slots
+		high, low := binance, binance
+		for slot := closedAt.Add(-24 * time.Hour); slot.Before(closedAt); slot = slot.Add(5 * time.Minute) {
+			v := BinanceAt(slot)
+			high = math.Max(high, v)
+			low = math.Min(low, v)
+		}
+
+		closes = append(closes, rates.DailyClose{
+			Date:     closedAt.Format("2006-01-02"),
+			BCV:      bcv,
+			Binance:  binance,
+			High:     high,
+			Low:      low,
+			Breach:   math.Trunc((binance-bcv)/bcv*100*100) / 100,
+			ClosedAt: closedAt,
+		})
+	}
+	return closes
+}
+
+// days returns the number of whole days since epoch in Venezuela time.
+func days(t time.Time) float64 {
+	local := t.In(rates.Venezuela)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	return math.Floor(day.Sub(epoch).Hours() / 24)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
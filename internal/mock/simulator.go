@@ -0,0 +1,167 @@
+package mock
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// Simulator parameters.
+const (
+	// premiumReversion is how fast the parallel premium reverts to
+	// baseBreach, per day: deviations halve in about five days.
+	premiumReversion = 1.0 / 7
+	// bcvVolatility is the BCV's share of the simulator volatility.
+	bcvVolatility = 0.25
+	// sourceNoise is the share of the volatility by which each parallel
+	// source strays from the Binance rate on every fetch.
+	sourceNoise = 0.1
+	// simulatorStep is the interval of the simulated history's intraday
+	// extremes.
+	simulatorStep = 5 * time.Minute
+)
+
+// Simulator generates random-walk rates for demos and load tests. Unlike
+// the other sources in this package its rates are random: the BCV rate
+// depreciates once a day by dailyDrift plus noise, and the parallel rate's
+// premium over it follows a mean-reverting random walk.
+//
+// Volatility is the standard deviation of the premium's daily log change;
+// rates with the same seed repeat only if they are fetched at the same
+// times.
+type Simulator struct {
+	volatility float64
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	at      time.Time
+	bcv     float64
+	premium float64 // log of parallel over BCV
+}
+
+// NewSimulator returns a simulator starting at the mock rates of now. A
+// zero seed picks a random one.
+func NewSimulator(volatility float64, seed uint64) *Simulator {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	now := time.Now()
+	return &Simulator{
+		volatility: volatility,
+		rng:        rand.New(rand.NewPCG(seed, seed)),
+		at:         now,
+		bcv:        BCVAt(now),
+		premium:    math.Log1p(baseBreach),
+	}
+}
+
+// BCV returns the simulated official rate.
+func (s *Simulator) BCV() rates.Scraper {
+	return simulatedSource{s: s, official: true}
+}
+
+// Binance returns the simulated parallel rate.
+func (s *Simulator) Binance() rates.Scraper {
+	return simulatedSource{s: s}
+}
+
+// Parallel returns the simulated parallel rate shifted by premium, with
+// noise of its own on every fetch.
+func (s *Simulator) Parallel(premium float64) rates.Scraper {
+	return simulatedSource{s: s, premium: premium, noisy: true}
+}
+
+// DailyCloses restarts the walk n days ago and returns its daily closes
+// for the n days before today, continuing from the last one. Call it
+// before the sources are first fetched.
+func (s *Simulator) DailyCloses(n int) []rates.DailyClose {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	today := time.Now().In(rates.Venezuela)
+	end := time.Date(today.Year(), today.Month(), today.Day(), 23, 55, 0, 0, rates.Venezuela)
+	s.at = end.AddDate(0, 0, -n-1)
+	s.bcv = BCVAt(s.at)
+	s.premium = math.Log1p(baseBreach)
+
+	closes := make([]rates.DailyClose, 0, n)
+	for i := n; i >= 1; i-- {
+		closedAt := end.AddDate(0, 0, -i)
+
+		// Intraday extremes over the day's 5-minute steps
+		high, low := 0.0, math.Inf(1)
+		for s.at.Before(closedAt) {
+			s.step(s.at.Add(simulatorStep))
+			v := s.parallel()
+			high = math.Max(high, v)
+			low = math.Min(low, v)
+		}
+
+		bcv, binance := round2(s.bcv), s.parallel()
+		closes = append(closes, rates.DailyClose{
+			Date:     closedAt.Format("2006-01-02"),
+			BCV:      bcv,
+			Binance:  binance,
+			High:     high,
+			Low:      low,
+			Breach:   math.Trunc((binance-bcv)/bcv*100*100) / 100,
+			ClosedAt: closedAt,
+		})
+	}
+	s.step(time.Now())
+	return closes
+}
+
+// step advances the walk to t. Callers must hold the lock.
+func (s *Simulator) step(t time.Time) {
+	if !t.After(s.at) {
+		return
+	}
+
+	// The BCV publishes once a day
+	for range int(days(t) - days(s.at)) {
+		s.bcv *= math.Exp(math.Log1p(dailyDrift) + bcvVolatility*s.volatility*s.rng.NormFloat64())
+	}
+
+	// The premium reverts to its mean: an exact Ornstein-Uhlenbeck step
+	dt := t.Sub(s.at).Hours() / 24
+	mean := math.Log1p(baseBreach)
+	decay := math.Exp(-premiumReversion * dt)
+	spread := s.volatility * math.Sqrt((1-decay*decay)/(2*premiumReversion))
+	s.premium = mean + (s.premium-mean)*decay + spread*s.rng.NormFloat64()
+	s.at = t
+}
+
+// parallel returns the current parallel rate. Callers must hold the lock.
+func (s *Simulator) parallel() float64 {
+	return round2(s.bcv * math.Exp(s.premium))
+}
+
+// simulatedSource returns a simulator's rate.
+type simulatedSource struct {
+	s        *Simulator
+	official bool
+	premium  float64
+	noisy    bool
+}
+
+// Fetch advances the walk to now and returns the rate.
+func (src simulatedSource) Fetch(context.Context) (float64, error) {
+	s := src.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.step(time.Now())
+	if src.official {
+		return round2(s.bcv), nil
+	}
+	rate := s.parallel() * (1 + src.premium)
+	if src.noisy {
+		rate *= math.Exp(sourceNoise * s.volatility * s.rng.NormFloat64())
+	}
+	return round2(rate), nil
+}
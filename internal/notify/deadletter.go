@@ -0,0 +1,411 @@
+package notify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/metrics"
+)
+
+// Dead letter errors.
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrNotifierMissing    = errors.New("notifier no longer configured")
+	ErrRedeliveryFailed   = errors.New("redelivery failed")
+)
+
+// deliveryAttempts is how many times a notifier is handed an event before
+// the event is dead-lettered.
+const deliveryAttempts = 3
+
+// retryDelay is the wait before retrying a failed delivery, doubling after
+// each attempt.
+const retryDelay = time.Second
+
+// DeadLetter is an event a notifier failed to deliver after every attempt,
+// kept until it's redelivered or discarded.
+type DeadLetter struct {
+	ID       string    `json:"id"`
+	Notifier string    `json:"notifier"`
+	Event    Event     `json:"event"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+// DeadLetterStore persists dead letters.
+type DeadLetterStore interface {
+	SaveDeadLetter(letter DeadLetter) error
+	DeleteDeadLetter(id string) error
+	// DeadLetters returns every dead letter, oldest first.
+	DeadLetters() ([]DeadLetter, error)
+}
+
+// MemoryDeadLetters keeps dead letters in memory.
+type MemoryDeadLetters struct {
+	mu      sync.RWMutex
+	letters []DeadLetter
+}
+
+// NewMemoryDeadLetters creates an empty in-memory dead letter store.
+func NewMemoryDeadLetters() *MemoryDeadLetters {
+	return &MemoryDeadLetters{}
+}
+
+// SaveDeadLetter adds a dead letter, replacing any with the same ID.
+func (m *MemoryDeadLetters) SaveDeadLetter(letter DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := slices.IndexFunc(m.letters, func(l DeadLetter) bool { return l.ID == letter.ID }); i >= 0 {
+		m.letters[i] = letter
+		return nil
+	}
+	m.letters = append(m.letters, letter)
+	return nil
+}
+
+// DeleteDeadLetter removes a dead letter.
+func (m *MemoryDeadLetters) DeleteDeadLetter(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.letters, func(l DeadLetter) bool { return l.ID == id })
+	if i < 0 {
+		return ErrDeadLetterNotFound
+	}
+	m.letters = slices.Delete(m.letters, i, i+1)
+	return nil
+}
+
+// DeadLetters returns every dead letter, oldest first.
+func (m *MemoryDeadLetters) DeadLetters() ([]DeadLetter, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.letters), nil
+}
+
+// SetDeadLetters replaces the in-memory dead letter store.
+func (d *Dispatcher) SetDeadLetters(store DeadLetterStore) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deadLetters = store
+}
+
+// DeadLetters returns the events notifiers failed to deliver, oldest
+// first.
+func (d *Dispatcher) DeadLetters() ([]DeadLetter, error) {
+	return d.deadLetterStore().DeadLetters()
+}
+
+// Redeliver hands a dead letter's event to its notifier again, once. The
+// dead letter is removed if it's delivered, and otherwise kept with the
+// new error, which is returned wrapping ErrRedeliveryFailed.
+func (d *Dispatcher) Redeliver(id string) (DeadLetter, error) {
+	store := d.deadLetterStore()
+	letter, err := findDeadLetter(store, id)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	notifiers := d.registered()
+	i := slices.IndexFunc(notifiers, func(n Notifier) bool { return n.Name() == letter.Notifier })
+	if i < 0 {
+		return letter, fmt.Errorf("%w: %s", ErrNotifierMissing, letter.Notifier)
+	}
+	n := notifiers[i]
+
+	event := letter.Event
+	event.Data = decodeData(event.Type, event.Data)
+	letter.Attempts++
+	if err := n.Notify(event); err != nil {
+		letter.Error = err.Error()
+		letter.FailedAt = time.Now()
+		if err := store.SaveDeadLetter(letter); err != nil {
+			log.Printf("Notify: Failed to update dead letter %s: %v", letter.ID, err)
+		}
+		return letter, fmt.Errorf("%w: %v", ErrRedeliveryFailed, err)
+	}
+
+	log.Printf("Notify: Redelivered %s event to %s", event.Type, n.Name())
+	return letter, store.DeleteDeadLetter(id)
+}
+
+// Discard removes a dead letter without delivering it.
+func (d *Dispatcher) Discard(id string) (DeadLetter, error) {
+	store := d.deadLetterStore()
+	letter, err := findDeadLetter(store, id)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	return letter, store.DeleteDeadLetter(id)
+}
+
+// Collect writes the number of dead letters per notifier.
+func (d *Dispatcher) Collect(w *metrics.Writer) {
+	letters, err := d.DeadLetters()
+	if err != nil {
+		return
+	}
+	counts := make(map[string]int)
+	for _, n := range d.registered() {
+		counts[n.Name()] = 0
+	}
+	for _, l := range letters {
+		counts[l.Notifier]++
+	}
+
+	w.Family("veswatch_dead_letters", metrics.Gauge, "Events a notifier failed to deliver, awaiting redelivery.")
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		w.Sample("veswatch_dead_letters", float64(counts[name]), "notifier", name)
+	}
+}
+
+// deadLetterStore returns the dead letter store.
+func (d *Dispatcher) deadLetterStore() DeadLetterStore {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.deadLetters
+}
+
+// RecipientError reports the recipients, by address, a notifier failed to
+// deliver an untargeted event to, so only they are retried.
+type RecipientError struct {
+	Errors map[string]error
+}
+
+func (e *RecipientError) Error() string {
+	addresses := slices.Sorted(maps.Keys(e.Errors))
+	if len(addresses) == 0 {
+		return "deliveries failed"
+	}
+	return fmt.Sprintf("%d deliveries failed, to %s: %v", len(addresses), addresses[0], e.Errors[addresses[0]])
+}
+
+// failedDelivery is an event a notifier failed to deliver.
+type failedDelivery struct {
+	event Event
+	err   error
+}
+
+// attempt hands events to a notifier once and returns the deliveries that
+// failed. Of an untargeted event, only the recipients that failed are
+// returned, each with a copy of the event targeted at them.
+func attempt(n Notifier, events []Event) []failedDelivery {
+	var failed []failedDelivery
+	for _, e := range events {
+		err := n.Notify(e)
+		var recipients *RecipientError
+		switch {
+		case err == nil:
+		case e.To == "" && errors.As(err, &recipients):
+			for to, err := range recipients.Errors {
+				targeted := e
+				targeted.To = to
+				failed = append(failed, failedDelivery{event: targeted, err: err})
+			}
+		default:
+			failed = append(failed, failedDelivery{event: e, err: err})
+		}
+	}
+	return failed
+}
+
+// retry is a delivery to a notifier that failed and is due to be
+// attempted again. Its outbox entry stays pending for the notifier until
+// the delivery succeeds or is dead-lettered.
+type retry struct {
+	outbox   Outbox
+	id       uint64
+	notifier Notifier
+	failed   []failedDelivery
+	attempts int
+	due      time.Time
+}
+
+// retryQueue holds the deliveries waiting to be retried, which a
+// background worker attempts as they fall due, so publishing never waits
+// for a failing channel.
+type retryQueue struct {
+	mu      sync.Mutex
+	pending []*retry
+	running bool // the worker is running
+	stopped bool
+	wake    chan struct{} // a retry was scheduled
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+// newRetryQueue creates an empty retry queue.
+func newRetryQueue() *retryQueue {
+	return &retryQueue{
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+	}
+}
+
+// handle attempts a delivery. Failures are retried in the background after
+// a delay doubling with each attempt, and dead-lettered after
+// deliveryAttempts. The outbox records the notifier was handed the event
+// once no retry is left.
+func (d *Dispatcher) handle(r *retry, events []Event) {
+	r.failed = attempt(r.notifier, events)
+	r.attempts++
+	if len(r.failed) > 0 && r.attempts < deliveryAttempts {
+		delay := retryDelay << (r.attempts - 1)
+		log.Printf("Notify: %s failed to deliver %s event (attempt %d of %d, retrying in %s): %v",
+			r.notifier.Name(), r.failed[0].event.Type, r.attempts, deliveryAttempts, delay, r.failed[0].err)
+		r.due = time.Now().Add(delay)
+		if d.schedule(r) {
+			return
+		}
+		if r.outbox != nil {
+			// Stopping: the outbox entry is resumed after the restart
+			return
+		}
+	}
+
+	for _, failure := range r.failed {
+		d.deadLetter(r.notifier, failure, r.attempts)
+	}
+	if r.outbox != nil {
+		d.delivered(r.outbox, r.id, r.notifier.Name())
+	}
+}
+
+// schedule queues a retry, starting the worker if it isn't running. It
+// reports false if the dispatcher is stopped.
+func (d *Dispatcher) schedule(r *retry) bool {
+	q := d.retries
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopped {
+		return false
+	}
+
+	q.pending = append(q.pending, r)
+	if !q.running {
+		q.running = true
+		q.done.Add(1)
+		go d.retryLoop()
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// retryLoop attempts the queued retries as they fall due, until none are
+// left or the dispatcher is stopped.
+func (d *Dispatcher) retryLoop() {
+	q := d.retries
+	defer q.done.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		q.mu.Lock()
+		if q.stopped || len(q.pending) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		i := 0
+		for j, r := range q.pending {
+			if r.due.Before(q.pending[i].due) {
+				i = j
+			}
+		}
+		next := q.pending[i]
+		wait := time.Until(next.due)
+		if wait <= 0 {
+			q.pending = slices.Delete(q.pending, i, i+1)
+			q.mu.Unlock()
+
+			events := make([]Event, len(next.failed))
+			for k, f := range next.failed {
+				events[k] = f.event
+			}
+			d.handle(next, events)
+			continue
+		}
+		q.mu.Unlock()
+
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-q.wake:
+			timer.Stop()
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// Stop stops retrying failed deliveries, waiting for an attempt in
+// progress. Deliveries still waiting for a retry stay in the outbox, to be
+// resumed after a restart; without an outbox they're dead-lettered.
+func (d *Dispatcher) Stop() {
+	q := d.retries
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return
+	}
+	q.stopped = true
+	close(q.stop)
+	pending := q.pending
+	q.pending = nil
+	q.mu.Unlock()
+	q.done.Wait()
+
+	for _, r := range pending {
+		if r.outbox != nil {
+			continue
+		}
+		for _, failure := range r.failed {
+			d.deadLetter(r.notifier, failure, r.attempts)
+		}
+	}
+}
+
+// deadLetter keeps an event a notifier failed to deliver.
+func (d *Dispatcher) deadLetter(n Notifier, failure failedDelivery, attempts int) {
+	event, err := failure.event, failure.err
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		log.Printf("Notify: Failed to dead-letter %s event for %s: %v", event.Type, n.Name(), err)
+		return
+	}
+	letter := DeadLetter{
+		ID:       hex.EncodeToString(id),
+		Notifier: n.Name(),
+		Event:    event,
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now(),
+	}
+	if err := d.deadLetterStore().SaveDeadLetter(letter); err != nil {
+		log.Printf("Notify: Failed to dead-letter %s event for %s: %v", event.Type, n.Name(), err)
+		return
+	}
+	log.Printf("Notify: %s failed to deliver %s event, dead-lettered as %s: %v", n.Name(), event.Type, letter.ID, err)
+}
+
+// findDeadLetter returns the dead letter with the given ID.
+func findDeadLetter(store DeadLetterStore, id string) (DeadLetter, error) {
+	letters, err := store.DeadLetters()
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	i := slices.IndexFunc(letters, func(l DeadLetter) bool { return l.ID == id })
+	if i < 0 {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return letters[i], nil
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flaky is a notifier failing its first failures deliveries.
+type flaky struct {
+	mu        sync.Mutex
+	failures  int
+	delivered []Event
+}
+
+func (f *flaky) Name() string { return "flaky" }
+
+func (f *flaky) Notify(event Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("unavailable")
+	}
+	f.delivered = append(f.delivered, event)
+	return nil
+}
+
+func (f *flaky) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.delivered)
+}
+
+// memoryOutbox is an outbox kept in memory.
+type memoryOutbox struct {
+	mu      sync.Mutex
+	entries []OutboxEntry
+}
+
+func (o *memoryOutbox) Add(event Event, notifiers []string) (uint64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	id := uint64(len(o.entries) + 1)
+	o.entries = append(o.entries, OutboxEntry{ID: id, Event: event, Notifiers: slices.Clone(notifiers)})
+	return id, nil
+}
+
+func (o *memoryOutbox) Delivered(id uint64, notifier string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, e := range o.entries {
+		if e.ID != id {
+			continue
+		}
+		e.Notifiers = slices.DeleteFunc(e.Notifiers, func(n string) bool { return n == notifier })
+		if len(e.Notifiers) == 0 {
+			o.entries = slices.Delete(o.entries, i, i+1)
+		} else {
+			o.entries[i] = e
+		}
+		return nil
+	}
+	return nil
+}
+
+func (o *memoryOutbox) Pending() ([]OutboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.entries), nil
+}
+
+// waitFor polls cond until it holds, failing the test after timeout.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %s", timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPublishRetriesInBackground(t *testing.T) {
+	n := &flaky{failures: 1}
+	outbox := &memoryOutbox{}
+	d := NewDispatcher()
+	d.Register(n)
+	d.SetOutbox(outbox)
+	t.Cleanup(d.Stop)
+
+	start := time.Now()
+	d.Publish(Event{Type: EventBCVUpdate, Message: "BCV: 36.50"})
+	if took := time.Since(start); took >= retryDelay {
+		t.Fatalf("Publish took %s, waiting for the retry", took)
+	}
+	if pending, _ := outbox.Pending(); len(pending) != 1 {
+		t.Fatalf("outbox has %d entries while the delivery is retried, want 1", len(pending))
+	}
+
+	waitFor(t, 5*retryDelay, func() bool { return n.count() == 1 })
+	waitFor(t, time.Second, func() bool {
+		pending, _ := outbox.Pending()
+		return len(pending) == 0
+	})
+	if letters, _ := d.DeadLetters(); len(letters) != 0 {
+		t.Errorf("got %d dead letters for a delivered event", len(letters))
+	}
+}
+
+func TestPublishDeadLettersAfterEveryAttempt(t *testing.T) {
+	n := &flaky{failures: deliveryAttempts}
+	d := NewDispatcher()
+	d.Register(n)
+	t.Cleanup(d.Stop)
+
+	d.Publish(Event{Type: EventBCVUpdate, Message: "BCV: 36.50"})
+	waitFor(t, 10*retryDelay, func() bool {
+		letters, _ := d.DeadLetters()
+		return len(letters) == 1
+	})
+	letters, _ := d.DeadLetters()
+	if letters[0].Attempts != deliveryAttempts {
+		t.Errorf("dead letter has %d attempts, want %d", letters[0].Attempts, deliveryAttempts)
+	}
+	if n.count() != 0 {
+		t.Errorf("event delivered %d times, want 0", n.count())
+	}
+}
+
+func TestStopLeavesRetriesInOutbox(t *testing.T) {
+	n := &flaky{failures: 1}
+	outbox := &memoryOutbox{}
+	d := NewDispatcher()
+	d.Register(n)
+	d.SetOutbox(outbox)
+
+	d.Publish(Event{Type: EventBCVUpdate, Message: "BCV: 36.50"})
+	d.Stop()
+
+	if pending, _ := outbox.Pending(); len(pending) != 1 {
+		t.Errorf("outbox has %d entries after stopping, want 1 to resume", len(pending))
+	}
+	if letters, _ := d.DeadLetters(); len(letters) != 0 {
+		t.Errorf("got %d dead letters, want the retry left in the outbox", len(letters))
+	}
+}
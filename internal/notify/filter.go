@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"errors"
+	"math"
+	"slices"
+
+	"github.com/veswatch/api/pkg/events"
+)
+
+// Filter selects the events a subscriber receives, so uninteresting ones
+// are dropped before they're sent. The zero Filter matches every event.
+type Filter struct {
+	// Events are the event types received. Empty means all of them.
+	Events []string `json:"events,omitempty"`
+
+	// Sources are the rate sources whose events are received, e.g. bcv.
+	// Events not about a single source, such as daily closes, always
+	// pass. Empty means every source.
+	Sources []string `json:"sources,omitempty"`
+
+	// MinChange drops rate updates that moved less than this percentage
+	// from the previous rate.
+	MinChange float64 `json:"minChange,omitempty"`
+}
+
+// Validate checks the minimum change. Event and source names are left to
+// the subscriber, which knows the ones it offers.
+func (f Filter) Validate() error {
+	if f.MinChange < 0 || math.IsNaN(f.MinChange) || math.IsInf(f.MinChange, 0) {
+		return errors.New("minChange must be a non-negative number")
+	}
+	return nil
+}
+
+// Matches reports whether the filter selects the event. Sources and the
+// minimum change apply to events whose data is one of the payloads of
+// package events.
+func (f Filter) Matches(event Event) bool {
+	if len(f.Events) > 0 && !slices.Contains(f.Events, event.Type) {
+		return false
+	}
+	switch data := event.Data.(type) {
+	case events.RateUpdated:
+		return f.MatchesUpdate(data)
+	case events.RateRejected:
+		return f.matchesSource(data.Source)
+	case events.SourceDegraded:
+		return f.matchesSource(data.Source)
+	default:
+		return true
+	}
+}
+
+// MatchesUpdate reports whether the filter selects a rate update. Without
+// a previous rate, the change is unknown and only a zero MinChange
+// matches.
+func (f Filter) MatchesUpdate(update events.RateUpdated) bool {
+	if !f.matchesSource(update.Source) {
+		return false
+	}
+	if f.MinChange == 0 {
+		return true
+	}
+	if update.Previous <= 0 {
+		return false
+	}
+	return math.Abs(update.Rate-update.Previous)/update.Previous*100 >= f.MinChange
+}
+
+// matchesSource reports whether the filter selects events about source.
+func (f Filter) matchesSource(source string) bool {
+	return len(f.Sources) == 0 || slices.Contains(f.Sources, source)
+}
@@ -0,0 +1,175 @@
+// Package notify provides event fan-out to notification channels.
+package notify
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/pkg/events"
+)
+
+// Event types emitted by the rate service. The payloads of those defined
+// in package events are the exported types there.
+const (
+	EventDailyClose   = events.TypeDailyClose
+	EventBCVUpdate    = events.TypeBCVUpdate
+	EventAlert        = "alert"
+	EventSourceError  = events.TypeSourceError
+	EventEscalation   = "escalation"
+	EventRateRejected = events.TypeRateRejected
+)
+
+// Event represents a notification emitted by the service.
+type Event struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Data    any       `json:"data,omitempty"`
+
+	// Channel and To target a single notifier and address (e.g. a user's
+	// alert rule). Untargeted events go to every notifier's own recipients.
+	Channel string `json:"channel,omitempty"`
+	To      string `json:"to,omitempty"`
+}
+
+// Notifier delivers events to a single channel.
+type Notifier interface {
+	Name() string
+	Notify(event Event) error
+}
+
+// Dispatcher fans events out to all registered notifiers.
+type Dispatcher struct {
+	mu          sync.RWMutex
+	notifiers   []Notifier
+	outbox      Outbox
+	deadLetters DeadLetterStore
+	retries     *retryQueue
+}
+
+// NewDispatcher creates a new event dispatcher.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{deadLetters: NewMemoryDeadLetters(), retries: newRetryQueue()}
+}
+
+// Register adds a notifier to the dispatcher.
+func (d *Dispatcher) Register(n Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notifiers = append(d.notifiers, n)
+	log.Printf("Notify: Registered %s notifier", n.Name())
+}
+
+// Publish delivers the event to every registered notifier, storing it in
+// the outbox first if there is one. Each notifier is handed the event
+// once; failed deliveries are retried in the background, then
+// dead-lettered, and are never propagated to the caller.
+//
+// The outbox is written in its own transaction, so an event announcing a
+// persisted change can be lost if the process stops between the two
+// writes; PublishStored closes that gap.
+func (d *Dispatcher) Publish(event Event) {
+	event, notifiers := d.prepare(event)
+	outbox := d.currentOutbox()
+	var id uint64
+	if outbox != nil && len(notifiers) > 0 {
+		var err error
+		if id, err = outbox.Add(event, names(notifiers)); err != nil {
+			log.Printf("Notify: Failed to store %s event in the outbox, delivering it anyway: %v", event.Type, err)
+			outbox = nil
+		}
+	}
+
+	for _, n := range notifiers {
+		d.deliver(outbox, id, n, event)
+	}
+}
+
+// PublishStored delivers the event like Publish, but has store write it to
+// the outbox, together with the change it announces, so that both are kept
+// or neither is. store is given the notifiers to store the event for, none
+// if there's no outbox, and must return the ID it was stored under. If
+// store fails, the event isn't delivered and its error is returned.
+func (d *Dispatcher) PublishStored(event Event, store func(event Event, notifiers []string) (uint64, error)) error {
+	event, notifiers := d.prepare(event)
+	outbox := d.currentOutbox()
+	var stored []string
+	if outbox != nil {
+		stored = names(notifiers)
+	}
+	id, err := store(event, stored)
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		outbox = nil
+	}
+
+	for _, n := range notifiers {
+		d.deliver(outbox, id, n, event)
+	}
+	return nil
+}
+
+// prepare timestamps an event and returns the notifiers it goes to.
+func (d *Dispatcher) prepare(event Event) (Event, []Notifier) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	var notifiers []Notifier
+	for _, n := range d.registered() {
+		// Targeted events only go to their channel, and are always logged
+		if event.Channel != "" && n.Name() != event.Channel && n.Name() != "log" {
+			continue
+		}
+		notifiers = append(notifiers, n)
+	}
+	return event, notifiers
+}
+
+// currentOutbox returns the outbox, if there is one.
+func (d *Dispatcher) currentOutbox() Outbox {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.outbox
+}
+
+// names returns the names of notifiers.
+func names(notifiers []Notifier) []string {
+	list := make([]string, len(notifiers))
+	for i, n := range notifiers {
+		list[i] = n.Name()
+	}
+	return list
+}
+
+// registered returns the registered notifiers.
+func (d *Dispatcher) registered() []Notifier {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return slices.Clone(d.notifiers)
+}
+
+// deliver hands an event to a notifier, scheduling a retry if it fails.
+// With an outbox, it records that the notifier was handed the event once
+// it's delivered or dead-lettered.
+func (d *Dispatcher) deliver(outbox Outbox, id uint64, n Notifier, event Event) {
+	d.handle(&retry{outbox: outbox, id: id, notifier: n}, []Event{event})
+}
+
+// LogNotifier writes events to the application log.
+type LogNotifier struct{}
+
+// Name returns the notifier name.
+func (LogNotifier) Name() string {
+	return "log"
+}
+
+// Notify logs the event message.
+func (LogNotifier) Notify(event Event) error {
+	log.Printf("Notify: [%s] %s", event.Type, event.Message)
+	return nil
+}
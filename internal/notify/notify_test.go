@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPublishStored(t *testing.T) {
+	n := &flaky{}
+	outbox := &memoryOutbox{}
+	d := NewDispatcher()
+	d.Register(LogNotifier{})
+	d.Register(n)
+	d.SetOutbox(outbox)
+	t.Cleanup(d.Stop)
+
+	var stored []string
+	err := d.PublishStored(Event{Type: EventDailyClose, Message: "Cierre"}, func(event Event, notifiers []string) (uint64, error) {
+		if event.Time.IsZero() {
+			t.Error("stored event has no time")
+		}
+		stored = notifiers
+		return outbox.Add(event, notifiers)
+	})
+	if err != nil {
+		t.Fatalf("PublishStored: %v", err)
+	}
+	if want := []string{"log", "flaky"}; !slices.Equal(stored, want) {
+		t.Errorf("stored for %v, want %v", stored, want)
+	}
+
+	waitFor(t, time.Second, func() bool { return n.count() == 1 })
+	waitFor(t, time.Second, func() bool {
+		pending, _ := outbox.Pending()
+		return len(pending) == 0
+	})
+}
+
+func TestPublishStoredWithoutOutbox(t *testing.T) {
+	n := &flaky{}
+	d := NewDispatcher()
+	d.Register(n)
+	t.Cleanup(d.Stop)
+
+	err := d.PublishStored(Event{Type: EventDailyClose}, func(_ Event, notifiers []string) (uint64, error) {
+		if len(notifiers) != 0 {
+			t.Errorf("stored for %v without an outbox", notifiers)
+		}
+		return 0, nil
+	})
+	if err != nil {
+		t.Fatalf("PublishStored: %v", err)
+	}
+	waitFor(t, time.Second, func() bool { return n.count() == 1 })
+}
+
+func TestPublishStoredFailure(t *testing.T) {
+	n := &flaky{}
+	d := NewDispatcher()
+	d.Register(n)
+	d.SetOutbox(&memoryOutbox{})
+	t.Cleanup(d.Stop)
+
+	failure := errors.New("disk full")
+	err := d.PublishStored(Event{Type: EventDailyClose}, func(Event, []string) (uint64, error) {
+		return 0, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("got error %v, want %v", err, failure)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n.count() != 0 {
+		t.Error("event delivered although it wasn't stored")
+	}
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+
+	"github.com/veswatch/api/pkg/events"
+)
+
+// OutboxEntry is an event stored in the outbox, with the notifiers it
+// hasn't been handed to yet.
+type OutboxEntry struct {
+	ID        uint64   `json:"id"`
+	Event     Event    `json:"event"`
+	Notifiers []string `json:"notifiers"`
+}
+
+// Outbox persists events until every notifier they're for has been handed
+// them, so events published right before a crash are delivered after the
+// restart.
+type Outbox interface {
+	// Add stores an event for the named notifiers and returns its ID.
+	Add(event Event, notifiers []string) (uint64, error)
+	// Delivered records that the named notifier was handed an event,
+	// removing the event once every notifier was.
+	Delivered(id uint64, notifier string) error
+	// Pending returns the stored events, oldest first.
+	Pending() ([]OutboxEntry, error)
+}
+
+// SetOutbox stores every published event in outbox before delivering it.
+// Call Resume once the notifiers are registered to deliver the events a
+// previous process left in it.
+func (d *Dispatcher) SetOutbox(outbox Outbox) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.outbox = outbox
+}
+
+// Resume delivers the events left in the outbox by a previous process to
+// the notifiers that weren't handed them. Notifiers no longer registered
+// are skipped.
+func (d *Dispatcher) Resume() {
+	outbox := d.currentOutbox()
+	if outbox == nil {
+		return
+	}
+
+	pending, err := outbox.Pending()
+	if err != nil {
+		log.Printf("Notify: Failed to read the outbox: %v", err)
+		return
+	}
+	if len(pending) > 0 {
+		log.Printf("Notify: Delivering %d events left in the outbox", len(pending))
+	}
+
+	for _, entry := range pending {
+		event := entry.Event
+		event.Data = decodeData(event.Type, event.Data)
+		for _, n := range d.registered() {
+			if slices.Contains(entry.Notifiers, n.Name()) {
+				d.deliver(outbox, entry.ID, n, event)
+			}
+		}
+		for _, name := range entry.Notifiers {
+			if !d.isRegistered(name) {
+				log.Printf("Notify: Dropping %s event for %s, which is no longer configured", event.Type, name)
+				d.delivered(outbox, entry.ID, name)
+			}
+		}
+	}
+}
+
+// delivered records that a notifier was handed an event. Failing to only
+// risks delivering it again after a restart, so it's logged.
+func (d *Dispatcher) delivered(outbox Outbox, id uint64, notifier string) {
+	if err := outbox.Delivered(id, notifier); err != nil {
+		log.Printf("Notify: Failed to update the outbox: %v", err)
+	}
+}
+
+// isRegistered reports whether a notifier with the given name is
+// registered.
+func (d *Dispatcher) isRegistered(name string) bool {
+	return slices.ContainsFunc(d.registered(), func(n Notifier) bool { return n.Name() == name })
+}
+
+// decodeData restores the payload of an event read back from the outbox:
+// the types of package events for the events they describe, and the JSON
+// as is for others, which notifiers pass on without inspecting it.
+func decodeData(eventType string, data any) any {
+	if data == nil {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	switch eventType {
+	case events.TypeBCVUpdate:
+		return decodeAs[events.RateUpdated](raw)
+	case events.TypeRateRejected:
+		return decodeAs[events.RateRejected](raw)
+	case events.TypeSourceError:
+		return decodeAs[events.SourceDegraded](raw)
+	case events.TypeDailyClose:
+		return decodeAs[events.DailyClose](raw)
+	default:
+		return json.RawMessage(raw)
+	}
+}
+
+// decodeAs decodes a payload into T, or leaves it as JSON if it doesn't
+// match.
+func decodeAs[T any](raw []byte) any {
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return json.RawMessage(raw)
+	}
+	return v
+}
@@ -0,0 +1,76 @@
+package notify
+
+// SMSProvider sends text messages through an SMS gateway.
+type SMSProvider interface {
+	SendSMS(to, body string) error
+}
+
+// TwilioSMS sends SMS through Twilio from a fixed sender number.
+type TwilioSMS struct {
+	client *TwilioClient
+	from   string
+}
+
+// NewTwilioSMS creates a Twilio SMS provider.
+func NewTwilioSMS(client *TwilioClient, from string) *TwilioSMS {
+	return &TwilioSMS{
+		client: client,
+		from:   from,
+	}
+}
+
+// SendSMS sends a text message.
+func (t *TwilioSMS) SendSMS(to, body string) error {
+	return t.client.SendMessage(t.from, to, body)
+}
+
+// SMSNotifier sends events as text messages. Recipients without an explicit
+// subscription receive alerts only, to keep message costs down.
+type SMSNotifier struct {
+	provider   SMSProvider
+	recipients []Recipient
+}
+
+// NewSMSNotifier creates an SMS notifier using the given provider.
+func NewSMSNotifier(provider SMSProvider, recipients []Recipient) *SMSNotifier {
+	subscribed := make([]Recipient, len(recipients))
+	for i, r := range recipients {
+		if len(r.Events) == 0 {
+			r.Events = []string{EventAlert}
+		}
+		subscribed[i] = r
+	}
+
+	return &SMSNotifier{
+		provider:   provider,
+		recipients: subscribed,
+	}
+}
+
+// Name returns the notifier name.
+func (n *SMSNotifier) Name() string {
+	return "sms"
+}
+
+// Notify sends the event message to every subscribed recipient, or only to
+// the event's address when it is targeted. Delivery continues past failed
+// recipients, which are returned in a RecipientError.
+func (n *SMSNotifier) Notify(event Event) error {
+	if event.To != "" {
+		return n.provider.SendSMS(event.To, event.Message)
+	}
+
+	failed := make(map[string]error)
+	for _, r := range n.recipients {
+		if !r.Wants(event.Type) {
+			continue
+		}
+		if err := n.provider.SendSMS(r.To, event.Message); err != nil {
+			failed[r.To] = err
+		}
+	}
+	if len(failed) > 0 {
+		return &RecipientError{Errors: failed}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// twilioBaseURL is the Twilio REST API root.
+const twilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+// TwilioClient sends messages through the Twilio Messaging API.
+type TwilioClient struct {
+	accountSID string
+	authToken  string
+	baseURL    string
+	client     *http.Client
+}
+
+// NewTwilioClient creates a Twilio client for the given account credentials.
+func NewTwilioClient(accountSID, authToken string) *TwilioClient {
+	return &TwilioClient{
+		accountSID: accountSID,
+		authToken:  authToken,
+		baseURL:    twilioBaseURL,
+		client:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// SendMessage sends a message from one address to another. Addresses are
+// E.164 phone numbers, prefixed with "whatsapp:" for WhatsApp.
+func (c *TwilioClient) SendMessage(from, to, body string) error {
+	form := url.Values{
+		"From": {from},
+		"To":   {to},
+		"Body": {body},
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", c.baseURL, url.PathEscape(c.accountSID))
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(c.accountSID, c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("twilio request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("twilio returned status %d: %s (code %d)", resp.StatusCode, apiErr.Message, apiErr.Code)
+		}
+		return fmt.Errorf("twilio returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Recipient is a message destination and the event types it receives.
+// An empty Events list receives every event.
+type Recipient struct {
+	To     string
+	Events []string
+}
+
+// Wants reports whether the recipient subscribed to the event type.
+func (r Recipient) Wants(eventType string) bool {
+	return len(r.Events) == 0 || slices.Contains(r.Events, eventType)
+}
+
+// WhatsAppNotifier sends events as WhatsApp messages through Twilio.
+type WhatsAppNotifier struct {
+	client     *TwilioClient
+	from       string
+	recipients []Recipient
+}
+
+// NewWhatsAppNotifier creates a WhatsApp notifier sending from the given
+// Twilio WhatsApp-enabled number.
+func NewWhatsAppNotifier(client *TwilioClient, from string, recipients []Recipient) *WhatsAppNotifier {
+	return &WhatsAppNotifier{
+		client:     client,
+		from:       whatsAppAddress(from),
+		recipients: recipients,
+	}
+}
+
+// Name returns the notifier name.
+func (n *WhatsAppNotifier) Name() string {
+	return "whatsapp"
+}
+
+// Notify sends the event message to every subscribed recipient, or only to
+// the event's address when it is targeted. Delivery continues past failed
+// recipients, which are returned in a RecipientError.
+func (n *WhatsAppNotifier) Notify(event Event) error {
+	if event.To != "" {
+		return n.client.SendMessage(n.from, whatsAppAddress(event.To), event.Message)
+	}
+
+	failed := make(map[string]error)
+	for _, r := range n.recipients {
+		if !r.Wants(event.Type) {
+			continue
+		}
+		if err := n.client.SendMessage(n.from, whatsAppAddress(r.To), event.Message); err != nil {
+			failed[r.To] = err
+		}
+	}
+	if len(failed) > 0 {
+		return &RecipientError{Errors: failed}
+	}
+	return nil
+}
+
+// whatsAppAddress adds Twilio's whatsapp: prefix to a phone number.
+func whatsAppAddress(number string) string {
+	if strings.HasPrefix(number, "whatsapp:") {
+		return number
+	}
+	return "whatsapp:" + number
+}
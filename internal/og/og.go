@@ -0,0 +1,147 @@
+// Package og renders the Open Graph image shared with links to VESWatch.
+package og
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Image size recommended by Open Graph consumers (WhatsApp, Twitter, etc.).
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// Card is the content of the image. Values are preformatted for display.
+type Card struct {
+	BCV      string
+	Parallel string
+	Breach   string
+	Date     string
+}
+
+var (
+	background = color.RGBA{0x0f, 0x17, 0x2a, 0xff}
+	panel      = color.RGBA{0x1e, 0x29, 0x3b, 0xff}
+	foreground = color.RGBA{0xf8, 0xfa, 0xfc, 0xff}
+	muted      = color.RGBA{0x94, 0xa3, 0xb8, 0xff}
+	accent     = color.RGBA{0xfa, 0xcc, 0x15, 0xff}
+)
+
+// fonts are parsed once on first use.
+var fonts = sync.OnceValues(func() (map[string]*opentype.Font, error) {
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, err
+	}
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*opentype.Font{"bold": bold, "regular": regular}, nil
+})
+
+// Render draws the card as a PNG image.
+func Render(card Card) ([]byte, error) {
+	f, err := fonts()
+	if err != nil {
+		return nil, fmt.Errorf("og: failed to load fonts: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	c := &canvas{img: img, fonts: f}
+
+	// Header
+	c.text("VESWatch", "bold", 56, accent, 64, 110, false)
+	c.text(card.Date, "regular", 36, muted, Width-64, 105, true)
+
+	// Rate panels
+	columns := []struct{ label, value string }{
+		{"BCV", card.BCV},
+		{"Paralelo", card.Parallel},
+		{"Brecha", card.Breach},
+	}
+	const gap = 32
+	panelWidth := (Width - 2*64 - 2*gap) / len(columns)
+	for i, col := range columns {
+		x := 64 + i*(panelWidth+gap)
+		draw.Draw(img, image.Rect(x, 180, x+panelWidth, 480), image.NewUniform(panel), image.Point{}, draw.Src)
+		c.text(col.label, "regular", 36, muted, x+32, 250, false)
+		c.text(col.value, "bold", fitSize(c, col.value, panelWidth-64, 64), foreground, x+32, 400, false)
+	}
+
+	// Footer
+	c.text("Tasas referenciales de fuentes públicas. No es asesoría financiera.", "regular", 26, muted, 64, 570, false)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("og: failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// canvas draws text onto an image.
+type canvas struct {
+	img   *image.RGBA
+	fonts map[string]*opentype.Font
+}
+
+// face returns a font face at the given size.
+func (c *canvas) face(name string, size float64) font.Face {
+	face, err := opentype.NewFace(c.fonts[name], &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		// Only fails for invalid options
+		panic(err)
+	}
+	return face
+}
+
+// text draws s with its baseline at y, starting at x or, when alignRight is
+// set, ending at x.
+func (c *canvas) text(s, fontName string, size float64, col color.Color, x, y int, alignRight bool) {
+	face := c.face(fontName, size)
+	defer face.Close()
+
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: face,
+	}
+	if alignRight {
+		x -= d.MeasureString(s).Ceil()
+	}
+	d.Dot = fixed.P(x, y)
+	d.DrawString(s)
+}
+
+// fitSize returns the largest size up to maxSize at which s fits in width.
+func fitSize(c *canvas, s string, width int, maxSize float64) float64 {
+	size := maxSize
+	for size > 12 {
+		face := c.face("bold", size)
+		w := font.MeasureString(face, s).Ceil()
+		face.Close()
+		if w <= width {
+			break
+		}
+		size -= 4
+	}
+	return size
+}
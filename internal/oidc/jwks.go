@@ -0,0 +1,277 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// keysMaxAge is how long fetched signing keys are used before they're
+	// fetched again, so rotated keys are picked up.
+	keysMaxAge = time.Hour
+
+	// refetchInterval limits how often the keys are fetched for tokens
+	// signed with an unknown key, or after a failed fetch.
+	refetchInterval = time.Minute
+
+	// maxJWKSBytes limits discovery and key set responses.
+	maxJWKSBytes = 1 << 20
+)
+
+// curveAlgs maps curve sizes to the ECDSA algorithm using them.
+var curveAlgs = map[int]string{256: "ES256", 384: "ES384", 521: "ES512"}
+
+// jwk is a signing key of the identity provider.
+type jwk struct {
+	alg string // the only algorithm the key may be used with, if set
+	key crypto.PublicKey
+}
+
+// key returns the signing key with the given ID, fetching the key set if
+// it's stale or doesn't have the key. A stale key is still used while the
+// identity provider is unreachable.
+func (v *Verifier) key(ctx context.Context, kid string) (jwk, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now()
+	key, ok := v.keys[kid]
+	if ok && now.Sub(v.fetched) < keysMaxAge {
+		return key, nil
+	}
+
+	if now.Sub(v.checked) >= refetchInterval {
+		v.checked = now
+		if err := v.fetch(ctx); err != nil {
+			log.Printf("OIDC: Failed to fetch signing keys: %v", err)
+			if v.keys == nil {
+				return jwk{}, fmt.Errorf("%w: %w", ErrUnavailable, err)
+			}
+		} else {
+			key, ok = v.keys[kid]
+		}
+	}
+
+	if !ok {
+		if v.keys == nil {
+			return jwk{}, ErrUnavailable
+		}
+		return jwk{}, fmt.Errorf("%w: unknown signing key", ErrInvalidToken)
+	}
+	return key, nil
+}
+
+// fetch loads the key set, discovering its URL first if needed. Callers
+// must hold the lock.
+func (v *Verifier) fetch(ctx context.Context) error {
+	if v.jwksURL == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		url := strings.TrimSuffix(v.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := v.get(ctx, url, &discovery); err != nil {
+			return err
+		}
+		if discovery.Issuer != v.cfg.Issuer {
+			return fmt.Errorf("discovery document at %s is for issuer %q", url, discovery.Issuer)
+		}
+		if discovery.JWKSURI == "" {
+			return fmt.Errorf("discovery document at %s has no jwks_uri", url)
+		}
+		v.jwksURL = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := v.get(ctx, v.jwksURL, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]jwk, len(set.Keys))
+	for _, raw := range set.Keys {
+		kid, key, err := parseJWK(raw)
+		if err != nil {
+			log.Printf("OIDC: Skipping signing key %q: %v", kid, err)
+			continue
+		}
+		keys[kid] = key
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no usable signing keys at %s", v.jwksURL)
+	}
+
+	if v.keys == nil {
+		log.Printf("OIDC: Loaded %d signing keys from %s", len(keys), v.jwksURL)
+	}
+	v.keys = keys
+	v.fetched = time.Now()
+	return nil
+}
+
+// get fetches a JSON document.
+func (v *Verifier) get(ctx context.Context, url string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(dst); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// parseJWK parses an RSA or EC signing key (RFC 7517).
+func parseJWK(raw json.RawMessage) (string, jwk, error) {
+	var k struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &k); err != nil {
+		return "", jwk{}, err
+	}
+	if k.Use != "" && k.Use != "sig" {
+		return k.Kid, jwk{}, fmt.Errorf("key use is %q", k.Use)
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return k.Kid, jwk{}, errors.New("invalid modulus")
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return k.Kid, jwk{}, errors.New("invalid exponent")
+		}
+		key := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		if key.N.BitLen() < 2048 {
+			return k.Kid, jwk{}, errors.New("RSA key is shorter than 2048 bits")
+		}
+		return k.Kid, jwk{alg: k.Alg, key: key}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		var params ecdh.Curve
+		switch k.Crv {
+		case "P-256":
+			curve, params = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, params = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, params = elliptic.P521(), ecdh.P521()
+		default:
+			return k.Kid, jwk{}, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		x, errX := b64.DecodeString(k.X)
+		y, errY := b64.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return k.Kid, jwk{}, errors.New("invalid point")
+		}
+
+		// Uncompressed point: 0x04 || X || Y, checked to be on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := params.NewPublicKey(point); err != nil {
+			return k.Kid, jwk{}, errors.New("invalid point")
+		}
+		key := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		return k.Kid, jwk{alg: k.Alg, key: key}, nil
+	}
+	return k.Kid, jwk{}, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// verify checks a JWS signature made with alg. Symmetric algorithms and
+// "none" aren't supported, as the keys come from the identity provider.
+func (k jwk) verify(alg string, signed, sig []byte) error {
+	if k.alg != "" && k.alg != alg {
+		return fmt.Errorf("%w: algorithm %s doesn't match the key", ErrInvalidToken, alg)
+	}
+	if len(alg) != 5 {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+	}
+
+	var hash crypto.Hash
+	var digest []byte
+	switch alg[2:] {
+	case "256":
+		sum := sha256.Sum256(signed)
+		hash, digest = crypto.SHA256, sum[:]
+	case "384":
+		sum := sha512.Sum384(signed)
+		hash, digest = crypto.SHA384, sum[:]
+	case "512":
+		sum := sha512.Sum512(signed)
+		hash, digest = crypto.SHA512, sum[:]
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+	}
+
+	var valid bool
+	switch key := k.key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			valid = rsa.VerifyPKCS1v15(key, hash, digest, sig) == nil
+		case "PS":
+			valid = rsa.VerifyPSS(key, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		default:
+			return fmt.Errorf("%w: algorithm %s doesn't match the key", ErrInvalidToken, alg)
+		}
+	case *ecdsa.PublicKey:
+		// JWS ECDSA signatures are the fixed-size concatenation r || s
+		bits := key.Curve.Params().BitSize
+		size := (bits + 7) / 8
+		if alg[:2] != "ES" || curveAlgs[bits] != alg || len(sig) != 2*size {
+			return fmt.Errorf("%w: algorithm %s doesn't match the key", ErrInvalidToken, alg)
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		valid = ecdsa.Verify(key, digest, r, s)
+	}
+	if !valid {
+		return fmt.Errorf("%w: invalid signature", ErrInvalidToken)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+// Package oidc authenticates clients with JWTs issued by an external
+// OpenID Connect identity provider, as an alternative to API keys for
+// organizations that already have single sign-on.
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/apikey"
+)
+
+// Token errors.
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnavailable  = errors.New("identity provider unavailable")
+)
+
+// leeway is the clock skew tolerated between this server and the identity
+// provider when checking expiry times.
+const leeway = time.Minute
+
+// b64 is the unpadded base64url encoding of JWTs and JWKs.
+var b64 = base64.RawURLEncoding
+
+// Config configures the identity provider tokens are checked against.
+type Config struct {
+	// Issuer must match the iss claim. Unless JWKSURL is set, the signing
+	// keys are found through the issuer's discovery document.
+	Issuer string
+
+	// Audience must be among the aud claim, e.g. the client ID this API is
+	// registered with.
+	Audience string
+
+	// JWKSURL overrides the discovered signing keys URL.
+	JWKSURL string
+
+	// RoleClaim names the claim holding the identity's roles, a string or
+	// a list; dots reach into nested objects, e.g. "realm_access.roles".
+	RoleClaim string
+
+	// Roles maps claim values, e.g. group names, to admin roles. Without
+	// it, claim values are taken as role names.
+	Roles map[string]string
+
+	// Tier is the quota tier of authenticated identities.
+	Tier string
+}
+
+// Verifier checks JWTs and maps them to API clients.
+type Verifier struct {
+	cfg    Config
+	client *http.Client
+
+	mu      sync.Mutex
+	jwksURL string
+	keys    map[string]jwk // by key ID
+	fetched time.Time      // when keys were last loaded
+	checked time.Time      // when keys were last requested, even if it failed
+}
+
+// NewVerifier creates a verifier for the configured identity provider.
+// Signing keys are fetched on first use, so the provider needn't be up at
+// startup.
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("OIDC issuer and audience are required")
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "roles"
+	}
+	if cfg.Tier == "" {
+		cfg.Tier = apikey.TierFree
+	}
+	return &Verifier{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		jwksURL: cfg.JWKSURL,
+	}, nil
+}
+
+// claims holds the registered claims of a token.
+type claims struct {
+	Issuer    string     `json:"iss"`
+	Subject   string     `json:"sub"`
+	Audience  stringList `json:"aud"`
+	Expiry    float64    `json:"exp"`
+	NotBefore float64    `json:"nbf"`
+}
+
+// Verify checks a JWT's signature, issuer, audience and expiry, and returns
+// the API client it identifies. Errors wrap ErrInvalidToken, or
+// ErrUnavailable when the signing keys can't be fetched.
+func (v *Verifier) Verify(ctx context.Context, token string) (apikey.Key, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return apikey.Key{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return apikey.Key{}, err
+	}
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return apikey.Key{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return apikey.Key{}, err
+	}
+	if err := key.verify(header.Alg, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return apikey.Key{}, err
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return apikey.Key{}, err
+	}
+	now := time.Now()
+	switch {
+	case c.Issuer != v.cfg.Issuer:
+		return apikey.Key{}, fmt.Errorf("%w: wrong issuer", ErrInvalidToken)
+	case !c.Audience.contains(v.cfg.Audience):
+		return apikey.Key{}, fmt.Errorf("%w: wrong audience", ErrInvalidToken)
+	case c.Expiry == 0 || now.After(unixTime(c.Expiry).Add(leeway)):
+		return apikey.Key{}, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	case c.NotBefore != 0 && now.Add(leeway).Before(unixTime(c.NotBefore)):
+		return apikey.Key{}, fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+	case c.Subject == "":
+		return apikey.Key{}, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	var all map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &all); err != nil {
+		return apikey.Key{}, err
+	}
+	return apikey.Key{
+		ID:   "oidc-" + c.Subject,
+		Name: apikey.IdentityPrefix + c.Subject,
+		Tier: v.cfg.Tier,
+		Role: v.role(claim(all, v.cfg.RoleClaim)),
+	}, nil
+}
+
+// role returns the most privileged admin role among the claim values.
+func (v *Verifier) role(values stringList) string {
+	granted := make(map[string]bool)
+	for _, value := range values {
+		if v.cfg.Roles != nil {
+			value = v.cfg.Roles[value]
+		}
+		granted[value] = true
+	}
+	for _, role := range []string{apikey.RoleAdmin, apikey.RoleOperator, apikey.RoleViewer} {
+		if granted[role] {
+			return role
+		}
+	}
+	return ""
+}
+
+// claim returns the string values of a claim, following dots into nested
+// objects.
+func claim(all map[string]json.RawMessage, path string) stringList {
+	name, rest, nested := strings.Cut(path, ".")
+	raw, ok := all[name]
+	if !ok {
+		return nil
+	}
+	if nested {
+		var inner map[string]json.RawMessage
+		if json.Unmarshal(raw, &inner) != nil {
+			return nil
+		}
+		return claim(inner, rest)
+	}
+	var values stringList
+	if json.Unmarshal(raw, &values) != nil {
+		return nil
+	}
+	return values
+}
+
+// stringList is a claim that's either a string or a list of strings, like
+// aud.
+type stringList []string
+
+// UnmarshalJSON accepts a single string or a list.
+func (s *stringList) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var one string
+		if err := json.Unmarshal(data, &one); err != nil {
+			return err
+		}
+		*s = []string{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+// contains reports whether value is in the list.
+func (s stringList) contains(value string) bool {
+	return slices.Contains(s, value)
+}
+
+// decodeSegment decodes a base64url JSON segment of a token.
+func decodeSegment(segment string, v any) error {
+	data, err := b64.DecodeString(segment)
+	if err != nil || json.Unmarshal(data, v) != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+	return nil
+}
+
+// unixTime converts a JWT numeric date.
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,391 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/veswatch/api/internal/apikey"
+)
+
+const (
+	testIssuer   = "https://login.example.com/realms/veswatch"
+	testAudience = "veswatch-api"
+)
+
+// keys are the signing keys of the test identity provider, generated once
+// as RSA keys are slow to make.
+var keys = sync.OnceValues(func() (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return rsaKey, ecKey
+})
+
+// provider serves a JWKS, counting how often it's fetched.
+type provider struct {
+	mu      sync.Mutex
+	keys    []map[string]string
+	status  int
+	fetches atomic.Int32
+}
+
+func (p *provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.fetches.Add(1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.status != 0 {
+		w.WriteHeader(p.status)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"keys": p.keys})
+}
+
+// set replaces the served keys, or makes the provider fail with status.
+func (p *provider) set(status int, keys ...map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status, p.keys = status, keys
+}
+
+// rsaJWK returns key as a JWK, restricted to alg if set.
+func rsaJWK(kid, alg string, key *rsa.PublicKey) map[string]string {
+	jwk := map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   b64.EncodeToString(key.N.Bytes()),
+		"e":   b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+	if alg != "" {
+		jwk["alg"] = alg
+	}
+	return jwk
+}
+
+// ecJWK returns a P-256 key as a JWK.
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	x, y := make([]byte, 32), make([]byte, 32)
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   b64.EncodeToString(key.X.FillBytes(x)),
+		"y":   b64.EncodeToString(key.Y.FillBytes(y)),
+	}
+}
+
+// newVerifier starts a provider serving the test keys: "rsa" for RS256
+// only, "rsa-any" for any RSA algorithm and "ec".
+func newVerifier(t *testing.T, cfg Config) (*Verifier, *provider) {
+	t.Helper()
+	rsaKey, ecKey := keys()
+	p := &provider{}
+	p.set(0, rsaJWK("rsa", "RS256", &rsaKey.PublicKey), rsaJWK("rsa-any", "", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey))
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+
+	cfg.Issuer, cfg.Audience, cfg.JWKSURL = testIssuer, testAudience, srv.URL
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return v, p
+}
+
+// claimsFor returns valid claims for sub.
+func claimsFor(sub string) map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss": testIssuer,
+		"aud": []string{testAudience, "account"},
+		"sub": sub,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+// sign returns a JWT of claims signed with key using alg. "none" leaves the
+// signature empty, HS256 signs with the key's RSA modulus as secret, and
+// other algorithms get a dummy signature.
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+
+	hash, digest := crypto.SHA256, sha256.Sum256([]byte(signed))
+	sum := digest[:]
+	if len(alg) == 5 && alg[2:] == "384" {
+		d := sha512.Sum384([]byte(signed))
+		hash, sum = crypto.SHA384, d[:]
+	}
+
+	var sig []byte
+	var err error
+	switch alg {
+	case "none":
+	case "HS256":
+		mac := hmac.New(sha256.New, key.(*rsa.PrivateKey).N.Bytes())
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), hash, sum)
+	case "PS256":
+		sig, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), hash, sum, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES256", "ES384":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), sum)
+		if err == nil {
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	default:
+		sig = []byte("signature")
+	}
+	if err != nil {
+		t.Fatalf("signing with %s: %v", alg, err)
+	}
+	return signed + "." + b64.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, ecKey := keys()
+	v, _ := newVerifier(t, Config{})
+
+	with := func(change func(map[string]any)) map[string]any {
+		c := claimsFor("user")
+		change(c)
+		return c
+	}
+	tests := []struct {
+		name  string
+		token func() string
+		valid bool
+	}{
+		{"RS256", func() string { return sign(t, "RS256", "rsa", rsaKey, claimsFor("user")) }, true},
+		{"PS256 on a key for any algorithm", func() string { return sign(t, "PS256", "rsa-any", rsaKey, claimsFor("user")) }, true},
+		{"ES256", func() string { return sign(t, "ES256", "ec", ecKey, claimsFor("user")) }, true},
+		{"single audience", func() string {
+			return sign(t, "ES256", "ec", ecKey, with(func(c map[string]any) { c["aud"] = testAudience }))
+		}, true},
+		{"expired within leeway", func() string {
+			return sign(t, "ES256", "ec", ecKey, with(func(c map[string]any) { c["exp"] = time.Now().Add(-leeway / 2).Unix() }))
+		}, true},
+
+		{"none", func() string { return sign(t, "none", "rsa-any", rsaKey, claimsFor("user")) }, false},
+		{"HS256", func() string { return sign(t, "HS256", "rsa-any", rsaKey, claimsFor("user")) }, false},
+		{"unsupported algorithm", func() string { return sign(t, "EdDSA", "rsa-any", rsaKey, claimsFor("user")) }, false},
+		{"unsupported hash", func() string { return sign(t, "RS224", "rsa-any", rsaKey, claimsFor("user")) }, false},
+		{"PS256 on an RS256 key", func() string { return sign(t, "PS256", "rsa", rsaKey, claimsFor("user")) }, false},
+		{"ES256 on an RSA key", func() string { return sign(t, "ES256", "rsa-any", ecKey, claimsFor("user")) }, false},
+		{"RS256 on an EC key", func() string { return sign(t, "RS256", "ec", rsaKey, claimsFor("user")) }, false},
+		{"ES384 on a P-256 key", func() string { return sign(t, "ES384", "ec", ecKey, claimsFor("user")) }, false},
+		{"unknown key", func() string { return sign(t, "RS256", "rotated", rsaKey, claimsFor("user")) }, false},
+		{"wrong issuer", func() string {
+			return sign(t, "RS256", "rsa", rsaKey, with(func(c map[string]any) { c["iss"] = "https://evil.example.com" }))
+		}, false},
+		{"wrong audience", func() string {
+			return sign(t, "RS256", "rsa", rsaKey, with(func(c map[string]any) { c["aud"] = []string{"account"} }))
+		}, false},
+		{"expired", func() string {
+			return sign(t, "RS256", "rsa", rsaKey, with(func(c map[string]any) { c["exp"] = time.Now().Add(-2 * leeway).Unix() }))
+		}, false},
+		{"no expiry", func() string {
+			return sign(t, "RS256", "rsa", rsaKey, with(func(c map[string]any) { delete(c, "exp") }))
+		}, false},
+		{"not yet valid", func() string {
+			return sign(t, "RS256", "rsa", rsaKey, with(func(c map[string]any) { c["nbf"] = time.Now().Add(2 * leeway).Unix() }))
+		}, false},
+		{"no subject", func() string { return sign(t, "RS256", "rsa", rsaKey, claimsFor("")) }, false},
+		{"tampered payload", func() string {
+			signed := sign(t, "RS256", "rsa", rsaKey, claimsFor("user"))
+			forged := sign(t, "RS256", "rsa", rsaKey, claimsFor("admin"))
+			return resign(forged, func([]byte) []byte { return signature(signed) })
+		}, false},
+		{"tampered RSA signature", func() string {
+			return resign(sign(t, "RS256", "rsa", rsaKey, claimsFor("user")), func(sig []byte) []byte {
+				sig[len(sig)/2] ^= 1
+				return sig
+			})
+		}, false},
+		{"tampered ECDSA signature", func() string {
+			return resign(sign(t, "ES256", "ec", ecKey, claimsFor("user")), func(sig []byte) []byte {
+				sig[len(sig)-1] ^= 1
+				return sig
+			})
+		}, false},
+		{"ECDSA signature too long", func() string {
+			return resign(sign(t, "ES256", "ec", ecKey, claimsFor("user")), func(sig []byte) []byte {
+				return append([]byte{0}, sig...)
+			})
+		}, false},
+		{"ECDSA signature too short", func() string {
+			return resign(sign(t, "ES256", "ec", ecKey, claimsFor("user")), func(sig []byte) []byte {
+				return sig[1:]
+			})
+		}, false},
+		{"ECDSA signature in ASN.1", func() string {
+			return resign(sign(t, "ES256", "ec", ecKey, claimsFor("user")), func([]byte) []byte {
+				digest := sha256.Sum256([]byte("anything"))
+				sig, err := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+				if err != nil {
+					t.Fatal(err)
+				}
+				return sig
+			})
+		}, false},
+		{"malformed", func() string { return "not.a-jwt" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := v.Verify(t.Context(), tt.token())
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("got %v, want an invalid token", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if key.ID != "oidc-user" || key.Name != apikey.IdentityPrefix+"user" || key.Tier != apikey.TierFree {
+				t.Errorf("got key %+v", key)
+			}
+		})
+	}
+}
+
+// signature returns the decoded signature of a token.
+func signature(token string) []byte {
+	sig, _ := b64.DecodeString(token[strings.LastIndexByte(token, '.')+1:])
+	return sig
+}
+
+// resign replaces a token's signature with what change makes of it.
+func resign(token string, change func(sig []byte) []byte) string {
+	signed := token[:strings.LastIndexByte(token, '.')]
+	return signed + "." + b64.EncodeToString(change(signature(token)))
+}
+
+func TestVerifyRoles(t *testing.T) {
+	rsaKey, _ := keys()
+	mapped, _ := newVerifier(t, Config{
+		RoleClaim: "realm_access.roles",
+		Roles:     map[string]string{"veswatch-admins": apikey.RoleAdmin, "veswatch-ops": apikey.RoleOperator, "dashboards": apikey.RoleViewer},
+	})
+	plain, _ := newVerifier(t, Config{})
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		claims   map[string]any
+		want     string
+	}{
+		{"nested list", mapped, map[string]any{"realm_access": map[string]any{"roles": []string{"dashboards", "veswatch-ops"}}}, apikey.RoleOperator},
+		{"nested string", mapped, map[string]any{"realm_access": map[string]any{"roles": "veswatch-admins"}}, apikey.RoleAdmin},
+		{"unmapped values", mapped, map[string]any{"realm_access": map[string]any{"roles": []string{"admin", "offline_access"}}}, ""},
+		{"top-level claim ignored", mapped, map[string]any{"roles": []string{"veswatch-admins"}}, ""},
+		{"not an object", mapped, map[string]any{"realm_access": []string{"veswatch-admins"}}, ""},
+		{"role names", plain, map[string]any{"roles": []string{"viewer", "admin"}}, apikey.RoleAdmin},
+		{"unknown role name", plain, map[string]any{"roles": "superuser"}, ""},
+		{"no claim", plain, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := claimsFor("user")
+			for k, v := range tt.claims {
+				c[k] = v
+			}
+			key, err := tt.verifier.Verify(t.Context(), sign(t, "RS256", "rsa", rsaKey, c))
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if key.Role != tt.want {
+				t.Errorf("role = %q, want %q", key.Role, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRefetch(t *testing.T) {
+	rsaKey, ecKey := keys()
+	v, p := newVerifier(t, Config{})
+	verify := func(kid string) error {
+		_, err := v.Verify(t.Context(), sign(t, "RS256", kid, rsaKey, claimsFor("user")))
+		return err
+	}
+	// elapse makes the last key request look older
+	elapse := func(d time.Duration) {
+		v.mu.Lock()
+		v.checked = v.checked.Add(-d)
+		v.fetched = v.fetched.Add(-d)
+		v.mu.Unlock()
+	}
+
+	// Keys aren't fetched until needed, and fetching fails while the
+	// provider is down
+	p.set(http.StatusServiceUnavailable)
+	if err := verify("rsa"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("provider down: got %v, want unavailable", err)
+	}
+	if err := verify("rsa"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("provider down: got %v, want unavailable", err)
+	}
+	if n := p.fetches.Load(); n != 1 {
+		t.Errorf("keys requested %d times while down, want once a minute", n)
+	}
+
+	p.set(0, rsaJWK("rsa", "RS256", &rsaKey.PublicKey))
+	elapse(refetchInterval)
+	if err := verify("rsa"); err != nil {
+		t.Fatalf("provider back: %v", err)
+	}
+
+	// A key rotated in is picked up on the next refetch, not on every
+	// token signed with it
+	p.set(0, rsaJWK("rsa", "RS256", &rsaKey.PublicKey), rsaJWK("rotated", "RS256", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey))
+	before := p.fetches.Load()
+	if err := verify("rotated"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("rotated key within a minute: got %v, want invalid", err)
+	}
+	if err := verify("rotated"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("rotated key within a minute: got %v, want invalid", err)
+	}
+	if n := p.fetches.Load() - before; n != 0 {
+		t.Errorf("unknown keys caused %d requests within a minute, want none", n)
+	}
+	elapse(refetchInterval)
+	if err := verify("rotated"); err != nil {
+		t.Errorf("rotated key after a minute: %v", err)
+	}
+	if n := p.fetches.Load() - before; n != 1 {
+		t.Errorf("got %d requests for the rotated key, want 1", n)
+	}
+
+	// Stale keys keep working while the provider is down
+	p.set(http.StatusServiceUnavailable)
+	elapse(keysMaxAge)
+	if err := verify("rsa"); err != nil {
+		t.Errorf("stale key with the provider down: %v", err)
+	}
+}
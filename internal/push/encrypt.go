@@ -0,0 +1,92 @@
+package push
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// recordSize is the aes128gcm record size advertised in the header.
+const recordSize = 4096
+
+// encrypt encrypts a payload for a subscription using the aes128gcm content
+// coding (RFC 8188) with Web Push key derivation (RFC 8291).
+func encrypt(payload []byte, keys Keys) ([]byte, error) {
+	uaPublicBytes, err := b64.DecodeString(keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := b64.DecodeString(keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+
+	// Ephemeral application server key for this message
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	shared, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("key agreement failed: %w", err)
+	}
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public)
+	keyInfo := "WebPush: info\x00" + string(uaPublicBytes) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single record: payload followed by the last-record delimiter
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	if len(plaintext)+gcm.Overhead() > recordSize {
+		return nil, fmt.Errorf("payload too large: %d bytes", len(payload))
+	}
+
+	// Header: salt || record size || key id length || key id (as_public)
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
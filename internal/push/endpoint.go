@@ -0,0 +1,72 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errPrivateAddress is returned when dialing a push endpoint that resolves
+// to an address that isn't public.
+var errPrivateAddress = errors.New("push endpoint resolves to a non-public address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// netip doesn't consider private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr reports whether an address is reachable on the public
+// internet, so pushing to it can't reach this host or its network.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// checkHost rejects endpoint hosts that are obviously local: loopback
+// names and non-public address literals. Names resolving to non-public
+// addresses are refused when dialing.
+func checkHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: endpoint must be a public host", ErrInvalidSubscription)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !publicAddr(addr) {
+		return fmt.Errorf("%w: endpoint must be a public host", ErrInvalidSubscription)
+	}
+	return nil
+}
+
+// newClient returns the HTTP client pushes are sent with. It refuses to
+// connect to non-public addresses, whatever the endpoint's name resolves
+// to, so subscriptions can't make the server send requests into its own
+// network.
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errPrivateAddress, address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return &http.Client{
+		Timeout:   15 * time.Second,
+		Transport: transport,
+		// Push services don't redirect; following one could lead anywhere
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
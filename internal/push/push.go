@@ -0,0 +1,339 @@
+// Package push delivers service events to browsers through the Web Push
+// protocol (RFC 8030) with VAPID authentication and aes128gcm encryption.
+package push
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/pkg/events"
+)
+
+// Errors returned by Subscribe.
+var (
+	// ErrInvalidSubscription is returned for malformed subscriptions.
+	ErrInvalidSubscription = errors.New("invalid push subscription")
+	// ErrTooManySubscriptions is returned when a client already has as
+	// many subscriptions as it may.
+	ErrTooManySubscriptions = errors.New("too many push subscriptions from this client")
+	// ErrSubscriptionsFull is returned when the service has as many
+	// subscriptions as it may.
+	ErrSubscriptionsFull = errors.New("push subscriptions are full")
+)
+
+// Events browsers can subscribe to. Subscriptions without events get all of them.
+var Events = []string{notify.EventBCVUpdate, notify.EventAlert, notify.EventDailyClose}
+
+const (
+	// messageTTL is how long push services keep undelivered messages.
+	messageTTL = 24 * time.Hour
+	// sendWorkers bounds concurrent deliveries per event.
+	sendWorkers = 8
+)
+
+// Keys are the browser's encryption keys from PushSubscription.toJSON().
+type Keys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// Subscription is a browser push subscription. Its filter's events,
+// sources and minChange are set at the top level of its JSON.
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     Keys   `json:"keys"`
+	notify.Filter
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Client is the address the subscription was made from, which the
+	// per-client limit counts by.
+	Client string `json:"client,omitempty"`
+}
+
+// Validate checks the endpoint, keys and filter. Endpoints must be https
+// URLs on a public host.
+func (s Subscription) Validate() error {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: endpoint must be an https URL", ErrInvalidSubscription)
+	}
+	if err := checkHost(u.Hostname()); err != nil {
+		return err
+	}
+	if p, err := b64.DecodeString(s.Keys.P256dh); err != nil || !validPublicKey(p) {
+		return fmt.Errorf("%w: keys.p256dh must be a base64url P-256 public key", ErrInvalidSubscription)
+	}
+	if a, err := b64.DecodeString(s.Keys.Auth); err != nil || len(a) != 16 {
+		return fmt.Errorf("%w: keys.auth must be a base64url 16-byte secret", ErrInvalidSubscription)
+	}
+	for _, event := range s.Events {
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidSubscription, event)
+		}
+	}
+	if err := s.Filter.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+	}
+	return nil
+}
+
+// validPublicKey reports whether p is an uncompressed P-256 point.
+func validPublicKey(p []byte) bool {
+	_, err := ecdh.P256().NewPublicKey(p)
+	return err == nil
+}
+
+// Store persists push subscriptions, keyed by endpoint.
+type Store interface {
+	Save(sub Subscription) error
+	Delete(endpoint string) error
+	List() ([]Subscription, error)
+}
+
+// MemoryStore keeps subscriptions in memory.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	subs map[string]Subscription
+}
+
+// NewMemoryStore creates an empty in-memory subscription store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		subs: make(map[string]Subscription),
+	}
+}
+
+// Save adds or replaces a subscription.
+func (m *MemoryStore) Save(sub Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs[sub.Endpoint] = sub
+	return nil
+}
+
+// Delete removes a subscription.
+func (m *MemoryStore) Delete(endpoint string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.subs, endpoint)
+	return nil
+}
+
+// List returns all subscriptions.
+func (m *MemoryStore) List() ([]Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := make([]Subscription, 0, len(m.subs))
+	for _, sub := range m.subs {
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// Service manages subscriptions and pushes events to them. It implements
+// notify.Notifier.
+type Service struct {
+	vapid  *VAPID
+	store  Store
+	client *http.Client
+
+	// mu serializes subscribing, so the limits hold.
+	mu        sync.Mutex
+	maxTotal  int
+	maxClient int
+}
+
+// NewService creates a push service. It accepts up to maxTotal
+// subscriptions, and up to maxClient from any one client; 0 means no limit.
+func NewService(vapid *VAPID, store Store, maxTotal, maxClient int) *Service {
+	return &Service{
+		vapid:     vapid,
+		store:     store,
+		client:    newClient(),
+		maxTotal:  maxTotal,
+		maxClient: maxClient,
+	}
+}
+
+// PublicKey returns the VAPID public key for browsers.
+func (s *Service) PublicKey() string {
+	return s.vapid.PublicKey()
+}
+
+// Subscribe validates and stores a subscription, replacing any with the
+// same endpoint. It fails with ErrTooManySubscriptions or
+// ErrSubscriptionsFull if storing it would exceed a limit.
+func (s *Service) Subscribe(sub Subscription) error {
+	if err := sub.Validate(); err != nil {
+		return err
+	}
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxTotal > 0 || s.maxClient > 0 {
+		subs, err := s.store.List()
+		if err != nil {
+			return fmt.Errorf("failed to list subscriptions: %w", err)
+		}
+		total, client := 0, 0
+		for _, existing := range subs {
+			if existing.Endpoint == sub.Endpoint {
+				continue
+			}
+			total++
+			if sub.Client != "" && existing.Client == sub.Client {
+				client++
+			}
+		}
+		switch {
+		case s.maxClient > 0 && client >= s.maxClient:
+			return ErrTooManySubscriptions
+		case s.maxTotal > 0 && total >= s.maxTotal:
+			return ErrSubscriptionsFull
+		}
+	}
+	return s.store.Save(sub)
+}
+
+// Unsubscribe removes a subscription.
+func (s *Service) Unsubscribe(endpoint string) error {
+	return s.store.Delete(endpoint)
+}
+
+// HasRecipient reports whether to is the endpoint of a subscription, so
+// alert rules only push to browsers that subscribed.
+func (s *Service) HasRecipient(to string) (bool, error) {
+	subs, err := s.store.List()
+	if err != nil {
+		return false, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	return slices.ContainsFunc(subs, func(sub Subscription) bool {
+		return sub.Endpoint == to
+	}), nil
+}
+
+// Name returns the notifier name.
+func (s *Service) Name() string {
+	return "webpush"
+}
+
+// message is the JSON payload delivered to the service worker. Version is
+// that of the data's schema, see package events.
+type message struct {
+	Type    string    `json:"type"`
+	Version int       `json:"version"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+	Time    time.Time `json:"time"`
+	Data    any       `json:"data,omitempty"`
+}
+
+// Notify pushes the event to every subscribed browser, or only to the
+// subscription whose endpoint is the event's address. Subscriptions the
+// push service reports as gone are removed; other failures are counted.
+func (s *Service) Notify(event notify.Event) error {
+	subs, err := s.store.List()
+	if err != nil {
+		return fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	payload, err := json.Marshal(message{
+		Type:    event.Type,
+		Version: events.Version,
+		Title:   "VESWatch",
+		Body:    event.Message,
+		Time:    event.Time,
+		Data:    event.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode push message: %w", err)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed = make(map[string]error)
+		sem    = make(chan struct{}, sendWorkers)
+	)
+	for _, sub := range subs {
+		if event.To != "" && sub.Endpoint != event.To {
+			continue
+		}
+		if event.To == "" && !sub.Matches(event) {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := s.send(sub, payload); err != nil {
+				log.Printf("Push: Delivery to %s failed: %v", sub.Endpoint, err)
+				mu.Lock()
+				failed[sub.Endpoint] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return &notify.RecipientError{Errors: failed}
+	}
+	return nil
+}
+
+// send encrypts and delivers a payload to a single subscription.
+func (s *Service) send(sub Subscription, payload []byte) error {
+	body, err := encrypt(payload, sub.Keys)
+	if err != nil {
+		return err
+	}
+	auth, err := s.vapid.authorization(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(messageTTL.Seconds())))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		// The browser unsubscribed or the subscription expired
+		log.Printf("Push: Removing expired subscription %s", sub.Endpoint)
+		return s.store.Delete(sub.Endpoint)
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
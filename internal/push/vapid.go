@@ -0,0 +1,102 @@
+package push
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// b64 is the unpadded base64url encoding used throughout Web Push.
+var b64 = base64.RawURLEncoding
+
+// VAPID holds the application server key pair identifying this server to
+// push services (RFC 8292).
+type VAPID struct {
+	key     *ecdsa.PrivateKey
+	public  []byte
+	subject string
+}
+
+// NewVAPID loads a VAPID key pair from a base64url-encoded raw P-256
+// private key. subject is a mailto: or https: contact for push services.
+func NewVAPID(privateKey, subject string) (*VAPID, error) {
+	raw, err := b64.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key encoding: %w", err)
+	}
+
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	public := key.PublicKey().Bytes()
+
+	// Uncompressed point: 0x04 || X || Y
+	return &VAPID{
+		key: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(public[1:33]),
+				Y:     new(big.Int).SetBytes(public[33:]),
+			},
+			D: new(big.Int).SetBytes(raw),
+		},
+		public:  public,
+		subject: subject,
+	}, nil
+}
+
+// GenerateVAPIDKeys returns a new base64url-encoded private and public key.
+func GenerateVAPIDKeys() (privateKey, publicKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return b64.EncodeToString(key.Bytes()), b64.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// PublicKey returns the base64url-encoded public key browsers pass as
+// applicationServerKey when subscribing.
+func (v *VAPID) PublicKey() string {
+	return b64.EncodeToString(v.public)
+}
+
+// authorization returns the Authorization header value for a push endpoint.
+func (v *VAPID) authorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	header := b64.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": v.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + b64.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, v.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+
+	// JWS ES256 signatures are the fixed-size concatenation r || s
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", unsigned, b64.EncodeToString(sig), v.PublicKey()), nil
+}
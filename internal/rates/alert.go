@@ -0,0 +1,469 @@
+package rates
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/notify"
+)
+
+// Alert sources besides individual source names.
+const (
+	AlertParallel = "parallel"
+	AlertBreach   = "breach"
+	AlertSpread   = "spread"
+)
+
+// Alert conditions.
+const (
+	ConditionAbove = "above"
+	ConditionBelow = "below"
+)
+
+// maxAlertsPerOwner caps the alert rules of a user, so a key can't have
+// the notifiers message any number of recipients.
+const maxAlertsPerOwner = 20
+
+// Alert rule errors.
+var (
+	ErrInvalidAlert  = errors.New("invalid alert rule")
+	ErrAlertNotFound = errors.New("alert rule not found")
+	ErrTooManyAlerts = errors.New("too many alert rules")
+)
+
+// phoneNumber matches the E.164 numbers of the channels messaging phones.
+var phoneNumber = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// RecipientChecker reports whether an address has agreed to receive a
+// channel's messages, e.g. whether it's the endpoint of a push
+// subscription.
+type RecipientChecker interface {
+	HasRecipient(to string) (bool, error)
+}
+
+// AlertRule triggers when a value crosses a threshold. Source is "bcv",
+// "binance", any configured parallel source name, "efectivo" (the cash
+// rate), "parallel" (the consensus rate), "breach" (the percentage gap
+// between parallel and BCV) or "spread" (the percentage gap between the
+// cheapest and the most expensive P2P venue, an arbitrage signal).
+//
+// Operator rules have no owner and notify every channel. User rules belong
+// to an owner and deliver to a single channel address (To).
+type AlertRule struct {
+	ID        string    `json:"id,omitempty"`
+	Owner     string    `json:"-"`
+	Source    string    `json:"source"`
+	Condition string    `json:"condition"`
+	Threshold float64   `json:"threshold"`
+	Channel   string    `json:"channel,omitempty"`
+	To        string    `json:"to,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+}
+
+// String returns the rule in source>threshold / source<threshold form.
+func (r AlertRule) String() string {
+	op := "<"
+	if r.Condition == ConditionAbove {
+		op = ">"
+	}
+	return fmt.Sprintf("%s%s%g", r.Source, op, r.Threshold)
+}
+
+// key identifies the rule in the triggered state.
+func (r AlertRule) key() string {
+	if r.ID != "" {
+		return r.ID
+	}
+	return r.String()
+}
+
+// matches reports whether value satisfies the rule.
+func (r AlertRule) matches(value float64) bool {
+	if r.Condition == ConditionAbove {
+		return value > r.Threshold
+	}
+	return value < r.Threshold
+}
+
+// Alert is the payload of an alert event.
+type Alert struct {
+	Rule  AlertRule `json:"rule"`
+	Value float64   `json:"value"`
+
+	// Spread names the venues of a spread alert.
+	Spread *Spread `json:"spread,omitempty"`
+}
+
+// Spread is the gap between the cheapest and the most expensive P2P venue.
+type Spread struct {
+	Percent  float64 `json:"percent"`
+	Low      string  `json:"low"`
+	LowRate  float64 `json:"lowRate"`
+	High     string  `json:"high"`
+	HighRate float64 `json:"highRate"`
+}
+
+// venueSpread returns the spread between the fresh parallel sources, or
+// nil with fewer than two. Outliers and rates supplied by a fallback are
+// left out, so a broken source doesn't pass for an arbitrage opportunity.
+func venueSpread(sources []SourceRate) *Spread {
+	var spread Spread
+	venues := 0
+	for _, src := range sources {
+		if src.Rate <= 0 || src.Stale || src.Outlier || src.Fallback != "" {
+			continue
+		}
+		venues++
+		if venues == 1 || src.Rate < spread.LowRate {
+			spread.Low, spread.LowRate = src.Name, src.Rate
+		}
+		if venues == 1 || src.Rate > spread.HighRate {
+			spread.High, spread.HighRate = src.Name, src.Rate
+		}
+	}
+	if venues < 2 {
+		return nil
+	}
+	spread.Percent = roundTo((spread.HighRate-spread.LowRate)/spread.LowRate*100, 2)
+	return &spread
+}
+
+// AlertStore persists user alert rules.
+type AlertStore interface {
+	SaveAlert(rule AlertRule) error
+	DeleteAlert(owner, id string) error
+	// Alerts returns the rules of an owner, or of everyone if owner is empty.
+	Alerts(owner string) ([]AlertRule, error)
+}
+
+// MemoryAlertStore keeps user alert rules in memory.
+type MemoryAlertStore struct {
+	mu    sync.RWMutex
+	rules []AlertRule
+}
+
+// NewMemoryAlertStore creates an empty in-memory alert store.
+func NewMemoryAlertStore() *MemoryAlertStore {
+	return &MemoryAlertStore{}
+}
+
+// SaveAlert adds a rule, replacing any rule with the same ID.
+func (m *MemoryAlertStore) SaveAlert(rule AlertRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = ReplaceAlert(m.rules, rule)
+	return nil
+}
+
+// DeleteAlert removes an owner's rule.
+func (m *MemoryAlertStore) DeleteAlert(owner, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.rules, func(r AlertRule) bool {
+		return r.ID == id && r.Owner == owner
+	})
+	if i < 0 {
+		return ErrAlertNotFound
+	}
+	m.rules = slices.Delete(m.rules, i, i+1)
+	return nil
+}
+
+// Alerts returns the rules of an owner, or all rules if owner is empty.
+func (m *MemoryAlertStore) Alerts(owner string) ([]AlertRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return FilterAlerts(m.rules, owner), nil
+}
+
+// ReplaceAlert returns rules with rule replacing the one with the same ID,
+// or appended if there is none. rules isn't modified.
+func ReplaceAlert(rules []AlertRule, rule AlertRule) []AlertRule {
+	i := slices.IndexFunc(rules, func(r AlertRule) bool {
+		return r.ID == rule.ID
+	})
+	if i < 0 {
+		return append(slices.Clip(rules), rule)
+	}
+	rules = slices.Clone(rules)
+	rules[i] = rule
+	return rules
+}
+
+// FilterAlerts returns the rules belonging to owner, or a copy of all rules
+// if owner is empty.
+func FilterAlerts(rules []AlertRule, owner string) []AlertRule {
+	filtered := make([]AlertRule, 0, len(rules))
+	for _, r := range rules {
+		if owner == "" || r.Owner == owner {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// alertState tracks which rules are currently triggered, so an alert fires
+// once when its condition starts holding rather than on every fetch.
+type alertState struct {
+	mu       sync.Mutex
+	rules    []AlertRule
+	store    AlertStore
+	channels []string
+	checkers map[string]RecipientChecker
+	active   map[string]bool
+}
+
+// SetAlertRules sets the operator's threshold alert rules. The first
+// evaluation after startup only records which conditions hold, so restarts
+// don't repeat alerts.
+func (s *Service) SetAlertRules(rules []AlertRule) {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	s.alerts.rules = rules
+	s.alerts.active = nil
+}
+
+// SetAlertStore replaces the default in-memory store for user alert rules.
+func (s *Service) SetAlertStore(store AlertStore) {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	s.alerts.store = store
+}
+
+// AlertStore returns the user alert rule store.
+func (s *Service) AlertStore() AlertStore {
+	return s.alerts.store
+}
+
+// SetAlertChannels sets the notifier channels user rules may deliver to.
+func (s *Service) SetAlertChannels(channels []string) {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	s.alerts.channels = channels
+}
+
+// SetRecipientChecker sets how the addresses of user rules delivering to
+// a channel are checked.
+func (s *Service) SetRecipientChecker(channel string, checker RecipientChecker) {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	if s.alerts.checkers == nil {
+		s.alerts.checkers = make(map[string]RecipientChecker)
+	}
+	s.alerts.checkers[channel] = checker
+}
+
+// CreateAlert validates and stores a user alert rule. It fails with
+// ErrTooManyAlerts once the owner has maxAlertsPerOwner rules.
+func (s *Service) CreateAlert(owner string, rule AlertRule) (AlertRule, error) {
+	if err := s.validateAlert(rule); err != nil {
+		return AlertRule{}, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return AlertRule{}, err
+	}
+	rule.ID = hex.EncodeToString(id)
+	rule.Owner = owner
+	rule.CreatedAt = time.Now()
+
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	existing, err := s.alerts.store.Alerts(owner)
+	if err != nil {
+		return AlertRule{}, err
+	}
+	if len(existing) >= maxAlertsPerOwner {
+		return AlertRule{}, ErrTooManyAlerts
+	}
+	if err := s.alerts.store.SaveAlert(rule); err != nil {
+		return AlertRule{}, err
+	}
+
+	// Don't fire for a condition that already holds when the rule is created
+	if s.alerts.active != nil {
+		values, _ := s.alertValues()
+		if value, ok := values[rule.Source]; ok {
+			s.alerts.active[rule.key()] = rule.matches(value)
+		}
+	}
+	return rule, nil
+}
+
+// DeleteAlert removes a user alert rule.
+func (s *Service) DeleteAlert(owner, id string) error {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	return s.alerts.store.DeleteAlert(owner, id)
+}
+
+// ListAlerts returns a user's alert rules.
+func (s *Service) ListAlerts(owner string) ([]AlertRule, error) {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	return s.alerts.store.Alerts(owner)
+}
+
+// validateAlert checks a user rule as CheckAlert does, and that channels
+// with a recipient checker only take the addresses it knows.
+func (s *Service) validateAlert(rule AlertRule) error {
+	if err := s.CheckAlert(rule); err != nil {
+		return err
+	}
+	s.alerts.mu.Lock()
+	checker := s.alerts.checkers[rule.Channel]
+	s.alerts.mu.Unlock()
+	if checker != nil {
+		ok, err := checker.HasRecipient(rule.To)
+		if err != nil {
+			return fmt.Errorf("failed to check recipient: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("%w: to isn't subscribed to %s", ErrInvalidAlert, rule.Channel)
+		}
+	}
+	return nil
+}
+
+// CheckAlert checks a user rule's source, condition and channel, and that
+// phone channels take E.164 numbers. It doesn't ask recipient checkers, so
+// restored rules can deliver to subscriptions restored alongside them.
+func (s *Service) CheckAlert(rule AlertRule) error {
+	known := []string{"bcv", "binance", AlertParallel, AlertBreach, AlertSpread, cashSource}
+	known = append(known, s.parallelNames...)
+	if !slices.Contains(known, rule.Source) {
+		return fmt.Errorf("%w: unknown source %q", ErrInvalidAlert, rule.Source)
+	}
+	if rule.Condition != ConditionAbove && rule.Condition != ConditionBelow {
+		return fmt.Errorf("%w: condition must be %q or %q", ErrInvalidAlert, ConditionAbove, ConditionBelow)
+	}
+	if rule.Threshold <= 0 && rule.Source != AlertBreach {
+		return fmt.Errorf("%w: threshold must be positive", ErrInvalidAlert)
+	}
+
+	s.alerts.mu.Lock()
+	channels := s.alerts.channels
+	s.alerts.mu.Unlock()
+	if !slices.Contains(channels, rule.Channel) {
+		return fmt.Errorf("%w: channel must be one of %v", ErrInvalidAlert, channels)
+	}
+	if rule.To == "" {
+		return fmt.Errorf("%w: to is required", ErrInvalidAlert)
+	}
+	switch rule.Channel {
+	case "sms", "whatsapp":
+		if !phoneNumber.MatchString(strings.TrimPrefix(rule.To, "whatsapp:")) {
+			return fmt.Errorf("%w: to must be an E.164 phone number", ErrInvalidAlert)
+		}
+	}
+	return nil
+}
+
+// alertValues returns the current value of every alertable source, and
+// the spread between P2P venues if there are at least two.
+func (s *Service) alertValues() (map[string]float64, *Spread) {
+	v := s.store.GetRatesV1(s.parallelNames)
+	s.setBreaches(&v)
+
+	values := map[string]float64{
+		"bcv":         v.BCV.Rate,
+		AlertParallel: v.Parallel.Rate,
+	}
+	for _, src := range v.Parallel.Sources {
+		values[src.Name] = src.Rate
+	}
+	if cash := s.store.GetParallel(cashSource); cash > 0 {
+		values[cashSource] = cash
+	}
+	if v.Breach != nil {
+		values[AlertBreach] = *v.Breach
+	}
+	spread := venueSpread(v.Parallel.Sources)
+	if spread != nil {
+		values[AlertSpread] = spread.Percent
+	}
+	return values, spread
+}
+
+// evaluateAlerts checks every operator and user rule against the current
+// values and publishes an alert event for each rule whose condition has just
+// started to hold.
+func (s *Service) evaluateAlerts() {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+
+	rules := slices.Clone(s.alerts.rules)
+	userRules, err := s.alerts.store.Alerts("")
+	if err != nil {
+		log.Printf("Alert store error: %v", err)
+	}
+	rules = append(rules, userRules...)
+	if len(rules) == 0 {
+		return
+	}
+
+	values, spread := s.alertValues()
+	baseline := s.alerts.active == nil
+	if baseline {
+		s.alerts.active = make(map[string]bool)
+	}
+
+	for _, rule := range rules {
+		// Rates of 0 mean the source hasn't loaded yet
+		value, ok := values[rule.Source]
+		if !ok || (value <= 0 && rule.Source != AlertBreach && rule.Source != AlertSpread) {
+			continue
+		}
+
+		key := rule.key()
+		matched := rule.matches(value)
+		wasActive := s.alerts.active[key]
+		s.alerts.active[key] = matched
+		if !matched || wasActive || baseline {
+			continue
+		}
+
+		log.Printf("Alert triggered: %s (value %.2f)", key, value)
+		if s.publisher != nil {
+			alert := Alert{Rule: rule, Value: value}
+			if rule.Source == AlertSpread {
+				alert.Spread = spread
+			}
+			s.publisher.Publish(notify.Event{
+				Type:    notify.EventAlert,
+				Time:    time.Now(),
+				Message: alertMessage(alert),
+				Data:    alert,
+				Channel: rule.Channel,
+				To:      rule.To,
+			})
+		}
+	}
+}
+
+// alertMessage describes a triggered alert for notification channels.
+func alertMessage(alert Alert) string {
+	rule, value := alert.Rule, alert.Value
+	direction := "bajó de"
+	if rule.Condition == ConditionAbove {
+		direction = "superó"
+	}
+	if s := alert.Spread; s != nil {
+		return fmt.Sprintf("Alerta: el spread entre plataformas P2P %s %.2f%% (actual %.2f%%: %s %.2f Bs, %s %.2f Bs)",
+			direction, rule.Threshold, value, s.Low, s.LowRate, s.High, s.HighRate)
+	}
+	if rule.Source == AlertBreach {
+		return fmt.Sprintf("Alerta: la brecha %s %.2f%% (actual %.2f%%)", direction, rule.Threshold, value)
+	}
+	return fmt.Sprintf("Alerta: %s %s %.2f Bs (actual %.2f Bs)", rule.Source, direction, rule.Threshold, value)
+}
@@ -0,0 +1,82 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// subscribers is a recipient checker knowing a fixed set of addresses.
+type subscribers []string
+
+func (s subscribers) HasRecipient(to string) (bool, error) {
+	for _, sub := range s {
+		if sub == to {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestCreateAlertRecipient(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	s.SetAlertChannels([]string{"sms", "whatsapp", "webpush"})
+	s.SetRecipientChecker("webpush", subscribers{"https://push.example.com/a"})
+
+	tests := []struct {
+		channel, to string
+		valid       bool
+	}{
+		{"sms", "+584121234567", true},
+		{"sms", "04121234567", false},
+		{"sms", "+58 412 1234567", false},
+		{"sms", "+0412123", false},
+		{"whatsapp", "+584121234567", true},
+		{"whatsapp", "whatsapp:+584121234567", true},
+		{"whatsapp", "whatsapp:04121234567", false},
+		{"webpush", "https://push.example.com/a", true},
+		{"webpush", "https://push.example.com/b", false},
+	}
+	for _, tt := range tests {
+		_, err := s.CreateAlert("owner", AlertRule{
+			Source:    "bcv",
+			Condition: ConditionAbove,
+			Threshold: 40,
+			Channel:   tt.channel,
+			To:        tt.to,
+		})
+		if tt.valid && err != nil {
+			t.Errorf("%s to %q: %v", tt.channel, tt.to, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidAlert) {
+			t.Errorf("%s to %q: got %v, want invalid", tt.channel, tt.to, err)
+		}
+	}
+}
+
+func TestCreateAlertLimit(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	s.SetAlertChannels([]string{"sms"})
+
+	create := func(owner string, i int) error {
+		_, err := s.CreateAlert(owner, AlertRule{
+			Source:    "bcv",
+			Condition: ConditionAbove,
+			Threshold: float64(40 + i),
+			Channel:   "sms",
+			To:        fmt.Sprintf("+5841212345%02d", i),
+		})
+		return err
+	}
+	for i := range maxAlertsPerOwner {
+		if err := create("owner", i); err != nil {
+			t.Fatalf("rule %d: %v", i+1, err)
+		}
+	}
+	if err := create("owner", maxAlertsPerOwner); !errors.Is(err, ErrTooManyAlerts) {
+		t.Errorf("rule over the limit: got %v, want %v", err, ErrTooManyAlerts)
+	}
+	if err := create("other", 0); err != nil {
+		t.Errorf("another owner's rule: %v", err)
+	}
+}
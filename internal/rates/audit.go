@@ -0,0 +1,258 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/pkg/events"
+)
+
+// ErrRateRejected is returned when a fetched rate fails validation.
+var ErrRateRejected = errors.New("rate update rejected")
+
+const (
+	// defaultMaxJump is the largest accepted relative change between
+	// consecutive updates, unless configured otherwise.
+	defaultMaxJump = 0.5
+
+	// defaultJumpConfirmations is how many consecutive samples must agree
+	// on a larger change before it's accepted, unless configured otherwise.
+	defaultJumpConfirmations = 3
+
+	// jumpAgreement is how close, relatively, the samples confirming a
+	// change must be to the first of them.
+	jumpAgreement = 0.05
+
+	// maxMemoryAudit is the number of entries a MemoryAuditLog keeps.
+	maxMemoryAudit = 10000
+)
+
+// AuditEntry records an accepted or rejected rate update.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	OldValue float64   `json:"oldValue"`
+	NewValue float64   `json:"newValue"`
+	Accepted bool      `json:"accepted"`
+	Reason   string    `json:"reason,omitempty"`
+
+	// Provenance is ProvenanceManual for rates entered by an operator.
+	Provenance string `json:"provenance,omitempty"`
+
+	// PinnedUntil is when an override expires.
+	PinnedUntil time.Time `json:"pinnedUntil,omitzero"`
+}
+
+// AuditLog defines the interface for the append-only rate update log.
+type AuditLog interface {
+	Append(entry AuditEntry) error
+	// Entries returns the most recent entries, newest first, optionally
+	// filtered by source. A limit of 0 returns all entries.
+	Entries(source string, limit int) ([]AuditEntry, error)
+}
+
+// MemoryAuditLog keeps the most recent audit entries in memory.
+type MemoryAuditLog struct {
+	mu      sync.RWMutex
+	entries []AuditEntry
+}
+
+// NewMemoryAuditLog creates a new in-memory audit log.
+func NewMemoryAuditLog() *MemoryAuditLog {
+	return &MemoryAuditLog{}
+}
+
+// Append adds an entry to the log, dropping the oldest beyond
+// maxMemoryAudit.
+func (l *MemoryAuditLog) Append(entry AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) >= maxMemoryAudit {
+		l.entries = slices.Delete(l.entries, 0, len(l.entries)-maxMemoryAudit+1)
+	}
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+// Entries returns the most recent entries, newest first.
+func (l *MemoryAuditLog) Entries(source string, limit int) ([]AuditEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return FilterAudit(l.entries, source, limit), nil
+}
+
+// FilterAudit returns entries matching source (all if empty), newest first,
+// up to limit (all if 0). entries must be in append order.
+func FilterAudit(entries []AuditEntry, source string, limit int) []AuditEntry {
+	var result []AuditEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if source != "" && entries[i].Source != source {
+			continue
+		}
+		result = append(result, entries[i])
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+	return result
+}
+
+// validateUpdate returns the reason a rate update must be rejected, or ""
+// if it is acceptable.
+func validateUpdate(newValue float64) string {
+	switch {
+	case math.IsNaN(newValue) || math.IsInf(newValue, 0):
+		return "rate is not a finite number"
+	case newValue <= 0:
+		return "rate must be positive"
+	}
+	return ""
+}
+
+// jumpState tracks, per source, the fetched rates that jumped further from
+// the previous one than allowed.
+type jumpState struct {
+	mu            sync.Mutex
+	limit         float64 // 0 disables the check
+	confirmations int     // 0 never accepts a jump
+	streaks       map[string]jumpStreak
+}
+
+// jumpStreak is a run of consecutive fetched rates agreeing on a jump.
+type jumpStreak struct {
+	rate  float64 // the first of them
+	count int
+}
+
+// SetMaxJump sets the largest accepted relative change between consecutive
+// updates of a source, e.g. 0.5 for 50%; 0 disables the check. A larger
+// change in fetched rates is accepted once confirmations consecutive
+// samples agree on it, so a real devaluation becomes the new baseline
+// instead of being rejected for good; 0 always rejects it.
+func (s *Service) SetMaxJump(limit float64, confirmations int) {
+	s.jumps.mu.Lock()
+	defer s.jumps.mu.Unlock()
+	s.jumps.limit = limit
+	s.jumps.confirmations = confirmations
+	s.jumps.streaks = nil
+}
+
+// checkJump returns the reason an update that moved more than the maximum
+// jump must be rejected, or "" if it didn't or the jump is confirmed.
+func (s *Service) checkJump(entry AuditEntry) string {
+	s.jumps.mu.Lock()
+	defer s.jumps.mu.Unlock()
+
+	oldValue, newValue := entry.OldValue, entry.NewValue
+	if s.jumps.limit <= 0 || oldValue <= 0 || math.Abs(newValue-oldValue)/oldValue <= s.jumps.limit {
+		if entry.Provenance != ProvenanceManual {
+			delete(s.jumps.streaks, entry.Source)
+		}
+		return ""
+	}
+	change := (newValue - oldValue) / oldValue * 100
+	reason := fmt.Sprintf("change of %.1f%% exceeds the %g%% limit", change, s.jumps.limit*100)
+	if entry.Provenance == ProvenanceManual || s.jumps.confirmations == 0 {
+		return reason
+	}
+
+	streak := s.jumps.streaks[entry.Source]
+	if streak.count == 0 || math.Abs(newValue-streak.rate)/streak.rate > jumpAgreement {
+		streak = jumpStreak{rate: newValue}
+	}
+	streak.count++
+	if streak.count < s.jumps.confirmations {
+		if s.jumps.streaks == nil {
+			s.jumps.streaks = make(map[string]jumpStreak)
+		}
+		s.jumps.streaks[entry.Source] = streak
+		return fmt.Sprintf("%s (%d of %d samples confirming it)", reason, streak.count, s.jumps.confirmations)
+	}
+
+	delete(s.jumps.streaks, entry.Source)
+	log.Printf("Rates: Accepting %s rate %.2f, a change of %.1f%% confirmed by %d consecutive samples",
+		entry.Source, newValue, change, streak.count)
+	return ""
+}
+
+// checkUpdate validates a rate update and records the outcome in the audit log.
+func (s *Service) checkUpdate(source string, oldValue, newValue float64) error {
+	return s.audited(AuditEntry{Source: source, OldValue: oldValue, NewValue: newValue})
+}
+
+// audited validates the update described by entry and records the outcome
+// in the audit log.
+func (s *Service) audited(entry AuditEntry) error {
+	entry.Time = time.Now()
+	entry.Accepted = true
+
+	reason := validateUpdate(entry.NewValue)
+	if reason == "" {
+		reason = s.checkJump(entry)
+	}
+	if reason == "" {
+		reason = s.checkBounds(entry.Source, entry.NewValue)
+	}
+	if reason != "" {
+		entry.Accepted = false
+		entry.Reason = reason
+	}
+
+	if err := s.audit.Append(entry); err != nil {
+		log.Printf("Audit log append error: %v", err)
+	}
+	if entry.Provenance != ProvenanceManual {
+		s.announceRejection(entry)
+	}
+
+	if reason != "" {
+		return fmt.Errorf("%w: %s: %s", ErrRateRejected, entry.Source, reason)
+	}
+	return nil
+}
+
+// rejectionState tracks the sources whose fetched rates are being rejected.
+type rejectionState struct {
+	mu     sync.Mutex
+	active map[string]bool
+}
+
+// announceRejection emits a rate rejected event when a source's fetched
+// rates start failing validation. Later rejections aren't announced until
+// one of its rates is accepted. Operators entering rates manually see the
+// rejection in the response instead.
+func (s *Service) announceRejection(entry AuditEntry) {
+	s.rejections.mu.Lock()
+	rejecting := s.rejections.active[entry.Source]
+	if s.rejections.active == nil {
+		s.rejections.active = make(map[string]bool)
+	}
+	s.rejections.active[entry.Source] = !entry.Accepted
+	s.rejections.mu.Unlock()
+
+	if entry.Accepted || rejecting || s.publisher == nil {
+		return
+	}
+	s.publisher.Publish(notify.Event{
+		Type:    notify.EventRateRejected,
+		Time:    entry.Time,
+		Message: fmt.Sprintf("Tasa %s rechazada: %.2f Bs (anterior %.2f), %s", entry.Source, entry.NewValue, entry.OldValue, entry.Reason),
+		Data: events.RateRejected{
+			Source:   entry.Source,
+			Rate:     entry.NewValue,
+			Previous: entry.OldValue,
+			Reason:   entry.Reason,
+		},
+	})
+}
+
+// GetAuditLog returns recent audit entries, newest first.
+func (s *Service) GetAuditLog(source string, limit int) ([]AuditEntry, error) {
+	return s.audit.Entries(source, limit)
+}
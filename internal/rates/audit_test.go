@@ -0,0 +1,90 @@
+package rates
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJumpConfirmed(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	s.SetMaxJump(0.5, 3)
+
+	// A devaluation is rejected until three samples agree on it
+	for i, rate := range []float64{60, 61, 60.5} {
+		err := s.checkUpdate("bcv", 36.5, rate)
+		if i < 2 && !errors.Is(err, ErrRateRejected) {
+			t.Fatalf("sample %d of %.2f: got %v, want rejected", i+1, rate, err)
+		}
+		if i == 2 && err != nil {
+			t.Fatalf("sample %d of %.2f: got %v, want accepted", i+1, rate, err)
+		}
+	}
+}
+
+func TestJumpStreakRestarts(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	s.SetMaxJump(0.5, 2)
+
+	// Samples that disagree start over, as do those within the limit
+	updates := []struct {
+		rate     float64
+		accepted bool
+	}{
+		{60, false},
+		{80, false},
+		{36.6, true},
+		{60, false},
+		{60.2, true},
+	}
+	for i, u := range updates {
+		err := s.checkUpdate("binance", 36.5, u.rate)
+		if (err == nil) != u.accepted {
+			t.Errorf("update %d of %.2f: got %v, want accepted %v", i+1, u.rate, err, u.accepted)
+		}
+	}
+}
+
+func TestJumpLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         float64
+		confirmations int
+		manual        bool
+		accepted      bool
+	}{
+		{"disabled", 0, 3, false, true},
+		{"never confirmed", 0.5, 0, false, false},
+		{"manual", 0.5, 1, true, false},
+		{"confirmed by one sample", 0.5, 1, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(nil, nil, nil)
+			s.SetMaxJump(tt.limit, tt.confirmations)
+			entry := AuditEntry{Source: "bcv", OldValue: 36.5, NewValue: 100}
+			if tt.manual {
+				entry.Provenance = ProvenanceManual
+			}
+			for range 3 {
+				if err := s.audited(entry); (err == nil) != tt.accepted {
+					t.Fatalf("got %v, want accepted %v", err, tt.accepted)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryAuditLogCapped(t *testing.T) {
+	l := NewMemoryAuditLog()
+	for i := range maxMemoryAudit + 10 {
+		l.Append(AuditEntry{Source: "bcv", NewValue: float64(i)})
+	}
+
+	entries, _ := l.Entries("", 0)
+	if len(entries) != maxMemoryAudit {
+		t.Fatalf("got %d entries, want %d", len(entries), maxMemoryAudit)
+	}
+	if newest, oldest := entries[0].NewValue, entries[len(entries)-1].NewValue; newest != maxMemoryAudit+9 || oldest != 10 {
+		t.Errorf("kept entries %v to %v, want 10 to %d", oldest, newest, maxMemoryAudit+9)
+	}
+}
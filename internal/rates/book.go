@@ -0,0 +1,160 @@
+package rates
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/stats"
+)
+
+const (
+	// bookBuckets is the number of equal-width price buckets in a book.
+	bookBuckets = 5
+	// bookPayTypes is the number of payment methods listed in a book.
+	bookPayTypes = 10
+)
+
+// ErrNoBook is returned before an ad book has been sampled.
+var ErrNoBook = errors.New("no ad book sampled yet")
+
+// Book is anonymized statistics about the ads sampled for a P2P rate, for
+// liquidity monitoring. Nothing identifying advertisers is kept.
+type Book struct {
+	Asset     string `json:"asset"`
+	Fiat      string `json:"fiat"`
+	TradeType string `json:"tradeType"`
+
+	// Ads is the number of ads the rate was computed from, out of Listed
+	// ads matching the search; Skipped ads were outside the order size
+	// limits.
+	Ads     int `json:"ads"`
+	Listed  int `json:"listed"`
+	Skipped int `json:"skipped,omitempty"`
+
+	// Surplus is the asset left for sale across the ads.
+	Surplus float64 `json:"surplus"`
+
+	Price     BookPrice     `json:"price"`
+	Buckets   []BookBucket  `json:"buckets"`
+	PayTypes  []BookPayType `json:"payTypes"`
+	SampledAt time.Time     `json:"sampledAt"`
+}
+
+// BookPrice summarizes the ad prices.
+type BookPrice struct {
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Max    float64 `json:"max"`
+}
+
+// BookBucket counts the ads priced in [From, To), the last bucket including
+// To.
+type BookBucket struct {
+	From    float64 `json:"from"`
+	To      float64 `json:"to"`
+	Ads     int     `json:"ads"`
+	Surplus float64 `json:"surplus"`
+}
+
+// BookPayType counts the ads accepting a payment method.
+type BookPayType struct {
+	Method string `json:"method"`
+	Ads    int    `json:"ads"`
+}
+
+// BookAd is a sampled ad as counted in a book.
+type BookAd struct {
+	Price    float64
+	Surplus  float64
+	PayTypes []string
+}
+
+// NewBook computes the price, surplus and payment method statistics of the
+// given ads. The other fields are left to the caller.
+func NewBook(ads []BookAd) Book {
+	book := Book{Ads: len(ads), Buckets: []BookBucket{}, PayTypes: []BookPayType{}}
+	if len(ads) == 0 {
+		return book
+	}
+
+	prices := make([]float64, len(ads))
+	methods := make(map[string]int)
+	for i, ad := range ads {
+		prices[i] = ad.Price
+		book.Surplus += ad.Surplus
+		for _, m := range ad.PayTypes {
+			methods[m]++
+		}
+	}
+	book.Surplus = roundTo(book.Surplus, 2)
+
+	lo, hi := slices.Min(prices), slices.Max(prices)
+	book.Price = BookPrice{Min: lo, Median: roundTo(stats.Median(prices), 2), Max: hi}
+
+	// Identical prices make a single bucket
+	n := bookBuckets
+	if hi == lo {
+		n = 1
+	}
+	width := (hi - lo) / float64(n)
+	book.Buckets = make([]BookBucket, n)
+	for i := range book.Buckets {
+		book.Buckets[i] = BookBucket{From: roundTo(lo+width*float64(i), 2), To: roundTo(lo+width*float64(i+1), 2)}
+	}
+	book.Buckets[n-1].To = hi
+	for _, ad := range ads {
+		i := n - 1
+		if width > 0 {
+			i = min(int((ad.Price-lo)/width), n-1)
+		}
+		book.Buckets[i].Ads++
+		book.Buckets[i].Surplus = roundTo(book.Buckets[i].Surplus+ad.Surplus, 2)
+	}
+
+	for m, count := range methods {
+		book.PayTypes = append(book.PayTypes, BookPayType{Method: m, Ads: count})
+	}
+	slices.SortFunc(book.PayTypes, func(a, b BookPayType) int {
+		if c := cmp.Compare(b.Ads, a.Ads); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Method, b.Method)
+	})
+	if len(book.PayTypes) > bookPayTypes {
+		book.PayTypes = book.PayTypes[:bookPayTypes]
+	}
+	return book
+}
+
+// In returns a copy of the book with timestamps expressed in loc.
+func (b Book) In(loc *time.Location) Book {
+	b.SampledAt = inLocation(b.SampledAt, loc)
+	return b
+}
+
+// bookState holds the latest Binance ad book.
+type bookState struct {
+	mu   sync.RWMutex
+	book *Book
+}
+
+// setBook records the latest Binance ad book.
+func (s *Service) setBook(book Book) {
+	s.book.mu.Lock()
+	defer s.book.mu.Unlock()
+	s.book.book = &book
+}
+
+// GetBook returns statistics about the ads sampled on the latest successful
+// Binance fetch.
+func (s *Service) GetBook() (Book, error) {
+	s.book.mu.RLock()
+	defer s.book.mu.RUnlock()
+	if s.book.book == nil {
+		return Book{}, ErrNoBook
+	}
+	return *s.book.book, nil
+}
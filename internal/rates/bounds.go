@@ -0,0 +1,158 @@
+package rates
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Bounds is the range of rates a source may report. A zero Min or Max
+// leaves that side open.
+type Bounds struct {
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+}
+
+const (
+	// boundsHeadroom is how close, relative to a bound, recent rates may
+	// come before wider bounds are suggested.
+	boundsHeadroom = 0.2
+	// boundsWidening is the factor by which suggested bounds clear recent
+	// rates.
+	boundsWidening = 2.0
+	// boundsWindow is the history suggestions are based on.
+	boundsWindow = 30 * 24 * time.Hour
+)
+
+// contains reports whether v is within the bounds.
+func (b Bounds) contains(v float64) bool {
+	return (b.Min <= 0 || v >= b.Min) && (b.Max <= 0 || v <= b.Max)
+}
+
+// String formats the bounds as min:max, leaving open sides empty.
+func (b Bounds) String() string {
+	side := func(v float64) string {
+		if v <= 0 {
+			return ""
+		}
+		return fmt.Sprintf("%g", v)
+	}
+	return side(b.Min) + ":" + side(b.Max)
+}
+
+// suggest returns wider bounds if rates between low and high come within
+// boundsHeadroom of them, as devaluation eventually pushes rates past any
+// fixed bounds.
+func (b Bounds) suggest(low, high float64) (Bounds, bool) {
+	wider, widened := b, false
+	if b.Max > 0 && high > b.Max*(1-boundsHeadroom) {
+		wider.Max = math.Ceil(high * boundsWidening)
+		widened = true
+	}
+	if b.Min > 0 && low > 0 && low < b.Min*(1+boundsHeadroom) {
+		wider.Min = math.Floor(low / boundsWidening)
+		widened = true
+	}
+	return wider, widened
+}
+
+// boundsState holds each source's bounds and which sources were reported
+// as nearing them.
+type boundsState struct {
+	mu     sync.Mutex
+	limits map[string]Bounds
+	near   map[string]bool
+}
+
+// SetBounds restricts the rates accepted from a source, including manual
+// entries, to b. Rates outside it are rejected like other invalid updates.
+func (s *Service) SetBounds(source string, b Bounds) {
+	s.bounds.mu.Lock()
+	defer s.bounds.mu.Unlock()
+	if s.bounds.limits == nil {
+		s.bounds.limits = make(map[string]Bounds)
+	}
+	s.bounds.limits[source] = b
+}
+
+// sourceBounds returns a source's bounds, if it has any.
+func (s *Service) sourceBounds(source string) (Bounds, bool) {
+	s.bounds.mu.Lock()
+	defer s.bounds.mu.Unlock()
+	b, ok := s.bounds.limits[source]
+	return b, ok
+}
+
+// checkBounds returns the reason a rate outside a source's bounds must be
+// rejected, or "" if it is within them. Accepted rates nearing the bounds
+// are logged once, with the suggested bounds, until they move away.
+func (s *Service) checkBounds(source string, rate float64) string {
+	b, ok := s.sourceBounds(source)
+	if !ok {
+		return ""
+	}
+	if !b.contains(rate) {
+		wider, _ := b.suggest(rate, rate)
+		return fmt.Sprintf("rate %.2f is outside the bounds %s (consider %s)", rate, b, wider)
+	}
+
+	wider, near := b.suggest(rate, rate)
+	s.bounds.mu.Lock()
+	defer s.bounds.mu.Unlock()
+	if near && !s.bounds.near[source] {
+		log.Printf("Rate %s %.2f is nearing its bounds %s, consider widening them to %s", source, rate, b, wider)
+	}
+	if s.bounds.near == nil {
+		s.bounds.near = make(map[string]bool)
+	}
+	s.bounds.near[source] = near
+	return ""
+}
+
+// recentRange returns the lowest and highest rates of a source over the
+// last boundsWindow. Daily closes cover BCV and Binance; other sources only
+// have their current rate.
+func (s *Service) recentRange(source string) (float64, float64) {
+	var current float64
+	switch source {
+	case "bcv":
+		current = s.store.GetBCV()
+	case "binance":
+		current = s.store.GetBinance()
+	default:
+		current = s.store.GetParallel(source)
+	}
+	low, high := current, current
+
+	include := func(v float64) {
+		if v <= 0 {
+			return
+		}
+		if low == 0 || v < low {
+			low = v
+		}
+		high = math.Max(high, v)
+	}
+
+	closes, err := s.dailyCloses()
+	if err != nil {
+		return low, high
+	}
+	since := time.Now().Add(-boundsWindow)
+	for _, c := range closes {
+		if c.ClosedAt.Before(since) {
+			continue
+		}
+		switch source {
+		case "bcv":
+			include(c.BCV)
+		case "binance":
+			include(c.Low)
+			include(c.High)
+			include(c.Binance)
+		}
+	}
+	return low, high
+}
@@ -0,0 +1,65 @@
+package rates
+
+import "fmt"
+
+// BreachDirection is how the breach, the percentage gap between a rate and
+// the BCV rate, is measured.
+type BreachDirection string
+
+// Breach directions.
+const (
+	// BreachPremium measures the gap against the BCV rate: how much more a
+	// dollar costs than officially, (rate - bcv) / bcv × 100.
+	BreachPremium BreachDirection = "premium"
+	// BreachDiscount measures the gap against the rate: how much the BCV
+	// rate undervalues the dollar, (rate - bcv) / rate × 100.
+	BreachDiscount BreachDirection = "discount"
+)
+
+// ParseBreachDirection parses a breach direction; empty is BreachPremium.
+func ParseBreachDirection(s string) (BreachDirection, error) {
+	switch d := BreachDirection(s); d {
+	case "":
+		return BreachPremium, nil
+	case BreachPremium, BreachDiscount:
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown breach direction %q (expected %q or %q)", s, BreachPremium, BreachDiscount)
+}
+
+// SetBreachDirection sets how breaches are measured, daily closes
+// included. The default is BreachPremium.
+func (s *Service) SetBreachDirection(d BreachDirection) {
+	s.breach = d
+}
+
+// of returns the breach of rate against bcv, truncated to 2 decimal
+// places, or nil unless both have been fetched.
+func (d BreachDirection) of(bcv, rate float64) *float64 {
+	if bcv <= 0 || rate <= 0 {
+		return nil
+	}
+	base := bcv
+	if d == BreachDiscount {
+		base = rate
+	}
+	breach := float64(int((rate-bcv)/base*100*100)) / 100
+	return &breach
+}
+
+// setBreaches sets the breach of the parallel rate and of every source in
+// v against its BCV rate.
+func (s *Service) setBreaches(v *RatesV1) {
+	bcv := v.BCV.Rate
+	v.Breach = s.breach.of(bcv, v.Parallel.Rate)
+	for i := range v.Parallel.Sources {
+		src := &v.Parallel.Sources[i]
+		src.Breach = s.breach.of(bcv, src.Rate)
+	}
+	if v.Zelle != nil {
+		v.Zelle.Breach = s.breach.of(bcv, v.Zelle.Rate)
+	}
+	if v.Cash != nil {
+		v.Cash.Breach = s.breach.of(bcv, v.Cash.Rate)
+	}
+}
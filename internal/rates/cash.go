@@ -0,0 +1,82 @@
+package rates
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// cashSource is the source name of the street cash-dollar rate.
+const cashSource = "efectivo"
+
+// ProvenanceManual marks a rate entered by an operator rather than fetched.
+const ProvenanceManual = "manual"
+
+// SetCashSource enables the cash-dollar ("efectivo") rate, fetched from
+// source with the parallel sources. Without a source, the rate can still be
+// entered with SetCash.
+func (s *Service) SetCashSource(source Scraper) {
+	s.cash = source
+	s.health.register(cashSource, 5*time.Minute)
+}
+
+// SetCash records a manually entered cash-dollar rate. Like fetched rates,
+// it's audited and rejected if it jumps too far from the previous value.
+func (s *Service) SetCash(rate float64) (SourceRate, error) {
+	entry := AuditEntry{
+		Source:     cashSource,
+		OldValue:   s.store.GetParallel(cashSource),
+		NewValue:   rate,
+		Provenance: ProvenanceManual,
+	}
+	if err := s.audited(entry); err != nil {
+		return SourceRate{}, err
+	}
+
+	s.store.SetParallelFrom(cashSource, rate, ProvenanceManual)
+	s.record(cashSource, rate)
+	log.Printf("Cash rate set manually: %.2f", rate)
+	s.evaluateAlerts()
+	return *s.GetRatesV1().Cash, nil
+}
+
+// fetchCash fetches the cash-dollar rate from its source. If fetching
+// fails, the previous value is retained.
+func (s *Service) fetchCash(ctx context.Context) error {
+	if err := s.checkBackoff(cashSource); err != nil {
+		log.Printf("Cash fetch skipped: %v", err)
+		return err
+	}
+
+	sample, err := fetchSample(ctx, s.cash)
+	rate := sample.Rate
+	if err == nil {
+		err = s.checkUpdate(cashSource, s.store.GetParallel(cashSource), rate)
+	}
+	s.recordFetch(cashSource, err, sample)
+	if err != nil {
+		log.Printf("Cash fetch error (%s, keeping previous value): %v", ClassifyError(err), err)
+		return fmt.Errorf("%s: %w", cashSource, err)
+	}
+
+	s.store.SetParallel(cashSource, rate)
+	s.record(cashSource, rate)
+	log.Printf("Cash rate updated: %.2f", rate)
+	return nil
+}
+
+// cashRate returns the cash-dollar rate as a source entry compared with the
+// headline parallel rate, or nil if it has neither a source nor a value.
+func (s *Service) cashRate(parallel float64) *SourceRate {
+	v := s.store.parallelValue(cashSource)
+	if s.cash == nil && v.rate <= 0 {
+		return nil
+	}
+
+	src := &SourceRate{Name: cashSource, Rate: v.rate, UpdatedAt: v.at, Provenance: v.provenance}
+	if v.rate > 0 && parallel > 0 {
+		src.Deviation = variation(parallel, v.rate)
+	}
+	return src
+}
@@ -0,0 +1,113 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Challenge errors.
+var (
+	// ErrChallenge is wrapped by scraper errors for anti-bot challenge pages
+	// (Cloudflare, CAPTCHAs) served instead of the requested content.
+	ErrChallenge = errors.New("blocked by anti-bot challenge")
+
+	// ErrBackingOff is returned for fetches skipped while a challenged
+	// source backs off.
+	ErrBackingOff = errors.New("backing off after anti-bot challenge")
+)
+
+// Challenge backoff bounds. The backoff doubles with each consecutive
+// challenge, since retrying on the usual schedule only keeps the source's
+// protection triggered.
+const (
+	challengeBackoff    = 15 * time.Minute
+	maxChallengeBackoff = 6 * time.Hour
+)
+
+// Source states reported by Status.
+const (
+	StateUnknown    = "unknown"
+	StateOK         = "ok"
+	StateFailing    = "failing"
+	StateChallenged = "challenged"
+)
+
+// SourceStatus describes a source's recent fetches.
+type SourceStatus struct {
+	Name        string   `json:"name"`
+	State       string   `json:"state"`
+	SuccessRate *float64 `json:"successRate,omitempty"`
+
+	// Fetches counts fetches since startup, and Failures the failed ones
+	// by kind.
+	Fetches  int64               `json:"fetches"`
+	Failures map[ErrorKind]int64 `json:"failures,omitempty"`
+
+	// LastError is the most recent failed fetch, even if later ones
+	// succeeded.
+	LastError *SourceError `json:"lastError,omitempty"`
+
+	// Challenges counts consecutive fetches blocked by an anti-bot
+	// challenge; BackoffUntil is when fetching resumes.
+	Challenges      int        `json:"challenges,omitempty"`
+	LastChallengeAt *time.Time `json:"lastChallengeAt,omitempty"`
+	BackoffUntil    *time.Time `json:"backoffUntil,omitempty"`
+
+	// Bounds are the rates accepted from the source, and SuggestedBounds
+	// wider ones once recent rates come close to them.
+	Bounds          *Bounds `json:"bounds,omitempty"`
+	SuggestedBounds *Bounds `json:"suggestedBounds,omitempty"`
+}
+
+// backoff returns how long to stop fetching after the given number of
+// consecutive challenges.
+func backoff(challenges int) time.Duration {
+	d := challengeBackoff
+	for i := 1; i < challenges && d < maxChallengeBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxChallengeBackoff)
+}
+
+// checkBackoff returns an error wrapping ErrBackingOff while a source backs
+// off after a challenge.
+func (s *Service) checkBackoff(name string) error {
+	until := s.health.backoffUntil(name)
+	if time.Now().Before(until) {
+		return fmt.Errorf("%s: %w until %s", name, ErrBackingOff, until.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// sourceNames returns the name of every fetched rate source.
+func (s *Service) sourceNames() []string {
+	names := append([]string{"bcv", "binance"}, s.parallelNames...)
+	for _, name := range s.regionNames {
+		names = append(names, regionSource(name))
+	}
+	if s.zelle != nil {
+		names = append(names, zelleSource)
+	}
+	if s.cash != nil {
+		names = append(names, cashSource)
+	}
+	return names
+}
+
+// Status returns the fetch status of every rate source.
+func (s *Service) Status() []SourceStatus {
+	names := s.sourceNames()
+	statuses := make([]SourceStatus, len(names))
+	now := time.Now()
+	for i, name := range names {
+		statuses[i] = s.health.status(name, now)
+		if b, ok := s.sourceBounds(name); ok {
+			statuses[i].Bounds = &b
+			if wider, widened := b.suggest(s.recentRange(name)); widened {
+				statuses[i].SuggestedBounds = &wider
+			}
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,361 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/stats"
+)
+
+// Sample is a rate aggregated from several quotes. Method names the
+// statistic used, e.g. "median".
+type Sample struct {
+	Rate   float64
+	Method string
+	Values []float64
+
+	// Book describes the sampled ads of P2P sources.
+	Book *Book
+
+	// When the fetch started, how long it took and what it received over
+	// HTTP, set by fetchSample
+	fetchedAt time.Time
+	took      time.Duration
+	transfer  Transfer
+}
+
+// SampledScraper is implemented by scrapers that aggregate multiple quotes,
+// exposing the individual values for confidence scoring.
+type SampledScraper interface {
+	FetchSample(ctx context.Context) (Sample, error)
+}
+
+// Confidence scores how much a source's current value can be trusted,
+// from 0 (no confidence) to 1.
+type Confidence struct {
+	Score       float64  `json:"score"`
+	SampleSize  int      `json:"sampleSize,omitempty"`
+	Dispersion  *float64 `json:"dispersion,omitempty"`
+	SuccessRate *float64 `json:"successRate,omitempty"`
+}
+
+const (
+	// historyWindow is the number of recent fetch outcomes considered.
+	historyWindow = 20
+	// fullSampleSize is the sample size that earns full sample confidence.
+	fullSampleSize = 10
+	// maxDispersion is the coefficient of variation (percent) at which
+	// variance confidence reaches zero.
+	maxDispersion = 5.0
+	// staleFactor is how many expected intervals old a value can be before
+	// recency confidence reaches zero.
+	staleFactor = 6.0
+)
+
+// Confidence factor weights.
+const (
+	weightSample   = 0.20
+	weightRecency  = 0.35
+	weightVariance = 0.20
+	weightSuccess  = 0.25
+)
+
+// sourceHealth tracks recent fetch outcomes and sample statistics for a source.
+type sourceHealth struct {
+	interval   time.Duration
+	outcomes   []bool
+	sampleSize int
+	dispersion *float64
+	method     string
+
+	// Fetch and failure counts since startup, and the kind of the last
+	// fetch's failure ("" after a success)
+	fetches  int64
+	failures map[ErrorKind]int64
+	lastKind ErrorKind
+
+	// The most recent failure, kept after later successes
+	lastError *SourceError
+
+	// Consecutive anti-bot challenges and the resulting backoff
+	challenges    int
+	lastChallenge time.Time
+	backoffUntil  time.Time
+}
+
+// healthTracker tracks health for all sources.
+type healthTracker struct {
+	mu      sync.RWMutex
+	sources map[string]*sourceHealth
+}
+
+// newHealthTracker creates an empty tracker.
+func newHealthTracker() *healthTracker {
+	return &healthTracker{
+		sources: make(map[string]*sourceHealth),
+	}
+}
+
+// register sets the expected refresh interval of a source.
+func (t *healthTracker) register(name string, interval time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.get(name).interval = interval
+}
+
+// record stores a fetch outcome. The sample's values are the individual
+// quotes of a successful sampled fetch, or nil for single-value sources.
+// It returns the failure's kind and whether it differs from the previous
+// fetch's.
+func (t *healthTracker) record(name string, err error, sample Sample) (ErrorKind, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := t.get(name)
+	h.outcomes = append(h.outcomes, err == nil)
+	if len(h.outcomes) > historyWindow {
+		h.outcomes = h.outcomes[len(h.outcomes)-historyWindow:]
+	}
+
+	kind := ClassifyError(err)
+	changed := kind != h.lastKind
+	h.fetches++
+	h.lastKind = kind
+	if err != nil {
+		if h.failures == nil {
+			h.failures = make(map[ErrorKind]int64)
+		}
+		h.failures[kind]++
+		h.lastError = newSourceError(name, err, time.Now())
+	}
+
+	switch {
+	case errors.Is(err, ErrChallenge):
+		now := time.Now()
+		h.challenges++
+		h.lastChallenge = now
+		h.backoffUntil = now.Add(backoff(h.challenges))
+	case err == nil:
+		h.challenges = 0
+		h.backoffUntil = time.Time{}
+	}
+
+	if err == nil && sample.Values != nil {
+		h.sampleSize = len(sample.Values)
+		h.dispersion = coefficientOfVariation(sample.Values)
+		h.method = sample.Method
+	}
+	return kind, changed
+}
+
+// backoffUntil returns when a challenged source may be fetched again, or
+// the zero time if it isn't backing off.
+func (t *healthTracker) backoffUntil(name string) time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if h, ok := t.sources[name]; ok {
+		return h.backoffUntil
+	}
+	return time.Time{}
+}
+
+// status summarizes a source's recent fetches.
+func (t *healthTracker) status(name string, now time.Time) SourceStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	status := SourceStatus{Name: name, State: StateUnknown}
+	h, ok := t.sources[name]
+	if ok && h.lastError != nil {
+		e := *h.lastError
+		status.LastError = &e
+	}
+	if !ok || len(h.outcomes) == 0 {
+		return status
+	}
+
+	status.Fetches = h.fetches
+	status.SuccessRate = roundPtr(successRate(h.outcomes))
+	if len(h.failures) > 0 {
+		status.Failures = maps.Clone(h.failures)
+	}
+	switch {
+	case now.Before(h.backoffUntil):
+		status.State = StateChallenged
+	case h.outcomes[len(h.outcomes)-1]:
+		status.State = StateOK
+	default:
+		status.State = StateFailing
+	}
+	if h.challenges > 0 {
+		status.Challenges = h.challenges
+		last, until := h.lastChallenge, h.backoffUntil
+		status.LastChallengeAt = &last
+		status.BackoffUntil = &until
+	}
+	return status
+}
+
+// lastErrors returns every source's most recent failure.
+func (t *healthTracker) lastErrors() []SourceError {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var errs []SourceError
+	for _, h := range t.sources {
+		if h.lastError != nil {
+			errs = append(errs, *h.lastError)
+		}
+	}
+	slices.SortFunc(errs, func(a, b SourceError) int {
+		return strings.Compare(a.Source, b.Source)
+	})
+	return errs
+}
+
+// restoreError loads a source's most recent failure, unless a newer one is
+// already recorded.
+func (t *healthTracker) restoreError(e SourceError) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := t.get(e.Source)
+	if h.lastError == nil || e.Time.After(h.lastError.Time) {
+		h.lastError = &e
+	}
+}
+
+// get returns the health entry for a source, creating it if needed.
+// Callers must hold the lock.
+func (t *healthTracker) get(name string) *sourceHealth {
+	h, ok := t.sources[name]
+	if !ok {
+		h = &sourceHealth{}
+		t.sources[name] = h
+	}
+	return h
+}
+
+// confidence computes the confidence for a source's current value. Factors
+// that don't apply (e.g. sample size for single-value sources) are left out
+// and the remaining weights are rescaled.
+func (t *healthTracker) confidence(src SourceRate, now time.Time) *Confidence {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	h, ok := t.sources[src.Name]
+	if !ok || src.Rate <= 0 {
+		return &Confidence{Score: 0}
+	}
+
+	conf := &Confidence{
+		SampleSize: h.sampleSize,
+		Dispersion: h.dispersion,
+	}
+
+	var score, weights float64
+	add := func(weight, factor float64) {
+		score += weight * math.Max(0, math.Min(1, factor))
+		weights += weight
+	}
+
+	if h.interval > 0 {
+		age := now.Sub(src.UpdatedAt)
+		add(weightRecency, 1-float64(age-h.interval)/(float64(h.interval)*(staleFactor-1)))
+	}
+	if h.sampleSize > 0 {
+		add(weightSample, float64(h.sampleSize)/fullSampleSize)
+	}
+	if h.dispersion != nil {
+		add(weightVariance, 1-*h.dispersion/maxDispersion)
+	}
+	if len(h.outcomes) > 0 {
+		rate := successRate(h.outcomes)
+		conf.SuccessRate = roundPtr(rate)
+		add(weightSuccess, rate)
+	}
+
+	if weights > 0 {
+		conf.Score = roundTo(score/weights, 2)
+	}
+	return conf
+}
+
+// annotate attaches confidence scores and aggregation methods to every
+// source in the response, and warnings for sources whose latest fetch
+// failed.
+func (t *healthTracker) annotate(v *RatesV1, now time.Time) {
+	v.BCV.Confidence = t.confidence(v.BCV, now)
+	t.warn(v, v.BCV)
+	for _, src := range []*SourceRate{v.Zelle, v.Cash} {
+		// Manually entered rates have no fetches to score
+		if src != nil && src.Provenance == "" {
+			src.Confidence = t.confidence(*src, now)
+			src.Method = t.method(src.Name)
+			t.warn(v, *src)
+		}
+	}
+	for i := range v.Parallel.Sources {
+		src := &v.Parallel.Sources[i]
+		src.Confidence = t.confidence(*src, now)
+		src.Method = t.method(src.Name)
+		t.warn(v, *src)
+	}
+}
+
+// warn adds a warning to the response if the source failed since its value
+// was last updated. Rates entered by an operator aren't affected by failures.
+func (t *healthTracker) warn(v *RatesV1, src SourceRate) {
+	if src.Provenance != "" {
+		return
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if h, ok := t.sources[src.Name]; ok && h.lastError != nil && h.lastError.Time.After(src.UpdatedAt) {
+		v.Warnings = append(v.Warnings, newWarning(*h.lastError))
+	}
+}
+
+// method returns the aggregation method of a sampled source's last
+// successful fetch.
+func (t *healthTracker) method(name string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if h, ok := t.sources[name]; ok {
+		return h.method
+	}
+	return ""
+}
+
+// successRate returns the fraction of successful outcomes.
+func successRate(outcomes []bool) float64 {
+	successes := 0
+	for _, ok := range outcomes {
+		if ok {
+			successes++
+		}
+	}
+	return float64(successes) / float64(len(outcomes))
+}
+
+// coefficientOfVariation returns the standard deviation of values as a
+// percentage of their mean.
+func coefficientOfVariation(values []float64) *float64 {
+	mean := stats.Mean(values)
+	if mean == 0 {
+		return nil
+	}
+	return roundPtr(stats.StdDev(values) / mean * 100)
+}
+
+// roundPtr rounds a value to 2 decimal places and returns a pointer to it.
+func roundPtr(v float64) *float64 {
+	r := roundTo(v, 2)
+	return &r
+}
@@ -0,0 +1,156 @@
+package rates
+
+import (
+	"math"
+	"time"
+
+	"github.com/veswatch/api/internal/stats"
+)
+
+// Parallel rate methods.
+const (
+	MethodSingle    = "single"
+	MethodMedian    = "median"
+	MethodConsensus = "consensus"
+)
+
+const (
+	// consensusMinSources is the number of fresh sources needed for outlier rejection.
+	consensusMinSources = 3
+	// consensusMaxAge excludes sources that haven't updated recently.
+	consensusMaxAge = 30 * time.Minute
+	// outlierThreshold is the robust z-score beyond which a source is rejected.
+	outlierThreshold = 3.0
+	// madScale makes the MAD a consistent estimator of the standard deviation.
+	madScale = 1.4826
+	// minSpread floors the scaled MAD at this fraction of the median, so
+	// when most sources agree exactly, ones a cent off aren't rejected.
+	minSpread = 0.005
+)
+
+// SourceRate is a single source's contribution to the parallel rate.
+type SourceRate struct {
+	Name       string      `json:"name"`
+	Rate       float64     `json:"rate,omitzero"`
+	UpdatedAt  time.Time   `json:"updatedAt,omitzero"`
+	Method     string      `json:"method,omitempty"`
+	Deviation  *float64    `json:"deviation,omitempty"`
+	Breach     *float64    `json:"breach,omitempty"`
+	Outlier    bool        `json:"outlier,omitempty"`
+	Stale      bool        `json:"stale,omitempty"`
+	Confidence *Confidence `json:"confidence,omitempty"`
+
+	// Provenance is ProvenanceManual for rates entered by an operator, or
+	// ProvenanceMirror for a BCV rate read from the mirror named in Fallback.
+	Provenance string `json:"provenance,omitempty"`
+
+	// PinnedUntil is when an operator override of the rate expires.
+	PinnedUntil time.Time `json:"pinnedUntil,omitzero"`
+
+	// Fallback names the source that supplied the rate while this one
+	// failed. Such a rate repeats that source and isn't counted again.
+	Fallback string `json:"fallback,omitempty"`
+}
+
+// ParallelRate is the headline parallel-market rate derived from all sources.
+type ParallelRate struct {
+	Rate    float64      `json:"rate,omitzero"`
+	Method  string       `json:"method,omitempty"`
+	Sources []SourceRate `json:"sources"`
+}
+
+// RatesV1 is the detailed rates response served under /v1.
+type RatesV1 struct {
+	BCV       SourceRate   `json:"bcv"`
+	Parallel  ParallelRate `json:"parallel"`
+	Breach    *float64     `json:"breach,omitempty"`
+	UpdatedAt time.Time    `json:"updatedAt,omitzero"`
+	Epoch     int64        `json:"updatedAtEpoch,omitzero"`
+	Warnings  []Warning    `json:"warnings,omitempty"`
+
+	// Status tells whether the BCV and parallel rates have been fetched,
+	// as in RateData.
+	Status string `json:"status"`
+
+	// Zelle is the rate of dollars sent through Zelle, when enabled. It's
+	// derived from Binance, so it isn't part of the parallel rate.
+	Zelle *SourceRate `json:"zelle,omitempty"`
+
+	// Cash is the street cash-dollar ("efectivo") rate, when configured or
+	// entered. It's compared with, not part of, the parallel rate.
+	Cash *SourceRate `json:"efectivo,omitempty"`
+
+	// Region is the region the parallel rate was sampled from, if not the
+	// headline sources.
+	Region *Region `json:"region,omitempty"`
+}
+
+// computeParallel derives the headline parallel rate. With at least
+// consensusMinSources fresh sources, sources whose robust z-score (based on
+// the median absolute deviation, at least minSpread of the median) exceeds
+// outlierThreshold are rejected and the median of the remaining ones is used. Each source's deviation from the
+// headline rate is reported in percent.
+func computeParallel(sources []SourceRate, now time.Time) ParallelRate {
+	var fresh []float64
+	for i := range sources {
+		if sources[i].Rate <= 0 || now.Sub(sources[i].UpdatedAt) > consensusMaxAge {
+			sources[i].Stale = true
+			continue
+		}
+		if sources[i].Fallback != "" {
+			continue
+		}
+		fresh = append(fresh, sources[i].Rate)
+	}
+
+	result := ParallelRate{Sources: sources}
+
+	switch {
+	case len(fresh) == 0:
+		// Fall back to the first source with any value at all
+		for _, src := range sources {
+			if src.Rate > 0 {
+				result.Rate = src.Rate
+				result.Method = MethodSingle
+				break
+			}
+		}
+		return result
+
+	case len(fresh) == 1:
+		result.Rate = fresh[0]
+		result.Method = MethodSingle
+
+	case len(fresh) < consensusMinSources:
+		result.Rate = stats.Median(fresh)
+		result.Method = MethodMedian
+
+	default:
+		m := stats.Median(fresh)
+		mad := max(stats.MAD(fresh)*madScale, m*minSpread)
+
+		var accepted []float64
+		for i := range sources {
+			if sources[i].Stale || sources[i].Fallback != "" {
+				continue
+			}
+			d := math.Abs(sources[i].Rate - m)
+			if d/mad > outlierThreshold {
+				sources[i].Outlier = true
+				continue
+			}
+			accepted = append(accepted, sources[i].Rate)
+		}
+
+		result.Rate = stats.Median(accepted)
+		result.Method = MethodConsensus
+	}
+
+	for i := range sources {
+		if sources[i].Rate > 0 && result.Rate > 0 {
+			sources[i].Deviation = variation(result.Rate, sources[i].Rate)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,36 @@
+package rates
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeParallelOutliers(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		rates    []float64
+		outliers []bool
+	}{
+		{"identical but one a cent off", []float64{60, 60, 60, 60.01}, []bool{false, false, false, false}},
+		{"identical but one 2% off", []float64{60, 60, 60, 61.2}, []bool{false, false, false, true}},
+		{"spread out", []float64{58, 60, 62, 90}, []bool{false, false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sources []SourceRate
+			for _, rate := range tt.rates {
+				sources = append(sources, SourceRate{Rate: rate, UpdatedAt: now})
+			}
+			p := computeParallel(sources, now)
+			if p.Method != MethodConsensus {
+				t.Fatalf("method = %q, want %q", p.Method, MethodConsensus)
+			}
+			for i, src := range p.Sources {
+				if src.Outlier != tt.outliers[i] {
+					t.Errorf("source at %v: outlier = %v, want %v", src.Rate, src.Outlier, tt.outliers[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Conversion errors.
+var (
+	ErrUnknownCurrency   = errors.New("unknown currency")
+	ErrUnknownSource     = errors.New("unknown rate source")
+	ErrRateUnavailable   = errors.New("rate not available")
+	ErrInvalidDate       = errors.New("invalid date")
+	ErrNoHistoricalRate  = errors.New("no historical rate for date")
+	ErrInvalidAmount     = errors.New("invalid amount")
+	errUnsupportedSource = fmt.Errorf("%w: use bcv or binance", ErrUnknownSource)
+)
+
+// Rate provenance values.
+const (
+	ProvenanceLive       = "live"
+	ProvenanceDailyClose = "daily_close"
+)
+
+// ConversionRequest describes a currency conversion.
+type ConversionRequest struct {
+	Amount float64
+	From   string
+	Source string
+	// Date is an optional YYYY-MM-DD date resolved against stored history.
+	Date string
+	// Precision rounds the result. The zero value uses ConversionPrecision.
+	Precision Precision
+}
+
+// Conversion is the result of a currency conversion.
+type Conversion struct {
+	Amount     float64   `json:"amount"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Result     float64   `json:"result"`
+	Source     string    `json:"source"`
+	Rate       float64   `json:"rate"`
+	Date       string    `json:"date,omitempty"`
+	RateDate   string    `json:"rateDate"`
+	Provenance string    `json:"provenance"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	Epoch      int64     `json:"updatedAtEpoch"`
+}
+
+// Convert converts an amount between USD and VES at the requested source rate.
+// When a date is given, the rate is taken from the most recent daily close on
+// or before that date.
+func (s *Service) Convert(req ConversionRequest) (Conversion, error) {
+	if req.Amount < 0 || math.IsNaN(req.Amount) || math.IsInf(req.Amount, 0) {
+		return Conversion{}, ErrInvalidAmount
+	}
+
+	from := strings.ToUpper(req.From)
+	if from == "" {
+		from = "USD"
+	}
+	var to string
+	switch from {
+	case "USD":
+		to = "VES"
+	case "VES":
+		to = "USD"
+	default:
+		return Conversion{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, req.From)
+	}
+
+	source := strings.ToLower(req.Source)
+	if source == "" {
+		source = "bcv"
+	}
+	if source != "bcv" && source != "binance" {
+		return Conversion{}, errUnsupportedSource
+	}
+
+	conv := Conversion{
+		Amount: req.Amount,
+		From:   from,
+		To:     to,
+		Source: source,
+		Date:   req.Date,
+	}
+
+	today := dayKey(time.Now())
+	if req.Date == "" || req.Date == today {
+		current := s.store.GetRateData()
+		conv.Rate = pickRate(source, current.BCV, current.Binance)
+		conv.RateDate = dayKey(current.UpdatedAt)
+		conv.Provenance = ProvenanceLive
+		conv.UpdatedAt = current.UpdatedAt
+	} else {
+		dailyClose, err := s.closeOnOrBefore(req.Date)
+		if err != nil {
+			return Conversion{}, err
+		}
+		conv.Rate = pickRate(source, dailyClose.BCV, dailyClose.Binance)
+		conv.RateDate = dailyClose.Date
+		conv.Provenance = ProvenanceDailyClose
+		conv.UpdatedAt = dailyClose.ClosedAt
+	}
+
+	if conv.Rate <= 0 {
+		return Conversion{}, fmt.Errorf("%w: %s", ErrRateUnavailable, source)
+	}
+
+	precision := req.Precision
+	if precision == (Precision{}) {
+		precision = ConversionPrecision
+	}
+	if from == "USD" {
+		conv.Result = precision.Round(req.Amount * conv.Rate)
+	} else {
+		conv.Result = precision.Round(req.Amount / conv.Rate)
+	}
+
+	conv.Epoch = unixSeconds(conv.UpdatedAt)
+	return conv, nil
+}
+
+// closeOnOrBefore returns the most recent daily close on or before the given date.
+func (s *Service) closeOnOrBefore(date string) (DailyClose, error) {
+	requested, err := time.ParseInLocation("2006-01-02", date, Venezuela)
+	if err != nil {
+		return DailyClose{}, fmt.Errorf("%w: %s (expected YYYY-MM-DD)", ErrInvalidDate, date)
+	}
+	if requested.After(time.Now()) {
+		return DailyClose{}, fmt.Errorf("%w: %s is in the future", ErrInvalidDate, date)
+	}
+
+	closes, err := s.dailyCloses()
+	if err != nil {
+		return DailyClose{}, err
+	}
+
+	// Closes are ordered by date, so walk backwards to the first match
+	for i := len(closes) - 1; i >= 0; i-- {
+		if closes[i].Date <= date {
+			return closes[i], nil
+		}
+	}
+
+	return DailyClose{}, fmt.Errorf("%w: %s", ErrNoHistoricalRate, date)
+}
+
+// pickRate returns the rate for the named source.
+func pickRate(source string, bcv, binance float64) float64 {
+	if source == "binance" {
+		return binance
+	}
+	return bcv
+}
+
+// roundTo rounds a value to the given number of decimal places.
+func roundTo(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
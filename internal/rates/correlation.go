@@ -0,0 +1,115 @@
+package rates
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/veswatch/api/internal/stats"
+)
+
+// Correlation limits.
+const (
+	// maxLagDays is the longest lag tested between the parallel rate and
+	// the BCV rate.
+	maxLagDays = 14
+
+	// minCorrelationCloses is the fewest closes with both rates that
+	// statistics are computed from.
+	minCorrelationCloses = 10
+)
+
+// Correlation describes how the BCV rate tracks the parallel (Binance) rate
+// over a range of daily closes.
+type Correlation struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Days int    `json:"days"`
+
+	// Correlation is the Pearson correlation of the two rates' closes.
+	Correlation float64 `json:"correlation"`
+
+	// ChangeCorrelation is the Pearson correlation of the rates' daily
+	// percent changes, which isn't inflated by their shared long-run trend.
+	ChangeCorrelation float64 `json:"changeCorrelation"`
+
+	// LagDays is how many days the BCV rate's moves trail the parallel
+	// rate's, the lag (up to two weeks) where their daily changes correlate
+	// best. LagCorrelation is the correlation at that lag.
+	LagDays        int     `json:"lagDays"`
+	LagCorrelation float64 `json:"lagCorrelation"`
+
+	// AverageGap is the average difference between the rates in bolívares,
+	// and AverageBreach the same in percent of the BCV rate.
+	AverageGap    float64 `json:"averageGap"`
+	AverageBreach float64 `json:"averageBreach"`
+}
+
+// Correlation computes how the BCV rate tracks the parallel rate over the
+// daily closes between from and to (YYYY-MM-DD, inclusive; empty for no
+// bound).
+func (s *Service) Correlation(from, to string) (Correlation, error) {
+	return query(s.queries, queryKey("correlation", from, to), func() (Correlation, error) {
+		return s.correlation(strings.TrimSpace(from), strings.TrimSpace(to))
+	})
+}
+
+func (s *Service) correlation(from, to string) (Correlation, error) {
+	closes, err := s.dailyCloses()
+	if err != nil {
+		return Correlation{}, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	var window []DailyClose
+	for _, c := range closes {
+		if (from == "" || c.Date >= from) && (to == "" || c.Date <= to) && c.BCV > 0 && c.Binance > 0 {
+			window = append(window, c)
+		}
+	}
+	if len(window) < minCorrelationCloses {
+		return Correlation{}, fmt.Errorf("%w: %d closes with both rates, need %d", ErrNotEnoughHistory, len(window), minCorrelationCloses)
+	}
+
+	bcv := make([]float64, len(window))
+	binance := make([]float64, len(window))
+	var gap, breach float64
+	for i, c := range window {
+		bcv[i], binance[i] = c.BCV, c.Binance
+		gap += c.Binance - c.BCV
+		breach += c.Breach
+	}
+	bcvChanges, binanceChanges := changes(bcv), changes(binance)
+
+	n := float64(len(window))
+	result := Correlation{
+		From:              window[0].Date,
+		To:                window[len(window)-1].Date,
+		Days:              len(window),
+		Correlation:       roundTo(stats.Pearson(bcv, binance), 3),
+		ChangeCorrelation: roundTo(stats.Pearson(bcvChanges, binanceChanges), 3),
+		AverageGap:        roundTo(gap/n, 2),
+		AverageBreach:     roundTo(breach/n, 2),
+	}
+
+	// Compare each BCV change with the parallel change lag days earlier
+	best := math.Inf(-1)
+	for lag := 0; lag <= maxLagDays && len(bcvChanges)-lag >= minCorrelationCloses/2; lag++ {
+		r := stats.Pearson(bcvChanges[lag:], binanceChanges[:len(binanceChanges)-lag])
+		if r > best {
+			best = r
+			result.LagDays = lag
+		}
+	}
+	result.LagCorrelation = roundTo(best, 3)
+
+	return result, nil
+}
+
+// changes returns the series' day-over-day percent changes.
+func changes(series []float64) []float64 {
+	out := make([]float64, 0, len(series)-1)
+	for i := 1; i < len(series); i++ {
+		out = append(out, (series[i]/series[i-1]-1)*100)
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Downsampling aggregations.
+const (
+	AggAvg  = "avg"
+	AggLast = "last"
+	AggOHLC = "ohlc"
+)
+
+// Intervals lists the downsampling intervals by name.
+var Intervals = map[string]time.Duration{
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// Downsampling errors.
+var (
+	ErrUnknownInterval    = errors.New("unknown interval")
+	ErrUnknownAggregation = errors.New("unknown aggregation")
+	ErrNoSamples          = errors.New("intraday samples are not recorded")
+)
+
+// Bucket aggregates the rates of one source over an interval starting at
+// Time. Value is set for avg and last, Open to Close for ohlc.
+type Bucket struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Value   float64   `json:"value,omitempty"`
+	Open    float64   `json:"open,omitempty"`
+	High    float64   `json:"high,omitempty"`
+	Low     float64   `json:"low,omitempty"`
+	Close   float64   `json:"close,omitempty"`
+	Samples int       `json:"samples"`
+}
+
+// SampleStore aggregates every accepted rate, as recorded by a time-series
+// sink, into buckets ordered by time and source. Daily buckets start at
+// midnight in Venezuela.
+type SampleStore interface {
+	Downsample(ctx context.Context, from, to time.Time, interval time.Duration, agg string) ([]Bucket, error)
+}
+
+// SetSamples sets the store downsampled history is read from. Without one,
+// only daily buckets are available, taken from the daily closes.
+func (s *Service) SetSamples(samples SampleStore) {
+	s.samples = samples
+}
+
+// Downsample aggregates the rates between from and to (YYYY-MM-DD,
+// inclusive, either of which may be empty for no bound) into buckets of
+// the named interval.
+func (s *Service) Downsample(ctx context.Context, from, to, interval, agg string) ([]Bucket, error) {
+	step, ok := Intervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("%w %q (expected 5m, 1h or 1d)", ErrUnknownInterval, interval)
+	}
+	if agg != AggAvg && agg != AggLast && agg != AggOHLC {
+		return nil, fmt.Errorf("%w %q (expected %q, %q or %q)", ErrUnknownAggregation, agg, AggAvg, AggLast, AggOHLC)
+	}
+
+	start, end, err := dayRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.samples == nil {
+		if step != Intervals["1d"] || agg == AggOHLC {
+			return nil, fmt.Errorf("%w: only interval 1d with agg avg or last is available", ErrNoSamples)
+		}
+		return s.dailyBuckets(from, to)
+	}
+	buckets, err := s.samples.Downsample(ctx, start, end, step, agg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range buckets {
+		buckets[i].Time = buckets[i].Time.In(Venezuela)
+	}
+	return buckets, nil
+}
+
+// dailyBuckets turns the daily closes into daily buckets, each holding the
+// close of the BCV and Binance rates.
+func (s *Service) dailyBuckets(from, to string) ([]Bucket, error) {
+	closes, err := s.QueryDailyCloses(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]Bucket, 0, 2*len(closes))
+	for _, c := range closes {
+		day, err := time.ParseInLocation("2006-01-02", c.Date, Venezuela)
+		if err != nil {
+			continue
+		}
+		for _, r := range []struct {
+			source string
+			rate   float64
+		}{{"bcv", c.BCV}, {"binance", c.Binance}} {
+			if r.rate > 0 {
+				buckets = append(buckets, Bucket{Time: day, Source: r.source, Value: r.rate, Samples: 1})
+			}
+		}
+	}
+	return buckets, nil
+}
+
+// dayRange returns the span from the start of day from to the end of day
+// to in Venezuela. A missing from is the zero time, a missing to now.
+func dayRange(from, to string) (time.Time, time.Time, error) {
+	var start time.Time
+	end := time.Now()
+	if from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, Venezuela)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %s (expected YYYY-MM-DD)", ErrInvalidDate, from)
+		}
+		start = t
+	}
+	if to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, Venezuela)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %s (expected YYYY-MM-DD)", ErrInvalidDate, to)
+		}
+		end = t.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
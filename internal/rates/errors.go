@@ -0,0 +1,119 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/veswatch/api/internal/metrics"
+)
+
+// ErrorKind classifies why a source fetch failed.
+type ErrorKind string
+
+// Error kinds.
+const (
+	// ErrorNetwork is a failed connection, timeout or server error.
+	ErrorNetwork ErrorKind = "network"
+
+	// ErrorBlocked is a request refused by the source or stopped before
+	// sending: an anti-bot challenge, a 403/429 (e.g. geo-blocking) or the
+	// outbound budget.
+	ErrorBlocked ErrorKind = "blocked"
+
+	// ErrorParse is a response that couldn't be read, typically because the
+	// source changed its markup or format.
+	ErrorParse ErrorKind = "parse"
+
+	// ErrorNotFound is a missing page or a response without any rates.
+	ErrorNotFound ErrorKind = "not_found"
+
+	// ErrorRejected is a rate that failed validation.
+	ErrorRejected ErrorKind = "rejected"
+
+	// ErrorUnknown is any other failure.
+	ErrorUnknown ErrorKind = "unknown"
+)
+
+// errorKinds lists every kind, in metrics order.
+var errorKinds = []ErrorKind{ErrorNetwork, ErrorBlocked, ErrorParse, ErrorNotFound, ErrorRejected, ErrorUnknown}
+
+// maxErrorMessage caps stored error messages, which can include a
+// source's response body.
+const maxErrorMessage = 256
+
+// SourceError is the most recent failed fetch of a source.
+type SourceError struct {
+	Source  string    `json:"source"`
+	Kind    ErrorKind `json:"kind"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// newSourceError records a failed fetch.
+func newSourceError(source string, err error, at time.Time) *SourceError {
+	msg := err.Error()
+	if runes := []rune(msg); len(runes) > maxErrorMessage {
+		msg = string(runes[:maxErrorMessage]) + "…"
+	}
+	return &SourceError{Source: source, Kind: ClassifyError(err), Message: msg, Time: at}
+}
+
+// Warning flags a source whose current value may be outdated because its
+// latest fetch failed.
+type Warning struct {
+	Source  string    `json:"source"`
+	Kind    ErrorKind `json:"kind"`
+	Since   time.Time `json:"since"`
+	Message string    `json:"message"`
+}
+
+// newWarning creates the warning for a source error.
+func newWarning(e SourceError) Warning {
+	return Warning{
+		Source:  e.Source,
+		Kind:    e.Kind,
+		Since:   e.Time,
+		Message: fmt.Sprintf("%s data may be outdated: the latest update failed (%s)", e.Source, e.Kind),
+	}
+}
+
+// kindedError is implemented by scraper errors that carry a classification.
+type kindedError interface {
+	error
+	ErrorKind() ErrorKind
+}
+
+// ClassifyError returns the kind of a fetch error, or "" for nil.
+func ClassifyError(err error) ErrorKind {
+	var kinded kindedError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrRateRejected):
+		return ErrorRejected
+	case errors.Is(err, ErrChallenge), errors.Is(err, ErrBackingOff):
+		return ErrorBlocked
+	case errors.As(err, &kinded):
+		return kinded.ErrorKind()
+	default:
+		return ErrorUnknown
+	}
+}
+
+// Collect writes per-source fetch metrics.
+func (s *Service) Collect(w *metrics.Writer) {
+	statuses := s.Status()
+
+	w.Family("veswatch_source_fetches_total", metrics.Counter, "Fetches attempted from a rate source.")
+	for _, st := range statuses {
+		w.Sample("veswatch_source_fetches_total", float64(st.Fetches), "source", st.Name)
+	}
+	w.Family("veswatch_source_fetch_failures_total", metrics.Counter, "Failed fetches from a rate source, by error kind.")
+	for _, st := range statuses {
+		for _, kind := range errorKinds {
+			w.Sample("veswatch_source_fetch_failures_total", float64(st.Failures[kind]), "source", st.Name, "kind", string(kind))
+		}
+	}
+	collectEscalations(w, s.Escalations())
+}
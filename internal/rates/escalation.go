@@ -0,0 +1,212 @@
+package rates
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/metrics"
+	"github.com/veswatch/api/internal/notify"
+)
+
+// Escalation kinds.
+const (
+	// EscalationBCVUnchanged is a BCV rate that hasn't changed for more
+	// business days than allowed.
+	EscalationBCVUnchanged = "bcv_unchanged"
+	// EscalationBinanceFailing is a Binance job that has failed for more
+	// consecutive runs than allowed.
+	EscalationBinanceFailing = "binance_failing"
+)
+
+// escalationKinds lists every kind, in metrics order.
+var escalationKinds = []string{EscalationBCVUnchanged, EscalationBinanceFailing}
+
+// Escalation flags a missed update: data consumers keep receiving although
+// it stopped being refreshed.
+type Escalation struct {
+	Kind    string    `json:"kind"`
+	Source  string    `json:"source"`
+	Since   time.Time `json:"since"`
+	Message string    `json:"message"`
+}
+
+// escalationState tracks missed updates and which escalations were
+// announced.
+type escalationState struct {
+	mu sync.Mutex
+
+	// Thresholds; zero disables the check
+	bcvDays         int
+	binanceFailures int
+
+	// Consecutive failed Binance runs, and when the first of them ran
+	binanceFailed       int
+	binanceFailingSince time.Time
+
+	active map[string]bool
+}
+
+// Default escalation thresholds.
+const (
+	defaultEscalationBCVDays         = 2
+	defaultEscalationBinanceFailures = 6
+)
+
+// SetEscalation sets when missed updates escalate: after the BCV rate stays
+// unchanged for more than bcvDays business days, or the Binance job fails
+// more than binanceFailures consecutive runs. Zero disables a check.
+func (s *Service) SetEscalation(bcvDays, binanceFailures int) {
+	s.escalation.mu.Lock()
+	defer s.escalation.mu.Unlock()
+	s.escalation.bcvDays = bcvDays
+	s.escalation.binanceFailures = binanceFailures
+}
+
+// recordBinanceRun counts consecutive failed Binance runs, including those
+// skipped while backing off or served by a fallback.
+func (s *Service) recordBinanceRun(err error) {
+	s.escalation.mu.Lock()
+	defer s.escalation.mu.Unlock()
+
+	switch {
+	case err == nil:
+		s.escalation.binanceFailed = 0
+		s.escalation.binanceFailingSince = time.Time{}
+	case s.escalation.binanceFailed == 0:
+		s.escalation.binanceFailed = 1
+		s.escalation.binanceFailingSince = time.Now()
+	default:
+		s.escalation.binanceFailed++
+	}
+}
+
+// Escalations returns the current missed updates. The instance is degraded
+// while there are any.
+func (s *Service) Escalations() []Escalation {
+	s.escalation.mu.Lock()
+	bcvDays, binanceFailures := s.escalation.bcvDays, s.escalation.binanceFailures
+	failed, failingSince := s.escalation.binanceFailed, s.escalation.binanceFailingSince
+	s.escalation.mu.Unlock()
+
+	var escalations []Escalation
+	if bcvDays > 0 {
+		if since, days := s.bcvUnchanged(time.Now()); days > bcvDays {
+			escalations = append(escalations, Escalation{
+				Kind:    EscalationBCVUnchanged,
+				Source:  "bcv",
+				Since:   since,
+				Message: fmt.Sprintf("BCV rate unchanged for %d business days", days),
+			})
+		}
+	}
+	if binanceFailures > 0 && failed > binanceFailures {
+		escalations = append(escalations, Escalation{
+			Kind:    EscalationBinanceFailing,
+			Source:  "binance",
+			Since:   failingSince,
+			Message: fmt.Sprintf("Binance job failed %d consecutive runs", failed),
+		})
+	}
+	return escalations
+}
+
+// bcvUnchanged returns when the daily closes first recorded the current
+// BCV rate, and the business days from then until the day before now. The
+// day of the change and the current day, whose update may be pending,
+// aren't counted.
+func (s *Service) bcvUnchanged(now time.Time) (time.Time, int) {
+	current := s.store.GetBCV()
+	closes, err := s.dailyCloses()
+	if current <= 0 || err != nil {
+		return time.Time{}, 0
+	}
+
+	// The closes holding the current rate, back to the last change
+	first := len(closes)
+	for first > 0 && closes[first-1].BCV == current {
+		first--
+	}
+	if first == len(closes) {
+		return time.Time{}, 0
+	}
+
+	since, err := time.ParseInLocation("2006-01-02", closes[first].Date, Venezuela)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return closes[first].ClosedAt, businessDaysBetween(since, now.In(Venezuela))
+}
+
+// businessDaysBetween counts the weekdays strictly between the calendar
+// days of from and to.
+func businessDaysBetween(from, to time.Time) int {
+	days := 0
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location())
+	for d := time.Date(from.Year(), from.Month(), from.Day()+1, 0, 0, 0, 0, from.Location()); d.Before(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			days++
+		}
+	}
+	return days
+}
+
+// checkEscalations emits an escalation event for each missed update that
+// wasn't already announced, and logs those that cleared.
+func (s *Service) checkEscalations() {
+	escalations := s.Escalations()
+
+	s.escalation.mu.Lock()
+	defer s.escalation.mu.Unlock()
+
+	active := make(map[string]bool, len(escalations))
+	for _, e := range escalations {
+		active[e.Kind] = true
+		if s.escalation.active[e.Kind] {
+			continue
+		}
+		log.Printf("Escalation: %s", e.Message)
+		if s.publisher != nil {
+			s.publisher.Publish(notify.Event{
+				Type:    notify.EventEscalation,
+				Message: escalationMessage(e),
+				Data:    e,
+			})
+		}
+	}
+	for kind := range s.escalation.active {
+		if !active[kind] {
+			log.Printf("Escalation cleared: %s", kind)
+		}
+	}
+	s.escalation.active = active
+}
+
+// escalationMessage describes an escalation for notifications.
+func escalationMessage(e Escalation) string {
+	switch e.Kind {
+	case EscalationBCVUnchanged:
+		return fmt.Sprintf("La tasa BCV no cambia desde el %s: los consumidores reciben un valor posiblemente desactualizado",
+			e.Since.In(Venezuela).Format("02/01/2006"))
+	case EscalationBinanceFailing:
+		return fmt.Sprintf("Binance no se actualiza desde las %s: las últimas ejecuciones fallaron",
+			e.Since.In(Venezuela).Format("02/01/2006 15:04"))
+	default:
+		return e.Message
+	}
+}
+
+// collectEscalations writes whether each kind of missed update escalated.
+func collectEscalations(w *metrics.Writer, escalations []Escalation) {
+	w.Family("veswatch_escalated", metrics.Gauge, "Whether a missed update escalated, by kind.")
+	for _, kind := range escalationKinds {
+		escalated := slices.ContainsFunc(escalations, func(e Escalation) bool { return e.Kind == kind })
+		value := 0.0
+		if escalated {
+			value = 1
+		}
+		w.Sample("veswatch_escalated", value, "kind", kind)
+	}
+}
@@ -0,0 +1,74 @@
+package rates
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// SetBinanceFallbacks sets the parallel sources, in order, that supply the
+// Binance rate while Binance fails. Names must have been registered with
+// AddParallelSource; unknown ones are ignored.
+func (s *Service) SetBinanceFallbacks(names []string) {
+	s.binanceFallbacks = nil
+	for _, name := range names {
+		if _, ok := s.parallelSources[name]; !ok {
+			log.Printf("Ignoring unknown Binance fallback source %q", name)
+			continue
+		}
+		s.binanceFallbacks = append(s.binanceFallbacks, name)
+	}
+}
+
+// fallbackBinance fills the Binance rate from the first fallback source with
+// a fresh rate, fetching it if the last one is older than consensusMaxAge.
+// It reports whether a fallback supplied the rate; a pinned Binance rate is
+// never replaced.
+func (s *Service) fallbackBinance(ctx context.Context) bool {
+	if _, pinned := s.store.pinnedUntil("binance"); pinned {
+		return false
+	}
+	for _, name := range s.binanceFallbacks {
+		rate, ok := s.fallbackRate(ctx, name)
+		if !ok {
+			continue
+		}
+		if err := s.checkUpdate("binance", s.store.GetBinance(), rate); err != nil {
+			log.Printf("Binance fallback %s rejected: %v", name, err)
+			continue
+		}
+
+		s.store.SetBinanceFallback(name, rate)
+		s.record("binance", rate)
+		log.Printf("Binance rate updated from fallback %s: %.2f", name, rate)
+		s.evaluateAlerts()
+		return true
+	}
+	return false
+}
+
+// fallbackRate returns a fallback source's rate, fetching it unless it was
+// updated recently or is pinned.
+func (s *Service) fallbackRate(ctx context.Context, name string) (float64, bool) {
+	v := s.store.parallelValue(name)
+	if _, pinned := s.store.pinnedUntil(name); v.rate > 0 && (pinned || time.Since(v.at) <= consensusMaxAge) {
+		return v.rate, true
+	}
+	if err := s.checkBackoff(name); err != nil {
+		return 0, false
+	}
+
+	sample, err := fetchSample(ctx, s.parallelSources[name])
+	if err == nil {
+		err = s.checkUpdate(name, v.rate, sample.Rate)
+	}
+	s.recordFetch(name, err, sample)
+	if err != nil {
+		log.Printf("Binance fallback %s fetch error (%s): %v", name, ClassifyError(err), err)
+		return 0, false
+	}
+
+	s.store.SetParallel(name, sample.Rate)
+	s.record(name, sample.Rate)
+	return sample.Rate, true
+}
@@ -0,0 +1,111 @@
+package rates
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxFetchRecords is the number of recent fetches kept per source.
+const maxFetchRecords = 1000
+
+// Transfer is what a fetch received over HTTP: the status of the last
+// response and the body bytes read.
+type Transfer struct {
+	Status int
+	Bytes  int64
+}
+
+// MeteredScraper is implemented by scrapers that measure their HTTP
+// responses. Transfer returns what was received since it was last called.
+type MeteredScraper interface {
+	Transfer() Transfer
+}
+
+// FetchRecord describes a fetch from a source, successful or not, so a
+// source getting slower or returning fewer quotes shows before it fails.
+type FetchRecord struct {
+	Time       time.Time `json:"time"`
+	Source     string    `json:"source"`
+	DurationMs float64   `json:"durationMs"`
+	Status     int       `json:"status,omitempty"`
+	Bytes      int64     `json:"bytes,omitempty"`
+	SampleSize int       `json:"sampleSize,omitempty"`
+
+	// Error is the kind of the fetch's failure, if it failed.
+	Error ErrorKind `json:"error,omitempty"`
+}
+
+// fetchLog keeps the recent fetches of every source, in memory.
+type fetchLog struct {
+	mu      sync.RWMutex
+	sources map[string][]FetchRecord // oldest first
+}
+
+// newFetchLog creates an empty fetch log.
+func newFetchLog() *fetchLog {
+	return &fetchLog{sources: make(map[string][]FetchRecord)}
+}
+
+// add records a fetch, dropping the source's oldest beyond maxFetchRecords.
+func (l *fetchLog) add(rec FetchRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records := l.sources[rec.Source]
+	if len(records) >= maxFetchRecords {
+		records = slices.Delete(records, 0, len(records)-maxFetchRecords+1)
+	}
+	l.sources[rec.Source] = append(records, rec)
+}
+
+// list returns the most recent fetches of a source, or of every source if
+// empty, newest first. A limit of 0 returns all of them.
+func (l *fetchLog) list(source string, limit int) []FetchRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var records []FetchRecord
+	if source != "" {
+		records = slices.Clone(l.sources[source])
+	} else {
+		for _, recs := range l.sources {
+			records = append(records, recs...)
+		}
+		slices.SortStableFunc(records, func(a, b FetchRecord) int {
+			return a.Time.Compare(b.Time)
+		})
+	}
+	slices.Reverse(records)
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records
+}
+
+// Fetches returns the most recent fetches of a source, or of every source
+// if empty, newest first, up to limit (all if 0).
+func (s *Service) Fetches(source string, limit int) ([]FetchRecord, error) {
+	if source != "" && !slices.Contains(s.sourceNames(), source) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownSource, source)
+	}
+	records := s.fetches.list(source, limit)
+	if records == nil {
+		records = []FetchRecord{}
+	}
+	return records, nil
+}
+
+// logFetch adds a fetch's measurements to the fetch log.
+func (s *Service) logFetch(name string, err error, sample Sample) {
+	s.fetches.add(FetchRecord{
+		Time:       sample.fetchedAt,
+		Source:     name,
+		DurationMs: roundTo(float64(sample.took)/float64(time.Millisecond), 1),
+		Status:     sample.transfer.Status,
+		Bytes:      sample.transfer.Bytes,
+		SampleSize: len(sample.Values),
+		Error:      ClassifyError(err),
+	})
+}
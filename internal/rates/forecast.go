@@ -0,0 +1,171 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Forecast models.
+const (
+	ModelLinear = "linear"
+	ModelEWMA   = "ewma"
+)
+
+// Forecast limits.
+const (
+	// MaxForecastDays is the furthest a forecast projects.
+	MaxForecastDays = 7
+
+	// forecastWindow is how many recent closes the models are fitted to.
+	forecastWindow = 30
+
+	// minForecastCloses is the fewest closes a forecast is made from.
+	minForecastCloses = 7
+
+	// ewmaAlpha weighs the latest close in the EWMA model's level and trend.
+	ewmaAlpha = 0.3
+
+	// flatTrend is the projected change, in percent, below which the trend
+	// is reported as flat.
+	flatTrend = 0.1
+)
+
+// Forecast errors.
+var (
+	ErrUnknownModel     = errors.New("unknown forecast model")
+	ErrInvalidHorizon   = errors.New("invalid forecast horizon")
+	ErrNotEnoughHistory = errors.New("not enough history")
+)
+
+// forecastModels fit each model to a series, returning the smoothed value at
+// the last point and the slope per day.
+var forecastModels = map[string]func([]float64) (float64, float64){
+	ModelLinear: fitLinear,
+	ModelEWMA:   fitEWMA,
+}
+
+// ForecastPoint is a projected daily close.
+type ForecastPoint struct {
+	Date string  `json:"date"`
+	Rate float64 `json:"rate"`
+}
+
+// Forecast projects the parallel (Binance) daily close a few days ahead.
+// It's a naive statistical extrapolation of recent history, meant for trend
+// indicators, not a prediction.
+type Forecast struct {
+	Experimental bool            `json:"experimental"`
+	Source       string          `json:"source"`
+	Model        string          `json:"model"`
+	Basis        int             `json:"basis"`
+	Last         ForecastPoint   `json:"last"`
+	Points       []ForecastPoint `json:"points"`
+
+	// Trend is the direction of the fitted model over the horizon: "up",
+	// "down" or "flat".
+	Trend string `json:"trend"`
+}
+
+// Forecast projects the parallel rate days ahead with the given model, fitted
+// to the most recent daily closes.
+func (s *Service) Forecast(model string, days int) (Forecast, error) {
+	return query(s.queries, queryKey("forecast", model, strconv.Itoa(days)), func() (Forecast, error) {
+		return s.forecast(model, days)
+	})
+}
+
+func (s *Service) forecast(model string, days int) (Forecast, error) {
+	fit, ok := forecastModels[model]
+	if !ok {
+		return Forecast{}, fmt.Errorf("%w %q (expected %q or %q)", ErrUnknownModel, model, ModelLinear, ModelEWMA)
+	}
+	if days < 1 || days > MaxForecastDays {
+		return Forecast{}, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidHorizon, MaxForecastDays)
+	}
+
+	closes, err := s.dailyCloses()
+	if err != nil {
+		return Forecast{}, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	var dates []string
+	var series []float64
+	for _, c := range closes {
+		if c.Binance > 0 {
+			dates = append(dates, c.Date)
+			series = append(series, c.Binance)
+		}
+	}
+	if len(series) > forecastWindow {
+		dates = dates[len(dates)-forecastWindow:]
+		series = series[len(series)-forecastWindow:]
+	}
+	if len(series) < minForecastCloses {
+		return Forecast{}, fmt.Errorf("%w: %d closes, need %d", ErrNotEnoughHistory, len(series), minForecastCloses)
+	}
+
+	last, err := time.Parse("2006-01-02", dates[len(dates)-1])
+	if err != nil {
+		return Forecast{}, fmt.Errorf("invalid close date: %w", err)
+	}
+
+	level, slope := fit(series)
+	f := Forecast{
+		Experimental: true,
+		Source:       "binance",
+		Model:        model,
+		Basis:        len(series),
+		Last:         ForecastPoint{Date: dates[len(dates)-1], Rate: series[len(series)-1]},
+		Points:       make([]ForecastPoint, 0, days),
+	}
+	for i := 1; i <= days; i++ {
+		f.Points = append(f.Points, ForecastPoint{
+			Date: last.AddDate(0, 0, i).Format("2006-01-02"),
+			Rate: roundTo(level+slope*float64(i), 2),
+		})
+	}
+
+	change := slope * float64(days) / level * 100
+	switch {
+	case change > flatTrend:
+		f.Trend = "up"
+	case change < -flatTrend:
+		f.Trend = "down"
+	default:
+		f.Trend = "flat"
+	}
+	return f, nil
+}
+
+// fitLinear fits a least-squares line to the series and returns its value at
+// the last point and its slope per day.
+func fitLinear(series []float64) (float64, float64) {
+	n := float64(len(series))
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range series {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / n
+	return intercept + slope*(n-1), slope
+}
+
+// fitEWMA smooths the series and its daily changes with exponentially
+// weighted moving averages (Holt's linear method) and returns the smoothed
+// level and trend.
+func fitEWMA(series []float64) (float64, float64) {
+	level, trend := series[0], series[1]-series[0]
+	for _, y := range series[1:] {
+		prev := level
+		level = ewmaAlpha*y + (1-ewmaAlpha)*(level+trend)
+		trend = ewmaAlpha*(level-prev) + (1-ewmaAlpha)*trend
+	}
+	return level, trend
+}
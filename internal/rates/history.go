@@ -0,0 +1,69 @@
+package rates
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/pkg/events"
+)
+
+// DailyClose represents the "cierre del día" snapshot for a single day. It
+// is also the payload of daily_close events.
+type DailyClose = events.DailyClose
+
+// History defines the interface for persisting daily close records.
+type History interface {
+	SaveDailyClose(dailyClose DailyClose) error
+	DailyCloses() ([]DailyClose, error)
+}
+
+// EventHistory is implemented by histories that can store a daily close
+// together with the event announcing it, for the named notifiers, in the
+// publisher's outbox, so a crash can't keep one without the other.
+type EventHistory interface {
+	SaveDailyCloseEvent(dailyClose DailyClose, event notify.Event, notifiers []string) (uint64, error)
+}
+
+// MemoryHistory keeps daily close records in memory.
+type MemoryHistory struct {
+	mu     sync.RWMutex
+	closes map[string]DailyClose
+}
+
+// NewMemoryHistory creates a new in-memory history.
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{
+		closes: make(map[string]DailyClose),
+	}
+}
+
+// SaveDailyClose stores a daily close, replacing any previous record for the same date.
+func (h *MemoryHistory) SaveDailyClose(dailyClose DailyClose) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closes[dailyClose.Date] = dailyClose
+	return nil
+}
+
+// DailyCloses returns all daily close records ordered by date.
+func (h *MemoryHistory) DailyCloses() ([]DailyClose, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	closes := make([]DailyClose, 0, len(h.closes))
+	for _, c := range h.closes {
+		closes = append(closes, c)
+	}
+	sort.Slice(closes, func(i, j int) bool {
+		return closes[i].Date < closes[j].Date
+	})
+
+	return closes, nil
+}
+
+// dayKey formats a time as the Venezuelan calendar date (YYYY-MM-DD).
+func dayKey(t time.Time) string {
+	return t.In(Venezuela).Format("2006-01-02")
+}
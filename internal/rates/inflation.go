@@ -0,0 +1,117 @@
+package rates
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// IndexPoint is a single monthly INPC (Índice Nacional de Precios al
+// Consumidor) observation as published by BCV.
+type IndexPoint struct {
+	Month time.Time `json:"month"`
+	Index float64   `json:"index"`
+}
+
+// InflationPoint represents the inflation figures for a single month.
+type InflationPoint struct {
+	Month        string   `json:"month"`
+	Index        float64  `json:"index"`
+	Monthly      *float64 `json:"monthly,omitempty"`
+	YearOverYear *float64 `json:"yearOverYear,omitempty"`
+}
+
+// InflationData represents the inflation series exposed by the API.
+type InflationData struct {
+	Latest    *InflationPoint  `json:"latest"`
+	Months    []InflationPoint `json:"months"`
+	UpdatedAt time.Time        `json:"updatedAt,omitzero"`
+	Epoch     int64            `json:"updatedAtEpoch,omitzero"`
+
+	// Status is StatusUnavailable until the INPC has been fetched.
+	Status string `json:"status"`
+}
+
+// InflationStore provides thread-safe storage for INPC data.
+type InflationStore struct {
+	mu        sync.RWMutex
+	points    []IndexPoint
+	updatedAt time.Time
+}
+
+// NewInflationStore creates a new InflationStore instance.
+func NewInflationStore() *InflationStore {
+	return &InflationStore{}
+}
+
+// Set replaces the stored INPC series.
+func (s *InflationStore) Set(points []IndexPoint) {
+	sorted := make([]IndexPoint, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Month.Before(sorted[j].Month)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.points = sorted
+	s.updatedAt = time.Now()
+}
+
+// GetInflationData returns the inflation series with monthly and
+// year-over-year variations calculated from the index values.
+func (s *InflationStore) GetInflationData() InflationData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byMonth := make(map[string]float64, len(s.points))
+	for _, p := range s.points {
+		byMonth[monthKey(p.Month)] = p.Index
+	}
+
+	months := make([]InflationPoint, 0, len(s.points))
+	for _, p := range s.points {
+		point := InflationPoint{
+			Month: monthKey(p.Month),
+			Index: p.Index,
+		}
+
+		if prev, ok := byMonth[monthKey(p.Month.AddDate(0, -1, 0))]; ok && prev > 0 {
+			point.Monthly = variation(prev, p.Index)
+		}
+		if prev, ok := byMonth[monthKey(p.Month.AddDate(-1, 0, 0))]; ok && prev > 0 {
+			point.YearOverYear = variation(prev, p.Index)
+		}
+
+		months = append(months, point)
+	}
+
+	data := InflationData{
+		Months:    months,
+		UpdatedAt: s.updatedAt,
+		Epoch:     unixSeconds(s.updatedAt),
+		Status:    StatusOK,
+	}
+	if len(months) == 0 {
+		data.Status = StatusUnavailable
+	}
+	if len(months) > 0 {
+		latest := months[len(months)-1]
+		data.Latest = &latest
+	}
+
+	return data
+}
+
+// monthKey formats a month as YYYY-MM.
+func monthKey(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// variation returns the percentage change between two index values,
+// rounded to 2 decimal places.
+func variation(from, to float64) *float64 {
+	v := math.Round((to/from-1)*100*100) / 100
+	return &v
+}
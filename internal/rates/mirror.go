@@ -0,0 +1,44 @@
+package rates
+
+import (
+	"context"
+	"log"
+)
+
+// ProvenanceMirror marks a BCV rate read from a mirror while the BCV site
+// was unreachable.
+const ProvenanceMirror = "mirror"
+
+// bcvMirror is a copy of the BCV rate served elsewhere.
+type bcvMirror struct {
+	name    string
+	scraper Scraper
+}
+
+// AddBCVMirror registers a mirror tried, in registration order, when the
+// BCV site can't be scraped.
+func (s *Service) AddBCVMirror(name string, mirror Scraper) {
+	s.bcvMirrors = append(s.bcvMirrors, bcvMirror{name: name, scraper: mirror})
+}
+
+// mirrorBCV sets the BCV rate from the first mirror that supplies a valid
+// one, and reports whether one did. A pinned BCV rate is never replaced.
+func (s *Service) mirrorBCV(ctx context.Context) bool {
+	if _, pinned := s.store.pinnedUntil("bcv"); pinned {
+		return false
+	}
+	for _, m := range s.bcvMirrors {
+		rate, err := m.scraper.Fetch(ctx)
+		if err == nil {
+			err = s.checkUpdate("bcv", s.store.GetBCV(), rate)
+		}
+		if err != nil {
+			log.Printf("BCV mirror %s failed (%s): %v", m.name, ClassifyError(err), err)
+			continue
+		}
+
+		s.setBCV(m.name, rate)
+		return true
+	}
+	return false
+}
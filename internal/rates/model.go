@@ -12,10 +12,53 @@ import (
 
 // RateData represents the current exchange rate information.
 type RateData struct {
-	BCV       float64   `json:"bcv"`
-	Binance   float64   `json:"binance"`
-	Breach    float64   `json:"breach"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	BCV       float64   `json:"bcv,omitzero"`
+	Binance   float64   `json:"binance,omitzero"`
+	Breach    *float64  `json:"breach,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitzero"`
+	Epoch     int64     `json:"updatedAtEpoch,omitzero"`
+
+	// Status tells whether every rate has been fetched. Rates never
+	// fetched are omitted, as are the breach and timestamps when they
+	// can't be computed.
+	Status string `json:"status"`
+
+	// BinanceFallback names the source that supplied the Binance rate while
+	// Binance failed.
+	BinanceFallback string `json:"binanceFallback,omitempty"`
+
+	// BCVMirror names the mirror that supplied the BCV rate while the BCV
+	// site was unreachable.
+	BCVMirror string `json:"bcvMirror,omitempty"`
+
+	// Region names the region the Binance rate was sampled from, if not
+	// the headline filter.
+	Region string `json:"region,omitempty"`
+}
+
+// Data availability statuses.
+const (
+	StatusOK          = "ok"
+	StatusPartial     = "partial"
+	StatusUnavailable = "unavailable"
+)
+
+// availability returns the status of data made of values, where values
+// never fetched are zero.
+func availability(values ...float64) string {
+	fetched := 0
+	for _, v := range values {
+		if v > 0 {
+			fetched++
+		}
+	}
+	switch fetched {
+	case len(values):
+		return StatusOK
+	case 0:
+		return StatusUnavailable
+	}
+	return StatusPartial
 }
 
 // RateStore provides thread-safe storage for rate data.
@@ -25,27 +68,166 @@ type RateStore struct {
 	binance float64
 	bcvTime time.Time
 	binTime time.Time
+
+	// binFallback is the source that supplied the Binance rate, if not Binance
+	binFallback string
+
+	// bcvMirror is the mirror that supplied the BCV rate, if not the BCV site
+	bcvMirror string
+
+	// Intraday Binance extremes for the current Venezuelan calendar day
+	binDay  string
+	binHigh float64
+	binLow  float64
+
+	// Additional parallel-market sources, keyed by name
+	parallel map[string]sourceValue
+
+	// Expiry of operator overrides, keyed by source name. An override stays
+	// listed after it expires, until a fetched rate replaces it.
+	pins map[string]time.Time
+
+	// changed is closed and replaced on every update
+	changed chan struct{}
+}
+
+// sourceValue is a rate with the time it was last updated and, unless
+// fetched, where it came from.
+type sourceValue struct {
+	rate       float64
+	at         time.Time
+	provenance string
 }
 
 // NewRateStore creates a new RateStore instance.
 func NewRateStore() *RateStore {
-	return &RateStore{}
+	return &RateStore{
+		parallel: make(map[string]sourceValue),
+		pins:     make(map[string]time.Time),
+		changed:  make(chan struct{}),
+	}
+}
+
+// Changed returns a channel that is closed on the next rate update.
+func (s *RateStore) Changed() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.changed
+}
+
+// notifyChanged wakes everyone waiting on Changed. Callers must hold the lock.
+func (s *RateStore) notifyChanged() {
+	close(s.changed)
+	s.changed = make(chan struct{})
 }
 
 // SetBCV updates the BCV rate value.
 func (s *RateStore) SetBCV(rate float64) {
+	s.SetBCVMirror("", rate)
+}
+
+// SetBCVMirror updates the BCV rate value with a rate read from the named
+// mirror.
+func (s *RateStore) SetBCVMirror(mirror string, rate float64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.setBCV(mirror, rate)
+	delete(s.pins, "bcv")
+	s.notifyChanged()
+}
+
+// setBCV updates the BCV rate value. Callers must hold the lock.
+func (s *RateStore) setBCV(mirror string, rate float64) {
 	s.bcv = rate
+	s.bcvMirror = mirror
 	s.bcvTime = time.Now()
 }
 
 // SetBinance updates the Binance rate value.
 func (s *RateStore) SetBinance(rate float64) {
+	s.SetBinanceFallback("", rate)
+}
+
+// SetBinanceFallback updates the Binance rate value with a rate supplied by
+// the named fallback source.
+func (s *RateStore) SetBinanceFallback(source string, rate float64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.setBinance(source, rate)
+	delete(s.pins, "binance")
+	s.notifyChanged()
+}
+
+// setBinance updates the Binance rate value and its intraday extremes.
+// Callers must hold the lock.
+func (s *RateStore) setBinance(source string, rate float64) {
 	s.binance = rate
+	s.binFallback = source
 	s.binTime = time.Now()
+
+	// Reset intraday extremes when a new day starts
+	if day := dayKey(s.binTime); day != s.binDay {
+		s.binDay = day
+		s.binHigh = rate
+		s.binLow = rate
+		return
+	}
+	if rate > s.binHigh {
+		s.binHigh = rate
+	}
+	if rate < s.binLow {
+		s.binLow = rate
+	}
+}
+
+// SetParallel updates the rate of an additional parallel-market source.
+func (s *RateStore) SetParallel(name string, rate float64) {
+	s.SetParallelFrom(name, rate, "")
+}
+
+// SetParallelFrom updates the rate of an additional parallel-market source
+// with a rate that wasn't fetched, e.g. ProvenanceManual.
+func (s *RateStore) SetParallelFrom(name string, rate float64, provenance string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parallel[name] = sourceValue{rate: rate, at: time.Now(), provenance: provenance}
+	delete(s.pins, name)
+	s.notifyChanged()
+}
+
+// Pin sets the rate of a source, "bcv", "binance" or a parallel-market
+// source, as entered by an operator. Callers should keep fetched rates out
+// of the store until the pin expires.
+func (s *RateStore) Pin(name string, rate float64, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch name {
+	case "bcv":
+		s.setBCV("", rate)
+	case "binance":
+		s.setBinance("", rate)
+	default:
+		s.parallel[name] = sourceValue{rate: rate, at: time.Now(), provenance: ProvenanceManual}
+	}
+	s.pins[name] = until
+	s.notifyChanged()
+}
+
+// pinnedUntil returns when a source's pin expires, if it's pinned.
+func (s *RateStore) pinnedUntil(name string) (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	until, ok := s.pins[name]
+	return until, ok && time.Now().Before(until)
+}
+
+// markPinned flags a source entered by an operator. Callers must hold the
+// lock.
+func (s *RateStore) markPinned(src *SourceRate) {
+	if until, ok := s.pins[src.Name]; ok {
+		src.Provenance = ProvenanceManual
+		src.PinnedUntil = until
+	}
 }
 
 // GetBCV returns the current BCV rate.
@@ -62,29 +244,109 @@ func (s *RateStore) GetBinance() float64 {
 	return s.binance
 }
 
+// GetParallel returns the current rate of an additional parallel-market source.
+func (s *RateStore) GetParallel(name string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.parallel[name].rate
+}
+
+// parallelValue returns the current rate of an additional parallel-market
+// source with the time it was last updated.
+func (s *RateStore) parallelValue(name string) sourceValue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.parallel[name]
+}
+
 // GetRateData returns the complete rate data with breach calculation.
 func (s *RateStore) GetRateData() RateData {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var breach float64
-	if s.bcv > 0 {
-		breach = ((s.binance - s.bcv) / s.bcv) * 100
-	}
-
 	// Use the most recent update time
 	updatedAt := s.bcvTime
 	if s.binTime.After(s.bcvTime) {
 		updatedAt = s.binTime
 	}
 
-	// Round breach to 2 decimal places
-	breach = float64(int(breach*100)) / 100
-
 	return RateData{
-		BCV:       s.bcv,
-		Binance:   s.binance,
-		Breach:    breach,
+		BCV:             s.bcv,
+		Binance:         s.binance,
+		BinanceFallback: s.binFallback,
+		BCVMirror:       s.bcvMirror,
+		UpdatedAt:       updatedAt,
+		Epoch:           unixSeconds(updatedAt),
+		Status:          availability(s.bcv, s.binance),
+	}
+}
+
+// GetRatesV1 returns the detailed rate data, with the parallel rate derived
+// from Binance and any additional sources listed in order.
+func (s *RateStore) GetRatesV1(order []string) RatesV1 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sources := []SourceRate{{Name: "binance", Rate: s.binance, UpdatedAt: s.binTime, Fallback: s.binFallback}}
+	for _, name := range order {
+		v := s.parallel[name]
+		sources = append(sources, SourceRate{Name: name, Rate: v.rate, UpdatedAt: v.at})
+	}
+	for i := range sources {
+		s.markPinned(&sources[i])
+	}
+	return s.ratesV1(sources)
+}
+
+// ratesV1 returns the detailed rate data with the parallel rate derived from
+// the given sources. Callers must hold the lock.
+func (s *RateStore) ratesV1(sources []SourceRate) RatesV1 {
+	updatedAt := s.bcvTime
+	for _, src := range sources {
+		if src.UpdatedAt.After(updatedAt) {
+			updatedAt = src.UpdatedAt
+		}
+	}
+
+	parallel := computeParallel(sources, time.Now())
+	bcv := SourceRate{Name: "bcv", Rate: s.bcv, UpdatedAt: s.bcvTime}
+	if s.bcvMirror != "" {
+		bcv.Provenance, bcv.Fallback = ProvenanceMirror, s.bcvMirror
+	}
+	s.markPinned(&bcv)
+
+	return RatesV1{
+		BCV:       bcv,
+		Parallel:  parallel,
 		UpdatedAt: updatedAt,
+		Epoch:     unixSeconds(updatedAt),
+		Status:    availability(s.bcv, parallel.Rate),
+	}
+}
+
+// GetDailyClose returns the closing snapshot for the given time's calendar day,
+// with the breach measured in direction d.
+// High and low fall back to the current Binance rate when no sample was taken that day.
+func (s *RateStore) GetDailyClose(at time.Time, d BreachDirection) DailyClose {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	high, low := s.binHigh, s.binLow
+	if s.binDay != dayKey(at) {
+		high, low = s.binance, s.binance
+	}
+
+	var breach float64
+	if b := d.of(s.bcv, s.binance); b != nil {
+		breach = *b
+	}
+	return DailyClose{
+		Date:     dayKey(at),
+		BCV:      s.bcv,
+		Binance:  s.binance,
+		High:     high,
+		Low:      low,
+		Breach:   breach,
+		ClosedAt: at,
 	}
 }
@@ -0,0 +1,42 @@
+package rates
+
+import (
+	"testing"
+	"time"
+)
+
+func BenchmarkGetRateData(b *testing.B) {
+	store := NewRateStore()
+	store.SetBCV(36.5)
+	store.SetBinance(38.2)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		store.GetRateData()
+	}
+}
+
+func BenchmarkGetRatesV1(b *testing.B) {
+	store := NewRateStore()
+	store.SetBCV(36.5)
+	store.SetBinance(38.2)
+	store.SetParallel("yadio", 38.0)
+	order := []string{"yadio"}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		store.GetRatesV1(order)
+	}
+}
+
+func TestGetDailyCloseBreach(t *testing.T) {
+	store := NewRateStore()
+	store.SetBCV(40)
+	store.SetBinance(50)
+
+	for d, want := range map[BreachDirection]float64{BreachPremium: 25, BreachDiscount: 20} {
+		if got := store.GetDailyClose(time.Now(), d).Breach; got != want {
+			t.Errorf("%s breach = %v, want %v", d, got, want)
+		}
+	}
+}
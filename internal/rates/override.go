@@ -0,0 +1,76 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// ErrInvalidOverride is returned for an override with an invalid expiry.
+var ErrInvalidOverride = errors.New("invalid override")
+
+// maxOverride is the longest an override may pin a rate.
+const maxOverride = 7 * 24 * time.Hour
+
+// SetOverride pins the rate of "bcv", "binance" or a parallel-market source
+// until the given time, for incidents where a scraper is broken but the
+// correct rate is known. Fetched rates are ignored until the override
+// expires. Like fetched rates, it's audited and rejected if it jumps too far
+// from the previous value.
+func (s *Service) SetOverride(source string, rate float64, until time.Time) (SourceRate, error) {
+	var current float64
+	switch {
+	case source == "bcv":
+		current = s.store.GetBCV()
+	case source == "binance":
+		current = s.store.GetBinance()
+	case slices.Contains(s.parallelNames, source):
+		current = s.store.GetParallel(source)
+	default:
+		return SourceRate{}, fmt.Errorf("%w %q", ErrUnknownSource, source)
+	}
+
+	switch d := time.Until(until); {
+	case d <= 0:
+		return SourceRate{}, fmt.Errorf("%w: expiry must be in the future", ErrInvalidOverride)
+	case d > maxOverride:
+		return SourceRate{}, fmt.Errorf("%w: expiry must be within %d days", ErrInvalidOverride, int(maxOverride/(24*time.Hour)))
+	}
+
+	entry := AuditEntry{
+		Source:      source,
+		OldValue:    current,
+		NewValue:    rate,
+		Provenance:  ProvenanceManual,
+		PinnedUntil: until,
+	}
+	if err := s.audited(entry); err != nil {
+		return SourceRate{}, err
+	}
+
+	s.store.Pin(source, rate, until)
+	s.record(source, rate)
+	log.Printf("Rate of %s pinned at %.2f until %s", source, rate, until.Format(time.RFC3339))
+	s.evaluateAlerts()
+
+	v := s.GetRatesV1()
+	if source == "bcv" {
+		return v.BCV, nil
+	}
+	i := slices.IndexFunc(v.Parallel.Sources, func(src SourceRate) bool {
+		return src.Name == source
+	})
+	return v.Parallel.Sources[i], nil
+}
+
+// pinned reports whether an override holds the rate of source, in which case
+// the fetched rate is ignored.
+func (s *Service) pinned(source string, rate float64) bool {
+	until, ok := s.store.pinnedUntil(source)
+	if ok {
+		log.Printf("Rate of %s pinned until %s, ignoring fetched %.2f", source, until.Format(time.RFC3339), rate)
+	}
+	return ok
+}
@@ -0,0 +1,99 @@
+package rates
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Rounding is how values are rounded to a number of decimals.
+type Rounding string
+
+// Rounding modes.
+const (
+	// RoundHalfUp rounds halves away from zero, as commercial rounding does.
+	RoundHalfUp Rounding = "half-up"
+	// RoundTruncate drops the extra decimals.
+	RoundTruncate Rounding = "truncate"
+)
+
+// Precision is the number of decimals values are rounded to, and how.
+// Negative Decimals leave values unrounded.
+type Precision struct {
+	Decimals int
+	Rounding Rounding
+}
+
+// ConversionPrecision is the precision of conversion results unless the
+// request sets another.
+var ConversionPrecision = Precision{Decimals: 2, Rounding: RoundHalfUp}
+
+// Round rounds v. Rounding works on v's shortest decimal representation,
+// so 1.005 rounds half up to 1.01 although the nearest float64 is slightly
+// below it.
+func (p Precision) Round(v float64) float64 {
+	if p.Decimals < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return v
+	}
+
+	digits := strconv.FormatFloat(math.Abs(v), 'f', -1, 64)
+	whole, frac, _ := strings.Cut(digits, ".")
+	if len(frac) <= p.Decimals {
+		return v
+	}
+
+	kept := []byte(whole + frac[:p.Decimals])
+	if p.Rounding != RoundTruncate && frac[p.Decimals] >= '5' {
+		kept = increment(kept)
+	}
+	rounded := string(kept[:len(kept)-p.Decimals]) + "." + string(kept[len(kept)-p.Decimals:])
+
+	r, err := strconv.ParseFloat(rounded, 64)
+	if err != nil {
+		return v
+	}
+	return math.Copysign(r, v)
+}
+
+// increment adds one to a string of decimal digits.
+func increment(digits []byte) []byte {
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '9' {
+			digits[i]++
+			return digits
+		}
+		digits[i] = '0'
+	}
+	return append([]byte{'1'}, digits...)
+}
+
+// Round returns a copy of the rate data with rates rounded to p.
+func (d RateData) Round(p Precision) RateData {
+	d.BCV = p.Round(d.BCV)
+	d.Binance = p.Round(d.Binance)
+	return d
+}
+
+// Round returns a copy of the detailed rates with rates rounded to p.
+func (v RatesV1) Round(p Precision) RatesV1 {
+	v.BCV.Rate = p.Round(v.BCV.Rate)
+	v.Parallel.Rate = p.Round(v.Parallel.Rate)
+	if v.Zelle != nil {
+		zelle := *v.Zelle
+		zelle.Rate = p.Round(zelle.Rate)
+		v.Zelle = &zelle
+	}
+	if v.Cash != nil {
+		cash := *v.Cash
+		cash.Rate = p.Round(cash.Rate)
+		v.Cash = &cash
+	}
+
+	sources := make([]SourceRate, len(v.Parallel.Sources))
+	for i, src := range v.Parallel.Sources {
+		src.Rate = p.Round(src.Rate)
+		sources[i] = src
+	}
+	v.Parallel.Sources = sources
+	return v
+}
@@ -0,0 +1,128 @@
+package rates
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Probe is the outcome of fetching one source for a self-test.
+type Probe struct {
+	Source string
+	Sample Sample
+	Took   time.Duration
+	Err    error
+
+	// Points is the INPC series, for the "inpc" source.
+	Points []IndexPoint
+}
+
+// Probe fetches every configured source once, concurrently, and checks the
+// fetched values. Nothing is stored, recorded or published. The returned
+// service replays the fetched values, so what they serialize to can be
+// checked without touching this one.
+func (s *Service) Probe(ctx context.Context) ([]Probe, *Service) {
+	type target struct {
+		name   string
+		source Scraper
+	}
+	targets := []target{{"bcv", s.bcvScraper}, {"binance", s.binanceFetcher}}
+	for _, name := range s.parallelNames {
+		targets = append(targets, target{name, s.parallelSources[name]})
+	}
+	for _, name := range s.regionNames {
+		targets = append(targets, target{regionSource(name), s.regions[name].source})
+	}
+	if s.zelle != nil {
+		targets = append(targets, target{zelleSource, s.zelle})
+	}
+	if s.cash != nil {
+		targets = append(targets, target{cashSource, s.cash})
+	}
+
+	probes := make([]Probe, len(targets)+1)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			sample, err := fetchSample(ctx, t.source)
+			if err == nil && (math.IsNaN(sample.Rate) || math.IsInf(sample.Rate, 0) || sample.Rate <= 0) {
+				err = fmt.Errorf("invalid rate %v", sample.Rate)
+			}
+			probes[i] = Probe{Source: t.name, Sample: sample, Took: time.Since(start), Err: err}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		start := time.Now()
+		points, err := s.inpcScraper.FetchINPC(ctx)
+		if err == nil && len(points) == 0 {
+			err = fmt.Errorf("empty INPC series")
+		}
+		probes[len(targets)] = Probe{Source: "inpc", Points: points, Took: time.Since(start), Err: err}
+	}()
+	wg.Wait()
+
+	return probes, s.replay(ctx, probes)
+}
+
+// replay returns a service configured like s whose sources return the
+// probed values.
+func (s *Service) replay(ctx context.Context, probes []Probe) *Service {
+	byName := make(map[string]Probe, len(probes))
+	for _, p := range probes {
+		byName[p.Source] = p
+	}
+	source := func(name string) Scraper {
+		p := byName[name]
+		return replaySource{sample: p.Sample, err: p.Err}
+	}
+
+	inpc := byName["inpc"]
+	r := NewService(source("bcv"), source("binance"), replayInflation{points: inpc.Points, err: inpc.Err})
+	for _, name := range s.parallelNames {
+		r.AddParallelSource(name, source(name))
+	}
+	for _, name := range s.regionNames {
+		r.AddRegion(s.regions[name].Region, source(regionSource(name)))
+	}
+	if s.zelle != nil {
+		r.SetZelle(source(zelleSource))
+	}
+	if s.cash != nil {
+		r.SetCashSource(source(cashSource))
+	}
+	r.SetBinanceFallbacks(s.binanceFallbacks)
+	r.SetBreachDirection(s.breach)
+	r.Initialize(ctx)
+	return r
+}
+
+// replaySource returns a fetched sample.
+type replaySource struct {
+	sample Sample
+	err    error
+}
+
+func (r replaySource) Fetch(context.Context) (float64, error) {
+	return r.sample.Rate, r.err
+}
+
+func (r replaySource) FetchSample(context.Context) (Sample, error) {
+	return r.sample, r.err
+}
+
+// replayInflation returns a fetched INPC series.
+type replayInflation struct {
+	points []IndexPoint
+	err    error
+}
+
+func (r replayInflation) FetchINPC(context.Context) ([]IndexPoint, error) {
+	return r.points, r.err
+}
@@ -0,0 +1,170 @@
+package rates
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxQueryResults bounds the number of cached history query results; the
+// cache is emptied when it fills up.
+const maxQueryResults = 256
+
+// queryCache caches the results of history queries, keyed by their
+// normalized parameters, until the history changes. Identical queries
+// running at the same time are computed once and share the result, so
+// cached results must not be modified.
+type queryCache struct {
+	mu         sync.Mutex
+	generation uint64
+	results    map[string]any
+	calls      map[string]*queryCall
+}
+
+// queryCall is a query in progress.
+type queryCall struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+func newQueryCache() *queryCache {
+	return &queryCache{
+		results: make(map[string]any),
+		calls:   make(map[string]*queryCall),
+	}
+}
+
+// invalidate drops every cached result. Queries in progress finish, but
+// their results aren't cached and later queries don't wait for them.
+func (c *queryCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.results)
+	clear(c.calls)
+}
+
+// query returns the cached result for key, waits for the identical query in
+// progress, or runs fn. Errors aren't cached.
+func query[T any](c *queryCache, key string, fn func() (T, error)) (T, error) {
+	c.mu.Lock()
+	if v, ok := c.results[key]; ok {
+		c.mu.Unlock()
+		return v.(T), nil
+	}
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return resultOf[T](call)
+	}
+	call := &queryCall{done: make(chan struct{})}
+	c.calls[key] = call
+	generation := c.generation
+	c.mu.Unlock()
+
+	call.val, call.err = fn()
+
+	c.mu.Lock()
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+	if call.err == nil && generation == c.generation {
+		if len(c.results) >= maxQueryResults {
+			clear(c.results)
+		}
+		c.results[key] = call.val
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return resultOf[T](call)
+}
+
+func resultOf[T any](call *queryCall) (T, error) {
+	if call.err != nil {
+		var zero T
+		return zero, call.err
+	}
+	return call.val.(T), nil
+}
+
+// queryKey joins a query name and its normalized parameters.
+func queryKey(name string, params ...string) string {
+	for i, p := range params {
+		params[i] = strings.TrimSpace(p)
+	}
+	return name + "?" + strings.Join(params, "&")
+}
+
+// dailyCloses returns the recorded daily closes ordered by date, loading
+// them from the history backend only after it changes.
+func (s *Service) dailyCloses() ([]DailyClose, error) {
+	return query(s.queries, queryKey("closes"), s.history.DailyCloses)
+}
+
+// saveDailyClose records a daily close and invalidates cached queries.
+func (s *Service) saveDailyClose(dailyClose DailyClose) error {
+	err := s.history.SaveDailyClose(dailyClose)
+	s.queries.invalidate()
+	return err
+}
+
+// History returns the history backend as seen through the service: saved
+// closes invalidate cached queries, and the closes returned must not be
+// modified.
+func (s *Service) History() History {
+	return serviceHistory{s}
+}
+
+type serviceHistory struct{ s *Service }
+
+func (h serviceHistory) SaveDailyClose(dailyClose DailyClose) error {
+	return h.s.saveDailyClose(dailyClose)
+}
+
+func (h serviceHistory) DailyCloses() ([]DailyClose, error) {
+	return h.s.dailyCloses()
+}
+
+// QueryDailyCloses returns the daily closes between from and to
+// (YYYY-MM-DD, inclusive), either of which may be empty for no bound.
+func (s *Service) QueryDailyCloses(from, to string) ([]DailyClose, error) {
+	return query(s.queries, queryKey("closes", from, to), func() ([]DailyClose, error) {
+		closes, err := s.dailyCloses()
+		if err != nil {
+			return nil, err
+		}
+		from, to := strings.TrimSpace(from), strings.TrimSpace(to)
+		filtered := make([]DailyClose, 0, len(closes))
+		for _, c := range closes {
+			if (from == "" || c.Date >= from) && (to == "" || c.Date <= to) {
+				filtered = append(filtered, c)
+			}
+		}
+		return filtered, nil
+	})
+}
+
+// CloseOn returns the daily close recorded on date (YYYY-MM-DD). Unlike
+// conversions, it doesn't fall back to an earlier close, so a date always
+// names the same close.
+func (s *Service) CloseOn(date string) (DailyClose, error) {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return DailyClose{}, fmt.Errorf("%w: %s (expected YYYY-MM-DD)", ErrInvalidDate, date)
+	}
+
+	closes, err := s.dailyCloses()
+	if err != nil {
+		return DailyClose{}, err
+	}
+	i, found := slices.BinarySearchFunc(closes, date, func(c DailyClose, date string) int {
+		return strings.Compare(c.Date, date)
+	})
+	if !found {
+		return DailyClose{}, fmt.Errorf("%w: %s", ErrNoHistoricalRate, date)
+	}
+	return closes[i], nil
+}
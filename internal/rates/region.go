@@ -0,0 +1,149 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrUnknownRegion is returned for a region that isn't configured.
+var ErrUnknownRegion = errors.New("unknown region")
+
+// Region is a named Binance P2P ad filter whose rate is tracked alongside
+// the headline one, e.g. ads paid through Venezuelan banks, which price
+// differently from ads paid internationally.
+type Region struct {
+	Name      string   `json:"name"`
+	Countries []string `json:"countries,omitempty"`
+	PayTypes  []string `json:"payTypes,omitempty"`
+}
+
+// regionSource is the source name a region's rate is fetched, audited and
+// recorded under.
+func regionSource(region string) string {
+	return "binance_" + region
+}
+
+// AddRegion registers a region and the Binance fetcher sampling its ads.
+// Regions are refreshed with the parallel sources.
+func (s *Service) AddRegion(region Region, source Scraper) {
+	if _, exists := s.regions[region.Name]; !exists {
+		s.regionNames = append(s.regionNames, region.Name)
+	}
+	s.regions[region.Name] = regionFetcher{Region: region, source: source}
+	s.health.register(regionSource(region.Name), 5*time.Minute)
+}
+
+// regionFetcher is a region with its fetcher.
+type regionFetcher struct {
+	Region
+	source Scraper
+}
+
+// fetchRegions fetches every region's rate. Failed regions keep their
+// previous value; the first error is returned.
+func (s *Service) fetchRegions(ctx context.Context) error {
+	var firstErr error
+	for _, name := range s.regionNames {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		source := regionSource(name)
+		if err := s.checkBackoff(source); err != nil {
+			log.Printf("Region %s fetch skipped: %v", name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		sample, err := fetchSample(ctx, s.regions[name].source)
+		rate := sample.Rate
+		if err == nil {
+			err = s.checkUpdate(source, s.store.GetParallel(source), rate)
+		}
+		s.recordFetch(source, err, sample)
+		if err != nil {
+			log.Printf("Region %s fetch error (%s, keeping previous value): %v", name, ClassifyError(err), err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", source, err)
+			}
+			continue
+		}
+
+		s.store.SetParallel(source, rate)
+		s.record(source, rate)
+		log.Printf("Region %s rate updated: %.2f", name, rate)
+	}
+	return firstErr
+}
+
+// region returns a configured region.
+func (s *Service) region(name string) (Region, error) {
+	r, ok := s.regions[name]
+	if !ok {
+		return Region{}, fmt.Errorf("%w %q", ErrUnknownRegion, name)
+	}
+	return r.Region, nil
+}
+
+// GetRegionRates returns the current rate data with the Binance rate
+// sampled from the region's ads.
+func (s *Service) GetRegionRates(name string) (RateData, error) {
+	region, err := s.region(name)
+	if err != nil {
+		return RateData{}, err
+	}
+	d := s.store.GetRegionRateData(regionSource(name))
+	d.Breach = s.breach.of(d.BCV, d.Binance)
+	d.Region = region.Name
+	return d, nil
+}
+
+// GetRegionRatesV1 returns the detailed rate data with the parallel rate
+// taken from the region's Binance ads alone.
+func (s *Service) GetRegionRatesV1(name string) (RatesV1, error) {
+	region, err := s.region(name)
+	if err != nil {
+		return RatesV1{}, err
+	}
+	v := s.store.GetRegionRatesV1(regionSource(name))
+	s.setBreaches(&v)
+	s.health.annotate(&v, time.Now())
+	v.Region = &region
+	return v, nil
+}
+
+// GetRegionRateData returns the rate data with the Binance rate replaced by
+// the named regional source's.
+func (s *RateStore) GetRegionRateData(source string) RateData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v := s.parallel[source]
+	updatedAt := s.bcvTime
+	if v.at.After(updatedAt) {
+		updatedAt = v.at
+	}
+
+	return RateData{
+		BCV:       s.bcv,
+		Binance:   v.rate,
+		BCVMirror: s.bcvMirror,
+		UpdatedAt: updatedAt,
+		Epoch:     unixSeconds(updatedAt),
+		Status:    availability(s.bcv, v.rate),
+	}
+}
+
+// GetRegionRatesV1 returns the detailed rate data with the parallel rate
+// derived from the named regional source alone.
+func (s *RateStore) GetRegionRatesV1(source string) RatesV1 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v := s.parallel[source]
+	return s.ratesV1([]SourceRate{{Name: source, Rate: v.rate, UpdatedAt: v.at}})
+}
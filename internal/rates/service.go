@@ -1,12 +1,41 @@
 package rates
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
+
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/pkg/events"
 )
 
 // Scraper defines the interface for exchange rate scrapers.
 type Scraper interface {
-	Fetch() (float64, error)
+	Fetch(ctx context.Context) (float64, error)
+}
+
+// InflationScraper defines the interface for INPC scrapers.
+type InflationScraper interface {
+	FetchINPC(ctx context.Context) ([]IndexPoint, error)
+}
+
+// Publisher defines the interface for emitting service events.
+type Publisher interface {
+	Publish(event notify.Event)
+}
+
+// StoredPublisher is implemented by publishers that can have an event
+// stored in their outbox together with the change it announces.
+type StoredPublisher interface {
+	PublishStored(event notify.Event, store func(event notify.Event, notifiers []string) (uint64, error)) error
+}
+
+// Sink receives every accepted rate, e.g. to write it to a time-series
+// database.
+type Sink interface {
+	Record(source string, rate float64, at time.Time)
 }
 
 // Service manages exchange rate fetching and storage.
@@ -14,63 +43,477 @@ type Service struct {
 	store          *RateStore
 	bcvScraper     Scraper
 	binanceFetcher Scraper
+	inpcScraper    InflationScraper
+	inflation      *InflationStore
+	history        History
+	queries        *queryCache
+	samples        SampleStore
+	publisher      Publisher
+	sinks          []Sink
+
+	// Additional parallel-market sources, in registration order
+	parallelNames   []string
+	parallelSources map[string]Scraper
+
+	// Parallel sources supplying the Binance rate while Binance fails
+	binanceFallbacks []string
+
+	// Copies of the BCV rate tried while the BCV site fails, in order
+	bcvMirrors []bcvMirror
+
+	// Regional Binance fetchers, in registration order
+	regionNames []string
+	regions     map[string]regionFetcher
+
+	// USD price of USDT on Zelle ads, from which the Zelle rate is derived
+	zelle Scraper
+
+	// Street cash-dollar rate source, if not only entered manually
+	cash Scraper
+
+	// How breaches are measured
+	breach BreachDirection
+
+	// Rates accepted from each source
+	bounds boundsState
+
+	health     *healthTracker
+	fetches    *fetchLog
+	audit      AuditLog
+	rejections rejectionState
+	jumps      jumpState
+	alerts     alertState
+	escalation escalationState
+	summaries  summaryCache
+	book       bookState
 }
 
 // NewService creates a new rate service.
-func NewService(bcvScraper, binanceFetcher Scraper) *Service {
+func NewService(bcvScraper, binanceFetcher Scraper, inpcScraper InflationScraper) *Service {
+	health := newHealthTracker()
+	health.register("bcv", 24*time.Hour)
+	health.register("binance", 5*time.Minute)
+
 	return &Service{
 		store:          NewRateStore(),
 		bcvScraper:     bcvScraper,
 		binanceFetcher: binanceFetcher,
+		inpcScraper:    inpcScraper,
+		inflation:      NewInflationStore(),
+		history:        NewMemoryHistory(),
+		queries:        newQueryCache(),
+
+		parallelSources: make(map[string]Scraper),
+		regions:         make(map[string]regionFetcher),
+		breach:          BreachPremium,
+
+		health:  health,
+		fetches: newFetchLog(),
+		audit:   NewMemoryAuditLog(),
+		jumps:   jumpState{limit: defaultMaxJump, confirmations: defaultJumpConfirmations},
+		alerts:  alertState{store: NewMemoryAlertStore()},
+		escalation: escalationState{
+			bcvDays:         defaultEscalationBCVDays,
+			binanceFailures: defaultEscalationBinanceFailures,
+		},
+	}
+}
+
+// AddParallelSource registers an additional parallel-market source that is
+// combined with Binance into the headline parallel rate.
+func (s *Service) AddParallelSource(name string, source Scraper) {
+	if _, exists := s.parallelSources[name]; !exists {
+		s.parallelNames = append(s.parallelNames, name)
+	}
+	s.parallelSources[name] = source
+	s.health.register(name, 5*time.Minute)
+}
+
+// SetHistory replaces the default in-memory history backend.
+func (s *Service) SetHistory(history History) {
+	s.history = history
+	s.queries.invalidate()
+}
+
+// SetAuditLog replaces the default in-memory audit log.
+func (s *Service) SetAuditLog(audit AuditLog) {
+	s.audit = audit
+}
+
+// SetPublisher sets the publisher used to emit service events.
+func (s *Service) SetPublisher(publisher Publisher) {
+	s.publisher = publisher
+}
+
+// AddSink registers a sink for accepted rates.
+func (s *Service) AddSink(sink Sink) {
+	s.sinks = append(s.sinks, sink)
+}
+
+// record passes an accepted rate to every sink.
+func (s *Service) record(source string, rate float64) {
+	now := time.Now()
+	for _, sink := range s.sinks {
+		sink.Record(source, rate, now)
 	}
 }
 
-// FetchBCV scrapes the BCV rate and updates the store.
-// If scraping fails, the previous value is retained.
-func (s *Service) FetchBCV() error {
-	rate, err := s.bcvScraper.Fetch()
+// FetchBCV scrapes the BCV rate and updates the store, unless an override
+// pins it. If scraping fails, the rate is taken from the first mirror that
+// has one, or else the previous value is retained.
+func (s *Service) FetchBCV(ctx context.Context) error {
+	if err := s.checkBackoff("bcv"); err != nil {
+		log.Printf("BCV fetch skipped: %v", err)
+		s.mirrorBCV(ctx)
+		return err
+	}
+
+	sample, err := fetchSample(ctx, s.bcvScraper)
+	rate := sample.Rate
+	if err == nil && s.pinned("bcv", rate) {
+		s.recordFetch("bcv", nil, sample)
+		return nil
+	}
+	if err == nil {
+		err = s.checkUpdate("bcv", s.store.GetBCV(), rate)
+	}
+	s.recordFetch("bcv", err, sample)
 	if err != nil {
-		log.Printf("BCV fetch error (keeping previous value): %v", err)
+		// A rejected rate isn't an outage mirrors could bridge
+		if len(s.bcvMirrors) == 0 || errors.Is(err, ErrRateRejected) {
+			log.Printf("BCV fetch error (%s, keeping previous value): %v", ClassifyError(err), err)
+			return err
+		}
+		log.Printf("BCV fetch error (%s, trying mirrors): %v", ClassifyError(err), err)
+		if !s.mirrorBCV(ctx) {
+			log.Println("BCV mirrors failed, keeping previous value")
+		}
 		return err
 	}
 
-	s.store.SetBCV(rate)
-	log.Printf("BCV rate updated: %.2f", rate)
+	s.setBCV("", rate)
 	return nil
 }
 
-// FetchBinance fetches the Binance P2P rate and updates the store.
-// If fetching fails, the previous value is retained.
-func (s *Service) FetchBinance() error {
-	rate, err := s.binanceFetcher.Fetch()
+// setBCV stores a BCV rate, read from the named mirror or, if empty, the
+// BCV site, and announces it if it changed.
+func (s *Service) setBCV(mirror string, rate float64) {
+	previous := s.store.GetBCV()
+	s.store.SetBCVMirror(mirror, rate)
+	s.record("bcv", rate)
+	if mirror != "" {
+		log.Printf("BCV rate updated from mirror %s: %.2f", mirror, rate)
+	} else {
+		log.Printf("BCV rate updated: %.2f", rate)
+	}
+
+	// Announce newly published rates, but not the initial load
+	if previous > 0 && rate != previous && s.publisher != nil {
+		s.publisher.Publish(notify.Event{
+			Type:    notify.EventBCVUpdate,
+			Message: fmt.Sprintf("BCV publicó nueva tasa: %.2f Bs/USD (anterior %.2f, %+.2f%%)", rate, previous, *variation(previous, rate)),
+			Data:    events.RateUpdated{Source: "bcv", Rate: rate, Previous: previous, Mirror: mirror},
+		})
+	}
+
+	s.evaluateAlerts()
+	s.checkEscalations()
+}
+
+// FetchBinance fetches the Binance P2P rate and updates the store, unless an
+// override pins it. If fetching fails, the rate is taken from the first
+// fallback source that has one, or else the previous value is retained.
+func (s *Service) FetchBinance(ctx context.Context) error {
+	err := s.fetchBinance(ctx)
+	s.recordBinanceRun(err)
+	s.checkEscalations()
+	return err
+}
+
+// fetchBinance runs a Binance fetch, returning whether it failed.
+func (s *Service) fetchBinance(ctx context.Context) error {
+	if err := s.checkBackoff("binance"); err != nil {
+		log.Printf("Binance fetch skipped: %v", err)
+		s.fallbackBinance(ctx)
+		return err
+	}
+
+	sample, err := fetchSample(ctx, s.binanceFetcher)
+	rate := sample.Rate
+	if err == nil && s.pinned("binance", rate) {
+		s.recordFetch("binance", nil, sample)
+		return nil
+	}
+	if err == nil {
+		err = s.checkUpdate("binance", s.store.GetBinance(), rate)
+	}
+	s.recordFetch("binance", err, sample)
 	if err != nil {
-		log.Printf("Binance fetch error (keeping previous value): %v", err)
+		if _, pinned := s.store.pinnedUntil("binance"); pinned || len(s.binanceFallbacks) == 0 {
+			log.Printf("Binance fetch error (%s, keeping previous value): %v", ClassifyError(err), err)
+			return err
+		}
+		log.Printf("Binance fetch error (%s, trying fallbacks): %v", ClassifyError(err), err)
+		if !s.fallbackBinance(ctx) {
+			log.Println("Binance fallbacks failed, keeping previous value")
+		}
 		return err
 	}
 
 	s.store.SetBinance(rate)
+	s.record("binance", rate)
+	if sample.Book != nil {
+		s.setBook(*sample.Book)
+	}
 	log.Printf("Binance rate updated: %.2f", rate)
+
+	s.evaluateAlerts()
 	return nil
 }
 
+// FetchParallel fetches all additional parallel-market sources, regions, the
+// Zelle rate and the cash rate.
+// Failed and pinned sources keep their previous value; the first error is
+// returned.
+func (s *Service) FetchParallel(ctx context.Context) error {
+	var firstErr error
+	for _, name := range s.parallelNames {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.checkBackoff(name); err != nil {
+			log.Printf("Parallel source %s fetch skipped: %v", name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		sample, err := fetchSample(ctx, s.parallelSources[name])
+		rate := sample.Rate
+		if err == nil && s.pinned(name, rate) {
+			s.recordFetch(name, nil, sample)
+			continue
+		}
+		if err == nil {
+			err = s.checkUpdate(name, s.store.GetParallel(name), rate)
+		}
+		s.recordFetch(name, err, sample)
+		if err != nil {
+			log.Printf("Parallel source %s fetch error (%s, keeping previous value): %v", name, ClassifyError(err), err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+
+		s.store.SetParallel(name, rate)
+		s.record(name, rate)
+		log.Printf("Parallel source %s rate updated: %.2f", name, rate)
+	}
+	if err := s.fetchRegions(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if s.zelle != nil {
+		if err := s.fetchZelle(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if s.cash != nil {
+		if err := s.fetchCash(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	s.evaluateAlerts()
+	return firstErr
+}
+
+// recordFetch records a fetch outcome in the source's health and the fetch
+// log, and emits a source error event when a source starts failing, or
+// fails in a different way than before.
+func (s *Service) recordFetch(name string, err error, sample Sample) {
+	s.logFetch(name, err, sample)
+	kind, changed := s.health.record(name, err, sample)
+	if err == nil || !changed || s.publisher == nil {
+		return
+	}
+	s.publisher.Publish(notify.Event{
+		Type:    notify.EventSourceError,
+		Message: fmt.Sprintf("Fuente %s fallando (%s): %v", name, kind, err),
+		Data:    events.SourceDegraded{Source: name, Kind: string(kind), Error: err.Error()},
+	})
+}
+
+// fetchSample fetches a rate, along with the individual quotes when the
+// scraper aggregates several, and measures the fetch.
+func fetchSample(ctx context.Context, scraper Scraper) (Sample, error) {
+	metered, isMetered := scraper.(MeteredScraper)
+	if isMetered {
+		// Discard anything received outside fetches, e.g. by a dry run
+		metered.Transfer()
+	}
+
+	start := time.Now()
+	var sample Sample
+	var err error
+	if sampled, ok := scraper.(SampledScraper); ok {
+		sample, err = sampled.FetchSample(ctx)
+	} else {
+		sample.Rate, err = scraper.Fetch(ctx)
+	}
+
+	sample.fetchedAt = start
+	sample.took = time.Since(start)
+	if isMetered {
+		sample.transfer = metered.Transfer()
+	}
+	return sample, err
+}
+
+// FetchInflation scrapes the BCV INPC series and updates the store.
+// If scraping fails, the previous series is retained.
+func (s *Service) FetchInflation(ctx context.Context) error {
+	points, err := s.inpcScraper.FetchINPC(ctx)
+	if err != nil {
+		log.Printf("INPC fetch error (keeping previous value): %v", err)
+		return err
+	}
+
+	s.inflation.Set(points)
+	log.Printf("INPC series updated: %d months", len(points))
+	return nil
+}
+
+// CloseDay snapshots the "cierre del día" record into history and
+// emits a daily close event.
+func (s *Service) CloseDay() error {
+	dailyClose := s.store.GetDailyClose(time.Now(), s.breach)
+	if dailyClose.BCV == 0 && dailyClose.Binance == 0 {
+		return fmt.Errorf("no rate data available for daily close")
+	}
+
+	event := notify.Event{
+		Type: notify.EventDailyClose,
+		Time: dailyClose.ClosedAt,
+		Message: fmt.Sprintf("Cierre del día %s: BCV %.2f Bs, Binance %.2f Bs (máx %.2f / mín %.2f), brecha %.2f%%",
+			dailyClose.Date, dailyClose.BCV, dailyClose.Binance, dailyClose.High, dailyClose.Low, dailyClose.Breach),
+		Data: dailyClose,
+	}
+	if err := s.publishDailyClose(dailyClose, event); err != nil {
+		log.Printf("Daily close save error: %v", err)
+		return err
+	}
+	log.Printf("Daily close recorded for %s: BCV %.2f, Binance %.2f", dailyClose.Date, dailyClose.BCV, dailyClose.Binance)
+	s.refreshSummaries()
+	s.checkEscalations()
+	return nil
+}
+
+// publishDailyClose saves a daily close and publishes the event announcing
+// it. When both the history and the publisher support it, they're stored in
+// one transaction, so the close can't be kept and its notifications lost.
+func (s *Service) publishDailyClose(dailyClose DailyClose, event notify.Event) error {
+	history, ok := s.history.(EventHistory)
+	publisher, stored := s.publisher.(StoredPublisher)
+	if !ok || !stored {
+		if err := s.saveDailyClose(dailyClose); err != nil {
+			return err
+		}
+		if s.publisher != nil {
+			s.publisher.Publish(event)
+		}
+		return nil
+	}
+
+	defer s.queries.invalidate()
+	return publisher.PublishStored(event, func(event notify.Event, notifiers []string) (uint64, error) {
+		return history.SaveDailyCloseEvent(dailyClose, event, notifiers)
+	})
+}
+
+// GetDailyCloses returns all recorded daily closes ordered by date.
+func (s *Service) GetDailyCloses() ([]DailyClose, error) {
+	return s.dailyCloses()
+}
+
 // GetRates returns the current rate data.
 func (s *Service) GetRates() RateData {
-	return s.store.GetRateData()
+	d := s.store.GetRateData()
+	d.Breach = s.breach.of(d.BCV, d.Binance)
+	return d
+}
+
+// GetRatesV1 returns the detailed rate data including the parallel consensus
+// and per-source confidence scores.
+func (s *Service) GetRatesV1() RatesV1 {
+	v := s.store.GetRatesV1(s.parallelNames)
+	v.Zelle = s.zelleRate()
+	v.Cash = s.cashRate(v.Parallel.Rate)
+	s.setBreaches(&v)
+	s.health.annotate(&v, time.Now())
+	return v
+}
+
+// Changed returns a channel that is closed on the next rate update.
+func (s *Service) Changed() <-chan struct{} {
+	return s.store.Changed()
+}
+
+// GetInflation returns the current inflation data.
+func (s *Service) GetInflation() InflationData {
+	return s.inflation.GetInflationData()
+}
+
+// UpdatedAt returns when the rate of source ("bcv", "binance" or a parallel
+// source) or, for "inpc", the INPC series was last updated, whether fetched
+// or restored from a snapshot. It's zero while the service holds none.
+func (s *Service) UpdatedAt(source string) time.Time {
+	if source == "inpc" {
+		s.inflation.mu.RLock()
+		defer s.inflation.mu.RUnlock()
+		return s.inflation.updatedAt
+	}
+
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+	switch source {
+	case "bcv":
+		return s.store.bcvTime
+	case "binance":
+		return s.store.binTime
+	}
+	return s.store.parallel[source].at
 }
 
 // Initialize performs the initial data fetch on startup.
-func (s *Service) Initialize() {
+func (s *Service) Initialize(ctx context.Context) {
 	log.Println("Initializing rate data...")
 
 	// Fetch Binance first (more reliable)
-	if err := s.FetchBinance(); err != nil {
+	if err := s.FetchBinance(ctx); err != nil {
 		log.Printf("Initial Binance fetch failed: %v", err)
 	}
 
+	// Fetch additional parallel sources and regions
+	if len(s.parallelNames) > 0 || len(s.regionNames) > 0 || s.zelle != nil || s.cash != nil {
+		if err := s.FetchParallel(ctx); err != nil {
+			log.Printf("Initial parallel sources fetch failed: %v", err)
+		}
+	}
+
 	// Attempt BCV fetch
-	if err := s.FetchBCV(); err != nil {
+	if err := s.FetchBCV(ctx); err != nil {
 		log.Printf("Initial BCV fetch failed: %v", err)
 	}
 
+	// Attempt INPC fetch
+	if err := s.FetchInflation(ctx); err != nil {
+		log.Printf("Initial INPC fetch failed: %v", err)
+	}
+
+	// Summarize the history loaded from storage
+	s.refreshSummaries()
+
 	log.Println("Rate data initialization complete")
 }
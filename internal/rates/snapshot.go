@@ -0,0 +1,91 @@
+package rates
+
+import (
+	"time"
+)
+
+// Snapshot is a point-in-time copy of all service state, used to hand data
+// between processes (e.g. a one-shot fetch job and a serverless handler).
+type Snapshot struct {
+	BCV                float64       `json:"bcv"`
+	BCVUpdatedAt       time.Time     `json:"bcvUpdatedAt"`
+	Binance            float64       `json:"binance"`
+	BinanceUpdatedAt   time.Time     `json:"binanceUpdatedAt"`
+	Inflation          []IndexPoint  `json:"inflation,omitempty"`
+	InflationUpdatedAt time.Time     `json:"inflationUpdatedAt"`
+	Parallel           []SourceRate  `json:"parallel,omitempty"`
+	DailyCloses        []DailyClose  `json:"dailyCloses,omitempty"`
+	Errors             []SourceError `json:"errors,omitempty"`
+	TakenAt            time.Time     `json:"takenAt"`
+}
+
+// Snapshot returns a copy of the current service state.
+func (s *Service) Snapshot() (Snapshot, error) {
+	closes, err := s.dailyCloses()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snap := Snapshot{
+		DailyCloses: closes,
+		Errors:      s.health.lastErrors(),
+		TakenAt:     time.Now(),
+	}
+
+	s.store.mu.RLock()
+	snap.BCV, snap.BCVUpdatedAt = s.store.bcv, s.store.bcvTime
+	snap.Binance, snap.BinanceUpdatedAt = s.store.binance, s.store.binTime
+	for name, v := range s.store.parallel {
+		snap.Parallel = append(snap.Parallel, SourceRate{Name: name, Rate: v.rate, UpdatedAt: v.at, Provenance: v.provenance})
+	}
+	s.store.mu.RUnlock()
+
+	s.inflation.mu.RLock()
+	snap.Inflation = append([]IndexPoint(nil), s.inflation.points...)
+	snap.InflationUpdatedAt = s.inflation.updatedAt
+	s.inflation.mu.RUnlock()
+
+	return snap, nil
+}
+
+// Restore loads service state from a snapshot. Values newer than the
+// snapshot's are kept.
+func (s *Service) Restore(snap Snapshot) error {
+	s.store.mu.Lock()
+	if snap.BCVUpdatedAt.After(s.store.bcvTime) {
+		s.store.bcv, s.store.bcvTime = snap.BCV, snap.BCVUpdatedAt
+		s.store.bcvMirror = ""
+	}
+	if snap.BinanceUpdatedAt.After(s.store.binTime) {
+		s.store.binance, s.store.binTime = snap.Binance, snap.BinanceUpdatedAt
+	}
+	for _, src := range snap.Parallel {
+		if src.UpdatedAt.After(s.store.parallel[src.Name].at) {
+			s.store.parallel[src.Name] = sourceValue{rate: src.Rate, at: src.UpdatedAt, provenance: src.Provenance}
+		}
+	}
+	s.store.notifyChanged()
+	s.store.mu.Unlock()
+
+	s.inflation.mu.Lock()
+	if snap.InflationUpdatedAt.After(s.inflation.updatedAt) {
+		s.inflation.points = append([]IndexPoint(nil), snap.Inflation...)
+		s.inflation.updatedAt = snap.InflationUpdatedAt
+	}
+	s.inflation.mu.Unlock()
+
+	for _, e := range snap.Errors {
+		s.health.restoreError(e)
+	}
+
+	for _, dailyClose := range snap.DailyCloses {
+		if err := s.saveDailyClose(dailyClose); err != nil {
+			return err
+		}
+	}
+	if len(snap.DailyCloses) > 0 {
+		s.refreshSummaries()
+	}
+
+	return nil
+}
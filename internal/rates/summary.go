@@ -0,0 +1,182 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Summary periods.
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// periodDays is the length of each summary period, ending at the latest
+// daily close.
+var periodDays = map[string]int{
+	PeriodWeek:  7,
+	PeriodMonth: 30,
+}
+
+// Summary errors.
+var (
+	ErrUnknownPeriod = errors.New("unknown period")
+	ErrNoSummary     = errors.New("no history for period")
+)
+
+// SourceSummary aggregates one source's daily closes over a period.
+type SourceSummary struct {
+	Average float64 `json:"average"`
+	High    float64 `json:"high"`
+	Low     float64 `json:"low"`
+	Open    float64 `json:"open"`
+	Close   float64 `json:"close"`
+
+	// Change is the rate's increase over the period, in percent.
+	Change float64 `json:"change"`
+
+	// Depreciation is the bolívar's loss of value against the dollar over
+	// the period, in percent.
+	Depreciation float64 `json:"depreciation"`
+}
+
+// Summary aggregates the daily closes of a week or month.
+type Summary struct {
+	Period        string        `json:"period"`
+	From          string        `json:"from"`
+	To            string        `json:"to"`
+	Days          int           `json:"days"`
+	BCV           SourceSummary `json:"bcv"`
+	Binance       SourceSummary `json:"binance"`
+	AverageBreach float64       `json:"averageBreach"`
+	ComputedAt    time.Time     `json:"computedAt"`
+}
+
+// summaryCache holds the precomputed summaries.
+type summaryCache struct {
+	mu        sync.RWMutex
+	summaries map[string]Summary
+}
+
+// GetSummary returns the precomputed summary for a period.
+func (s *Service) GetSummary(period string) (Summary, error) {
+	if _, ok := periodDays[period]; !ok {
+		return Summary{}, fmt.Errorf("%w %q (expected %q or %q)", ErrUnknownPeriod, period, PeriodWeek, PeriodMonth)
+	}
+
+	s.summaries.mu.RLock()
+	defer s.summaries.mu.RUnlock()
+	summary, ok := s.summaries.summaries[period]
+	if !ok {
+		return Summary{}, ErrNoSummary
+	}
+	return summary, nil
+}
+
+// RefreshSummaries recomputes the period summaries from history. It runs
+// whenever history changes, so requests never aggregate on the fly.
+func (s *Service) RefreshSummaries() error {
+	closes, err := s.dailyCloses()
+	if err != nil {
+		return fmt.Errorf("failed to load history for summaries: %w", err)
+	}
+
+	now := time.Now()
+	summaries := make(map[string]Summary, len(periodDays))
+	for period, days := range periodDays {
+		if summary, ok := summarize(period, days, closes, now); ok {
+			summaries[period] = summary
+		}
+	}
+
+	s.summaries.mu.Lock()
+	s.summaries.summaries = summaries
+	s.summaries.mu.Unlock()
+	return nil
+}
+
+// refreshSummaries recomputes summaries, logging failures.
+func (s *Service) refreshSummaries() {
+	if err := s.RefreshSummaries(); err != nil {
+		log.Printf("Summary refresh error: %v", err)
+	}
+}
+
+// summarize aggregates the closes in the days ending at the latest close.
+// closes must be ordered by date.
+func summarize(period string, days int, closes []DailyClose, now time.Time) (Summary, bool) {
+	if len(closes) == 0 {
+		return Summary{}, false
+	}
+
+	last := closes[len(closes)-1]
+	end, err := time.Parse("2006-01-02", last.Date)
+	if err != nil {
+		return Summary{}, false
+	}
+	from := end.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+
+	var window []DailyClose
+	for _, c := range closes {
+		if c.Date >= from {
+			window = append(window, c)
+		}
+	}
+
+	var breach float64
+	var breachDays int
+	for _, c := range window {
+		if c.BCV > 0 && c.Binance > 0 {
+			breach += c.Breach
+			breachDays++
+		}
+	}
+
+	summary := Summary{
+		Period:     period,
+		From:       window[0].Date,
+		To:         last.Date,
+		Days:       len(window),
+		BCV:        summarizeSource(window, func(c DailyClose) float64 { return c.BCV }),
+		Binance:    summarizeSource(window, func(c DailyClose) float64 { return c.Binance }),
+		ComputedAt: now,
+	}
+	if breachDays > 0 {
+		summary.AverageBreach = roundTo(breach/float64(breachDays), 2)
+	}
+	return summary, true
+}
+
+// summarizeSource aggregates one source's closes, skipping days it had no
+// rate.
+func summarizeSource(window []DailyClose, rate func(DailyClose) float64) SourceSummary {
+	var sum SourceSummary
+	var total float64
+	var n int
+	for _, c := range window {
+		v := rate(c)
+		if v <= 0 {
+			continue
+		}
+		if n == 0 {
+			sum.Open, sum.High, sum.Low = v, v, v
+		}
+		sum.High = math.Max(sum.High, v)
+		sum.Low = math.Min(sum.Low, v)
+		sum.Close = v
+		total += v
+		n++
+	}
+	if n == 0 {
+		return SourceSummary{}
+	}
+
+	sum.Average = roundTo(total/float64(n), 2)
+	sum.Change = roundTo((sum.Close/sum.Open-1)*100, 2)
+	sum.Depreciation = roundTo((1-sum.Open/sum.Close)*100, 2)
+	return sum
+}
@@ -0,0 +1,74 @@
+package rates
+
+import "time"
+
+// unixSeconds returns t as Unix epoch seconds, or 0 for the zero time.
+func unixSeconds(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// inLocation returns t expressed in loc, leaving the zero time untouched.
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.In(loc)
+}
+
+// In returns a copy of the rate data with timestamps expressed in loc.
+func (d RateData) In(loc *time.Location) RateData {
+	d.UpdatedAt = inLocation(d.UpdatedAt, loc)
+	return d
+}
+
+// In returns a copy of the detailed rates with timestamps expressed in loc.
+func (v RatesV1) In(loc *time.Location) RatesV1 {
+	v.UpdatedAt = inLocation(v.UpdatedAt, loc)
+	v.BCV = v.BCV.In(loc)
+	if v.Zelle != nil {
+		zelle := v.Zelle.In(loc)
+		v.Zelle = &zelle
+	}
+	if v.Cash != nil {
+		cash := v.Cash.In(loc)
+		v.Cash = &cash
+	}
+
+	sources := make([]SourceRate, len(v.Parallel.Sources))
+	for i, src := range v.Parallel.Sources {
+		sources[i] = src.In(loc)
+	}
+	v.Parallel.Sources = sources
+
+	if v.Warnings != nil {
+		warnings := make([]Warning, len(v.Warnings))
+		for i, w := range v.Warnings {
+			w.Since = inLocation(w.Since, loc)
+			warnings[i] = w
+		}
+		v.Warnings = warnings
+	}
+	return v
+}
+
+// In returns a copy of the source rate with timestamps expressed in loc.
+func (r SourceRate) In(loc *time.Location) SourceRate {
+	r.UpdatedAt = inLocation(r.UpdatedAt, loc)
+	r.PinnedUntil = inLocation(r.PinnedUntil, loc)
+	return r
+}
+
+// In returns a copy of the inflation data with timestamps expressed in loc.
+func (d InflationData) In(loc *time.Location) InflationData {
+	d.UpdatedAt = inLocation(d.UpdatedAt, loc)
+	return d
+}
+
+// In returns a copy of the conversion with timestamps expressed in loc.
+func (c Conversion) In(loc *time.Location) Conversion {
+	c.UpdatedAt = inLocation(c.UpdatedAt, loc)
+	return c
+}
@@ -0,0 +1,25 @@
+package rates
+
+import (
+	"log"
+	"time"
+	_ "time/tzdata" // hosts without a zoneinfo database still get the zone
+)
+
+// Timezone is the IANA zone of Venezuela's calendar.
+const Timezone = "America/Caracas"
+
+// Venezuela is the timezone calendar days are counted in. Unlike a fixed
+// UTC-4 offset, it knows the UTC-4:30 years (2007-2016) and any later
+// change shipped in tzdata.
+var Venezuela = loadVenezuela()
+
+func loadVenezuela() *time.Location {
+	loc, err := time.LoadLocation(Timezone)
+	if err != nil {
+		// Only a broken ZONEINFO override gets here
+		log.Printf("Failed to load timezone %s, using UTC-4: %v", Timezone, err)
+		return time.FixedZone("VET", -4*60*60)
+	}
+	return loc
+}
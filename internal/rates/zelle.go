@@ -0,0 +1,60 @@
+package rates
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// zelleSource is the source name of the Zelle rate.
+const zelleSource = "zelle"
+
+// SetZelle enables the Zelle rate: the bolívares a dollar sent through Zelle
+// buys, derived from the Binance rate and the USD price of USDT on ads paid
+// through Zelle or bank transfer, which source fetches. It's refreshed with
+// the parallel sources.
+func (s *Service) SetZelle(source Scraper) {
+	s.zelle = source
+	s.health.register(zelleSource, 5*time.Minute)
+}
+
+// fetchZelle fetches the USD price of USDT and derives the Zelle rate from
+// it. If fetching fails, the previous value is retained.
+func (s *Service) fetchZelle(ctx context.Context) error {
+	if err := s.checkBackoff(zelleSource); err != nil {
+		log.Printf("Zelle fetch skipped: %v", err)
+		return err
+	}
+
+	sample, err := fetchSample(ctx, s.zelle)
+	rate := 0.0
+	if err == nil {
+		binance := s.store.GetBinance()
+		if binance <= 0 || sample.Rate <= 0 {
+			err = fmt.Errorf("cannot derive rate from Binance %.2f and USD price %.4f", binance, sample.Rate)
+		} else {
+			rate = roundTo(binance/sample.Rate, 2)
+			err = s.checkUpdate(zelleSource, s.store.GetParallel(zelleSource), rate)
+		}
+	}
+	s.recordFetch(zelleSource, err, sample)
+	if err != nil {
+		log.Printf("Zelle fetch error (%s, keeping previous value): %v", ClassifyError(err), err)
+		return fmt.Errorf("%s: %w", zelleSource, err)
+	}
+
+	s.store.SetParallel(zelleSource, rate)
+	s.record(zelleSource, rate)
+	log.Printf("Zelle rate updated: %.2f (USDT at %.4f USD)", rate, sample.Rate)
+	return nil
+}
+
+// zelleRate returns the Zelle rate as a source entry, or nil if disabled.
+func (s *Service) zelleRate() *SourceRate {
+	if s.zelle == nil {
+		return nil
+	}
+	v := s.store.parallelValue(zelleSource)
+	return &SourceRate{Name: zelleSource, Rate: v.rate, UpdatedAt: v.at}
+}
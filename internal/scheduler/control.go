@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrUnknownJob is returned for job names the scheduler doesn't run.
+var ErrUnknownJob = errors.New("unknown job")
+
+// Pause stops the scheduled runs of a job, e.g. a source that's returning
+// bad data, until Resume. Runs started with RunNow still happen. With a
+// state store, the pause outlasts restarts; it only applies to this
+// instance.
+func (s *Scheduler) Pause(job string) error {
+	return s.setPaused(job, true)
+}
+
+// Resume restarts the scheduled runs of a paused job from its next
+// scheduled time.
+func (s *Scheduler) Resume(job string) error {
+	return s.setPaused(job, false)
+}
+
+// setPaused pauses or resumes a job and persists the change.
+func (s *Scheduler) setPaused(job string, paused bool) error {
+	if _, ok := s.jobFunc(job); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, job)
+	}
+
+	s.mu.Lock()
+	s.jobHistory(job).paused = paused
+	s.mu.Unlock()
+	s.save(job)
+
+	if paused {
+		log.Printf("Scheduler: Paused %s job", job)
+	} else {
+		log.Printf("Scheduler: Resumed %s job", job)
+	}
+	return nil
+}
+
+// RunNow runs a job right away, whether or not it's paused or this
+// instance holds its lease, and returns its error. It fails with ErrBusy
+// while the job is running.
+func (s *Scheduler) RunNow(job string) error {
+	fn, ok := s.jobFunc(job)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, job)
+	}
+	log.Printf("Scheduler: Running %s job on demand", job)
+	return s.run(job, fn)
+}
+
+// jobFunc returns the function a job runs.
+func (s *Scheduler) jobFunc(job string) (func(context.Context) error, bool) {
+	switch job {
+	case JobBinance:
+		return s.service.FetchBinance, true
+	case JobParallel:
+		return s.service.FetchParallel, true
+	case JobBCV:
+		return s.service.FetchBCV, true
+	case JobInflation:
+		return s.service.FetchInflation, true
+	case JobDailyClose:
+		return ignoreContext(s.service.CloseDay), true
+	}
+	for _, p := range s.periodic {
+		if p.name == job {
+			return p.fn, true
+		}
+	}
+	return nil, false
+}
+
+// paused reports whether a job is paused, logging the scheduled run it
+// skips if so.
+func (s *Scheduler) paused(job string) bool {
+	s.mu.RLock()
+	h := s.history[job]
+	paused := h != nil && h.paused
+	s.mu.RUnlock()
+
+	if paused {
+		log.Printf("Scheduler: Skipping %s run (paused)", job)
+	}
+	return paused
+}
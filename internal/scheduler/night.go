@@ -0,0 +1,56 @@
+package scheduler
+
+import "time"
+
+// binanceInterval is how often the Binance job runs by day.
+const binanceInterval = 5 * time.Minute
+
+// NightSchedule runs the Binance job every Interval from Start until End,
+// times of day in the scheduler's timezone, instead of every 5 minutes.
+// P2P liquidity is thin overnight, so the extra requests only risk being
+// rate-limited. A window ending before it starts spans midnight.
+type NightSchedule struct {
+	Start    time.Duration
+	End      time.Duration
+	Interval time.Duration
+}
+
+// SetBinanceNight slows the Binance job overnight. It must be called before
+// Start.
+func (s *Scheduler) SetBinanceNight(night NightSchedule) {
+	s.night = &night
+}
+
+// nextBinanceRun returns when the Binance job runs after one at now. Night
+// runs stop at the end of the night, so day runs resume on time.
+func (s *Scheduler) nextBinanceRun(now time.Time) time.Time {
+	n := s.night
+	local := now.In(s.location)
+	if n == nil || !n.contains(local) {
+		return now.Add(binanceInterval)
+	}
+
+	next := now.Add(n.Interval)
+	end := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location).Add(n.End)
+	if !end.After(local) {
+		end = time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, s.location).Add(n.End)
+	}
+	if end.Before(next) {
+		return end
+	}
+	return next
+}
+
+// contains reports whether t's time of day falls in the night.
+func (n NightSchedule) contains(t time.Time) bool {
+	d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if n.Start <= n.End {
+		return d >= n.Start && d < n.End
+	}
+	return d >= n.Start || d < n.End
+}
+
+// clock formats a time of day as HH:MM.
+func clock(d time.Duration) string {
+	return time.Time{}.Add(d).Format("15:04")
+}
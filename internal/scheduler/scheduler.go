@@ -2,16 +2,80 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"runtime/debug"
 	"sync"
 	"time"
+
+	"github.com/veswatch/api/internal/rates"
 )
 
-// RateService defines the interface for rate fetching operations.
+// ErrPanic is recorded for a job run that panicked.
+var ErrPanic = errors.New("job panicked")
+
+// RateService defines the interface for rate fetching operations. Fetches
+// stop once their context is cancelled.
 type RateService interface {
-	Initialize()
-	FetchBCV() error
-	FetchBinance() error
+	FetchBCV(ctx context.Context) error
+	FetchBinance(ctx context.Context) error
+	FetchParallel(ctx context.Context) error
+	FetchInflation(ctx context.Context) error
+	CloseDay() error
+	RefreshSummaries() error
+
+	// UpdatedAt returns when a source's data was last updated, or the zero
+	// time while the service holds none.
+	UpdatedAt(source string) time.Time
+}
+
+// Job names used to report scheduling state.
+const (
+	JobBinance         = "binance"
+	JobParallel        = "parallel"
+	JobBCV             = "bcv"
+	JobInflation       = "inflation"
+	JobDailyClose      = "daily_close"
+	JobBackup          = "backup"
+	JobSummary         = "summary"
+	JobAnalytics       = "analytics"
+	JobDailyAggregates = "daily_aggregates"
+	JobMaintenance     = "maintenance"
+	JobSync            = "sync"
+)
+
+// Leases grants jobs to one of several instances sharing a store.
+type Leases interface {
+	// Acquire takes or renews this instance's lease on job for ttl and
+	// reports whether this instance holds it.
+	Acquire(ctx context.Context, job string, ttl time.Duration) (bool, error)
+}
+
+// dailyLease is the lease on jobs running at a time of day: long enough
+// that hosts with skewed clocks don't run them twice, and short enough for
+// another instance to take them over the next day.
+const dailyLease = 12 * time.Hour
+
+// leaseTimeout bounds acquiring a lease.
+const leaseTimeout = 10 * time.Second
+
+// Intervals of the parallel sources and INPC jobs.
+const (
+	parallelInterval  = 5 * time.Minute
+	inflationInterval = 24 * time.Hour
+)
+
+// bcvHour and bcvMinute are when BCV is scraped, giving it time to publish
+// the day's rate around 11:00.
+const bcvHour, bcvMinute = 11, 30
+
+// periodicJob is an additional job registered with Every or EveryContext.
+type periodicJob struct {
+	name     string
+	interval time.Duration
+	fn       func(context.Context) error
 }
 
 // Scheduler manages timed jobs for fetching exchange rates.
@@ -19,61 +83,349 @@ type Scheduler struct {
 	service RateService
 	stop    chan struct{}
 	wg      sync.WaitGroup
+
+	// Runs in progress, including abandoned ones, and the context they
+	// derive from, cancelled by Stop
+	runs   sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	periodic []periodicJob
+	leases   Leases
+	watchdog Watchdog
+	location *time.Location
+	night    *NightSchedule
+	states   StateStore
+
+	mu       sync.RWMutex
+	nextRuns map[string]time.Time
+	running  map[string]time.Time
+	history  map[string]*runHistory
 }
 
 // New creates a new scheduler instance.
 func New(service RateService) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
-		service: service,
-		stop:    make(chan struct{}),
+		service:  service,
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+		watchdog: DefaultWatchdog(),
+		location: rates.Venezuela,
+		nextRuns: make(map[string]time.Time),
+		running:  make(map[string]time.Time),
+		history:  make(map[string]*runHistory),
+	}
+}
+
+// NextRun returns the next scheduled run of the named job.
+// While a job is running, its next run is reported in the past.
+func (s *Scheduler) NextRun(job string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nextRuns[job]
+}
+
+// Stalled reports whether any job has been running for longer than maxRun.
+func (s *Scheduler) Stalled(maxRun time.Duration) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, started := range s.running {
+		if time.Since(started) > maxRun {
+			return true
+		}
+	}
+	return false
+}
+
+// run executes a job, tracking it as running for the duration of the call
+// and recording its outcome. A panic fails the run instead of ending the
+// job's loop. A run past its deadline has its context cancelled and is
+// abandoned: it's recorded as timed out, and later runs fail with ErrBusy,
+// unrecorded, until the call returns.
+func (s *Scheduler) run(job string, fn func(context.Context) error) error {
+	s.mu.Lock()
+	if _, busy := s.running[job]; busy {
+		s.mu.Unlock()
+		return ErrBusy
+	}
+	started := time.Now()
+	s.running[job] = started
+	timeout := s.deadline(job)
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	done := make(chan error, 1)
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		defer cancel()
+		err := recovered(job, func() error { return fn(ctx) })
+
+		s.mu.Lock()
+		delete(s.running, job)
+		s.mu.Unlock()
+		done <- err
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Jobs honouring the context return as it's cancelled
+		select {
+		case err = <-done:
+		default:
+			log.Printf("Scheduler: %s job exceeded its %s deadline, abandoning the run", job, timeout)
+		}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w (%s)", ErrTimeout, timeout)
+	}
+
+	s.mu.Lock()
+	s.record(job, started, time.Since(started), err)
+	s.mu.Unlock()
+	s.save(job)
+
+	return err
+}
+
+// recovered calls fn, turning a panic into an error wrapping ErrPanic.
+func recovered(job string, fn func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("Scheduler: %s job panicked: %v\n%s", job, p, debug.Stack())
+			err = fmt.Errorf("%w: %v", ErrPanic, p)
+		}
+	}()
+	return fn()
+}
+
+// setNextRun records the next scheduled run of the named job.
+func (s *Scheduler) setNextRun(job string, next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextRuns[job] = next
+}
+
+// SetLeases makes the fetch and daily close jobs run only on the instance
+// holding their lease, so instances sharing a store don't repeat them.
+// Periodic jobs hold their lease for one interval, renewing it on every run,
+// so another instance takes a job over once its holder stops running it.
+// It must be called before Start.
+func (s *Scheduler) SetLeases(leases Leases) {
+	s.leases = leases
+}
+
+// SetLocation sets the timezone the daily jobs' times of day and weekends
+// are in, Venezuela's by default. It must be called before Start.
+func (s *Scheduler) SetLocation(loc *time.Location) {
+	s.location = loc
+}
+
+// leased reports whether this instance should run job, taking or renewing
+// its lease for ttl. Without leases, every job runs here.
+func (s *Scheduler) leased(job string, ttl time.Duration) bool {
+	if s.leases == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaseTimeout)
+	defer cancel()
+	held, err := s.leases.Acquire(ctx, job, ttl)
+	if err != nil {
+		// A missed fetch is worse than a repeated one
+		log.Printf("Scheduler: Failed to acquire %s lease, running anyway: %v", job, err)
+		return true
+	}
+	if !held {
+		s.mu.Lock()
+		s.jobHistory(job).skipped++
+		s.mu.Unlock()
+	}
+	return held
+}
+
+// Every registers an additional job that runs fn at the given interval.
+// Jobs must be registered before Start.
+func (s *Scheduler) Every(name string, interval time.Duration, fn func() error) {
+	s.EveryContext(name, interval, ignoreContext(fn))
+}
+
+// EveryContext is like Every for a job that stops once its context is
+// cancelled, which happens when a run exceeds its deadline.
+func (s *Scheduler) EveryContext(name string, interval time.Duration, fn func(context.Context) error) {
+	s.periodic = append(s.periodic, periodicJob{name: name, interval: interval, fn: fn})
+}
+
+// ignoreContext adapts a job that can't be cancelled.
+func ignoreContext(fn func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return fn()
 	}
 }
 
 // Start begins the scheduler jobs.
 func (s *Scheduler) Start() {
 	log.Println("Scheduler: Starting...")
+	s.restore()
 
 	// Initialize data on startup
-	s.service.Initialize()
+	recovered("initial fetch", func() error {
+		s.initialFetch()
+		return nil
+	})
 
 	// Start Binance refresh job (every 5 minutes)
 	s.wg.Add(1)
 	go s.binanceJob()
 
+	// Start additional parallel sources refresh job (every 5 minutes)
+	s.wg.Add(1)
+	go s.parallelJob()
+
 	// Start BCV daily job
 	s.wg.Add(1)
 	go s.bcvDailyJob()
 
+	// Start INPC refresh job (once a day)
+	s.wg.Add(1)
+	go s.inflationJob()
+
+	// Start daily close job
+	s.wg.Add(1)
+	go s.dailyCloseJob()
+
+	// Start additional periodic jobs
+	for _, job := range s.periodic {
+		s.wg.Add(1)
+		go s.periodicJob(job)
+	}
+
+	// Watch for jobs that miss their runs
+	s.wg.Add(1)
+	go s.watch()
+
 	log.Println("Scheduler: All jobs started")
 }
 
-// Stop gracefully stops all scheduler jobs.
+// initialFetch fetches every source on startup, Binance first as the most
+// reliable, then the summaries. Paused jobs are skipped, as are jobs that
+// succeeded since they were last due, e.g. right before a restart, while
+// the service still holds their data.
+func (s *Scheduler) initialFetch() {
+	now := time.Now()
+	fetches := []struct {
+		job    string
+		source string    // whose data the job updates, if a single one
+		due    time.Time // when the job was last due
+		fn     func(context.Context) error
+	}{
+		{JobBinance, "binance", now.Add(-binanceInterval), s.service.FetchBinance},
+		{JobParallel, "", now.Add(-parallelInterval), s.service.FetchParallel},
+		{JobBCV, "bcv", s.previousRunTime(bcvHour, bcvMinute, true), s.service.FetchBCV},
+		{JobInflation, "inpc", now.Add(-inflationInterval), s.service.FetchInflation},
+	}
+	for _, f := range fetches {
+		if s.paused(f.job) {
+			continue
+		}
+		if last := s.lastSuccess(f.job); f.source != "" && !last.Before(f.due) && !s.service.UpdatedAt(f.source).IsZero() {
+			log.Printf("Scheduler: Skipping initial %s fetch (last succeeded %s)", f.job, last.Format(time.RFC3339))
+			continue
+		}
+		if err := s.run(f.job, f.fn); err != nil {
+			log.Printf("Scheduler: Initial %s fetch failed: %v", f.job, err)
+		}
+	}
+
+	if err := s.service.RefreshSummaries(); err != nil {
+		log.Printf("Scheduler: Summary refresh failed: %v", err)
+	}
+}
+
+// Stop gracefully stops all scheduler jobs. Runs in progress are cancelled,
+// and Stop returns once they, and any abandoned past their deadline, have
+// returned, so none outlives what they write to.
 func (s *Scheduler) Stop() {
 	log.Println("Scheduler: Stopping...")
 	close(s.stop)
+	s.cancel()
 	s.wg.Wait()
+	s.runs.Wait()
 	log.Println("Scheduler: Stopped")
 }
 
-// binanceJob refreshes Binance rates every 5 minutes.
+// binanceJob refreshes Binance rates every 5 minutes, or less often
+// overnight with a night schedule.
 func (s *Scheduler) binanceJob() {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(5 * time.Minute)
+	if s.night != nil {
+		log.Printf("Scheduler: Binance refresh job started (every 5 minutes, every %s from %s to %s)",
+			s.night.Interval, clock(s.night.Start), clock(s.night.End))
+	} else {
+		log.Println("Scheduler: Binance refresh job started (every 5 minutes)")
+	}
+
+	// Resume an interval after the last run, which a restart may have
+	// skipped
+	now := time.Now()
+	next := s.nextBinanceRun(now)
+	if resume := s.nextBinanceRun(s.lastRun(JobBinance)); resume.After(now) && resume.Before(next) {
+		next = resume
+	}
+	for {
+		s.setNextRun(JobBinance, next)
+
+		select {
+		case <-s.stop:
+			log.Println("Scheduler: Binance job stopped")
+			return
+		case <-time.After(time.Until(next)):
+			// Runs keep to their schedule rather than drifting by their
+			// duration, skipping those a slow run overlapped
+			after := s.nextBinanceRun(next)
+			if !s.paused(JobBinance) && s.leased(JobBinance, after.Sub(next)) {
+				log.Println("Scheduler: Refreshing Binance rate")
+				if err := s.run(JobBinance, s.service.FetchBinance); err != nil {
+					log.Printf("Scheduler: Binance refresh failed: %v", err)
+				}
+			}
+			if now := time.Now(); after.Before(now) {
+				after = s.nextBinanceRun(now)
+			}
+			next = after
+		}
+	}
+}
+
+// parallelJob refreshes additional parallel-market sources every 5 minutes.
+func (s *Scheduler) parallelJob() {
+	defer s.wg.Done()
+
+	interval := parallelInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	s.setNextRun(JobParallel, time.Now().Add(interval))
 
-	log.Println("Scheduler: Binance refresh job started (every 5 minutes)")
+	log.Println("Scheduler: Parallel sources refresh job started (every 5 minutes)")
 
 	for {
 		select {
 		case <-s.stop:
-			log.Println("Scheduler: Binance job stopped")
+			log.Println("Scheduler: Parallel sources job stopped")
 			return
-		case <-ticker.C:
-			log.Println("Scheduler: Refreshing Binance rate")
-			if err := s.service.FetchBinance(); err != nil {
-				log.Printf("Scheduler: Binance refresh failed: %v", err)
+		case t := <-ticker.C:
+			if !s.paused(JobParallel) && s.leased(JobParallel, interval) {
+				if err := s.run(JobParallel, s.service.FetchParallel); err != nil {
+					log.Printf("Scheduler: Parallel sources refresh failed: %v", err)
+				}
 			}
+			s.setNextRun(JobParallel, t.Add(interval))
 		}
 	}
 }
@@ -88,6 +440,7 @@ func (s *Scheduler) bcvDailyJob() {
 		// Calculate time until next BCV update (11:00 AM Venezuela time)
 		nextRun := s.nextBCVRunTime()
 		waitDuration := time.Until(nextRun)
+		s.setNextRun(JobBCV, nextRun)
 
 		log.Printf("Scheduler: Next BCV scrape scheduled for %s (in %s)",
 			nextRun.Format(time.RFC3339), waitDuration.Round(time.Minute))
@@ -97,47 +450,163 @@ func (s *Scheduler) bcvDailyJob() {
 			log.Println("Scheduler: BCV job stopped")
 			return
 		case <-time.After(waitDuration):
-			if s.isWeekday() {
+			switch {
+			case !s.isWeekday():
+				log.Println("Scheduler: Skipping BCV scrape (weekend)")
+			case s.paused(JobBCV):
+			case s.leased(JobBCV, dailyLease):
 				log.Println("Scheduler: Running BCV daily scrape")
-				if err := s.service.FetchBCV(); err != nil {
+				if err := s.run(JobBCV, s.service.FetchBCV); err != nil {
 					log.Printf("Scheduler: BCV daily scrape failed: %v", err)
 				}
-			} else {
-				log.Println("Scheduler: Skipping BCV scrape (weekend)")
 			}
 		}
 	}
 }
 
-// nextBCVRunTime calculates the next time to run the BCV scraper.
-// BCV typically updates around 11:00 AM Venezuela time (UTC-4).
-func (s *Scheduler) nextBCVRunTime() time.Time {
-	// Venezuela timezone (UTC-4)
-	loc := time.FixedZone("VET", -4*60*60)
-	now := time.Now().In(loc)
+// inflationJob refreshes the INPC series once a day, keeping to the
+// interval across restarts when the state is persisted.
+// BCV publishes INPC monthly, so a daily check picks up new releases promptly.
+func (s *Scheduler) inflationJob() {
+	defer s.wg.Done()
+
+	interval := inflationInterval
+	log.Println("Scheduler: INPC refresh job started (every 24 hours)")
+
+	next := s.resumeAt(JobInflation, interval)
+	for {
+		s.setNextRun(JobInflation, next)
+
+		select {
+		case <-s.stop:
+			log.Println("Scheduler: INPC job stopped")
+			return
+		case <-time.After(time.Until(next)):
+			if !s.paused(JobInflation) && s.leased(JobInflation, interval) {
+				log.Println("Scheduler: Refreshing INPC series")
+				if err := s.run(JobInflation, s.service.FetchInflation); err != nil {
+					log.Printf("Scheduler: INPC refresh failed: %v", err)
+				}
+			}
+			if next = next.Add(interval); next.Before(time.Now()) {
+				next = time.Now().Add(interval)
+			}
+		}
+	}
+}
+
+// dailyCloseHour and dailyCloseMinute are when the day closes.
+const dailyCloseHour, dailyCloseMinute = 23, 55
+
+// dailyCloseJob records the "cierre del día" snapshot every evening.
+// The parallel market trades on weekends, so this runs every day.
+func (s *Scheduler) dailyCloseJob() {
+	defer s.wg.Done()
+
+	log.Println("Scheduler: Daily close job started")
+
+	if s.missedDailyClose(time.Now()) && !s.paused(JobDailyClose) && s.leased(JobDailyClose, dailyLease) {
+		log.Println("Scheduler: Recording the daily close missed while stopped")
+		if err := s.run(JobDailyClose, ignoreContext(s.service.CloseDay)); err != nil {
+			log.Printf("Scheduler: Daily close failed: %v", err)
+		}
+	}
+
+	for {
+		nextRun := s.nextRunTime(dailyCloseHour, dailyCloseMinute, false)
+		waitDuration := time.Until(nextRun)
+		s.setNextRun(JobDailyClose, nextRun)
+
+		log.Printf("Scheduler: Next daily close scheduled for %s (in %s)",
+			nextRun.Format(time.RFC3339), waitDuration.Round(time.Minute))
+
+		select {
+		case <-s.stop:
+			log.Println("Scheduler: Daily close job stopped")
+			return
+		case <-time.After(waitDuration):
+			if !s.paused(JobDailyClose) && s.leased(JobDailyClose, dailyLease) {
+				log.Println("Scheduler: Recording daily close")
+				if err := s.run(JobDailyClose, ignoreContext(s.service.CloseDay)); err != nil {
+					log.Printf("Scheduler: Daily close failed: %v", err)
+				}
+			}
+		}
+	}
+}
 
-	// Target time: 11:30 AM (giving BCV time to update)
-	targetHour := 11
-	targetMinute := 30
+// periodicJob runs a job registered with Every at its interval, keeping
+// to it across restarts when the state is persisted.
+func (s *Scheduler) periodicJob(job periodicJob) {
+	defer s.wg.Done()
 
-	next := time.Date(now.Year(), now.Month(), now.Day(),
-		targetHour, targetMinute, 0, 0, loc)
+	log.Printf("Scheduler: %s job started (every %s)", job.name, job.interval)
+
+	next := s.resumeAt(job.name, job.interval)
+	for {
+		s.setNextRun(job.name, next)
 
-	// If we've passed today's target time, schedule for tomorrow
-	if now.After(next) {
-		next = next.Add(24 * time.Hour)
+		select {
+		case <-s.stop:
+			log.Printf("Scheduler: %s job stopped", job.name)
+			return
+		case <-time.After(time.Until(next)):
+			if !s.paused(job.name) {
+				if err := s.run(job.name, job.fn); err != nil {
+					log.Printf("Scheduler: %s job failed: %v", job.name, err)
+				}
+			}
+			if next = next.Add(job.interval); next.Before(time.Now()) {
+				next = time.Now().Add(job.interval)
+			}
+		}
 	}
+}
+
+// nextBCVRunTime calculates the next time to run the BCV scraper.
+// BCV typically updates around 11:00 AM Venezuela time.
+func (s *Scheduler) nextBCVRunTime() time.Time {
+	return s.nextRunTime(bcvHour, bcvMinute, true)
+}
+
+// nextRunTime calculates the next occurrence of the given time of day in the
+// scheduler's timezone, optionally skipping weekends.
+func (s *Scheduler) nextRunTime(targetHour, targetMinute int, weekdaysOnly bool) time.Time {
+	now := time.Now().In(s.location)
 
-	// Skip to Monday if next run falls on weekend
-	for next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
-		next = next.Add(24 * time.Hour)
+	// Days are stepped by date rather than by 24 hours, so the time of day
+	// holds across offset changes
+	for day := 0; ; day++ {
+		next := time.Date(now.Year(), now.Month(), now.Day()+day,
+			targetHour, targetMinute, 0, 0, s.location)
+		if now.After(next) || (weekdaysOnly && !weekday(next)) {
+			continue
+		}
+		return next
 	}
+}
 
-	return next
+// previousRunTime returns the last occurrence, up to now, of the given time
+// of day in the scheduler's timezone, optionally skipping weekends.
+func (s *Scheduler) previousRunTime(targetHour, targetMinute int, weekdaysOnly bool) time.Time {
+	now := time.Now().In(s.location)
+
+	for day := 0; ; day-- {
+		prev := time.Date(now.Year(), now.Month(), now.Day()+day,
+			targetHour, targetMinute, 0, 0, s.location)
+		if prev.After(now) || (weekdaysOnly && !weekday(prev)) {
+			continue
+		}
+		return prev
+	}
 }
 
-// isWeekday returns true if today is a weekday.
+// isWeekday returns true if today is a weekday in the scheduler's timezone.
 func (s *Scheduler) isWeekday() bool {
-	weekday := time.Now().Weekday()
-	return weekday != time.Saturday && weekday != time.Sunday
+	return weekday(time.Now().In(s.location))
+}
+
+// weekday reports whether t falls on a weekday.
+func weekday(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
 }
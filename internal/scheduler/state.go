@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// JobState is the persisted outcome of a job's most recent run, and
+// whether it's paused, so a restarted scheduler resumes where the previous
+// process left off.
+type JobState struct {
+	Job            string    `json:"job"`
+	LastRun        time.Time `json:"lastRun"`
+	LastDurationMs int64     `json:"lastDurationMs"`
+	LastError      string    `json:"lastError,omitempty"`
+	LastSuccess    time.Time `json:"lastSuccess,omitzero"`
+	Paused         bool      `json:"paused,omitempty"`
+}
+
+// StateStore persists job states across restarts.
+type StateStore interface {
+	SaveJobState(state JobState) error
+	JobStates() ([]JobState, error)
+}
+
+// SetStateStore persists the outcome of every run to store and restores
+// the last ones when the scheduler starts. It must be called before Start.
+func (s *Scheduler) SetStateStore(store StateStore) {
+	s.states = store
+}
+
+// restore loads the persisted job states into the run history, skipping
+// jobs no longer scheduled. Run counts still start from zero.
+func (s *Scheduler) restore() {
+	if s.states == nil {
+		return
+	}
+	states, err := s.states.JobStates()
+	if err != nil {
+		log.Printf("Scheduler: Failed to load job states, starting afresh: %v", err)
+		return
+	}
+
+	scheduled := map[string]bool{
+		JobBinance: true, JobParallel: true, JobBCV: true, JobInflation: true, JobDailyClose: true,
+	}
+	for _, job := range s.periodic {
+		scheduled[job.name] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	restored := 0
+	for _, st := range states {
+		if !scheduled[st.Job] {
+			continue
+		}
+		restored++
+		h := s.jobHistory(st.Job)
+		h.lastRun = st.LastRun
+		h.lastDuration = time.Duration(st.LastDurationMs) * time.Millisecond
+		h.lastSuccess = st.LastSuccess
+		h.paused = st.Paused
+		h.lastErr = nil
+		if st.LastError != "" {
+			h.lastErr = errors.New(st.LastError)
+		}
+	}
+	log.Printf("Scheduler: Restored the state of %d jobs", restored)
+	for job, h := range s.history {
+		if h.paused {
+			log.Printf("Scheduler: %s job is paused", job)
+		}
+	}
+}
+
+// save persists a job's most recent run. Failing to save only costs the
+// state on the next restart, so it's logged.
+func (s *Scheduler) save(job string) {
+	if s.states == nil {
+		return
+	}
+
+	s.mu.RLock()
+	h := s.history[job]
+	if h == nil {
+		s.mu.RUnlock()
+		return
+	}
+	st := JobState{
+		Job:            job,
+		LastRun:        h.lastRun,
+		LastDurationMs: h.lastDuration.Milliseconds(),
+		LastSuccess:    h.lastSuccess,
+		Paused:         h.paused,
+	}
+	if h.lastErr != nil {
+		st.LastError = h.lastErr.Error()
+	}
+	s.mu.RUnlock()
+
+	if err := s.states.SaveJobState(st); err != nil {
+		log.Printf("Scheduler: Failed to save %s job state: %v", job, err)
+	}
+}
+
+// lastRun returns the start of a job's most recent run, which may predate
+// this process.
+func (s *Scheduler) lastRun(job string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if h := s.history[job]; h != nil {
+		return h.lastRun
+	}
+	return time.Time{}
+}
+
+// lastSuccess returns the start of a job's most recent successful run,
+// which may predate this process.
+func (s *Scheduler) lastSuccess(job string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if h := s.history[job]; h != nil {
+		return h.lastSuccess
+	}
+	return time.Time{}
+}
+
+// resumeAt returns when a job running every interval first runs: an
+// interval after its last run, so restarts don't postpone it, or right
+// away if that has passed. Jobs that never ran wait a full interval.
+func (s *Scheduler) resumeAt(job string, interval time.Duration) time.Time {
+	now := time.Now()
+	last := s.lastRun(job)
+	switch due := last.Add(interval); {
+	case last.IsZero(), due.After(now.Add(interval)):
+		return now.Add(interval)
+	case due.Before(now):
+		return now
+	default:
+		return due
+	}
+}
+
+// missedDailyClose reports whether the process was stopped through today's
+// daily close. It can still be recorded until the day ends, after which the
+// close would be taken from the next day's rates.
+func (s *Scheduler) missedDailyClose(now time.Time) bool {
+	if s.states == nil {
+		return false
+	}
+	local := now.In(s.location)
+	due := time.Date(local.Year(), local.Month(), local.Day(), dailyCloseHour, dailyCloseMinute, 0, 0, s.location)
+	return local.After(due) && s.lastSuccess(JobDailyClose).Before(due)
+}
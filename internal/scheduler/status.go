@@ -0,0 +1,230 @@
+package scheduler
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/veswatch/api/internal/metrics"
+)
+
+// Job results.
+const (
+	ResultOK    = "ok"
+	ResultError = "error"
+)
+
+// runHistory is a job's record of past runs.
+type runHistory struct {
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastErr      error
+	lastSuccess  time.Time
+	runs         int64
+	failures     int64
+	panics       int64
+	timeouts     int64
+	skipped      int64
+
+	// Time spent in runs that returned, for the watchdog's deadline.
+	completed int64
+	busy      time.Duration
+
+	overdue bool
+
+	// Set while the job's scheduled runs are paused.
+	paused bool
+}
+
+// JobStatus describes a job's schedule and its most recent run.
+type JobStatus struct {
+	Name    string    `json:"name"`
+	NextRun time.Time `json:"nextRun,omitzero"`
+	Running bool      `json:"running"`
+
+	// Overdue is set when the job missed its scheduled run.
+	Overdue bool `json:"overdue"`
+
+	// Paused is set while an operator has paused the job's scheduled runs.
+	Paused bool `json:"paused"`
+
+	// The most recent completed run; zero until the job first runs.
+	LastRun        time.Time `json:"lastRun,omitzero"`
+	LastDurationMs int64     `json:"lastDurationMs"`
+	LastResult     string    `json:"lastResult,omitempty"`
+	LastError      string    `json:"lastError,omitempty"`
+
+	// LastSuccess is the start of the most recent successful run.
+	LastSuccess time.Time `json:"lastSuccess,omitzero"`
+
+	// Runs and failures since startup. Panics count the failures caused
+	// by a panic.
+	Runs     int64 `json:"runs"`
+	Failures int64 `json:"failures"`
+	Panics   int64 `json:"panics"`
+
+	// Timeouts count the runs abandoned past their deadline.
+	Timeouts int64 `json:"timeouts"`
+
+	// Skipped counts runs left to the instance holding the job's lease.
+	Skipped int64 `json:"skipped"`
+}
+
+// jobHistory returns a job's record of past runs, creating it if needed.
+// Callers must hold the lock.
+func (s *Scheduler) jobHistory(job string) *runHistory {
+	h := s.history[job]
+	if h == nil {
+		h = &runHistory{}
+		s.history[job] = h
+	}
+	return h
+}
+
+// record stores the outcome of a run. Callers must hold the lock.
+func (s *Scheduler) record(job string, started time.Time, took time.Duration, err error) {
+	h := s.jobHistory(job)
+	h.lastRun, h.lastDuration, h.lastErr = started, took, err
+	h.runs++
+	if err != nil {
+		h.failures++
+	} else {
+		h.lastSuccess = started
+	}
+	switch {
+	case errors.Is(err, ErrPanic):
+		h.panics++
+	case errors.Is(err, ErrTimeout):
+		h.timeouts++
+	}
+	if !errors.Is(err, ErrTimeout) {
+		h.completed++
+		h.busy += took
+	}
+}
+
+// Status returns the state of every scheduled job, ordered by name.
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make(map[string]bool)
+	for name := range s.nextRuns {
+		names[name] = true
+	}
+	for name := range s.history {
+		names[name] = true
+	}
+
+	statuses := make([]JobStatus, 0, len(names))
+	for name := range names {
+		_, running := s.running[name]
+		st := JobStatus{
+			Name:    name,
+			NextRun: s.nextRuns[name],
+			Running: running,
+		}
+		if h := s.history[name]; h != nil {
+			st.Runs = h.runs
+			st.Failures = h.failures
+			st.Panics = h.panics
+			st.Timeouts = h.timeouts
+			st.Overdue = h.overdue
+			st.Skipped = h.skipped
+			st.Paused = h.paused
+
+			// Jobs left to other instances may not have run here, and
+			// restored jobs may not have run since the restart
+			if !h.lastRun.IsZero() {
+				st.LastRun = h.lastRun
+				st.LastDurationMs = h.lastDuration.Milliseconds()
+				st.LastResult = ResultOK
+				if h.lastErr != nil {
+					st.LastResult = ResultError
+					st.LastError = h.lastErr.Error()
+				}
+			}
+			st.LastSuccess = h.lastSuccess
+		}
+		statuses = append(statuses, st)
+	}
+
+	slices.SortFunc(statuses, func(a, b JobStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return statuses
+}
+
+// Collect writes the scheduler's metrics.
+func (s *Scheduler) Collect(w *metrics.Writer) {
+	statuses := s.Status()
+
+	gauge := func(name, help string, value func(JobStatus) (float64, bool)) {
+		w.Family(name, metrics.Gauge, help)
+		for _, st := range statuses {
+			if v, ok := value(st); ok {
+				w.Sample(name, v, "job", st.Name)
+			}
+		}
+	}
+	counter := func(name, help string, value func(JobStatus) int64) {
+		w.Family(name, metrics.Counter, help)
+		for _, st := range statuses {
+			w.Sample(name, float64(value(st)), "job", st.Name)
+		}
+	}
+
+	counter("veswatch_scheduler_job_runs_total", "Completed runs of a scheduled job.", func(st JobStatus) int64 {
+		return st.Runs
+	})
+	counter("veswatch_scheduler_job_failures_total", "Failed runs of a scheduled job.", func(st JobStatus) int64 {
+		return st.Failures
+	})
+	counter("veswatch_scheduler_job_panics_total", "Runs of a scheduled job that panicked.", func(st JobStatus) int64 {
+		return st.Panics
+	})
+	counter("veswatch_scheduler_job_timeouts_total", "Runs of a scheduled job abandoned past their deadline.", func(st JobStatus) int64 {
+		return st.Timeouts
+	})
+	counter("veswatch_scheduler_job_skipped_total", "Runs of a scheduled job left to the instance holding its lease.", func(st JobStatus) int64 {
+		return st.Skipped
+	})
+	gauge("veswatch_scheduler_job_running", "Whether a scheduled job is running.", func(st JobStatus) (float64, bool) {
+		return boolValue(st.Running), true
+	})
+	gauge("veswatch_scheduler_job_overdue", "Whether a scheduled job missed its scheduled run.", func(st JobStatus) (float64, bool) {
+		return boolValue(st.Overdue), true
+	})
+	gauge("veswatch_scheduler_job_paused", "Whether a scheduled job is paused.", func(st JobStatus) (float64, bool) {
+		return boolValue(st.Paused), true
+	})
+	gauge("veswatch_scheduler_job_next_run_timestamp_seconds", "Next scheduled run of a job, in Unix time.", func(st JobStatus) (float64, bool) {
+		return unixSeconds(st.NextRun), !st.NextRun.IsZero()
+	})
+	gauge("veswatch_scheduler_job_last_run_timestamp_seconds", "Start of a job's most recent run, in Unix time.", func(st JobStatus) (float64, bool) {
+		return unixSeconds(st.LastRun), !st.LastRun.IsZero()
+	})
+	gauge("veswatch_scheduler_job_last_duration_seconds", "Duration of a job's most recent run.", func(st JobStatus) (float64, bool) {
+		return float64(st.LastDurationMs) / 1000, !st.LastRun.IsZero()
+	})
+	gauge("veswatch_scheduler_job_last_success_timestamp_seconds", "Start of a job's most recent successful run, in Unix time.", func(st JobStatus) (float64, bool) {
+		return unixSeconds(st.LastSuccess), !st.LastSuccess.IsZero()
+	})
+	gauge("veswatch_scheduler_job_last_success", "Whether a job's most recent run succeeded.", func(st JobStatus) (float64, bool) {
+		return boolValue(st.LastResult == ResultOK), !st.LastRun.IsZero()
+	})
+}
+
+// boolValue returns 1 for true and 0 for false.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// unixSeconds returns t as fractional Unix seconds.
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// Errors recorded for runs the watchdog stopped or prevented.
+var (
+	ErrTimeout = errors.New("job exceeded its deadline")
+	ErrBusy    = errors.New("previous run still in progress")
+)
+
+// Watchdog sets the deadline of job runs: Factor times the job's average
+// run time, and at least Min. A job that hasn't started Grace after its
+// scheduled run is reported overdue.
+type Watchdog struct {
+	Factor float64
+	Min    time.Duration
+	Grace  time.Duration
+}
+
+// watchInterval is how often the watchdog looks for overdue jobs.
+const watchInterval = 30 * time.Second
+
+// DefaultWatchdog returns the default watchdog: runs may take 10 times
+// their average and at least 5 minutes, and jobs are overdue a minute
+// after their scheduled run.
+func DefaultWatchdog() Watchdog {
+	return Watchdog{Factor: 10, Min: 5 * time.Minute, Grace: time.Minute}
+}
+
+// SetWatchdog replaces the default watchdog. It must be called before
+// Start.
+func (s *Scheduler) SetWatchdog(w Watchdog) {
+	s.watchdog = w
+}
+
+// deadline returns how long a run of job may take. Callers must hold the
+// lock.
+func (s *Scheduler) deadline(job string) time.Duration {
+	timeout := s.watchdog.Min
+	if h := s.history[job]; h != nil && h.completed > 0 {
+		average := h.busy / time.Duration(h.completed)
+		timeout = max(timeout, time.Duration(s.watchdog.Factor*float64(average)))
+	}
+	return timeout
+}
+
+// watch checks for overdue jobs until the scheduler stops.
+func (s *Scheduler) watch() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.checkOverdue(now)
+		}
+	}
+}
+
+// checkOverdue flags the jobs whose scheduled run is more than the grace
+// period late, logging each job once as it becomes overdue.
+func (s *Scheduler) checkOverdue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for job, next := range s.nextRuns {
+		_, running := s.running[job]
+		overdue := !running && now.Sub(next) > s.watchdog.Grace
+
+		h := s.jobHistory(job)
+		if overdue && !h.overdue {
+			log.Printf("Scheduler: %s job is overdue, it was due at %s", job, next.Format(time.RFC3339))
+		}
+		h.overdue = overdue
+	}
+}
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/veswatch/api/internal/stats"
+)
+
+// ErrInvalidAggregation is returned for an unknown or malformed aggregation.
+var ErrInvalidAggregation = errors.New("invalid aggregation")
+
+// Quote is a sampled P2P ad: its price and the asset quantity it offers.
+type Quote struct {
+	Price  float64
+	Volume float64
+}
+
+// Aggregation reduces sampled quotes to a single rate. Its name is reported
+// as the source's method.
+type Aggregation struct {
+	Name string
+	fn   func([]Quote) float64
+}
+
+// Aggregate returns the rate for a non-empty set of quotes.
+func (a Aggregation) Aggregate(quotes []Quote) float64 {
+	return a.fn(quotes)
+}
+
+// Median returns the median price.
+func Median() Aggregation {
+	return Aggregation{Name: "median", fn: func(quotes []Quote) float64 {
+		return stats.Median(prices(quotes))
+	}}
+}
+
+// TrimmedMean returns the mean price after dropping pct percent of the
+// quotes at each end, which discards outliers while averaging the rest.
+func TrimmedMean(pct float64) Aggregation {
+	name := "trimmed_mean:" + strconv.FormatFloat(pct, 'f', -1, 64)
+	return Aggregation{Name: name, fn: func(quotes []Quote) float64 {
+		return stats.TrimmedMean(prices(quotes), pct)
+	}}
+}
+
+// VWAP returns the volume-weighted average price, weighing each ad by the
+// quantity it offers. Without volumes, it falls back to the median.
+func VWAP() Aggregation {
+	return Aggregation{Name: "vwap", fn: func(quotes []Quote) float64 {
+		var sum, volume float64
+		for _, q := range quotes {
+			sum += q.Price * q.Volume
+			volume += q.Volume
+		}
+		if volume <= 0 {
+			return stats.Median(prices(quotes))
+		}
+		return sum / volume
+	}}
+}
+
+// Percentile returns the p-th percentile price (0-100).
+func Percentile(p float64) Aggregation {
+	name := "percentile:" + strconv.FormatFloat(p, 'f', -1, 64)
+	return Aggregation{Name: name, fn: func(quotes []Quote) float64 {
+		return stats.Percentile(prices(quotes), p)
+	}}
+}
+
+// ParseAggregation parses an aggregation: "median", "trimmed_mean" (20%
+// trimmed) or "trimmed_mean:<pct>", "vwap", or "percentile:<p>".
+func ParseAggregation(s string) (Aggregation, error) {
+	name, arg, hasArg := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+
+	var value float64
+	if hasArg {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return Aggregation{}, fmt.Errorf("%w %q: bad parameter", ErrInvalidAggregation, s)
+		}
+		value = v
+	}
+
+	switch {
+	case name == "median" && !hasArg:
+		return Median(), nil
+	case name == "vwap" && !hasArg:
+		return VWAP(), nil
+	case name == "trimmed_mean" && !hasArg:
+		return TrimmedMean(20), nil
+	case name == "trimmed_mean" && value >= 0 && value < 50:
+		return TrimmedMean(value), nil
+	case name == "percentile" && hasArg && value >= 0 && value <= 100:
+		return Percentile(value), nil
+	}
+	return Aggregation{}, fmt.Errorf(`%w %q (expected "median", "trimmed_mean[:pct]", "vwap" or "percentile:p")`, ErrInvalidAggregation, s)
+}
+
+// prices returns a copy of the quotes' prices.
+func prices(quotes []Quote) []float64 {
+	out := make([]float64, len(quotes))
+	for i, q := range quotes {
+		out[i] = q.Price
+	}
+	return out
+}
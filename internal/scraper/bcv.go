@@ -6,16 +6,17 @@
 package scraper
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gocolly/colly/v2"
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/pkg/vesparse"
 )
 
 const (
@@ -25,36 +26,96 @@ const (
 // BCVScraper scrapes the official USD rate from BCV website using Colly.
 type BCVScraper struct {
 	collector *colly.Collector
+	url       string
+	meter     *meter
+
+	// Range of rates the last-resort selector accepts
+	minRate, maxRate float64
 }
 
 // NewBCVScraper creates a new BCV scraper instance.
-func NewBCVScraper() *BCVScraper {
+func NewBCVScraper(opts ...Option) *BCVScraper {
+	o := applyOptions(opts)
+
 	c := colly.NewCollector(
 		colly.AllowedDomains("www.bcv.org.ve", "bcv.org.ve"),
-		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		colly.UserAgent(defaultUserAgent),
 	)
 
 	// Set timeouts
 	c.SetRequestTimeout(30 * time.Second)
 
 	// Disable TLS verification for BCV (quick fix for proxy/certificate issues)
-	c.WithTransport(&http.Transport{
+	c.WithTransport(o.roundTripper(&http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	})
-	log.Printf("BCV: TLS verification disabled")
+	}))
+	if o.transport == nil {
+		log.Printf("BCV: TLS verification disabled")
+	}
 
 	return &BCVScraper{
 		collector: c,
+		url:       bcvURL,
+		meter:     o.meter,
+		minRate:   o.minRate,
+		maxRate:   o.maxRate,
 	}
 }
 
+// NewBCVMirror creates a scraper for a copy of the BCV home page served
+// elsewhere, e.g. a Wayback Machine snapshot, for when the BCV site is
+// unreachable.
+func NewBCVMirror(pageURL string, opts ...Option) *BCVScraper {
+	o := applyOptions(opts)
+
+	c := colly.NewCollector(colly.UserAgent(defaultUserAgent))
+	c.SetRequestTimeout(30 * time.Second)
+	c.WithTransport(o.roundTripper(nil))
+
+	return &BCVScraper{
+		collector: c,
+		url:       pageURL,
+		meter:     o.meter,
+		minRate:   o.minRate,
+		maxRate:   o.maxRate,
+	}
+}
+
+// plausible reports whether a rate is within the scraper's bounds.
+func (s *BCVScraper) plausible(rate float64) bool {
+	return (s.minRate <= 0 || rate >= s.minRate) && (s.maxRate <= 0 || rate <= s.maxRate)
+}
+
+// BCVResult describes a successful BCV scrape.
+type BCVResult struct {
+	Rate     float64
+	Selector string
+}
+
 // Fetch scrapes the current USD rate from BCV website.
-func (s *BCVScraper) Fetch() (float64, error) {
+func (s *BCVScraper) Fetch(ctx context.Context) (float64, error) {
+	result, err := s.Inspect(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.Rate, nil
+}
+
+// Transfer returns what the scraper received since it was last called.
+func (s *BCVScraper) Transfer() rates.Transfer {
+	return s.meter.take()
+}
+
+// Inspect scrapes the current USD rate and reports which selector matched.
+func (s *BCVScraper) Inspect(ctx context.Context) (BCVResult, error) {
 	var rate float64
+	var selector string
 	var scrapeErr error
+	var status int
 
 	// Clone collector for thread safety
 	c := s.collector.Clone()
+	c.Context = ctx
 
 	// Track if we found the rate
 	found := false
@@ -68,10 +129,11 @@ func (s *BCVScraper) Fetch() (float64, error) {
 			rateStr = e.Text
 		}
 
-		parsed, err := parseVESRate(rateStr)
+		parsed, err := vesparse.Parse(rateStr)
 		if err == nil && parsed > 0 {
 			rate = parsed
 			found = true
+			selector = "#dolar"
 			log.Printf("BCV: Found USD rate using #dolar selector: %.4f", rate)
 		}
 	})
@@ -90,10 +152,11 @@ func (s *BCVScraper) Fetch() (float64, error) {
 				rateStr = e.Text
 			}
 
-			parsed, err := parseVESRate(rateStr)
+			parsed, err := vesparse.Parse(rateStr)
 			if err == nil && parsed > 0 {
 				rate = parsed
 				found = true
+				selector = ".recuadrotsmc .centmark"
 				log.Printf("BCV: Found USD rate using fallback selector: %.4f", rate)
 			}
 		}
@@ -106,10 +169,11 @@ func (s *BCVScraper) Fetch() (float64, error) {
 		}
 
 		rateStr := e.ChildText("strong")
-		parsed, err := parseVESRate(rateStr)
+		parsed, err := vesparse.Parse(rateStr)
 		if err == nil && parsed > 0 {
 			rate = parsed
 			found = true
+			selector = "div.col-sm-6.col-xs-6.centmark"
 			log.Printf("BCV: Found USD rate using col-sm-6 selector: %.4f", rate)
 		}
 	})
@@ -120,17 +184,19 @@ func (s *BCVScraper) Fetch() (float64, error) {
 			return
 		}
 
-		parsed, err := parseVESRate(e.Text)
-		if err == nil && parsed > 20 && parsed < 200 {
-			// Reasonable USD/VES rate range check
+		// Any number on the page matches, so only plausible rates are taken
+		parsed, err := vesparse.Parse(e.Text)
+		if err == nil && parsed > 0 && s.plausible(parsed) {
 			rate = parsed
 			found = true
+			selector = "strong"
 			log.Printf("BCV: Found USD rate using strong tag fallback: %.4f", rate)
 		}
 	})
 
 	c.OnError(func(r *colly.Response, err error) {
-		scrapeErr = fmt.Errorf("BCV request failed: %w (status: %d)", err, r.StatusCode)
+		status = r.StatusCode
+		scrapeErr = responseError(status, fmt.Errorf("BCV request failed: %w (status: %d)", err, r.StatusCode))
 		log.Printf("BCV scrape error: %v", scrapeErr)
 	})
 
@@ -139,50 +205,17 @@ func (s *BCVScraper) Fetch() (float64, error) {
 	})
 
 	// Visit the BCV website
-	if err := c.Visit(bcvURL); err != nil {
-		return 0, fmt.Errorf("failed to visit BCV: %w", err)
+	if err := c.Visit(s.url); err != nil {
+		return BCVResult{}, responseError(status, fmt.Errorf("failed to visit BCV: %w", err))
 	}
 
 	if scrapeErr != nil {
-		return 0, scrapeErr
+		return BCVResult{}, scrapeErr
 	}
 
 	if !found || rate == 0 {
-		return 0, fmt.Errorf("BCV: USD rate not found on page")
-	}
-
-	return rate, nil
-}
-
-// parseVESRate extracts a float rate from a Venezuelan formatted string.
-// Venezuelan format uses comma as decimal separator (e.g., "45,82")
-func parseVESRate(s string) (float64, error) {
-	// Clean the string
-	s = strings.TrimSpace(s)
-
-	// Remove any currency symbols, spaces, and non-numeric chars except comma and dot
-	re := regexp.MustCompile(`[^\d,.]`)
-	s = re.ReplaceAllString(s, "")
-
-	if s == "" {
-		return 0, fmt.Errorf("empty rate string")
-	}
-
-	// Venezuelan format: comma as decimal separator
-	// Replace comma with dot for parsing
-	s = strings.Replace(s, ",", ".", -1)
-
-	// Handle multiple dots (thousand separators)
-	parts := strings.Split(s, ".")
-	if len(parts) > 2 {
-		// Assume last part is decimals, rest are thousand separators
-		s = strings.Join(parts[:len(parts)-1], "") + "." + parts[len(parts)-1]
-	}
-
-	rate, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse rate '%s': %w", s, err)
+		return BCVResult{}, newError(rates.ErrorParse, "BCV: USD rate not found on page")
 	}
 
-	return rate, nil
+	return BCVResult{Rate: rate, Selector: selector}, nil
 }
@@ -2,30 +2,109 @@ package scraper
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/veswatch/api/internal/rates"
 )
 
 const (
 	binanceP2PURL = "https://p2p.binance.com/bapi/c2c/v2/friendly/c2c/adv/search"
+
+	// maxBinanceResponse bounds the response read; a page of 20 ads is
+	// around 40 KB.
+	maxBinanceResponse = 4 << 20
 )
 
 // BinanceFetcher fetches USDT/VES rates from Binance P2P.
 type BinanceFetcher struct {
 	client *http.Client
+	params BinanceParams
+	meter  *meter
+}
+
+// BinanceParams select which P2P ads are sampled.
+type BinanceParams struct {
+	// Rows is the number of ads sampled (at most 20) from Page.
+	Rows int
+	Page int
+
+	// Asset is the crypto asset, e.g. "USDT", and Fiat the currency it's
+	// priced in, "VES" by default.
+	Asset string
+	Fiat  string
+
+	// TradeType is "BUY" (ads selling the asset, what a buyer pays) or
+	// "SELL".
+	TradeType string
+
+	// Countries and PayTypes restrict ads to the given country codes and
+	// payment methods; empty means any.
+	Countries []string
+	PayTypes  []string
+
+	// Merchant filters: MerchantsOnly samples verified merchants only;
+	// ProMerchantAds and ShieldMerchantAds set Binance's flags of the same
+	// name.
+	MerchantsOnly     bool
+	ProMerchantAds    bool
+	ShieldMerchantAds bool
+
+	// Order size filters, in units of Asset: ads whose minimum order is
+	// above MinOrderCap, or whose largest possible order (the order limit
+	// or the amount left, whichever is smaller) is below MaxOrderFloor, are
+	// skipped before aggregation, as such ads skew the rate. 0 disables
+	// each.
+	MinOrderCap   float64
+	MaxOrderFloor float64
+
+	// Aggregation reduces the sampled ads to the rate; the median by
+	// default.
+	Aggregation Aggregation
+}
+
+// DefaultBinanceParams returns the default sampling: the median of the first
+// 10 USDT buy ads from any advertiser.
+func DefaultBinanceParams() BinanceParams {
+	return BinanceParams{
+		Rows:        10,
+		Page:        1,
+		Asset:       "USDT",
+		Fiat:        "VES",
+		TradeType:   "BUY",
+		Aggregation: Median(),
+	}
 }
 
 // NewBinanceFetcher creates a new Binance P2P fetcher.
-func NewBinanceFetcher() *BinanceFetcher {
+func NewBinanceFetcher(opts ...Option) *BinanceFetcher {
+	o := applyOptions(opts)
+
+	params := DefaultBinanceParams()
+	if o.binance != nil {
+		params = *o.binance
+	}
+	if params.Aggregation.fn == nil {
+		params.Aggregation = Median()
+	}
+	if params.Fiat == "" {
+		params.Fiat = "VES"
+	}
+
 	return &BinanceFetcher{
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: o.roundTripper(nil),
 		},
+		params: params,
+		meter:  o.meter,
 	}
 }
 
@@ -54,6 +133,11 @@ type binanceResponse struct {
 			FiatUnit      string `json:"fiatUnit"`
 			TradeType     string `json:"tradeType"`
 			SurplusAmount string `json:"surplusAmount"`
+			MinSingle     string `json:"minSingleTransAmount"`
+			MaxSingle     string `json:"maxSingleTransAmount"`
+			TradeMethods  []struct {
+				Identifier string `json:"identifier"`
+			} `json:"tradeMethods"`
 		} `json:"adv"`
 		Advertiser struct {
 			NickName        string  `json:"nickName"`
@@ -64,103 +148,188 @@ type binanceResponse struct {
 	Total int `json:"total"`
 }
 
+// BinanceResult describes a successful Binance P2P fetch.
+type BinanceResult struct {
+	Rate   float64
+	Method string
+	Prices []float64
+	Total  int
+	Book   rates.Book
+}
+
 // Fetch retrieves the current USDT/VES rate from Binance P2P.
-func (f *BinanceFetcher) Fetch() (float64, error) {
+func (f *BinanceFetcher) Fetch(ctx context.Context) (float64, error) {
+	result, err := f.Inspect(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.Rate, nil
+}
+
+// FetchSample retrieves the current rate along with the sampled ad prices.
+func (f *BinanceFetcher) FetchSample(ctx context.Context) (rates.Sample, error) {
+	result, err := f.Inspect(ctx)
+	if err != nil {
+		return rates.Sample{}, err
+	}
+	return rates.Sample{Rate: result.Rate, Method: result.Method, Values: result.Prices, Book: &result.Book}, nil
+}
+
+// Transfer returns what the fetcher received since it was last called.
+func (f *BinanceFetcher) Transfer() rates.Transfer {
+	return f.meter.take()
+}
+
+// Inspect retrieves the current USDT/VES rate along with the sampled prices.
+func (f *BinanceFetcher) Inspect(ctx context.Context) (BinanceResult, error) {
 	// Build request payload
+	p := f.params
 	reqBody := binanceRequest{
-		Fiat:              "VES",
-		Page:              1,
-		Rows:              10,
-		TradeType:         "BUY",
-		Asset:             "USDT",
-		ProMerchantAds:    false,
-		ShieldMerchantAds: false,
+		Fiat:              p.Fiat,
+		Page:              p.Page,
+		Rows:              p.Rows,
+		TradeType:         p.TradeType,
+		Asset:             p.Asset,
+		Countries:         p.Countries,
+		PayTypes:          p.PayTypes,
+		ProMerchantAds:    p.ProMerchantAds,
+		ShieldMerchantAds: p.ShieldMerchantAds,
+	}
+	if p.MerchantsOnly {
+		merchant := "merchant"
+		reqBody.PublisherType = &merchant
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return 0, fmt.Errorf("failed to marshal request: %w", err)
+		return BinanceResult{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", binanceP2PURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", binanceP2PURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return BinanceResult{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers to mimic browser request
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("User-Agent", defaultUserAgent)
 
-	log.Printf("Binance: Fetching P2P USDT/VES rates")
+	log.Printf("Binance: Fetching P2P %s/%s rates", p.Asset, p.Fiat)
 
 	resp, err := f.client.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("binance request failed: %w", err)
+		return BinanceResult{}, requestError(fmt.Errorf("binance request failed: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("binance returned status %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return BinanceResult{}, newError(statusKind(resp.StatusCode), "binance returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBinanceResponse+1))
 	if err != nil {
-		return 0, fmt.Errorf("failed to read response: %w", err)
+		return BinanceResult{}, newError(rates.ErrorNetwork, "failed to read response: %w", err)
 	}
+	if len(body) > maxBinanceResponse {
+		return BinanceResult{}, newError(rates.ErrorParse, "response exceeds %d bytes", maxBinanceResponse)
+	}
+
+	return decodeBinance(body, p)
+}
 
+// decodeBinance parses a P2P search response and aggregates its ads into
+// the rate. The response is untrusted: ads with a price that isn't a
+// positive finite number are skipped, volumes that aren't are ignored, and
+// a rate that overflows is an error.
+func decodeBinance(body []byte, p BinanceParams) (BinanceResult, error) {
 	var result binanceResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return 0, fmt.Errorf("failed to parse response: %w", err)
+		return BinanceResult{}, newError(rates.ErrorParse, "failed to parse response: %w", err)
 	}
 
 	if len(result.Data) == 0 {
-		return 0, fmt.Errorf("no P2P ads found for USDT/VES")
+		return BinanceResult{}, newError(rates.ErrorNotFound, "no P2P ads found for %s/%s", p.Asset, p.Fiat)
 	}
 
-	// Calculate median price from first few results for a representative rate
-	var prices []float64
+	// Aggregate the sampled ads into a representative rate
+	var quotes []Quote
+	var ads []rates.BookAd
+	skipped := 0
 	for _, ad := range result.Data {
 		price, err := strconv.ParseFloat(ad.Adv.Price, 64)
-		if err != nil {
-			log.Printf("Binance: Failed to parse price '%s': %v", ad.Adv.Price, err)
+		if err != nil || !finite(price) || price <= 0 {
+			log.Printf("Binance: Invalid price %q", ad.Adv.Price)
+			continue
+		}
+		// Volume only matters to VWAP, which ignores ads without one
+		volume, err := strconv.ParseFloat(ad.Adv.SurplusAmount, 64)
+		if err != nil || !finite(volume) || volume < 0 {
+			volume = 0
+		}
+		if !p.orderSizeOK(price, volume, ad.Adv.MinSingle, ad.Adv.MaxSingle) {
+			skipped++
 			continue
 		}
-		prices = append(prices, price)
+		quotes = append(quotes, Quote{Price: price, Volume: volume})
+
+		entry := rates.BookAd{Price: price, Surplus: volume}
+		for _, m := range ad.Adv.TradeMethods {
+			entry.PayTypes = append(entry.PayTypes, m.Identifier)
+		}
+		ads = append(ads, entry)
+	}
+
+	if skipped > 0 {
+		log.Printf("Binance: Skipped %d ads outside the order size limits", skipped)
+	}
+	if len(quotes) == 0 && skipped > 0 {
+		return BinanceResult{}, newError(rates.ErrorNotFound, "no P2P ads within the order size limits for %s/%s", p.Asset, p.Fiat)
+	}
+	if len(quotes) == 0 {
+		return BinanceResult{}, newError(rates.ErrorParse, "no valid prices found")
 	}
 
-	if len(prices) == 0 {
-		return 0, fmt.Errorf("no valid prices found")
+	agg := p.Aggregation
+	rate := agg.Aggregate(quotes)
+	if !finite(rate) || rate <= 0 {
+		return BinanceResult{}, newError(rates.ErrorParse, "invalid %s rate %v", agg.Name, rate)
 	}
+	log.Printf("Binance: Found %d prices, %s: %.2f", len(quotes), agg.Name, rate)
 
-	// Use the median price for a more stable rate
-	rate := median(prices)
-	log.Printf("Binance: Found %d prices, median: %.2f", len(prices), rate)
+	book := rates.NewBook(ads)
+	book.Asset, book.Fiat, book.TradeType = p.Asset, p.Fiat, p.TradeType
+	book.Listed, book.Skipped = result.Total, skipped
+	book.SampledAt = time.Now()
 
-	return rate, nil
+	return BinanceResult{Rate: rate, Method: agg.Name, Prices: prices(quotes), Total: result.Total, Book: book}, nil
 }
 
-// median calculates the median of a slice of float64.
-func median(prices []float64) float64 {
-	n := len(prices)
-	if n == 0 {
-		return 0
-	}
-
-	// Sort prices
-	sorted := make([]float64, n)
-	copy(sorted, prices)
-	for i := 0; i < n-1; i++ {
-		for j := i + 1; j < n; j++ {
-			if sorted[i] > sorted[j] {
-				sorted[i], sorted[j] = sorted[j], sorted[i]
-			}
+// finite reports whether f is neither infinite nor NaN.
+func finite(f float64) bool {
+	return !math.IsInf(f, 0) && !math.IsNaN(f)
+}
+
+// orderSizeOK reports whether an ad's order limits, given in fiat, are within
+// the order size filters. Limits that can't be parsed pass.
+func (p BinanceParams) orderSizeOK(price, available float64, minSingle, maxSingle string) bool {
+	if price <= 0 {
+		return true
+	}
+	if p.MinOrderCap > 0 {
+		if lo, err := strconv.ParseFloat(minSingle, 64); err == nil && lo/price > p.MinOrderCap {
+			return false
 		}
 	}
-
-	if n%2 == 0 {
-		return (sorted[n/2-1] + sorted[n/2]) / 2
+	if p.MaxOrderFloor > 0 {
+		largest := available
+		if hi, err := strconv.ParseFloat(maxSingle, 64); err == nil && (largest <= 0 || hi/price < largest) {
+			largest = hi / price
+		}
+		if largest > 0 && largest < p.MaxOrderFloor {
+			return false
+		}
 	}
-	return sorted[n/2]
+	return true
 }
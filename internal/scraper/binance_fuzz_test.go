@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixtureBody returns the body of a recorded response under
+// testdata/fixtures.
+func fixtureBody(tb testing.TB, name string) []byte {
+	tb.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "fixtures", name))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		tb.Fatalf("invalid recording %s: %v", name, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return body
+}
+
+// quietLog discards the standard logger's output until the test ends.
+func quietLog(tb testing.TB) {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(out) })
+}
+
+func FuzzDecodeBinance(f *testing.F) {
+	quietLog(f)
+	f.Add(fixtureBody(f, "p2p.binance.com/bapi_c2c_v2_friendly_c2c_adv_search.http"))
+	f.Add([]byte(`{"data":[]}`))
+	f.Add([]byte(`{"data":[{"adv":{"price":"NaN","surplusAmount":"-1"}},{"adv":{"price":"1e308","surplusAmount":"1e308"}}]}`))
+	f.Add([]byte(`{"data":[{"adv":{"price":"36.5","minSingleTransAmount":"x","maxSingleTransAmount":"0"}}],"total":-1}`))
+
+	params := DefaultBinanceParams()
+	f.Fuzz(func(t *testing.T, body []byte) {
+		result, err := decodeBinance(body, params)
+		if err != nil {
+			return
+		}
+		if !finite(result.Rate) || result.Rate <= 0 {
+			t.Fatalf("rate %v isn't a positive finite number", result.Rate)
+		}
+		if len(result.Prices) == 0 {
+			t.Fatal("rate without prices")
+		}
+		for _, price := range result.Prices {
+			if !finite(price) || price <= 0 {
+				t.Fatalf("price %v isn't a positive finite number", price)
+			}
+		}
+	})
+}
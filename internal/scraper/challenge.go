@@ -0,0 +1,101 @@
+package scraper
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// challengePeek is how much of an HTML response is searched for challenge
+// markers.
+const challengePeek = 32 << 10
+
+// challengeMarker is a body fragment identifying a challenge page.
+type challengeMarker struct {
+	fragment string
+	provider string
+}
+
+// challengeMarkers appear only on challenge pages, so they're checked on
+// any HTML response.
+var challengeMarkers = []challengeMarker{
+	{"cf-chl-", "cloudflare"},
+	{"cf_chl_opt", "cloudflare"},
+	{"/cdn-cgi/challenge-platform/", "cloudflare"},
+	{"_incapsula_resource", "imperva"},
+	{"captcha-delivery.com", "datadome"},
+	{"ddos-guard", "ddos-guard"},
+}
+
+// blockMarkers also appear on ordinary pages (e.g. a contact form's
+// CAPTCHA), so they're only checked on error responses.
+var blockMarkers = []challengeMarker{
+	{"just a moment...", "cloudflare"},
+	{"attention required! | cloudflare", "cloudflare"},
+	{"captcha", "captcha"},
+}
+
+// challengeTransport fails requests answered with an anti-bot challenge
+// page, so they're reported as challenges rather than parse failures.
+type challengeTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip sends the request and checks the response for a challenge.
+func (t *challengeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := detectChallenge(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if provider != "" {
+		resp.Body.Close()
+		return nil, newError(rates.ErrorBlocked, "%w: %s on %s (status %d)", rates.ErrChallenge, provider, req.URL.Hostname(), resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// detectChallenge returns the provider of a challenge page, or "" for a
+// regular response. The peeked part of the body is put back for the caller.
+func detectChallenge(resp *http.Response) (string, error) {
+	if resp.Header.Get("Cf-Mitigated") == "challenge" {
+		return "cloudflare", nil
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return "", nil
+	}
+
+	peek, err := io.ReadAll(io.LimitReader(resp.Body, challengePeek))
+	if err != nil {
+		return "", newError(rates.ErrorNetwork, "failed to read response: %w", err)
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+
+	body := strings.ToLower(string(peek))
+	for _, m := range challengeMarkers {
+		if strings.Contains(body, m.fragment) {
+			return m.provider, nil
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		for _, m := range blockMarkers {
+			if strings.Contains(body, m.fragment) {
+				return m.provider, nil
+			}
+		}
+	}
+	return "", nil
+}
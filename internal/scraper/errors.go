@@ -0,0 +1,68 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// Error is a scraper failure classified by kind, so callers can tell a
+// changed page from a blocked or unreachable source.
+type Error struct {
+	Kind rates.ErrorKind
+	Err  error
+}
+
+// Error returns the underlying error's message.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKind returns the failure's classification.
+func (e *Error) ErrorKind() rates.ErrorKind {
+	return e.Kind
+}
+
+// newError creates a classified error with a formatted message.
+func newError(kind rates.ErrorKind, format string, args ...any) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+// requestError classifies a failed request. Errors already classified
+// (challenges, the outbound budget) keep their kind; the rest are network
+// failures.
+func requestError(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Kind: rates.ErrorNetwork, Err: err}
+}
+
+// responseError classifies a failed colly request by its status, which is
+// 0 if no response was received.
+func responseError(status int, err error) error {
+	if status == 0 {
+		return requestError(err)
+	}
+	return &Error{Kind: statusKind(status), Err: err}
+}
+
+// statusKind classifies an unsuccessful HTTP status.
+func statusKind(status int) rates.ErrorKind {
+	switch status {
+	case http.StatusNotFound, http.StatusGone:
+		return rates.ErrorNotFound
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusUnavailableForLegalReasons:
+		return rates.ErrorBlocked
+	default:
+		return rates.ErrorNetwork
+	}
+}
@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/veswatch/api/internal/fixture"
+	"github.com/veswatch/api/internal/rates"
+)
+
+// fixtures is the directory of the recorded BCV and Binance responses.
+const fixtures = "testdata/fixtures"
+
+func TestBCVReplay(t *testing.T) {
+	quietLog(t)
+	s := NewBCVScraper(WithTransport(fixture.Replay{Dir: fixtures}))
+
+	result, err := s.Inspect(t.Context())
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if result.Rate != 45.8203 || result.Selector != "#dolar" {
+		t.Errorf("got %v from %q, want 45.8203 from #dolar", result.Rate, result.Selector)
+	}
+	if transfer := s.Transfer(); transfer.Status != 200 || transfer.Bytes == 0 {
+		t.Errorf("got transfer %+v, want status 200 and the page's bytes", transfer)
+	}
+}
+
+func TestBinanceReplay(t *testing.T) {
+	quietLog(t)
+	f := NewBinanceFetcher(WithTransport(fixture.Replay{Dir: fixtures}))
+
+	result, err := f.Inspect(t.Context())
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if math.Abs(result.Rate-46.32) > 1e-9 || result.Method != "median" {
+		t.Errorf("got %v by %q, want 46.32 by median", result.Rate, result.Method)
+	}
+	if len(result.Prices) != 10 || result.Total != 184 {
+		t.Errorf("got %d prices of %d ads, want 10 of 184", len(result.Prices), result.Total)
+	}
+	book := result.Book
+	if book.Ads != 10 || book.Price.Min != 46.25 || book.Price.Max != 46.45 || book.Surplus != 7562.5 {
+		t.Errorf("got book %+v", book)
+	}
+	if len(book.PayTypes) != 2 || book.PayTypes[0].Ads != 10 {
+		t.Errorf("got payment methods %+v, want 2 on every ad", book.PayTypes)
+	}
+	if transfer := f.Transfer(); transfer.Status != 200 || transfer.Bytes == 0 {
+		t.Errorf("got transfer %+v, want status 200 and the response's bytes", transfer)
+	}
+}
+
+func TestBinanceReplayVWAP(t *testing.T) {
+	quietLog(t)
+	params := DefaultBinanceParams()
+	params.Aggregation = VWAP()
+	f := NewBinanceFetcher(WithTransport(fixture.Replay{Dir: fixtures}), WithBinanceParams(params))
+
+	sample, err := f.FetchSample(t.Context())
+	if err != nil {
+		t.Fatalf("FetchSample failed: %v", err)
+	}
+	if sample.Method != "vwap" || sample.Rate < 46.25 || sample.Rate > 46.45 {
+		t.Errorf("got %v by %q, want a vwap within the ad prices", sample.Rate, sample.Method)
+	}
+}
+
+func TestINPCReplay(t *testing.T) {
+	quietLog(t)
+	s := NewINPCScraper(WithTransport(fixture.Replay{Dir: fixtures}))
+
+	points, err := s.FetchINPC(t.Context())
+	if err != nil {
+		t.Fatalf("FetchINPC failed: %v", err)
+	}
+	if len(points) == 0 {
+		t.Fatal("no index values")
+	}
+	first := points[0]
+	if want := time.Date(2025, time.January, 1, 0, 0, 0, 0, first.Month.Location()); !first.Month.Equal(want) || first.Index != 1131.52 {
+		t.Errorf("got first point %v %v, want 2025-01 1131.52", first.Month, first.Index)
+	}
+}
+
+func TestReplayWithoutRecording(t *testing.T) {
+	quietLog(t)
+	replay := WithTransport(fixture.Replay{Dir: t.TempDir()})
+
+	if _, err := NewBCVScraper(replay).Fetch(t.Context()); err == nil {
+		t.Error("BCV fetch succeeded without a recording")
+	}
+	_, err := NewBinanceFetcher(replay).Fetch(t.Context())
+	if kind := rates.ClassifyError(err); err == nil || kind == rates.ErrorParse {
+		t.Errorf("Binance fetch without a recording: got %v (%s), want a request error", err, kind)
+	}
+}
@@ -0,0 +1,137 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultUserAgent is the browser User-Agent sent without header profiles.
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// HeaderProfile is a set of browser-like request headers.
+type HeaderProfile struct {
+	Name      string            `json:"name"`
+	UserAgent string            `json:"userAgent"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// DefaultHeaderProfiles returns the built-in profile: a desktop Chrome.
+func DefaultHeaderProfiles() []HeaderProfile {
+	return []HeaderProfile{{
+		Name:      "chrome-windows",
+		UserAgent: defaultUserAgent,
+	}}
+}
+
+// HeaderProfiles rotates requests through header profiles, one profile per
+// request in turn. Profiles loaded from a file are reloaded when it
+// changes, so they can be adjusted without a restart.
+type HeaderProfiles struct {
+	path string
+
+	mu       sync.Mutex
+	profiles []HeaderProfile
+	modTime  time.Time
+	next     int
+}
+
+// NewHeaderProfiles creates a rotation over fixed profiles.
+func NewHeaderProfiles(profiles []HeaderProfile) *HeaderProfiles {
+	return &HeaderProfiles{profiles: profiles}
+}
+
+// LoadHeaderProfiles creates a rotation over the profiles in a JSON file
+// holding an array of profiles.
+func LoadHeaderProfiles(path string) (*HeaderProfiles, error) {
+	p := &HeaderProfiles{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// reload reads the profiles file if it changed. Callers must hold the lock,
+// except while loading.
+func (p *HeaderProfiles) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read header profiles: %w", err)
+	}
+	if info.ModTime().Equal(p.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read header profiles: %w", err)
+	}
+	var profiles []HeaderProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("failed to parse header profiles: %w", err)
+	}
+	if len(profiles) == 0 {
+		return errors.New("header profiles file is empty")
+	}
+	for i, profile := range profiles {
+		if profile.UserAgent == "" {
+			return fmt.Errorf("header profile %d (%q) has no userAgent", i, profile.Name)
+		}
+	}
+
+	p.profiles, p.modTime = profiles, info.ModTime()
+	log.Printf("Scraper: Loaded %d header profiles from %s", len(profiles), p.path)
+	return nil
+}
+
+// Next returns the next profile in the rotation.
+func (p *HeaderProfiles) Next() HeaderProfile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.path != "" {
+		if err := p.reload(); err != nil {
+			log.Printf("Scraper: Keeping previous header profiles: %v", err)
+		}
+	}
+	if len(p.profiles) == 0 {
+		return DefaultHeaderProfiles()[0]
+	}
+
+	profile := p.profiles[p.next%len(p.profiles)]
+	p.next++
+	return profile
+}
+
+// Transport wraps base (http.DefaultTransport if nil) so each request is
+// sent with the next profile's headers.
+func (p *HeaderProfiles) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &profileTransport{profiles: p, base: base}
+}
+
+// profileTransport applies header profiles to a transport.
+type profileTransport struct {
+	profiles *HeaderProfiles
+	base     http.RoundTripper
+}
+
+// RoundTrip sends the request with the next profile's headers.
+func (t *profileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	profile := t.profiles.Next()
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", profile.UserAgent)
+	for k, v := range profile.Headers {
+		req.Header.Set(k, v)
+	}
+	return t.base.RoundTrip(req)
+}
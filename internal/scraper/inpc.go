@@ -0,0 +1,163 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/pkg/vesparse"
+)
+
+const (
+	inpcURL = "https://www.bcv.org.ve/estadisticas/consumidor"
+)
+
+// spanishMonths maps Spanish month names and abbreviations to months.
+var spanishMonths = map[string]time.Month{
+	"ene": time.January, "enero": time.January,
+	"feb": time.February, "febrero": time.February,
+	"mar": time.March, "marzo": time.March,
+	"abr": time.April, "abril": time.April,
+	"may": time.May, "mayo": time.May,
+	"jun": time.June, "junio": time.June,
+	"jul": time.July, "julio": time.July,
+	"ago": time.August, "agosto": time.August,
+	"sep": time.September, "sept": time.September, "septiembre": time.September, "setiembre": time.September,
+	"oct": time.October, "octubre": time.October,
+	"nov": time.November, "noviembre": time.November,
+	"dic": time.December, "diciembre": time.December,
+}
+
+// periodPattern matches month/year labels such as "Enero 2025", "ene-25" or "01/2025".
+var periodPattern = regexp.MustCompile(`(?i)^([a-záéíóú]+|\d{1,2})[\s\-/.]+(\d{2}|\d{4})$`)
+
+// INPCScraper scrapes the monthly INPC series from the BCV website using Colly.
+type INPCScraper struct {
+	collector *colly.Collector
+}
+
+// NewINPCScraper creates a new INPC scraper instance.
+func NewINPCScraper(opts ...Option) *INPCScraper {
+	o := applyOptions(opts)
+
+	c := colly.NewCollector(
+		colly.AllowedDomains("www.bcv.org.ve", "bcv.org.ve"),
+		colly.UserAgent(defaultUserAgent),
+	)
+
+	// Set timeouts
+	c.SetRequestTimeout(30 * time.Second)
+
+	// Same TLS workaround as the BCV rate scraper
+	c.WithTransport(o.roundTripper(&http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}))
+
+	return &INPCScraper{
+		collector: c,
+	}
+}
+
+// FetchINPC scrapes the published INPC series from the BCV website.
+func (s *INPCScraper) FetchINPC(ctx context.Context) ([]rates.IndexPoint, error) {
+	var points []rates.IndexPoint
+	var scrapeErr error
+	var status int
+
+	// Clone collector for thread safety
+	c := s.collector.Clone()
+	c.Context = ctx
+
+	seen := make(map[time.Time]bool)
+
+	// INPC tables list one month per row: period label followed by the index value
+	c.OnHTML("table tr", func(e *colly.HTMLElement) {
+		cells := e.ChildTexts("td")
+		if len(cells) < 2 {
+			return
+		}
+
+		month, err := parsePeriod(cells[0])
+		if err != nil || seen[month] {
+			return
+		}
+
+		index, err := vesparse.Parse(cells[1])
+		if err != nil || index <= 0 {
+			return
+		}
+
+		seen[month] = true
+		points = append(points, rates.IndexPoint{
+			Month: month,
+			Index: index,
+		})
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		status = r.StatusCode
+		scrapeErr = responseError(status, fmt.Errorf("INPC request failed: %w (status: %d)", err, r.StatusCode))
+		log.Printf("INPC scrape error: %v", scrapeErr)
+	})
+
+	c.OnRequest(func(r *colly.Request) {
+		log.Printf("INPC: Scraping %s", r.URL.String())
+	})
+
+	if err := c.Visit(inpcURL); err != nil {
+		return nil, responseError(status, fmt.Errorf("failed to visit BCV INPC page: %w", err))
+	}
+
+	if scrapeErr != nil {
+		return nil, scrapeErr
+	}
+
+	if len(points) == 0 {
+		return nil, newError(rates.ErrorParse, "INPC: no index values found on page")
+	}
+
+	log.Printf("INPC: Found %d monthly index values", len(points))
+	return points, nil
+}
+
+// parsePeriod parses a Spanish month/year label into the first day of that month.
+func parsePeriod(s string) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	m := periodPattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("unrecognized period '%s'", s)
+	}
+
+	var month time.Month
+	if n, err := strconv.Atoi(m[1]); err == nil {
+		if n < 1 || n > 12 {
+			return time.Time{}, fmt.Errorf("invalid month in period '%s'", s)
+		}
+		month = time.Month(n)
+	} else {
+		var ok bool
+		month, ok = spanishMonths[m[1]]
+		if !ok {
+			return time.Time{}, fmt.Errorf("unknown month name in period '%s'", s)
+		}
+	}
+
+	year, err := strconv.Atoi(m[2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid year in period '%s'", s)
+	}
+	if year < 100 {
+		year += 2000
+	}
+
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), nil
+}
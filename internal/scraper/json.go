@@ -0,0 +1,129 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/pkg/vesparse"
+)
+
+// JSONFetcher reads a rate from a field of a JSON API response, for
+// parallel-market sources that publish a plain JSON quote.
+type JSONFetcher struct {
+	name   string
+	url    string
+	path   []string
+	client *http.Client
+	meter  *meter
+}
+
+// NewJSONFetcher creates a fetcher reading the dot-separated field path
+// (e.g. "USD.VES" or "data.0.price") from the response at url.
+func NewJSONFetcher(name, url, path string, opts ...Option) *JSONFetcher {
+	o := applyOptions(opts)
+
+	var fields []string
+	if path != "" {
+		fields = strings.Split(path, ".")
+	}
+
+	return &JSONFetcher{
+		name: name,
+		url:  url,
+		path: fields,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: o.roundTripper(nil),
+		},
+		meter: o.meter,
+	}
+}
+
+// Fetch retrieves the rate from the configured endpoint.
+func (f *JSONFetcher) Fetch(ctx context.Context) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	log.Printf("%s: Fetching %s", f.name, f.url)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, requestError(fmt.Errorf("%s request failed: %w", f.name, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return 0, newError(statusKind(resp.StatusCode), "%s returned status %d: %s", f.name, resp.StatusCode, string(body))
+	}
+
+	var doc any
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return 0, newError(rates.ErrorParse, "failed to parse response: %w", err)
+	}
+
+	value, err := lookupPath(doc, f.path)
+	if err != nil {
+		return 0, newError(rates.ErrorParse, "%s: %w", f.name, err)
+	}
+
+	var rate float64
+	switch v := value.(type) {
+	case float64:
+		rate = v
+	case string:
+		if rate, err = strconv.ParseFloat(v, 64); err != nil {
+			if rate, err = vesparse.Parse(v); err != nil {
+				return 0, newError(rates.ErrorParse, "%s: %w", f.name, err)
+			}
+		}
+	default:
+		return 0, newError(rates.ErrorParse, "%s: field %q is not a number", f.name, strings.Join(f.path, "."))
+	}
+
+	if rate <= 0 {
+		return 0, newError(rates.ErrorParse, "%s: invalid rate %v", f.name, rate)
+	}
+	return rate, nil
+}
+
+// Transfer returns what the fetcher received since it was last called.
+func (f *JSONFetcher) Transfer() rates.Transfer {
+	return f.meter.take()
+}
+
+// lookupPath walks a decoded JSON document along the given keys.
+// Numeric keys index into arrays.
+func lookupPath(doc any, path []string) (any, error) {
+	current := doc
+	for _, key := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			v, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("field %q not found", key)
+			}
+			current = v
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("index %q out of range", key)
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("cannot descend into %q", key)
+		}
+	}
+	return current, nil
+}
@@ -0,0 +1,159 @@
+package scraper
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/metrics"
+	"github.com/veswatch/api/internal/rates"
+)
+
+// ErrBudgetExceeded is returned for a request over its host's hourly budget.
+var ErrBudgetExceeded = errors.New("outbound request budget exceeded")
+
+// HostLimit is the outbound request policy for a host.
+type HostLimit struct {
+	// Interval is the minimum time between requests; later requests wait.
+	Interval time.Duration
+
+	// PerHour is the request budget per clock hour; requests over it fail
+	// with ErrBudgetExceeded. 0 means unlimited.
+	PerHour int
+}
+
+// hostState tracks a host's recent requests.
+type hostState struct {
+	next     time.Time // earliest time the next request may start
+	window   time.Time // start of the current budget hour
+	count    int       // requests in the current hour
+	requests int64
+	rejected int64
+}
+
+// Limiter spaces out and budgets outbound requests per host. A single
+// limiter is shared by every scraper, so retries and manual refreshes count
+// against the same budget as scheduled fetches.
+type Limiter struct {
+	limits   map[string]HostLimit
+	fallback HostLimit
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// NewLimiter creates a limiter. Limits apply to a host and its subdomains
+// (e.g. "bcv.org.ve" covers "www.bcv.org.ve"); other hosts get fallback.
+func NewLimiter(limits map[string]HostLimit, fallback HostLimit) *Limiter {
+	return &Limiter{
+		limits:   limits,
+		fallback: fallback,
+		hosts:    make(map[string]*hostState),
+	}
+}
+
+// limit returns the policy for a host and the key its state is tracked
+// under.
+func (l *Limiter) limit(host string) (string, HostLimit) {
+	for domain, limit := range l.limits {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return domain, limit
+		}
+	}
+	return host, l.fallback
+}
+
+// wait reserves a request slot for host, returning how long to wait before
+// sending it.
+func (l *Limiter) wait(host string, now time.Time) (time.Duration, error) {
+	key, limit := l.limit(host)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h := l.hosts[key]
+	if h == nil {
+		h = &hostState{}
+		l.hosts[key] = h
+	}
+
+	if window := now.Truncate(time.Hour); !h.window.Equal(window) {
+		h.window = window
+		h.count = 0
+	}
+	if limit.PerHour > 0 && h.count >= limit.PerHour {
+		h.rejected++
+		return 0, newError(rates.ErrorBlocked, "%w for %s (%d per hour)", ErrBudgetExceeded, key, limit.PerHour)
+	}
+	h.count++
+	h.requests++
+
+	start := now
+	if h.next.After(start) {
+		start = h.next
+	}
+	h.next = start.Add(limit.Interval)
+	return start.Sub(now), nil
+}
+
+// Transport wraps base (http.DefaultTransport if nil) so its requests are
+// limited.
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &limitedTransport{limiter: l, base: base}
+}
+
+// limitedTransport applies a limiter to a transport.
+type limitedTransport struct {
+	limiter *Limiter
+	base    http.RoundTripper
+}
+
+// RoundTrip waits for the request's slot, then sends it.
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay, err := t.limiter.wait(req.URL.Hostname(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Collect writes the limiter's metrics.
+func (l *Limiter) Collect(w *metrics.Writer) {
+	l.mu.Lock()
+	hosts := make([]string, 0, len(l.hosts))
+	for host := range l.hosts {
+		hosts = append(hosts, host)
+	}
+	slices.Sort(hosts)
+	requests := make([]int64, len(hosts))
+	rejected := make([]int64, len(hosts))
+	for i, host := range hosts {
+		requests[i], rejected[i] = l.hosts[host].requests, l.hosts[host].rejected
+	}
+	l.mu.Unlock()
+
+	w.Family("veswatch_outbound_requests_total", metrics.Counter, "Outbound requests sent to a host.")
+	for i, host := range hosts {
+		w.Sample("veswatch_outbound_requests_total", float64(requests[i]), "host", host)
+	}
+	w.Family("veswatch_outbound_rejected_total", metrics.Counter, "Outbound requests refused for exceeding a host's budget.")
+	for i, host := range hosts {
+		w.Sample("veswatch_outbound_rejected_total", float64(rejected[i]), "host", host)
+	}
+}
@@ -0,0 +1,59 @@
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// meter measures the responses a scraper receives, for the fetch log.
+type meter struct {
+	mu     sync.Mutex
+	status int
+	bytes  int64
+}
+
+// take returns what was received since it was last called.
+func (m *meter) take() rates.Transfer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := rates.Transfer{Status: m.status, Bytes: m.bytes}
+	m.status, m.bytes = 0, 0
+	return t
+}
+
+// meterTransport records the status and body size of responses.
+type meterTransport struct {
+	base  http.RoundTripper
+	meter *meter
+}
+
+// RoundTrip sends the request and counts the response body as it's read.
+func (t *meterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.meter.mu.Lock()
+	t.meter.status = resp.StatusCode
+	t.meter.mu.Unlock()
+	resp.Body = &meteredBody{ReadCloser: resp.Body, meter: t.meter}
+	return resp, nil
+}
+
+// meteredBody counts the bytes read from a response body.
+type meteredBody struct {
+	io.ReadCloser
+	meter *meter
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.meter.mu.Lock()
+	b.meter.bytes += int64(n)
+	b.meter.mu.Unlock()
+	return n, err
+}
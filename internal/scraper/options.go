@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"net/http"
+)
+
+// Option configures a scraper.
+type Option func(*options)
+
+// options holds scraper settings.
+type options struct {
+	transport http.RoundTripper
+	limiter   *Limiter
+	profiles  *HeaderProfiles
+	binance   *BinanceParams
+	meter     *meter
+
+	// Range of rates the BCV scraper's last-resort selector accepts
+	minRate, maxRate float64
+}
+
+// WithTransport sets the HTTP transport used for outbound requests, e.g. a
+// fixture replay transport in tests and dry runs.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithLimiter sends requests through a shared outbound limiter.
+func WithLimiter(l *Limiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// WithHeaderProfiles sends requests with rotating browser header profiles
+// instead of the built-in User-Agent.
+func WithHeaderProfiles(p *HeaderProfiles) Option {
+	return func(o *options) {
+		o.profiles = p
+	}
+}
+
+// WithBinanceParams sets which P2P ads the Binance fetcher samples, instead
+// of DefaultBinanceParams.
+func WithBinanceParams(params BinanceParams) Option {
+	return func(o *options) {
+		o.binance = &params
+	}
+}
+
+// WithBounds restricts the BCV scraper's last-resort selector, which matches
+// any number on the page, to rates within [min, max]. A zero min or max
+// leaves that side open.
+func WithBounds(min, max float64) Option {
+	return func(o *options) {
+		o.minRate, o.maxRate = min, max
+	}
+}
+
+// applyOptions builds the settings from a list of options.
+func applyOptions(opts []Option) options {
+	o := options{meter: &meter{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// roundTripper returns the transport for outbound requests: the configured
+// transport, or fallback (nil for http.DefaultTransport), measured by the
+// meter, failing on anti-bot challenge pages, with header profiles and
+// behind the limiter if they are set.
+func (o options) roundTripper(fallback http.RoundTripper) http.RoundTripper {
+	rt := fallback
+	if o.transport != nil {
+		rt = o.transport
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	rt = &meterTransport{base: rt, meter: o.meter}
+	rt = &challengeTransport{base: rt}
+	if o.profiles != nil {
+		rt = o.profiles.Transport(rt)
+	}
+	if o.limiter != nil {
+		rt = o.limiter.Transport(rt)
+	}
+	return rt
+}
@@ -0,0 +1,222 @@
+// Package sheets appends daily closes to a Google Sheet.
+package sheets
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/internal/rates"
+)
+
+// sheetsScope grants read/write access to spreadsheets.
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+// apiBase is the Sheets API endpoint.
+const apiBase = "https://sheets.googleapis.com/v4/spreadsheets/"
+
+// credentials is the subset of a service account key file used here.
+type credentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// Exporter appends a row to a spreadsheet for every daily close event. The
+// sheet must be shared with the service account's email.
+type Exporter struct {
+	email    string
+	key      *rsa.PrivateKey
+	tokenURI string
+
+	appendURL string
+	client    *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewExporter loads a service account key file and targets a spreadsheet
+// range, e.g. "Cierres!A:G".
+func NewExporter(credentialsFile, spreadsheetID, sheetRange string) (*Exporter, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account key: %w", err)
+	}
+
+	var creds credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	if creds.ClientEmail == "" || creds.PrivateKey == "" {
+		return nil, errors.New("service account key is missing client_email or private_key")
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return nil, errors.New("service account private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service account private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account private key is not an RSA key")
+	}
+
+	return &Exporter{
+		email:     creds.ClientEmail,
+		key:       key,
+		tokenURI:  creds.TokenURI,
+		appendURL: apiBase + url.PathEscape(spreadsheetID) + "/values/" + url.PathEscape(sheetRange) + ":append?valueInputOption=USER_ENTERED&insertDataOption=INSERT_ROWS",
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Name returns the notifier name.
+func (e *Exporter) Name() string {
+	return "sheets"
+}
+
+// Notify appends daily closes as rows of date, BCV, Binance, high, low,
+// breach and close time. Other events are ignored.
+func (e *Exporter) Notify(event notify.Event) error {
+	if event.Type != notify.EventDailyClose {
+		return nil
+	}
+	c, ok := event.Data.(rates.DailyClose)
+	if !ok {
+		return fmt.Errorf("unexpected daily close payload %T", event.Data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return e.Append(ctx, []any{c.Date, c.BCV, c.Binance, c.High, c.Low, c.Breach, c.ClosedAt.Format(time.RFC3339)})
+}
+
+// Append adds a row after the last row of the range.
+func (e *Exporter) Append(ctx context.Context, row []any) error {
+	token, err := e.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]any{"values": [][]any{row}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.appendURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sheets request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sheets returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// accessToken returns a cached OAuth access token, exchanging a signed JWT
+// assertion for a new one when it's about to expire.
+func (e *Exporter) accessToken(ctx context.Context) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.token != "" && time.Until(e.expires) > time.Minute {
+		return e.token, nil
+	}
+
+	assertion, err := e.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("token endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	e.token = token.AccessToken
+	e.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return e.token, nil
+}
+
+// assertion builds the RS256-signed JWT for the token exchange.
+func (e *Exporter) assertion(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iss":   e.email,
+		"scope": sheetsScope,
+		"aud":   e.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, e.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token assertion: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
@@ -0,0 +1,115 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Influx writes points to InfluxDB 2.x (or a compatible API) using the line
+// protocol, as measurement veswatch_rate with a source tag and a rate field.
+type Influx struct {
+	writeURL   string
+	bucketsURL string
+	token      string
+	client     *http.Client
+}
+
+// NewInflux creates an InfluxDB backend for a server URL, organization,
+// bucket and API token.
+func NewInflux(serverURL, org, bucket, token string) *Influx {
+	q := url.Values{}
+	q.Set("org", org)
+	q.Set("bucket", bucket)
+	q.Set("precision", "s")
+
+	lookup := url.Values{}
+	lookup.Set("org", org)
+	lookup.Set("name", bucket)
+
+	serverURL = strings.TrimRight(serverURL, "/")
+	return &Influx{
+		writeURL:   serverURL + "/api/v2/write?" + q.Encode(),
+		bucketsURL: serverURL + "/api/v2/buckets?" + lookup.Encode(),
+		token:      token,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name identifies the backend in logs.
+func (i *Influx) Name() string {
+	return "influxdb"
+}
+
+// Write sends the points in a single request.
+func (i *Influx) Write(ctx context.Context, points []Point) error {
+	var body strings.Builder
+	for _, p := range points {
+		fmt.Fprintf(&body, "veswatch_rate,source=%s rate=%g %d\n", escapeTag(p.Source), p.Rate, p.Time.Unix())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.writeURL, strings.NewReader(body.String()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.token != "" {
+		req.Header.Set("Authorization", "Token "+i.token)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("influxdb request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("influxdb returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Ping checks that the server is reachable and the token can see the
+// bucket, without writing.
+func (i *Influx) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, i.bucketsURL, nil)
+	if err != nil {
+		return err
+	}
+	if i.token != "" {
+		req.Header.Set("Authorization", "Token "+i.token)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("influxdb request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("influxdb returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var result struct {
+		Buckets []struct{} `json:"buckets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to parse influxdb response: %w", err)
+	}
+	if len(result.Buckets) == 0 {
+		return fmt.Errorf("influxdb bucket not found")
+	}
+	return nil
+}
+
+// escapeTag escapes a line protocol tag value.
+func escapeTag(v string) string {
+	return strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `).Replace(v)
+}
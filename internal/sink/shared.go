@@ -0,0 +1,92 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/internal/snapshot"
+)
+
+// Leases grants scheduled jobs to one of the instances sharing the database.
+// Expiry is set and compared by the database's clock, so the instances'
+// clocks needn't agree.
+type Leases struct {
+	db     *sql.DB
+	holder string
+}
+
+// Leases returns the job leases held under holder, which must be unique to
+// this instance.
+func (t *Timescale) Leases(holder string) *Leases {
+	return &Leases{db: t.db, holder: holder}
+}
+
+// Acquire takes the lease on job for ttl if it's free or expired, or renews
+// it if this instance holds it, and reports whether this instance holds it.
+func (l *Leases) Acquire(ctx context.Context, job string, ttl time.Duration) (bool, error) {
+	var holder string
+	err := l.db.QueryRowContext(ctx, `INSERT INTO veswatch_leases (job, holder, expires_at)
+		VALUES ($1, $2, now() + $3::interval)
+		ON CONFLICT (job) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE veswatch_leases.holder = EXCLUDED.holder OR veswatch_leases.expires_at <= now()
+		RETURNING holder`, job, l.holder, fmt.Sprintf("%d milliseconds", ttl.Milliseconds())).Scan(&holder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("timescaledb lease failed: %w", err)
+	}
+	return true, nil
+}
+
+// Release gives up every lease this instance holds, so another instance
+// takes its jobs over without waiting for them to expire.
+func (l *Leases) Release(ctx context.Context) error {
+	if _, err := l.db.ExecContext(ctx, `DELETE FROM veswatch_leases WHERE holder = $1`, l.holder); err != nil {
+		return fmt.Errorf("timescaledb lease release failed: %w", err)
+	}
+	return nil
+}
+
+// Snapshots returns the snapshot store shared by the instances.
+func (t *Timescale) Snapshots() snapshot.Store {
+	return timescaleSnapshots{t.db}
+}
+
+type timescaleSnapshots struct{ db *sql.DB }
+
+func (s timescaleSnapshots) Load(ctx context.Context) (rates.Snapshot, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM veswatch_snapshot`).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rates.Snapshot{}, snapshot.ErrNotFound
+	}
+	if err != nil {
+		return rates.Snapshot{}, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap rates.Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return rates.Snapshot{}, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+func (s timescaleSnapshots) Save(ctx context.Context, snap rates.Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO veswatch_snapshot (data, saved_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, saved_at = EXCLUDED.saved_at`, string(data), snap.TakenAt)
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Package sink writes fetched rates to external time-series databases.
+package sink
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Point is a rate observed from a source.
+type Point struct {
+	Source string
+	Rate   float64
+	Time   time.Time
+}
+
+// Backend writes a batch of points to a time-series database.
+type Backend interface {
+	Name() string
+	Write(ctx context.Context, points []Point) error
+}
+
+// Recorder buffers points and writes them to a backend in the background,
+// so a slow or unreachable database never delays rate fetching.
+type Recorder struct {
+	backend  Backend
+	points   chan Point
+	interval time.Duration
+	done     chan struct{}
+	once     sync.Once
+}
+
+// recorderBuffer is how many points can wait for a flush before new ones are
+// dropped.
+const recorderBuffer = 1024
+
+// recorderBatch is the most points written in one request.
+const recorderBatch = 100
+
+// NewRecorder starts a recorder that flushes to backend every interval.
+func NewRecorder(backend Backend, interval time.Duration) *Recorder {
+	r := &Recorder{
+		backend:  backend,
+		points:   make(chan Point, recorderBuffer),
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Record queues a rate for writing. It never blocks; points are dropped if
+// the buffer is full.
+func (r *Recorder) Record(source string, rate float64, at time.Time) {
+	select {
+	case r.points <- Point{Source: source, Rate: rate, Time: at}:
+	default:
+		log.Printf("Sink: %s buffer full, dropping %s point", r.backend.Name(), source)
+	}
+}
+
+// Close flushes queued points and stops the recorder.
+func (r *Recorder) Close() {
+	r.once.Do(func() {
+		close(r.points)
+		<-r.done
+	})
+}
+
+// run batches queued points until the recorder is closed.
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	var batch []Point
+	for {
+		select {
+		case p, ok := <-r.points:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, p)
+			if len(batch) >= recorderBatch {
+				batch = r.flush(batch)
+			}
+		case <-ticker.C:
+			batch = r.flush(batch)
+		}
+	}
+}
+
+// flush writes a batch and returns the emptied slice. Failed batches are
+// logged and dropped; the history API remains the source of truth.
+func (r *Recorder) flush(batch []Point) []Point {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := r.backend.Write(ctx, batch); err != nil {
+		log.Printf("Sink: %s write of %d points failed: %v", r.backend.Name(), len(batch), err)
+	}
+	return batch[:0]
+}
@@ -0,0 +1,222 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+
+	// PostgreSQL driver for TimescaleDB
+	_ "github.com/lib/pq"
+)
+
+// Timescale writes points to a TimescaleDB (or plain PostgreSQL) table
+// veswatch_rates (time, source, rate), created on first use, and maintains
+// their daily aggregates in veswatch_daily. Instances sharing the database
+// also share job leases and a snapshot of their state.
+type Timescale struct {
+	db *sql.DB
+}
+
+// NewTimescale connects to a PostgreSQL DSN and prepares the tables. The rates
+// table is made a hypertable when the TimescaleDB extension is available.
+func NewTimescale(ctx context.Context, dsn string) (*Timescale, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open timescaledb: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS veswatch_rates (
+		time   TIMESTAMPTZ      NOT NULL,
+		source TEXT             NOT NULL,
+		rate   DOUBLE PRECISION NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create veswatch_rates table: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `SELECT create_hypertable('veswatch_rates', 'time', if_not_exists => TRUE)`); err != nil {
+		log.Printf("Sink: veswatch_rates is a plain table (TimescaleDB extension unavailable: %v)", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS veswatch_daily (
+		day     DATE             NOT NULL,
+		source  TEXT             NOT NULL,
+		open    DOUBLE PRECISION NOT NULL,
+		high    DOUBLE PRECISION NOT NULL,
+		low     DOUBLE PRECISION NOT NULL,
+		close   DOUBLE PRECISION NOT NULL,
+		avg     DOUBLE PRECISION NOT NULL,
+		samples BIGINT           NOT NULL,
+		PRIMARY KEY (day, source)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create veswatch_daily table: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS veswatch_leases (
+		job        TEXT        PRIMARY KEY,
+		holder     TEXT        NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create veswatch_leases table: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS veswatch_snapshot (
+		id       BOOLEAN     PRIMARY KEY DEFAULT TRUE CHECK (id),
+		data     JSONB       NOT NULL,
+		saved_at TIMESTAMPTZ NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create veswatch_snapshot table: %w", err)
+	}
+
+	return &Timescale{db: db}, nil
+}
+
+// Name identifies the backend in logs.
+func (t *Timescale) Name() string {
+	return "timescaledb"
+}
+
+// Write inserts the points in a single statement.
+func (t *Timescale) Write(ctx context.Context, points []Point) error {
+	var query strings.Builder
+	query.WriteString("INSERT INTO veswatch_rates (time, source, rate) VALUES ")
+
+	args := make([]any, 0, len(points)*3)
+	for i, p := range points {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		fmt.Fprintf(&query, "($%d, $%d, $%d)", i*3+1, i*3+2, i*3+3)
+		args = append(args, p.Time, p.Source, p.Rate)
+	}
+
+	if _, err := t.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("timescaledb insert failed: %w", err)
+	}
+	return nil
+}
+
+// bucketOrigin aligns buckets to midnight in Venezuela (UTC-4).
+const bucketOrigin = "2000-01-01T00:00:00-04:00"
+
+// aggregates are the SQL expressions computing each aggregation from raw
+// rates, in the order of the bucket fields they fill.
+var aggregates = map[string]string{
+	rates.AggAvg:  "avg(rate)",
+	rates.AggLast: "(array_agg(rate ORDER BY time DESC))[1]",
+	rates.AggOHLC: "(array_agg(rate ORDER BY time))[1], max(rate), min(rate), (array_agg(rate ORDER BY time DESC))[1]",
+}
+
+// dailyAggregates are the veswatch_daily columns holding each aggregation.
+var dailyAggregates = map[string]string{
+	rates.AggAvg:  "avg",
+	rates.AggLast: "close",
+	rates.AggOHLC: "open, high, low, close",
+}
+
+// dayExpr is the SQL expression of a timestamp's calendar date in Venezuela.
+const dayExpr = "(((%s) AT TIME ZONE 'UTC') - interval '4 hours')::date"
+
+// dailyTimeout bounds a refresh of the daily aggregates.
+const dailyTimeout = time.Minute
+
+// RefreshDaily recomputes the daily aggregates of the last two aggregated
+// days onwards, covering the day in progress and rates written late for
+// the previous one. The first refresh aggregates the whole table.
+func (t *Timescale) RefreshDaily(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dailyTimeout)
+	defer cancel()
+
+	var since sql.NullTime
+	err := t.db.QueryRowContext(ctx, `SELECT ((max(day) - 1)::timestamp + interval '4 hours') AT TIME ZONE 'UTC' FROM veswatch_daily`).Scan(&since)
+	if err != nil {
+		return fmt.Errorf("timescaledb daily aggregates failed: %w", err)
+	}
+
+	query := `INSERT INTO veswatch_daily (day, source, open, high, low, close, avg, samples)
+		SELECT ` + fmt.Sprintf(dayExpr, "time") + ` AS d, source,
+			(array_agg(rate ORDER BY time))[1], max(rate), min(rate),
+			(array_agg(rate ORDER BY time DESC))[1], avg(rate), count(*)
+		FROM veswatch_rates
+		WHERE $1::timestamptz IS NULL OR time >= $1
+		GROUP BY d, source
+		ON CONFLICT (day, source) DO UPDATE SET
+			open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low,
+			close = EXCLUDED.close, avg = EXCLUDED.avg, samples = EXCLUDED.samples`
+	if _, err := t.db.ExecContext(ctx, query, since); err != nil {
+		return fmt.Errorf("timescaledb daily aggregates failed: %w", err)
+	}
+	return nil
+}
+
+// Downsample aggregates the recorded rates in [from, to) into buckets of
+// the given interval, in the database. Daily buckets are read from the
+// daily aggregates; shorter ones require PostgreSQL 14 or later for
+// date_bin.
+func (t *Timescale) Downsample(ctx context.Context, from, to time.Time, interval time.Duration, agg string) ([]rates.Bucket, error) {
+	expr, ok := aggregates[agg]
+	if !ok {
+		return nil, fmt.Errorf("unknown aggregation %q", agg)
+	}
+
+	query := `SELECT date_bin($1::interval, time, $2::timestamptz) AS bucket, source, count(*), ` + expr + `
+		FROM veswatch_rates
+		WHERE time >= $3 AND time < $4
+		GROUP BY bucket, source
+		ORDER BY bucket, source`
+	args := []any{fmt.Sprintf("%d seconds", int64(interval.Seconds())), bucketOrigin, from, to}
+	if interval == 24*time.Hour {
+		query = `SELECT (day::timestamp + interval '4 hours') AT TIME ZONE 'UTC' AS bucket, source, samples, ` + dailyAggregates[agg] + `
+			FROM veswatch_daily
+			WHERE day >= ` + fmt.Sprintf(dayExpr, "$1::timestamptz") + ` AND day <= ` + fmt.Sprintf(dayExpr, "$2::timestamptz - interval '1 microsecond'") + `
+			ORDER BY bucket, source`
+		args = []any{from, to}
+	}
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("timescaledb downsample failed: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []rates.Bucket
+	for rows.Next() {
+		var b rates.Bucket
+		dest := []any{&b.Time, &b.Source, &b.Samples}
+		if agg == rates.AggOHLC {
+			dest = append(dest, &b.Open, &b.High, &b.Low, &b.Close)
+		} else {
+			dest = append(dest, &b.Value)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("timescaledb downsample failed: %w", err)
+		}
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("timescaledb downsample failed: %w", err)
+	}
+	return buckets, nil
+}
+
+// Ping checks the database connection.
+func (t *Timescale) Ping(ctx context.Context) error {
+	return t.db.PingContext(ctx)
+}
+
+// Close closes the database connection.
+func (t *Timescale) Close() error {
+	return t.db.Close()
+}
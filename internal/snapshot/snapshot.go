@@ -0,0 +1,152 @@
+// Package snapshot persists rate snapshots to external backends.
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// ErrNotFound is returned when no snapshot has been saved yet.
+var ErrNotFound = errors.New("snapshot not found")
+
+// Store saves and loads rate snapshots.
+type Store interface {
+	Load(ctx context.Context) (rates.Snapshot, error)
+	Save(ctx context.Context, snap rates.Snapshot) error
+}
+
+// Open returns the store for a location: an http(s) URL (GET to load, PUT to
+// save, e.g. a presigned object storage URL) or a local file path.
+func Open(location string) (Store, error) {
+	switch {
+	case location == "":
+		return nil, fmt.Errorf("snapshot location is empty")
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return &HTTPStore{
+			url:    location,
+			client: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	default:
+		return &FileStore{path: location}, nil
+	}
+}
+
+// FileStore keeps the snapshot in a local JSON file.
+type FileStore struct {
+	path string
+}
+
+// Load reads the snapshot file.
+func (s *FileStore) Load(ctx context.Context) (rates.Snapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return rates.Snapshot{}, ErrNotFound
+	}
+	if err != nil {
+		return rates.Snapshot{}, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap rates.Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return rates.Snapshot{}, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// Save atomically replaces the snapshot file.
+func (s *FileStore) Save(ctx context.Context, snap rates.Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".snapshot-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// HTTPStore keeps the snapshot behind an HTTP URL.
+type HTTPStore struct {
+	url    string
+	client *http.Client
+}
+
+// Load fetches the snapshot with GET.
+func (s *HTTPStore) Load(ctx context.Context) (rates.Snapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return rates.Snapshot{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return rates.Snapshot{}, fmt.Errorf("snapshot request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return rates.Snapshot{}, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return rates.Snapshot{}, fmt.Errorf("snapshot backend returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var snap rates.Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snap); err != nil {
+		return rates.Snapshot{}, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// Save uploads the snapshot with PUT.
+func (s *HTTPStore) Save(ctx context.Context, snap rates.Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("snapshot upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("snapshot backend returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
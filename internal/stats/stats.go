@@ -0,0 +1,101 @@
+// Package stats provides the descriptive statistics used to aggregate quotes,
+// detect outliers and summarize history. Functions never modify their input.
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Mean returns the arithmetic mean, or 0 for no values.
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// StdDev returns the population standard deviation, or 0 for no values.
+func StdDev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	mean := Mean(values)
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sq / float64(len(values)))
+}
+
+// Median returns the median, or 0 for no values.
+func Median(values []float64) float64 {
+	return Percentile(values, 50)
+}
+
+// Percentile returns the p-th percentile (0-100), interpolating linearly
+// between the closest ranks, or 0 for no values.
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := Sorted(values)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// MAD returns the median absolute deviation from the median, a measure of
+// spread that ignores outliers. Multiply by 1.4826 to estimate the standard
+// deviation of normally distributed values.
+func MAD(values []float64) float64 {
+	m := Median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - m)
+	}
+	return Median(deviations)
+}
+
+// TrimmedMean returns the mean after dropping pct percent of the values at
+// each end. If that would drop every value, it returns the median.
+func TrimmedMean(values []float64, pct float64) float64 {
+	sorted := Sorted(values)
+	k := int(float64(len(sorted)) * pct / 100)
+	if 2*k >= len(sorted) {
+		return Median(sorted)
+	}
+	return Mean(sorted[k : len(sorted)-k])
+}
+
+// Pearson returns the Pearson correlation coefficient of two equally long
+// series, or 0 if either is constant.
+func Pearson(x, y []float64) float64 {
+	meanX, meanY := Mean(x), Mean(y)
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// Sorted returns a sorted copy of values.
+func Sorted(values []float64) []float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return sorted
+}
@@ -0,0 +1,141 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{"empty", nil, 0},
+		{"single", []float64{36.5}, 36.5},
+		{"odd", []float64{3, 1, 2}, 2},
+		{"even", []float64{4, 1, 3, 2}, 2.5},
+		{"duplicates", []float64{5, 5, 1, 5}, 5},
+		{"negative", []float64{-3, -1, -2, -4}, -2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Median(tt.values); got != tt.want {
+				t.Errorf("Median(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{40, 10, 30, 20}
+	tests := []struct {
+		name   string
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{"empty", nil, 50, 0},
+		{"single", []float64{36.5}, 90, 36.5},
+		{"min", values, 0, 10},
+		{"max", values, 100, 40},
+		{"rank", values, 100.0 / 3, 20},
+		{"interpolated", values, 50, 25},
+		{"quarter", values, 25, 17.5},
+		{"even ends", []float64{1, 2}, 75, 1.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Percentile(tt.values, tt.p); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Percentile(%v, %v) = %v, want %v", tt.values, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMAD(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{"empty", nil, 0},
+		{"single", []float64{36.5}, 0},
+		{"odd", []float64{1, 2, 3, 4, 100}, 1},
+		{"even", []float64{1, 2, 3, 4}, 1},
+		{"constant", []float64{7, 7, 7, 7}, 0},
+		{"outlier", []float64{46.3, 46.2, 46.4, 46.3, 90}, 0.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MAD(tt.values); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("MAD(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInputUnchanged(t *testing.T) {
+	values := []float64{3, 1, 2, 5, 4}
+	orig := slices.Clone(values)
+
+	Median(values)
+	Percentile(values, 90)
+	MAD(values)
+	TrimmedMean(values, 20)
+	if !slices.Equal(values, orig) {
+		t.Errorf("input changed to %v, want %v", values, orig)
+	}
+}
+
+// sample returns n ad prices around 46, like several pages of P2P ads.
+func sample(n int) []float64 {
+	r := rand.New(rand.NewPCG(1, 2))
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = 46 + r.NormFloat64()*0.5
+	}
+	return values
+}
+
+// sampleSizes are one page of ads, several pages and a long history.
+var sampleSizes = []int{20, 200, 5000}
+
+func BenchmarkMedian(b *testing.B) {
+	for _, n := range sampleSizes {
+		values := sample(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				Median(values)
+			}
+		})
+	}
+}
+
+func BenchmarkPercentile(b *testing.B) {
+	for _, n := range sampleSizes {
+		values := sample(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				Percentile(values, 90)
+			}
+		})
+	}
+}
+
+func BenchmarkMAD(b *testing.B) {
+	for _, n := range sampleSizes {
+		values := sample(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				MAD(values)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// alertRecord is a user alert rule as stored on disk. The owner isn't part
+// of the rule's API representation, so it's stored alongside it.
+type alertRecord struct {
+	Owner string `json:"owner"`
+	rates.AlertRule
+}
+
+// AlertFile keeps user alert rules in a JSON file, rewritten atomically on
+// every change.
+type AlertFile struct {
+	mu    sync.Mutex
+	path  string
+	rules []rates.AlertRule
+}
+
+// OpenAlertFile loads the alert rules file, creating it on first save.
+func OpenAlertFile(path string) (*AlertFile, error) {
+	f := &AlertFile{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert rules: %w", err)
+	}
+
+	var records []alertRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode alert rules: %w", err)
+	}
+	for _, rec := range records {
+		rule := rec.AlertRule
+		rule.Owner = rec.Owner
+		f.rules = append(f.rules, rule)
+	}
+	return f, nil
+}
+
+// SaveAlert adds a rule, replacing any rule with the same ID.
+func (f *AlertFile) SaveAlert(rule rates.AlertRule) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	previous := f.rules
+	f.rules = rates.ReplaceAlert(f.rules, rule)
+	if err := f.write(); err != nil {
+		f.rules = previous
+		return err
+	}
+	return nil
+}
+
+// DeleteAlert removes an owner's rule.
+func (f *AlertFile) DeleteAlert(owner, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := slices.IndexFunc(f.rules, func(r rates.AlertRule) bool {
+		return r.ID == id && r.Owner == owner
+	})
+	if i < 0 {
+		return rates.ErrAlertNotFound
+	}
+
+	previous := f.rules
+	f.rules = slices.Delete(slices.Clone(f.rules), i, i+1)
+	if err := f.write(); err != nil {
+		f.rules = previous
+		return err
+	}
+	return nil
+}
+
+// Alerts returns the rules of an owner, or all rules if owner is empty.
+func (f *AlertFile) Alerts(owner string) ([]rates.AlertRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return rates.FilterAlerts(f.rules, owner), nil
+}
+
+// write replaces the file with the current rules.
+// Callers must hold the lock.
+func (f *AlertFile) write() error {
+	records := make([]alertRecord, len(f.rules))
+	for i, rule := range f.rules {
+		records[i] = alertRecord{Owner: rule.Owner, AlertRule: rule}
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert rules: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".alerts-*")
+	if err != nil {
+		return fmt.Errorf("failed to create alert rules file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write alert rules: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write alert rules: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace alert rules: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Package store provides persistent storage backends for the rate service.
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// AuditFile is an append-only audit log stored as JSON Lines.
+type AuditFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// OpenAuditFile opens (creating if needed) an audit log file for appending.
+func OpenAuditFile(path string) (*AuditFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return &AuditFile{
+		path: path,
+		file: f,
+	}, nil
+}
+
+// Append writes an entry as a single line and syncs it to disk.
+func (a *AuditFile) Append(entry rates.AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := a.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return a.file.Sync()
+}
+
+// Entries reads the log and returns the most recent entries, newest first.
+func (a *AuditFile) Entries(source string, limit int) ([]rates.AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []rates.AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry rates.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip a torn final line from a crash mid-write
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return rates.FilterAudit(entries, source, limit), nil
+}
+
+// Close closes the underlying file.
+func (a *AuditFile) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
@@ -0,0 +1,340 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/veswatch/api/internal/analytics"
+	"github.com/veswatch/api/internal/apikey"
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/internal/push"
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/internal/scheduler"
+	"github.com/veswatch/api/internal/snapshot"
+)
+
+// Bolt buckets.
+var (
+	bucketSnapshot    = []byte("snapshot")
+	bucketHistory     = []byte("history")
+	bucketKeys        = []byte("apikeys")
+	bucketPush        = []byte("push")
+	bucketAlerts      = []byte("alerts")
+	bucketAnalytics   = []byte("analytics")
+	bucketJobs        = []byte("jobs")
+	bucketOutbox      = []byte("outbox")
+	bucketDeadLetters = []byte("deadletters")
+)
+
+// snapshotKey is the key of the latest snapshot in its bucket.
+var snapshotKey = []byte("latest")
+
+// Bolt is an embedded, pure-Go key-value database (bbolt) holding snapshots,
+// history, API keys, push subscriptions, alert rules, request analytics,
+// scheduler state and undelivered events in a single file.
+// Each kind of record is exposed through the interface its consumer defines.
+type Bolt struct {
+	// mu is held exclusively while the file is compacted and replaced.
+	mu sync.RWMutex
+	db *bolt.DB
+
+	// analyticsDays is the analytics retention in days, 0 for no limit.
+	analyticsDays int
+}
+
+// OpenBolt opens (creating if needed) a database file. Only one process can
+// hold it open.
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketSnapshot, bucketHistory, bucketKeys, bucketPush, bucketAlerts, bucketAnalytics, bucketJobs, bucketOutbox, bucketDeadLetters} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+// Close closes the database.
+func (b *Bolt) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.db.Close()
+}
+
+// view runs a read-only transaction.
+func (b *Bolt) view(fn func(tx *bolt.Tx) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.db.View(fn)
+}
+
+// update runs a read-write transaction.
+func (b *Bolt) update(fn func(tx *bolt.Tx) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.db.Update(fn)
+}
+
+// put stores v as JSON under key.
+func (b *Bolt) put(bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s record: %w", bucket, err)
+	}
+	err = b.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s record: %w", bucket, err)
+	}
+	return nil
+}
+
+// each decodes every record of a bucket in key order.
+func each[T any](b *Bolt, bucket []byte, fn func(T)) error {
+	err := b.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(k, data []byte) error {
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				return fmt.Errorf("record %q: %w", k, err)
+			}
+			fn(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read %s records: %w", bucket, err)
+	}
+	return nil
+}
+
+// Snapshots returns the snapshot store.
+func (b *Bolt) Snapshots() snapshot.Store {
+	return boltSnapshots{b}
+}
+
+type boltSnapshots struct{ b *Bolt }
+
+func (s boltSnapshots) Load(ctx context.Context) (rates.Snapshot, error) {
+	var data []byte
+	err := s.b.view(func(tx *bolt.Tx) error {
+		// Bolt's values are only valid during the transaction
+		data = slices.Clone(tx.Bucket(bucketSnapshot).Get(snapshotKey))
+		return nil
+	})
+	if err != nil {
+		return rates.Snapshot{}, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if data == nil {
+		return rates.Snapshot{}, snapshot.ErrNotFound
+	}
+
+	var snap rates.Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return rates.Snapshot{}, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+func (s boltSnapshots) Save(ctx context.Context, snap rates.Snapshot) error {
+	return s.b.put(bucketSnapshot, snapshotKey, snap)
+}
+
+// History returns the daily close history, keyed by date.
+func (b *Bolt) History() rates.History {
+	return boltHistory{b}
+}
+
+type boltHistory struct{ b *Bolt }
+
+func (h boltHistory) SaveDailyClose(dailyClose rates.DailyClose) error {
+	return h.b.put(bucketHistory, []byte(dailyClose.Date), dailyClose)
+}
+
+// SaveDailyCloseEvent stores a daily close and, for the named notifiers, the
+// event announcing it in the outbox, in one transaction.
+func (h boltHistory) SaveDailyCloseEvent(dailyClose rates.DailyClose, event notify.Event, notifiers []string) (uint64, error) {
+	data, err := json.Marshal(dailyClose)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal %s record: %w", bucketHistory, err)
+	}
+	var id uint64
+	err = h.b.update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketHistory).Put([]byte(dailyClose.Date), data); err != nil {
+			return err
+		}
+		if len(notifiers) == 0 {
+			return nil
+		}
+		id, err = addOutbox(tx, event, notifiers)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s record: %w", bucketHistory, err)
+	}
+	return id, nil
+}
+
+func (h boltHistory) DailyCloses() ([]rates.DailyClose, error) {
+	var closes []rates.DailyClose
+	err := each(h.b, bucketHistory, func(c rates.DailyClose) {
+		closes = append(closes, c)
+	})
+	return closes, err
+}
+
+// APIKeys returns the API key store. Only secret hashes are stored.
+func (b *Bolt) APIKeys() apikey.Store {
+	return boltKeys{b}
+}
+
+type boltKeys struct{ b *Bolt }
+
+func (k boltKeys) SaveKey(key apikey.Key) error {
+	return k.b.put(bucketKeys, []byte(key.ID), keyRecord{Hash: key.Hash, Key: key})
+}
+
+func (k boltKeys) Keys() ([]apikey.Key, error) {
+	keys := make(map[string]apikey.Key)
+	err := each(k.b, bucketKeys, func(rec keyRecord) {
+		key := rec.Key
+		key.Hash = rec.Hash
+		keys[key.ID] = key
+	})
+	return apikey.SortKeys(keys), err
+}
+
+// Subscriptions returns the push subscription store, keyed by endpoint.
+func (b *Bolt) Subscriptions() push.Store {
+	return boltSubscriptions{b}
+}
+
+type boltSubscriptions struct{ b *Bolt }
+
+func (s boltSubscriptions) Save(sub push.Subscription) error {
+	return s.b.put(bucketPush, []byte(sub.Endpoint), sub)
+}
+
+func (s boltSubscriptions) Delete(endpoint string) error {
+	err := s.b.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPush).Delete([]byte(endpoint))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	return nil
+}
+
+func (s boltSubscriptions) List() ([]push.Subscription, error) {
+	var subs []push.Subscription
+	err := each(s.b, bucketPush, func(sub push.Subscription) {
+		subs = append(subs, sub)
+	})
+	slices.SortFunc(subs, func(a, b push.Subscription) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return subs, err
+}
+
+// Alerts returns the user alert rule store, keyed by rule ID.
+func (b *Bolt) Alerts() rates.AlertStore {
+	return boltAlerts{b}
+}
+
+type boltAlerts struct{ b *Bolt }
+
+func (a boltAlerts) SaveAlert(rule rates.AlertRule) error {
+	return a.b.put(bucketAlerts, []byte(rule.ID), alertRecord{Owner: rule.Owner, AlertRule: rule})
+}
+
+func (a boltAlerts) DeleteAlert(owner, id string) error {
+	err := a.b.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketAlerts)
+		data := bucket.Get([]byte(id))
+		if data == nil {
+			return rates.ErrAlertNotFound
+		}
+
+		var rec alertRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		if rec.Owner != owner {
+			return rates.ErrAlertNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	return nil
+}
+
+func (a boltAlerts) Alerts(owner string) ([]rates.AlertRule, error) {
+	var rules []rates.AlertRule
+	err := each(a.b, bucketAlerts, func(rec alertRecord) {
+		rule := rec.AlertRule
+		rule.Owner = rec.Owner
+		rules = append(rules, rule)
+	})
+	slices.SortFunc(rules, func(a, b rates.AlertRule) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return rates.FilterAlerts(rules, owner), err
+}
+
+// Analytics returns the request analytics store, keyed by date.
+func (b *Bolt) Analytics() analytics.Store {
+	return boltAnalytics{b}
+}
+
+type boltAnalytics struct{ b *Bolt }
+
+func (a boltAnalytics) SaveDay(rec analytics.Record) error {
+	return a.b.put(bucketAnalytics, []byte(rec.Date), rec)
+}
+
+func (a boltAnalytics) Days() ([]analytics.Record, error) {
+	var days []analytics.Record
+	err := each(a.b, bucketAnalytics, func(rec analytics.Record) {
+		days = append(days, rec)
+	})
+	return days, err
+}
+
+// Jobs returns the scheduler state store, keyed by job name.
+func (b *Bolt) Jobs() scheduler.StateStore {
+	return boltJobs{b}
+}
+
+type boltJobs struct{ b *Bolt }
+
+func (j boltJobs) SaveJobState(state scheduler.JobState) error {
+	return j.b.put(bucketJobs, []byte(state.Job), state)
+}
+
+func (j boltJobs) JobStates() ([]scheduler.JobState, error) {
+	var states []scheduler.JobState
+	err := each(j.b, bucketJobs, func(state scheduler.JobState) {
+		states = append(states, state)
+	})
+	return states, err
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/veswatch/api/internal/apikey"
+)
+
+// keyRecord is an API key as stored on disk. The secret hash isn't part of
+// the key's API representation, so it's stored alongside it.
+type keyRecord struct {
+	Hash string `json:"hash"`
+	apikey.Key
+}
+
+// KeyFile keeps managed API keys in a JSON file, rewritten atomically on
+// every change. Only secret hashes are stored.
+type KeyFile struct {
+	mu   sync.Mutex
+	path string
+	keys map[string]apikey.Key
+}
+
+// OpenKeyFile loads the API keys file, creating it on first save.
+func OpenKeyFile(path string) (*KeyFile, error) {
+	f := &KeyFile{
+		path: path,
+		keys: make(map[string]apikey.Key),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+
+	var records []keyRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode API keys: %w", err)
+	}
+	for _, rec := range records {
+		key := rec.Key
+		key.Hash = rec.Hash
+		f.keys[key.ID] = key
+	}
+	return f, nil
+}
+
+// SaveKey adds or replaces a key.
+func (f *KeyFile) SaveKey(key apikey.Key) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	previous, existed := f.keys[key.ID]
+	f.keys[key.ID] = key
+	if err := f.write(); err != nil {
+		if existed {
+			f.keys[key.ID] = previous
+		} else {
+			delete(f.keys, key.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Keys returns all keys ordered by creation time.
+func (f *KeyFile) Keys() ([]apikey.Key, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return apikey.SortKeys(f.keys), nil
+}
+
+// write replaces the file with the current keys. The file holds secret
+// hashes, so it's only readable by the owner.
+// Callers must hold the lock.
+func (f *KeyFile) write() error {
+	keys := apikey.SortKeys(f.keys)
+	records := make([]keyRecord, len(keys))
+	for i, key := range keys {
+		records[i] = keyRecord{Hash: key.Hash, Key: key}
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API keys: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".keys-*")
+	if err != nil {
+		return fmt.Errorf("failed to create API keys file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write API keys: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write API keys: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace API keys: %w", err)
+	}
+	return nil
+}
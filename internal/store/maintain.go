@@ -0,0 +1,145 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// compactTxSize is the most data copied per transaction while compacting.
+const compactTxSize = 64 << 20
+
+// Maintenance is the outcome of a maintenance run.
+type Maintenance struct {
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+
+	// Deleted counts the records removed by retention, by bucket.
+	Deleted map[string]int `json:"deleted"`
+
+	// File sizes in bytes before and after compaction.
+	SizeBefore int64 `json:"sizeBefore"`
+	SizeAfter  int64 `json:"sizeAfter"`
+	Reclaimed  int64 `json:"reclaimed"`
+}
+
+// SetAnalyticsRetention sets how many days of request analytics maintenance
+// keeps; 0 keeps all.
+func (b *Bolt) SetAnalyticsRetention(days int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.analyticsDays = days
+}
+
+// Maintain deletes records past their retention and compacts the database
+// file, returning the space reclaimed. Bolt never shrinks its file on its
+// own, so pages freed by deletes and rewrites are only returned by
+// compaction. Other operations wait until it's done.
+func (b *Bolt) Maintain() (Maintenance, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m := Maintenance{StartedAt: time.Now(), Deleted: make(map[string]int)}
+	path := b.db.Path()
+
+	if b.analyticsDays > 0 {
+		cutoff := m.StartedAt.AddDate(0, 0, -b.analyticsDays).Format("2006-01-02")
+		n, err := b.deleteBefore(bucketAnalytics, cutoff)
+		if err != nil {
+			return m, err
+		}
+		m.Deleted[string(bucketAnalytics)] = n
+	}
+
+	before, err := os.Stat(path)
+	if err != nil {
+		return m, fmt.Errorf("failed to compact database: %w", err)
+	}
+	m.SizeBefore = before.Size()
+
+	if err := b.compact(path); err != nil {
+		return m, err
+	}
+
+	after, err := os.Stat(path)
+	if err != nil {
+		return m, fmt.Errorf("failed to compact database: %w", err)
+	}
+	m.SizeAfter = after.Size()
+	m.Reclaimed = m.SizeBefore - m.SizeAfter
+	m.DurationMs = time.Since(m.StartedAt).Milliseconds()
+
+	log.Printf("Store: Maintenance deleted %v, compacted %d to %d bytes in %d ms", m.Deleted, m.SizeBefore, m.SizeAfter, m.DurationMs)
+	return m, nil
+}
+
+// deleteBefore deletes the records of a date-keyed bucket older than
+// cutoff (YYYY-MM-DD).
+func (b *Bolt) deleteBefore(bucket []byte, cutoff string) (int, error) {
+	n := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		// Deleting through a cursor skips keys, so collect them first
+		bkt := tx.Bucket(bucket)
+		var keys [][]byte
+		c := bkt.Cursor()
+		for k, _ := c.First(); k != nil && string(k) < cutoff; k, _ = c.Next() {
+			keys = append(keys, slices.Clone(k))
+		}
+		for _, k := range keys {
+			if err := bkt.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(keys)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old %s records: %w", bucket, err)
+	}
+	return n, nil
+}
+
+// compact copies the database into a new file and replaces the open one
+// with it. Must be called with mu held.
+func (b *Bolt) compact(path string) error {
+	tmp := path + ".compact"
+	os.Remove(tmp)
+
+	dst, err := bolt.Open(tmp, 0o600, nil)
+	if err != nil {
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	if err := bolt.Compact(dst, b.db, compactTxSize); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+
+	// The file can't be replaced while open; on failure the original is
+	// reopened so the store keeps working
+	if err := b.db.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	renameErr := os.Rename(tmp, path)
+	if renameErr != nil {
+		os.Remove(tmp)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return fmt.Errorf("failed to reopen database after compaction: %w", err)
+	}
+	b.db = db
+	if renameErr != nil {
+		return fmt.Errorf("failed to compact database: %w", renameErr)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/veswatch/api/internal/notify"
+)
+
+// Outbox returns the event outbox, keyed by a sequence number so events
+// are read back in the order they were published.
+func (b *Bolt) Outbox() notify.Outbox {
+	return boltOutbox{b}
+}
+
+type boltOutbox struct{ b *Bolt }
+
+// outboxKey encodes an event ID as a key that sorts numerically.
+func outboxKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, id)
+}
+
+func (o boltOutbox) Add(event notify.Event, notifiers []string) (uint64, error) {
+	var id uint64
+	err := o.b.update(func(tx *bolt.Tx) error {
+		var err error
+		id, err = addOutbox(tx, event, notifiers)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s record: %w", bucketOutbox, err)
+	}
+	return id, nil
+}
+
+// addOutbox stores an event in the outbox within tx and returns its ID.
+func addOutbox(tx *bolt.Tx, event notify.Event, notifiers []string) (uint64, error) {
+	bucket := tx.Bucket(bucketOutbox)
+	id, err := bucket.NextSequence()
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(notify.OutboxEntry{ID: id, Event: event, Notifiers: notifiers})
+	if err != nil {
+		return 0, err
+	}
+	return id, bucket.Put(outboxKey(id), data)
+}
+
+func (o boltOutbox) Delivered(id uint64, notifier string) error {
+	err := o.b.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketOutbox)
+		data := bucket.Get(outboxKey(id))
+		if data == nil {
+			return nil
+		}
+		var entry notify.OutboxEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+
+		entry.Notifiers = slices.DeleteFunc(entry.Notifiers, func(name string) bool { return name == notifier })
+		if len(entry.Notifiers) == 0 {
+			return bucket.Delete(outboxKey(id))
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(outboxKey(id), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s record: %w", bucketOutbox, err)
+	}
+	return nil
+}
+
+func (o boltOutbox) Pending() ([]notify.OutboxEntry, error) {
+	var entries []notify.OutboxEntry
+	err := each(o.b, bucketOutbox, func(entry notify.OutboxEntry) {
+		entries = append(entries, entry)
+	})
+	return entries, err
+}
+
+// DeadLetters returns the store of events notifiers failed to deliver,
+// keyed by ID.
+func (b *Bolt) DeadLetters() notify.DeadLetterStore {
+	return boltDeadLetters{b}
+}
+
+type boltDeadLetters struct{ b *Bolt }
+
+func (d boltDeadLetters) SaveDeadLetter(letter notify.DeadLetter) error {
+	return d.b.put(bucketDeadLetters, []byte(letter.ID), letter)
+}
+
+func (d boltDeadLetters) DeleteDeadLetter(id string) error {
+	err := d.b.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketDeadLetters)
+		if bucket.Get([]byte(id)) == nil {
+			return notify.ErrDeadLetterNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return nil
+}
+
+func (d boltDeadLetters) DeadLetters() ([]notify.DeadLetter, error) {
+	var letters []notify.DeadLetter
+	err := each(d.b, bucketDeadLetters, func(letter notify.DeadLetter) {
+		letters = append(letters, letter)
+	})
+	slices.SortFunc(letters, func(a, b notify.DeadLetter) int {
+		if c := a.FailedAt.Compare(b.FailedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return letters, err
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/veswatch/api/internal/push"
+)
+
+// PushFile keeps push subscriptions in a JSON file, rewritten atomically on
+// every change.
+type PushFile struct {
+	mu   sync.Mutex
+	path string
+	subs map[string]push.Subscription
+}
+
+// OpenPushFile loads the subscriptions file, creating it on first save.
+func OpenPushFile(path string) (*PushFile, error) {
+	f := &PushFile{
+		path: path,
+		subs: make(map[string]push.Subscription),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read push subscriptions: %w", err)
+	}
+
+	var subs []push.Subscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("failed to decode push subscriptions: %w", err)
+	}
+	for _, sub := range subs {
+		f.subs[sub.Endpoint] = sub
+	}
+	return f, nil
+}
+
+// Save adds or replaces a subscription.
+func (f *PushFile) Save(sub push.Subscription) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[sub.Endpoint] = sub
+	return f.write()
+}
+
+// Delete removes a subscription.
+func (f *PushFile) Delete(endpoint string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[endpoint]; !ok {
+		return nil
+	}
+	delete(f.subs, endpoint)
+	return f.write()
+}
+
+// List returns all subscriptions ordered by creation time.
+func (f *PushFile) List() ([]push.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sorted(), nil
+}
+
+// sorted returns the subscriptions ordered by creation time.
+// Callers must hold the lock.
+func (f *PushFile) sorted() []push.Subscription {
+	subs := make([]push.Subscription, 0, len(f.subs))
+	for _, sub := range f.subs {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+	})
+	return subs
+}
+
+// write replaces the file with the current subscriptions.
+// Callers must hold the lock.
+func (f *PushFile) write() error {
+	data, err := json.Marshal(f.sorted())
+	if err != nil {
+		return fmt.Errorf("failed to marshal push subscriptions: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".push-*")
+	if err != nil {
+		return fmt.Errorf("failed to create push subscriptions file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write push subscriptions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write push subscriptions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace push subscriptions: %w", err)
+	}
+	return nil
+}
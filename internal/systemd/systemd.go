@@ -0,0 +1,72 @@
+// Package systemd implements the sd_notify protocol used by systemd to track
+// service readiness and watchdog keep-alives.
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Notification states.
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+// Notify sends a state string to the systemd notification socket.
+// It returns false without error when not running under systemd.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// Abstract namespace sockets are announced with a leading '@'
+	addr := &net.UnixAddr{Name: socket, Net: "unixgram"}
+	if socket[0] == '@' {
+		addr.Name = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Status formats a free-form status line shown by systemctl status.
+func Status(msg string) string {
+	return "STATUS=" + msg
+}
+
+// MainPID formats a MAINPID assignment, used when another process takes over.
+func MainPID(pid int) string {
+	return "MAINPID=" + strconv.Itoa(pid)
+}
+
+// WatchdogInterval returns the watchdog timeout configured for this process,
+// or zero when the watchdog is disabled.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	// The watchdog applies only to the PID it was configured for
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil || pid != os.Getpid() {
+			return 0
+		}
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
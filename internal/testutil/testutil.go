@@ -0,0 +1,329 @@
+// Package testutil runs the API end to end for integration tests: the real
+// rates service, HTTP handler and embedded database, fed by fake sources
+// whose rates the test controls, behind an httptest server.
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/veswatch/api/internal/contract"
+	httphandlers "github.com/veswatch/api/internal/http"
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/internal/store"
+)
+
+// Initial rates of the fake sources.
+const (
+	DefaultBCV      = 36.5
+	DefaultBinance  = 38.2
+	DefaultParallel = 38.0
+)
+
+// AdminToken is the admin token of every test server.
+const AdminToken = "test-admin-token"
+
+// Source is a fake rate source returning the rate it was last set to.
+type Source struct {
+	mu    sync.Mutex
+	rate  float64
+	err   error
+	calls int
+}
+
+// NewSource creates a source returning rate.
+func NewSource(rate float64) *Source {
+	return &Source{rate: rate}
+}
+
+// Set makes the source return rate from its next fetch on.
+func (s *Source) Set(rate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rate, s.err = rate, nil
+}
+
+// Fail makes the source's fetches fail with err until Set is called.
+func (s *Source) Fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Fetch returns the source's rate or error.
+func (s *Source) Fetch(context.Context) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.rate, nil
+}
+
+// Calls returns how many times the source was fetched.
+func (s *Source) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// Inflation is a fake INPC source.
+type Inflation struct {
+	mu     sync.Mutex
+	points []rates.IndexPoint
+}
+
+// Set makes the source return points from its next fetch on.
+func (i *Inflation) Set(points []rates.IndexPoint) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.points = slices.Clone(points)
+}
+
+// FetchINPC returns the points the source was set to.
+func (i *Inflation) FetchINPC(context.Context) ([]rates.IndexPoint, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return slices.Clone(i.points), nil
+}
+
+// Events records the events the service publishes. It's registered as the
+// "test" notification channel, which user alert rules may deliver to.
+type Events struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+// Name returns the channel name.
+func (e *Events) Name() string {
+	return "test"
+}
+
+// Notify records the event.
+func (e *Events) Notify(event notify.Event) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+	return nil
+}
+
+// All returns every event recorded so far.
+func (e *Events) All() []notify.Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.events)
+}
+
+// OfType returns the events of a type recorded so far.
+func (e *Events) OfType(typ string) []notify.Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var events []notify.Event
+	for _, ev := range e.events {
+		if ev.Type == typ {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// Server is a running API server backed by fake sources and a temporary
+// database. Tests reach into Service and Handler to configure what the
+// options don't cover.
+type Server struct {
+	URL     string
+	Client  *http.Client
+	Service *rates.Service
+	Handler *httphandlers.Handler
+	DB      *store.Bolt
+
+	BCV       *Source
+	Binance   *Source
+	Parallel  map[string]*Source
+	Inflation *Inflation
+	Events    *Events
+}
+
+// options are the settings of New.
+type options struct {
+	parallel []string
+	setup    []func(*Server)
+}
+
+// Option configures a test server.
+type Option func(*options)
+
+// WithParallel adds parallel-market sources with the given names, starting
+// at DefaultParallel.
+func WithParallel(names ...string) Option {
+	return func(o *options) {
+		o.parallel = append(o.parallel, names...)
+	}
+}
+
+// WithSetup calls fn with the server after it's wired and before it starts
+// serving, e.g. to call the handler's setters.
+func WithSetup(fn func(*Server)) Option {
+	return func(o *options) {
+		o.setup = append(o.setup, fn)
+	}
+}
+
+// New starts a test server and stops it when the test ends. Sources aren't
+// fetched until Fetch is called, and no scheduler runs. Every response is
+// checked against the OpenAPI spec, failing the test on a violation.
+func New(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	db, err := store.OpenBolt(filepath.Join(tb.TempDir(), "veswatch.db"))
+	if err != nil {
+		tb.Fatalf("testutil: failed to open database: %v", err)
+	}
+
+	s := &Server{
+		DB:        db,
+		BCV:       NewSource(DefaultBCV),
+		Binance:   NewSource(DefaultBinance),
+		Parallel:  make(map[string]*Source),
+		Inflation: &Inflation{},
+		Events:    &Events{},
+	}
+
+	s.Service = rates.NewService(s.BCV, s.Binance, s.Inflation)
+	for _, name := range o.parallel {
+		s.Parallel[name] = NewSource(DefaultParallel)
+		s.Service.AddParallelSource(name, s.Parallel[name])
+	}
+	s.Service.SetHistory(db.History())
+	s.Service.SetAlertStore(db.Alerts())
+
+	dispatcher := notify.NewDispatcher()
+	dispatcher.Register(s.Events)
+	// Events go through the database, as with VESWATCH_DB
+	dispatcher.SetDeadLetters(db.DeadLetters())
+	dispatcher.SetOutbox(db.Outbox())
+	s.Service.SetPublisher(dispatcher)
+	s.Service.SetAlertChannels([]string{s.Events.Name()})
+
+	validator, err := contract.New()
+	if err != nil {
+		tb.Fatalf("testutil: failed to load OpenAPI spec: %v", err)
+	}
+
+	s.Handler = httphandlers.NewHandler(s.Service)
+	s.Handler.SetAdminToken(AdminToken)
+	s.Handler.SetValidator(contractCheck{tb, validator})
+	for _, fn := range o.setup {
+		fn(s)
+	}
+
+	srv := httptest.NewServer(s.Handler.Routes())
+	s.URL = srv.URL
+	s.Client = srv.Client()
+	tb.Cleanup(func() {
+		srv.Close()
+		dispatcher.Stop()
+		if err := db.Close(); err != nil {
+			tb.Errorf("testutil: failed to close database: %v", err)
+		}
+	})
+	return s
+}
+
+// contractCheck fails the test on responses violating the OpenAPI spec.
+type contractCheck struct {
+	tb        testing.TB
+	validator *contract.Validator
+}
+
+// Check checks an exchange, reporting a violation as a test error.
+func (c contractCheck) Check(r *http.Request, status int, header http.Header, body []byte) error {
+	err := c.validator.Check(r, status, header, body)
+	if err != nil {
+		c.tb.Errorf("testutil: contract violation: %v", err)
+	}
+	return err
+}
+
+// Fetch fetches every source once, as the scheduler does at startup.
+func (s *Server) Fetch() {
+	s.Service.Initialize(context.Background())
+}
+
+// Get sends a GET request for path and decodes the JSON response into v,
+// unless v is nil. It returns the response status.
+func (s *Server) Get(tb testing.TB, path string, v any) int {
+	tb.Helper()
+	return s.Do(tb, http.MethodGet, path, nil, v)
+}
+
+// Admin sends a request with the admin token, encoding body as JSON unless
+// it's nil, and decodes the JSON response into v, unless v is nil. It
+// returns the response status.
+func (s *Server) Admin(tb testing.TB, method, path string, body, v any) int {
+	tb.Helper()
+	return s.Do(tb, method, path, body, v, "Authorization", "Bearer "+AdminToken)
+}
+
+// Do sends a request with the given header key and value pairs, encoding
+// body as JSON unless it's nil, and decodes the JSON response into v,
+// unless v is nil. It returns the response status.
+func (s *Server) Do(tb testing.TB, method, path string, body, v any, header ...string) int {
+	tb.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			tb.Fatalf("testutil: failed to encode %s %s body: %v", method, path, err)
+		}
+	}
+	req, err := http.NewRequest(method, s.URL+path, &payload)
+	if err != nil {
+		tb.Fatalf("testutil: invalid request %s %s: %v", method, path, err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		tb.Fatalf("testutil: %s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			tb.Fatalf("testutil: failed to decode %s %s response (status %d): %v", method, path, resp.StatusCode, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// WaitFor polls cond until it holds, failing the test if it doesn't within
+// timeout. It suits results delivered asynchronously, like notifications.
+func WaitFor(tb testing.TB, timeout time.Duration, cond func() bool) {
+	tb.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			tb.Fatalf("testutil: condition not met within %s", timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
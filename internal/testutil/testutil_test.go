@@ -0,0 +1,93 @@
+package testutil_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/veswatch/api/internal/notify"
+	"github.com/veswatch/api/internal/rates"
+	"github.com/veswatch/api/internal/testutil"
+)
+
+func TestRateUpdate(t *testing.T) {
+	srv := testutil.New(t)
+	srv.Fetch()
+
+	var data rates.RateData
+	if status := srv.Get(t, "/rates", &data); status != http.StatusOK {
+		t.Fatalf("GET /rates: status %d", status)
+	}
+	if data.BCV != testutil.DefaultBCV || data.Binance != testutil.DefaultBinance {
+		t.Fatalf("got BCV %.2f and Binance %.2f, want %.2f and %.2f", data.BCV, data.Binance, testutil.DefaultBCV, testutil.DefaultBinance)
+	}
+	if len(srv.Events.OfType(notify.EventBCVUpdate)) != 0 {
+		t.Error("the initial load was announced")
+	}
+
+	srv.BCV.Set(37.1)
+	if err := srv.Service.FetchBCV(t.Context()); err != nil {
+		t.Fatalf("FetchBCV: %v", err)
+	}
+	srv.Get(t, "/rates", &data)
+	if data.BCV != 37.1 {
+		t.Errorf("got BCV %.2f after the update, want 37.10", data.BCV)
+	}
+	testutil.WaitFor(t, time.Second, func() bool {
+		return len(srv.Events.OfType(notify.EventBCVUpdate)) == 1
+	})
+}
+
+func TestSourceFailure(t *testing.T) {
+	srv := testutil.New(t)
+	srv.Fetch()
+
+	srv.BCV.Fail(errors.New("connection refused"))
+	if err := srv.Service.FetchBCV(t.Context()); err == nil {
+		t.Fatal("FetchBCV succeeded with a failing source")
+	}
+
+	var v1 rates.RatesV1
+	if status := srv.Get(t, "/v1/rates", &v1); status != http.StatusOK {
+		t.Fatalf("GET /v1/rates: status %d", status)
+	}
+	if v1.BCV.Rate != testutil.DefaultBCV {
+		t.Errorf("got BCV %.2f, want the previous %.2f", v1.BCV.Rate, testutil.DefaultBCV)
+	}
+	if len(v1.Warnings) != 1 || v1.Warnings[0].Source != "bcv" {
+		t.Errorf("got warnings %+v, want one for bcv", v1.Warnings)
+	}
+}
+
+func TestDailyClose(t *testing.T) {
+	srv := testutil.New(t)
+	srv.Fetch()
+
+	if err := srv.Service.CloseDay(); err != nil {
+		t.Fatalf("CloseDay: %v", err)
+	}
+
+	var history struct {
+		Closes []rates.DailyClose `json:"closes"`
+	}
+	if status := srv.Get(t, "/rates/history", &history); status != http.StatusOK {
+		t.Fatalf("GET /rates/history: status %d", status)
+	}
+	if len(history.Closes) != 1 || history.Closes[0].BCV != testutil.DefaultBCV {
+		t.Fatalf("got closes %+v, want one at BCV %.2f", history.Closes, testutil.DefaultBCV)
+	}
+
+	// The close is announced and, once delivered, leaves the outbox
+	testutil.WaitFor(t, time.Second, func() bool {
+		return len(srv.Events.OfType(notify.EventDailyClose)) == 1
+	})
+	testutil.WaitFor(t, time.Second, func() bool {
+		pending, err := srv.DB.Outbox().Pending()
+		return err == nil && len(pending) == 0
+	})
+	closes, err := srv.DB.History().DailyCloses()
+	if err != nil || len(closes) != 1 {
+		t.Errorf("database has closes %+v (%v), want one", closes, err)
+	}
+}
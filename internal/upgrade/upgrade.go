@@ -0,0 +1,235 @@
+// Package upgrade provides zero-downtime binary upgrades by handing the
+// listening socket from a running process to its replacement.
+//
+// On the upgrade signal the running process starts the (possibly new)
+// binary from disk, passing it the listener. The child starts serving on the
+// same socket and reports readiness; only then does the parent stop accepting
+// connections and drain its in-flight requests.
+package upgrade
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// envListenerFD names the inherited listener descriptors in the child,
+	// comma-separated in the order of the configured addresses.
+	envListenerFD = "VESWATCH_LISTENER_FD"
+	// envReadyFD names the descriptor used to report readiness to the parent.
+	envReadyFD = "VESWATCH_READY_FD"
+
+	// readyTimeout bounds how long the parent waits for the child.
+	readyTimeout = 2 * time.Minute
+)
+
+// unixPrefix marks a Unix domain socket address, e.g. unix:/run/veswatch.sock.
+const unixPrefix = "unix:"
+
+// Listen returns the listeners inherited from a parent process, or new
+// listeners on addrs. Addresses are TCP host:port pairs or Unix socket paths
+// prefixed with "unix:". New TCP listeners set SO_REUSEPORT where available.
+func Listen(addrs []string) ([]net.Listener, error) {
+	if fds := os.Getenv(envListenerFD); fds != "" {
+		return inherit(fds, len(addrs))
+	}
+
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		var ln net.Listener
+		var err error
+		if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+			ln, err = listenUnix(path)
+		} else {
+			ln, err = listenReusePort(addr)
+		}
+		if err != nil {
+			closeAll(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// inherit returns the listeners passed by the parent process.
+func inherit(fds string, want int) ([]net.Listener, error) {
+	parts := strings.Split(fds, ",")
+	if len(parts) != want {
+		return nil, fmt.Errorf("upgrade: inherited %d listeners, but %d addresses are configured", len(parts), want)
+	}
+
+	listeners := make([]net.Listener, 0, len(parts))
+	for _, part := range parts {
+		fd, err := strconv.Atoi(part)
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("upgrade: invalid %s: %w", envListenerFD, err)
+		}
+
+		f := os.NewFile(uintptr(fd), "listener")
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("upgrade: failed to inherit listener: %w", err)
+		}
+		log.Printf("Upgrade: Inherited listener on %s", ln.Addr())
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// listenUnix opens a Unix domain socket listener, removing a socket left
+// behind by a process that didn't shut down cleanly.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("upgrade: %s is in use by another process", path)
+		}
+		os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+func closeAll(listeners []net.Listener) {
+	for _, ln := range listeners {
+		ln.Close()
+	}
+}
+
+// Inherited reports whether this process was started by an upgrade.
+func Inherited() bool {
+	return os.Getenv(envListenerFD) != ""
+}
+
+// Ready tells the parent process, if any, that this process is serving.
+func Ready() error {
+	fdStr := os.Getenv(envReadyFD)
+	if fdStr == "" {
+		return nil
+	}
+
+	fd, err := strconv.Atoi(fdStr)
+	if err != nil {
+		return fmt.Errorf("upgrade: invalid %s: %w", envReadyFD, err)
+	}
+
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+
+	if _, err := f.Write([]byte{1}); err != nil {
+		return fmt.Errorf("upgrade: failed to signal readiness: %w", err)
+	}
+	return nil
+}
+
+// Upgrade starts a new copy of the current executable with the listeners
+// and waits until it reports readiness. On success the caller should stop
+// accepting connections and shut down gracefully.
+func Upgrade(listeners []net.Listener) error {
+	files := make([]*os.File, 0, len(listeners)+1)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	fds := make([]string, 0, len(listeners))
+	for _, ln := range listeners {
+		var f *os.File
+		var err error
+		switch ln := ln.(type) {
+		case *net.TCPListener:
+			f, err = ln.File()
+		case *net.UnixListener:
+			f, err = ln.File()
+			// The new process serves on the socket after this one closes
+			ln.SetUnlinkOnClose(false)
+		default:
+			return fmt.Errorf("upgrade: unsupported listener type %T", ln)
+		}
+		if err != nil {
+			return fmt.Errorf("upgrade: failed to get listener file: %w", err)
+		}
+		// ExtraFiles start at descriptor 3
+		fds = append(fds, strconv.Itoa(3+len(files)))
+		files = append(files, f)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("upgrade: failed to create ready pipe: %w", err)
+	}
+	defer readyR.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		readyW.Close()
+		return fmt.Errorf("upgrade: failed to locate executable: %w", err)
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, readyW)
+	cmd.Env = append(filterEnv(os.Environ()),
+		envListenerFD+"="+strings.Join(fds, ","),
+		envReadyFD+"="+strconv.Itoa(3+len(files)),
+	)
+
+	if err := cmd.Start(); err != nil {
+		readyW.Close()
+		return fmt.Errorf("upgrade: failed to start new process: %w", err)
+	}
+	readyW.Close()
+
+	log.Printf("Upgrade: Started new process (pid %d), waiting for readiness", cmd.Process.Pid)
+
+	result := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := readyR.Read(buf); err != nil {
+			result <- fmt.Errorf("upgrade: new process exited before becoming ready: %w", err)
+			return
+		}
+		result <- nil
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			cmd.Process.Kill()
+			return err
+		}
+	case <-time.After(readyTimeout):
+		cmd.Process.Kill()
+		return fmt.Errorf("upgrade: new process not ready after %s", readyTimeout)
+	}
+
+	// The child outlives us; release it so it isn't reaped as ours
+	cmd.Process.Release()
+
+	log.Printf("Upgrade: New process is ready")
+	return nil
+}
+
+// filterEnv removes upgrade variables inherited from a previous upgrade, and
+// the systemd watchdog PID so the new process takes over the watchdog.
+func filterEnv(env []string) []string {
+	filtered := make([]string, 0, len(env))
+	for _, kv := range env {
+		if strings.HasPrefix(kv, envListenerFD+"=") || strings.HasPrefix(kv, envReadyFD+"=") || strings.HasPrefix(kv, "WATCHDOG_PID=") {
+			continue
+		}
+		filtered = append(filtered, kv)
+	}
+	return filtered
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package upgrade
+
+import (
+	"net"
+	"os"
+)
+
+// Signal is nil on platforms without upgrade support.
+var Signal os.Signal
+
+// listenReusePort opens a plain TCP listener.
+func listenReusePort(addr string) (net.Listener, error) {
+	return net.Listen("tcp", addr)
+}
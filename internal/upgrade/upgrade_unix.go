@@ -0,0 +1,33 @@
+//go:build unix
+
+package upgrade
+
+import (
+	"context"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Signal triggers a zero-downtime upgrade.
+var Signal os.Signal = syscall.SIGUSR2
+
+// listenReusePort opens a TCP listener with SO_REUSEPORT set, so a
+// replacement process can bind the same address while this one drains.
+func listenReusePort(addr string) (net.Listener, error) {
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var sockErr error
+			err := c.Control(func(fd uintptr) {
+				sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return sockErr
+		},
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
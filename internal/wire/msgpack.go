@@ -0,0 +1,241 @@
+// Package wire provides the binary response encodings offered alongside JSON.
+package wire
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MarshalMsgpack encodes v as MessagePack. Struct fields follow their json
+// tags (names, omitempty, omitzero and "-"), so both encodings share the same schema;
+// times are encoded as RFC 3339 strings like in JSON. Go float and integer
+// types keep their MessagePack float and int types.
+func MarshalMsgpack(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeValue(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// writeValue encodes a single value.
+func writeValue(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+
+	if v.Type() == timeType {
+		writeString(buf, v.Interface().(time.Time).Format(time.RFC3339Nano))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return writeValue(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			buf.WriteByte(0xcf)
+			binary.Write(buf, binary.BigEndian, v.Uint())
+			return nil
+		}
+		writeInt(buf, int64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v.Float()))
+	case reflect.String:
+		writeString(buf, v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		writeLength(buf, v.Len(), 0x90, 0xdc, 0xdd)
+		for i := range v.Len() {
+			if err := writeValue(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return writeMap(buf, v)
+	case reflect.Struct:
+		return writeStruct(buf, v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// writeMap encodes a map with string (or text-marshalable) keys, sorted.
+func writeMap(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key, iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	writeLength(buf, len(entries), 0x80, 0xde, 0xdf)
+	for _, e := range entries {
+		writeString(buf, e.key)
+		if err := writeValue(buf, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapKey converts a map key to its string form.
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if m, ok := k.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+	return "", fmt.Errorf("msgpack: unsupported map key type %s", k.Type())
+}
+
+// writeStruct encodes a struct as a map keyed by its json field names.
+func writeStruct(buf *bytes.Buffer, v reflect.Value) error {
+	type field struct {
+		name  string
+		value reflect.Value
+	}
+
+	var fields []field
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		fv := v.Field(i)
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmpty(fv) {
+			continue
+		}
+		if strings.Contains(","+opts+",", ",omitzero,") && fv.IsZero() {
+			continue
+		}
+		fields = append(fields, field{name, fv})
+	}
+
+	writeLength(buf, len(fields), 0x80, 0xde, 0xdf)
+	for _, f := range fields {
+		writeString(buf, f.name)
+		if err := writeValue(buf, f.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isEmpty reports whether a value is empty in the encoding/json omitempty sense.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// writeInt encodes an integer in its most compact form.
+func writeInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= 0x7f:
+		buf.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+// writeString encodes a UTF-8 string.
+func writeString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n <= 31:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.WriteString(s)
+}
+
+// writeLength encodes an array or map header: fixed form up to 15 entries,
+// then the 16- and 32-bit forms.
+func writeLength(buf *bytes.Buffer, n int, fixed, len16, len32 byte) {
+	switch {
+	case n <= 15:
+		buf.WriteByte(fixed | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(len16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(len32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
@@ -0,0 +1,173 @@
+package wire
+
+import (
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+// The encoders below follow api/rates.proto. Fields holding zero values are
+// omitted, as proto3 requires for non-optional scalars.
+
+// MarshalRates encodes the /rates payload as a veswatch.v1.Rates message.
+func MarshalRates(d rates.RateData) []byte {
+	var b []byte
+	b = appendDouble(b, 1, d.BCV)
+	b = appendDouble(b, 2, d.Binance)
+	if d.Breach != nil {
+		b = appendOptionalDouble(b, 3, *d.Breach)
+	}
+	b = appendTime(b, 4, d.UpdatedAt)
+	b = appendInt64(b, 5, d.Epoch)
+	b = appendString(b, 6, d.BinanceFallback)
+	b = appendString(b, 7, d.Region)
+	b = appendString(b, 8, d.Status)
+	b = appendString(b, 9, d.BCVMirror)
+	return b
+}
+
+// MarshalRatesV1 encodes the /v1/rates payload as a veswatch.v1.RatesV1 message.
+func MarshalRatesV1(v rates.RatesV1) []byte {
+	var b []byte
+	b = appendMessage(b, 1, sourceRate(v.BCV))
+	b = appendMessage(b, 2, parallelRate(v.Parallel))
+	if v.Breach != nil {
+		b = appendOptionalDouble(b, 3, *v.Breach)
+	}
+	b = appendTime(b, 4, v.UpdatedAt)
+	b = appendInt64(b, 5, v.Epoch)
+	for _, w := range v.Warnings {
+		b = appendMessage(b, 6, warning(w))
+	}
+	if v.Region != nil {
+		b = appendMessage(b, 7, region(*v.Region))
+	}
+	if v.Zelle != nil {
+		b = appendMessage(b, 8, sourceRate(*v.Zelle))
+	}
+	if v.Cash != nil {
+		b = appendMessage(b, 9, sourceRate(*v.Cash))
+	}
+	b = appendString(b, 10, v.Status)
+	return b
+}
+
+func region(r rates.Region) []byte {
+	var b []byte
+	b = appendString(b, 1, r.Name)
+	for _, c := range r.Countries {
+		b = appendString(b, 2, c)
+	}
+	for _, p := range r.PayTypes {
+		b = appendString(b, 3, p)
+	}
+	return b
+}
+
+func warning(w rates.Warning) []byte {
+	var b []byte
+	b = appendString(b, 1, w.Source)
+	b = appendString(b, 2, string(w.Kind))
+	b = appendTime(b, 3, w.Since)
+	b = appendString(b, 4, w.Message)
+	return b
+}
+
+func parallelRate(p rates.ParallelRate) []byte {
+	var b []byte
+	b = appendDouble(b, 1, p.Rate)
+	b = appendString(b, 2, p.Method)
+	for _, src := range p.Sources {
+		b = appendMessage(b, 3, sourceRate(src))
+	}
+	return b
+}
+
+func sourceRate(s rates.SourceRate) []byte {
+	var b []byte
+	b = appendString(b, 1, s.Name)
+	b = appendDouble(b, 2, s.Rate)
+	b = appendTime(b, 3, s.UpdatedAt)
+	if s.Deviation != nil {
+		b = appendOptionalDouble(b, 4, *s.Deviation)
+	}
+	b = appendBool(b, 5, s.Outlier)
+	b = appendBool(b, 6, s.Stale)
+	if s.Confidence != nil {
+		b = appendMessage(b, 7, confidence(*s.Confidence))
+	}
+	b = appendString(b, 8, s.Method)
+	b = appendString(b, 9, s.Fallback)
+	b = appendString(b, 10, s.Provenance)
+	b = appendTime(b, 11, s.PinnedUntil)
+	if s.Breach != nil {
+		b = appendOptionalDouble(b, 12, *s.Breach)
+	}
+	return b
+}
+
+func confidence(c rates.Confidence) []byte {
+	var b []byte
+	b = appendDouble(b, 1, c.Score)
+	b = appendInt64(b, 2, int64(c.SampleSize))
+	if c.Dispersion != nil {
+		b = appendOptionalDouble(b, 3, *c.Dispersion)
+	}
+	if c.SuccessRate != nil {
+		b = appendOptionalDouble(b, 4, *c.SuccessRate)
+	}
+	return b
+}
+
+func appendDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	return appendOptionalDouble(b, num, v)
+}
+
+// appendOptionalDouble encodes an explicitly present double, even if zero.
+func appendOptionalDouble(b []byte, num protowire.Number, v float64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func appendInt64(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// appendTime encodes a timestamp as an RFC 3339 string, keeping its zone.
+func appendTime(b []byte, num protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	return appendString(b, num, t.Format(time.RFC3339Nano))
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
@@ -0,0 +1,431 @@
+package wire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/veswatch/api/internal/rates"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+// caracas is the zone timestamps are rendered in, to check it's kept.
+var caracas = time.FixedZone("VET", -4*60*60)
+
+var rateData = rates.RateData{
+	BCV:             36.5,
+	Binance:         38.91,
+	Breach:          ptr(6.6),
+	UpdatedAt:       time.Date(2026, 1, 15, 9, 30, 0, 500, caracas),
+	Epoch:           1768483800,
+	Status:          "ok",
+	BinanceFallback: "yadio",
+	BCVMirror:       "dolarapi",
+	Region:          "ve",
+}
+
+var ratesV1 = rates.RatesV1{
+	BCV: rates.SourceRate{
+		Name:       "bcv",
+		Rate:       36.5,
+		UpdatedAt:  time.Date(2026, 1, 15, 9, 0, 0, 0, caracas),
+		Provenance: "mirror",
+		Fallback:   "dolarapi",
+		Confidence: &rates.Confidence{Score: 0.9, SuccessRate: ptr(1)},
+	},
+	Parallel: rates.ParallelRate{
+		Rate:   38.91,
+		Method: "median",
+		Sources: []rates.SourceRate{
+			{
+				Name:       "binance",
+				Rate:       38.91,
+				Method:     "median",
+				Deviation:  ptr(0), // explicitly present
+				Breach:     ptr(6.6),
+				Confidence: &rates.Confidence{Score: 0.8, SampleSize: 184, Dispersion: ptr(0.4)},
+			},
+			{Name: "yadio", Rate: 41, Deviation: ptr(5.37), Outlier: true, Stale: true, PinnedUntil: time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+		},
+	},
+	Breach:    ptr(6.6),
+	UpdatedAt: time.Date(2026, 1, 15, 9, 30, 0, 0, caracas),
+	Epoch:     1768483800,
+	Warnings: []rates.Warning{
+		{Source: "bcv", Kind: rates.ErrorBlocked, Since: time.Date(2026, 1, 15, 8, 0, 0, 0, caracas), Message: "403 Forbidden"},
+	},
+	Status: "partial",
+	Zelle:  &rates.SourceRate{Name: "zelle", Rate: 37.74, Method: "discount"},
+	Cash:   &rates.SourceRate{Name: "efectivo", Rate: 40, Provenance: "manual"},
+	Region: &rates.Region{Name: "ve", Countries: []string{"VE"}, PayTypes: []string{"Banesco", "Mercantil"}},
+}
+
+// protoField is a field declared in api/rates.proto.
+type protoField struct {
+	name, typ          string
+	optional, repeated bool
+}
+
+var (
+	messageDecl = regexp.MustCompile(`^message (\w+) \{`)
+	fieldDecl   = regexp.MustCompile(`^\s*(optional |repeated )?(\w+) (\w+) = (\d+);`)
+)
+
+// loadSchema reads the messages of api/rates.proto, by field number.
+func loadSchema(t *testing.T) map[string]map[protowire.Number]protoField {
+	t.Helper()
+
+	f, err := os.Open("../../api/rates.proto")
+	if err != nil {
+		t.Fatalf("failed to open rates.proto: %v", err)
+	}
+	defer f.Close()
+
+	schema := make(map[string]map[protowire.Number]protoField)
+	var message string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := messageDecl.FindStringSubmatch(line); m != nil {
+			message = m[1]
+			schema[message] = make(map[protowire.Number]protoField)
+			continue
+		}
+		if line == "}" {
+			message = ""
+			continue
+		}
+		if m := fieldDecl.FindStringSubmatch(line); m != nil && message != "" {
+			num, _ := strconv.Atoi(m[4])
+			schema[message][protowire.Number(num)] = protoField{
+				name:     m[3],
+				typ:      m[2],
+				optional: m[1] == "optional ",
+				repeated: m[1] == "repeated ",
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read rates.proto: %v", err)
+	}
+	return schema
+}
+
+// jsonName returns the JSON name of a proto field, in lowerCamelCase.
+func jsonName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// wireType returns the wire type of a proto field type.
+func wireType(typ string) protowire.Type {
+	switch typ {
+	case "double":
+		return protowire.Fixed64Type
+	case "int64", "bool":
+		return protowire.VarintType
+	default:
+		return protowire.BytesType
+	}
+}
+
+// decodeProto decodes a message against the schema, keyed by the fields'
+// JSON names, with numbers as float64 like encoding/json.
+func decodeProto(t *testing.T, schema map[string]map[protowire.Number]protoField, message string, b []byte) map[string]any {
+	t.Helper()
+
+	fields, ok := schema[message]
+	if !ok {
+		t.Fatalf("message %s isn't in rates.proto", message)
+	}
+	decoded := make(map[string]any)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("%s: invalid tag: %v", message, protowire.ParseError(n))
+		}
+		b = b[n:]
+		field, ok := fields[num]
+		if !ok {
+			t.Fatalf("%s: field %d isn't in rates.proto", message, num)
+		}
+		if want := wireType(field.typ); typ != want {
+			t.Fatalf("%s.%s: wire type %d, want %d", message, field.name, typ, want)
+		}
+
+		var value any
+		switch field.typ {
+		case "double":
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				t.Fatalf("%s.%s: %v", message, field.name, protowire.ParseError(n))
+			}
+			b, value = b[n:], math.Float64frombits(v)
+		case "int64", "bool":
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				t.Fatalf("%s.%s: %v", message, field.name, protowire.ParseError(n))
+			}
+			b = b[n:]
+			if field.typ == "bool" {
+				value = protowire.DecodeBool(v)
+			} else {
+				value = float64(int64(v))
+			}
+		default:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatalf("%s.%s: %v", message, field.name, protowire.ParseError(n))
+			}
+			b = b[n:]
+			if field.typ == "string" {
+				value = string(v)
+			} else {
+				value = decodeProto(t, schema, field.typ, v)
+			}
+		}
+
+		// proto3 leaves out scalars holding their default value
+		if !field.optional && !field.repeated && reflect.ValueOf(value).IsZero() {
+			if _, ok := value.(map[string]any); !ok {
+				t.Errorf("%s.%s: default value %v is encoded", message, field.name, value)
+			}
+		}
+
+		key := jsonName(field.name)
+		if field.repeated {
+			list, _ := decoded[key].([]any)
+			decoded[key] = append(list, value)
+			continue
+		}
+		if _, dup := decoded[key]; dup {
+			t.Errorf("%s.%s: encoded twice", message, field.name)
+		}
+		decoded[key] = value
+	}
+	return decoded
+}
+
+// prune removes from a JSON object the fields proto3 leaves out: scalars
+// holding their default value, unless optional, and empty lists. It fails
+// on fields the message doesn't declare.
+func prune(t *testing.T, schema map[string]map[protowire.Number]protoField, message string, obj map[string]any) map[string]any {
+	t.Helper()
+
+	byName := make(map[string]protoField)
+	for _, f := range schema[message] {
+		byName[jsonName(f.name)] = f
+	}
+	for key, value := range obj {
+		field, ok := byName[key]
+		if !ok {
+			t.Errorf("%s: JSON field %q isn't in rates.proto", message, key)
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			obj[key] = prune(t, schema, field.typ, v)
+		case []any:
+			if len(v) == 0 {
+				delete(obj, key)
+			}
+			for i, elem := range v {
+				if m, ok := elem.(map[string]any); ok {
+					v[i] = prune(t, schema, field.typ, m)
+				}
+			}
+		default:
+			if value == nil || !field.optional && reflect.ValueOf(value).IsZero() {
+				delete(obj, key)
+			}
+		}
+	}
+	return obj
+}
+
+// jsonObject returns v as decoded from its JSON encoding.
+func jsonObject(t *testing.T, v any) map[string]any {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal JSON: %v", err)
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	return obj
+}
+
+func TestProtoMatchesSchema(t *testing.T) {
+	schema := loadSchema(t)
+
+	tests := []struct {
+		name    string
+		message string
+		value   any
+		encoded []byte
+	}{
+		{"rates", "Rates", rateData, MarshalRates(rateData)},
+		{"v1", "RatesV1", ratesV1, MarshalRatesV1(ratesV1)},
+		{"empty rates", "Rates", rates.RateData{}, MarshalRates(rates.RateData{})},
+		{"empty v1", "RatesV1", rates.RatesV1{}, MarshalRatesV1(rates.RatesV1{})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodeProto(t, schema, tt.message, tt.encoded)
+			want := prune(t, schema, tt.message, jsonObject(t, tt.value))
+			if !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(want)
+				t.Errorf("protobuf and JSON differ:\ngot  %s\nwant %s", gotJSON, wantJSON)
+			}
+		})
+	}
+
+	// An explicitly present zero survives
+	v1 := decodeProto(t, schema, "RatesV1", MarshalRatesV1(ratesV1))
+	source := v1["parallel"].(map[string]any)["sources"].([]any)[0].(map[string]any)
+	if deviation, ok := source["deviation"]; !ok || deviation != 0.0 {
+		t.Errorf("deviation = %v (present %t), want an explicit 0", deviation, ok)
+	}
+}
+
+// decodeMsgpack decodes the MessagePack value at the start of b, with
+// numbers as float64 like encoding/json, and returns the rest of b.
+func decodeMsgpack(t *testing.T, b []byte) (any, []byte) {
+	t.Helper()
+
+	if len(b) == 0 {
+		t.Fatal("msgpack: unexpected end of input")
+	}
+	take := func(n int) []byte {
+		if len(b) < n {
+			t.Fatal("msgpack: unexpected end of input")
+		}
+		data := b[:n]
+		b = b[n:]
+		return data
+	}
+	c := take(1)[0]
+
+	var length int
+	switch {
+	case c <= 0x7f:
+		return float64(c), b
+	case c >= 0xe0:
+		return float64(int8(c)), b
+	case c >= 0xa0 && c <= 0xbf:
+		return string(take(int(c & 0x1f))), b
+	case c >= 0x90 && c <= 0x9f:
+		return decodeArray(t, int(c&0x0f), b)
+	case c >= 0x80 && c <= 0x8f:
+		return decodeMap(t, int(c&0x0f), b)
+	}
+	switch c {
+	case 0xc0:
+		return nil, b
+	case 0xc2, 0xc3:
+		return c == 0xc3, b
+	case 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(take(8))), b
+	case 0xd2:
+		return float64(int32(binary.BigEndian.Uint32(take(4)))), b
+	case 0xd3:
+		return float64(int64(binary.BigEndian.Uint64(take(8)))), b
+	case 0xcf:
+		return float64(binary.BigEndian.Uint64(take(8))), b
+	case 0xd9:
+		return string(take(int(take(1)[0]))), b
+	case 0xda:
+		return string(take(int(binary.BigEndian.Uint16(take(2))))), b
+	case 0xdb:
+		return string(take(int(binary.BigEndian.Uint32(take(4))))), b
+	case 0xdc:
+		length = int(binary.BigEndian.Uint16(take(2)))
+		return decodeArray(t, length, b)
+	case 0xdd:
+		length = int(binary.BigEndian.Uint32(take(4)))
+		return decodeArray(t, length, b)
+	case 0xde:
+		length = int(binary.BigEndian.Uint16(take(2)))
+		return decodeMap(t, length, b)
+	case 0xdf:
+		length = int(binary.BigEndian.Uint32(take(4)))
+		return decodeMap(t, length, b)
+	}
+	t.Fatalf("msgpack: unexpected type byte %#x", c)
+	return nil, nil
+}
+
+func decodeArray(t *testing.T, n int, b []byte) (any, []byte) {
+	t.Helper()
+	list := make([]any, n)
+	for i := range n {
+		list[i], b = decodeMsgpack(t, b)
+	}
+	return list, b
+}
+
+func decodeMap(t *testing.T, n int, b []byte) (any, []byte) {
+	t.Helper()
+	obj := make(map[string]any, n)
+	for range n {
+		var key, value any
+		key, b = decodeMsgpack(t, b)
+		name, ok := key.(string)
+		if !ok {
+			t.Fatalf("msgpack: map key %v isn't a string", key)
+		}
+		value, b = decodeMsgpack(t, b)
+		if _, dup := obj[name]; dup {
+			t.Errorf("msgpack: key %q encoded twice", name)
+		}
+		obj[name] = value
+	}
+	return obj, b
+}
+
+func TestMsgpackMatchesJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"rates", rateData},
+		{"v1", ratesV1},
+		{"empty rates", rates.RateData{}},
+		{"empty v1", rates.RatesV1{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := MarshalMsgpack(tt.value)
+			if err != nil {
+				t.Fatalf("MarshalMsgpack: %v", err)
+			}
+			got, rest := decodeMsgpack(t, encoded)
+			if len(rest) > 0 {
+				t.Errorf("%d trailing bytes", len(rest))
+			}
+			if want := jsonObject(t, tt.value); !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(want)
+				t.Errorf("MessagePack and JSON differ:\ngot  %s\nwant %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
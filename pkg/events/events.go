@@ -0,0 +1,74 @@
+// Package events defines the payloads of the events VESWatch publishes to
+// its notification channels, e.g. the data of Web Push messages, so
+// integrators can decode them into stable types:
+//
+//	var msg struct {
+//		Type string          `json:"type"`
+//		Data json.RawMessage `json:"data"`
+//	}
+//	json.Unmarshal(payload, &msg)
+//	if msg.Type == events.TypeBCVUpdate {
+//		var update events.RateUpdated
+//		json.Unmarshal(msg.Data, &update)
+//	}
+//
+// The payloads follow the JSON Schema in api/events.schema.json. Fields are
+// only added within a Version; removing or changing one bumps it.
+package events
+
+import "time"
+
+// Version is the version of the payloads' schema, sent along with every
+// event.
+const Version = 1
+
+// Types of the events with a payload defined here.
+const (
+	TypeBCVUpdate    = "bcv_update"
+	TypeRateRejected = "rate_rejected"
+	TypeSourceError  = "source_error"
+	TypeDailyClose   = "daily_close"
+)
+
+// RateUpdated is the payload of bcv_update events: BCV published a rate
+// different from the previous one.
+type RateUpdated struct {
+	Source   string  `json:"source"`
+	Rate     float64 `json:"rate"`
+	Previous float64 `json:"previous"`
+
+	// Mirror names the copy of the source the rate was read from while the
+	// source was unreachable.
+	Mirror string `json:"mirror,omitempty"`
+}
+
+// RateRejected is the payload of rate_rejected events: a source started
+// reporting rates that fail validation, which are discarded.
+type RateRejected struct {
+	Source   string  `json:"source"`
+	Rate     float64 `json:"rate"`
+	Previous float64 `json:"previous"`
+	Reason   string  `json:"reason"`
+}
+
+// SourceDegraded is the payload of source_error events: a source started
+// failing, or failed in a different way than before. Kind classifies the
+// error, e.g. network, blocked or rejected.
+type SourceDegraded struct {
+	Source string `json:"source"`
+	Kind   string `json:"kind"`
+	Error  string `json:"error"`
+}
+
+// DailyClose is the payload of daily_close events: the day's closing rates
+// in bolívares per dollar, recorded every evening. Date is the day in
+// Venezuela, as YYYY-MM-DD.
+type DailyClose struct {
+	Date     string    `json:"date"`
+	BCV      float64   `json:"bcv"`
+	Binance  float64   `json:"binance"`
+	High     float64   `json:"high"`
+	Low      float64   `json:"low"`
+	Breach   float64   `json:"breach"`
+	ClosedAt time.Time `json:"closedAt"`
+}
@@ -0,0 +1,154 @@
+// Package format provides locale-aware money formatting for Venezuelan
+// bolívares and the foreign currencies quoted against them.
+//
+//	format.Money(1234567.89, "VES", "es-VE") // "Bs. 1.234.567,89"
+//	format.Money(1234.5, "USD", "en-US")     // "$1,234.50"
+package format
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Formatting errors.
+var (
+	ErrUnknownLocale   = errors.New("unknown locale")
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+)
+
+// DefaultLocale is the locale used when none is specified.
+const DefaultLocale = "es-VE"
+
+// Locale describes number formatting conventions.
+type Locale struct {
+	Tag          string
+	Decimal      string
+	Group        string
+	SymbolSpaced bool
+}
+
+// Currency describes a currency's symbol and precision.
+// Spaced symbols (such as "Bs.") are always separated from the number.
+type Currency struct {
+	Code     string
+	Symbol   string
+	Decimals int
+	Spaced   bool
+}
+
+var locales = map[string]Locale{
+	"es-VE": {Tag: "es-VE", Decimal: ",", Group: ".", SymbolSpaced: true},
+	"es-ES": {Tag: "es-ES", Decimal: ",", Group: ".", SymbolSpaced: true},
+	"en-US": {Tag: "en-US", Decimal: ".", Group: ",", SymbolSpaced: false},
+}
+
+var currencies = map[string]Currency{
+	"VES":  {Code: "VES", Symbol: "Bs.", Decimals: 2, Spaced: true},
+	"USD":  {Code: "USD", Symbol: "$", Decimals: 2},
+	"EUR":  {Code: "EUR", Symbol: "€", Decimals: 2},
+	"USDT": {Code: "USDT", Symbol: "USDT", Decimals: 2, Spaced: true},
+}
+
+// LookupLocale returns the locale for a BCP 47 tag such as "es-VE".
+// Matching is case-insensitive and accepts "_" as separator.
+func LookupLocale(tag string) (Locale, error) {
+	if tag == "" {
+		tag = DefaultLocale
+	}
+
+	normalized := strings.ReplaceAll(tag, "_", "-")
+	for key, l := range locales {
+		if strings.EqualFold(key, normalized) {
+			return l, nil
+		}
+	}
+
+	return Locale{}, fmt.Errorf("%w: %s", ErrUnknownLocale, tag)
+}
+
+// LookupCurrency returns the currency for an ISO 4217 code such as "VES".
+func LookupCurrency(code string) (Currency, error) {
+	c, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+	}
+	return c, nil
+}
+
+// Number formats an amount with the locale's separators and the given
+// number of decimals, e.g. "1.234.567,89" for es-VE.
+func Number(amount float64, decimals int, locale string) (string, error) {
+	l, err := LookupLocale(locale)
+	if err != nil {
+		return "", err
+	}
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return "", ErrInvalidAmount
+	}
+
+	return formatNumber(amount, decimals, l), nil
+}
+
+// Money formats an amount as a currency string, e.g. "Bs. 1.234.567,89"
+// for VES in es-VE. Negative amounts are prefixed with "-".
+func Money(amount float64, currency, locale string) (string, error) {
+	l, err := LookupLocale(locale)
+	if err != nil {
+		return "", err
+	}
+	c, err := LookupCurrency(currency)
+	if err != nil {
+		return "", err
+	}
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return "", ErrInvalidAmount
+	}
+
+	number := formatNumber(math.Abs(amount), c.Decimals, l)
+
+	sign := ""
+	if amount < 0 && number != formatNumber(0, c.Decimals, l) {
+		sign = "-"
+	}
+
+	sep := ""
+	if l.SymbolSpaced || c.Spaced {
+		sep = " "
+	}
+
+	return sign + c.Symbol + sep + number, nil
+}
+
+// formatNumber renders an amount using the locale's separators.
+func formatNumber(amount float64, decimals int, l Locale) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+
+	s := strconv.FormatFloat(math.Abs(amount), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if amount < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+
+	// Insert group separators every three digits
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(l.Group)
+		}
+		b.WriteRune(digit)
+	}
+
+	if fracPart != "" {
+		b.WriteString(l.Decimal)
+		b.WriteString(fracPart)
+	}
+
+	return b.String()
+}
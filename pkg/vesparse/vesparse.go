@@ -0,0 +1,163 @@
+// Package vesparse parses numbers written in Venezuelan (and international)
+// notation, as found on BCV publications, bank statements and P2P listings.
+//
+//	vesparse.Parse("Bs. 1.234.567,89") // 1234567.89
+//	vesparse.Parse("36,12345678")      // 36.12345678
+//	vesparse.Parse("1,234,567.89")     // 1234567.89
+//	vesparse.Parse("(45,82)")          // -45.82
+//
+// The parser guarantees that it never panics, that every successful result
+// is a finite number, and that formatting a result with the same decimal
+// separator and parsing it again yields the same value.
+package vesparse
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parse errors.
+var (
+	ErrEmpty   = errors.New("no number found")
+	ErrInvalid = errors.New("invalid number")
+)
+
+// Options controls how ambiguous inputs are resolved.
+type Options struct {
+	// Decimal is the separator assumed when an input such as "1.234" or
+	// "1,234" could be read either way. Defaults to ',' (Venezuelan usage).
+	Decimal rune
+}
+
+// Parse parses a number using Venezuelan conventions for ambiguous input.
+func Parse(s string) (float64, error) {
+	return ParseWithOptions(s, Options{Decimal: ','})
+}
+
+// ParseWithOptions parses a number, resolving ambiguous separators with opts.
+//
+// Currency symbols and surrounding text are ignored. A leading minus sign
+// (before or after a currency symbol) or enclosing parentheses mark a
+// negative value. When both ',' and '.' appear, the last one is the decimal
+// separator. A separator that appears more than once is a grouping separator
+// and must delimit groups of three digits.
+func ParseWithOptions(s string, opts Options) (float64, error) {
+	if opts.Decimal != '.' {
+		opts.Decimal = ','
+	}
+
+	first := strings.IndexFunc(s, isDigit)
+	if first < 0 {
+		return 0, fmt.Errorf("%w in '%s'", ErrEmpty, s)
+	}
+	last := strings.LastIndexFunc(s, isDigit)
+
+	prefix, body, suffix := s[:first], s[first:last+1], s[last+1:]
+
+	negative := strings.ContainsAny(prefix, "-−") ||
+		(strings.Contains(prefix, "(") && strings.Contains(suffix, ")"))
+
+	// Drop whitespace used as digit grouping (e.g. "1 234,56")
+	body = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, body)
+
+	for _, r := range body {
+		if !isDigit(r) && r != ',' && r != '.' {
+			return 0, fmt.Errorf("%w: unexpected '%c' in '%s'", ErrInvalid, r, s)
+		}
+	}
+
+	normalized, err := normalize(body, opts.Decimal)
+	if err != nil {
+		return 0, fmt.Errorf("%w: '%s': %v", ErrInvalid, s, err)
+	}
+
+	value, err := strconv.ParseFloat(normalized, 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("%w: '%s'", ErrInvalid, s)
+	}
+
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// normalize converts a body of digits and separators into a Go float literal.
+func normalize(body string, decimal rune) (string, error) {
+	commas := strings.Count(body, ",")
+	dots := strings.Count(body, ".")
+
+	switch {
+	case commas == 0 && dots == 0:
+		return body, nil
+
+	case commas > 0 && dots > 0:
+		// The separator that appears last is the decimal separator
+		dec, group := ",", "."
+		if strings.LastIndex(body, ".") > strings.LastIndex(body, ",") {
+			dec, group = ".", ","
+		}
+		if strings.Count(body, dec) > 1 {
+			return "", fmt.Errorf("repeated decimal separator")
+		}
+		intPart, frac, _ := strings.Cut(body, dec)
+		if err := checkGroups(intPart, group); err != nil {
+			return "", err
+		}
+		return strings.ReplaceAll(intPart, group, "") + "." + frac, nil
+	}
+
+	sep := ","
+	if dots > 0 {
+		sep = "."
+	}
+
+	// Repeated separator: must be digit grouping
+	if commas > 1 || dots > 1 {
+		if err := checkGroups(body, sep); err != nil {
+			return "", err
+		}
+		return strings.ReplaceAll(body, sep, ""), nil
+	}
+
+	intPart, frac, _ := strings.Cut(body, sep)
+	if intPart == "" || frac == "" {
+		return "", fmt.Errorf("dangling separator")
+	}
+
+	// "1.234" or "1,234" could be either a decimal or a grouped integer
+	ambiguous := len(frac) == 3 && len(intPart) <= 3 && intPart != "0"
+	if ambiguous && sep != string(decimal) {
+		return intPart + frac, nil
+	}
+
+	return intPart + "." + frac, nil
+}
+
+// checkGroups verifies that sep delimits a leading group of 1-3 digits
+// followed by groups of exactly three digits.
+func checkGroups(s, sep string) error {
+	groups := strings.Split(s, sep)
+	if len(groups[0]) < 1 || len(groups[0]) > 3 {
+		return fmt.Errorf("invalid digit grouping")
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return fmt.Errorf("invalid digit grouping")
+		}
+	}
+	return nil
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
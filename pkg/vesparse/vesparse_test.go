@@ -0,0 +1,145 @@
+package vesparse
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/veswatch/api/pkg/format"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"36,50", 36.5},
+		{"36.50", 36.5},
+		{"Bs. 36,50", 36.5},
+		{"Bs 36,50", 36.5},
+		{"Bs.S 36,50", 36.5},
+		{"Bs.D 36,50", 36.5},
+		{"Bs.F 36,50", 36.5},
+		{"VES 36,50", 36.5},
+		{"1.234,56", 1234.56},
+		{"Bs. 1.234.567,89", 1234567.89},
+		{"1,234,567.89", 1234567.89},
+		{"1 234,56", 1234.56},
+		{"36,12345678", 36.12345678},
+		{"1.234", 1234},
+		{"1,234", 1.234},
+		{"0,123", 0.123},
+		{"1.234.567", 1234567},
+		{"42", 42},
+		{"-36,50", -36.5},
+		{"Bs. -36,50", -36.5},
+		{"-Bs. 36,50", -36.5},
+		{"(45,82)", -45.82},
+		{"Tasa: 36,50 Bs/USD", 36.5},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	tests := []struct {
+		in      string
+		decimal rune
+		want    float64
+	}{
+		{"1.234", '.', 1.234},
+		{"1,234", '.', 1234},
+		{"1.234", ',', 1234},
+		{"1,234", ',', 1.234},
+		{"1.234,56", '.', 1234.56},
+		{"36.50", '.', 36.5},
+		{"1.234", 0, 1234},
+	}
+	for _, tt := range tests {
+		got, err := ParseWithOptions(tt.in, Options{Decimal: tt.decimal})
+		if err != nil {
+			t.Errorf("ParseWithOptions(%q, %q) failed: %v", tt.in, tt.decimal, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseWithOptions(%q, %q) = %v, want %v", tt.in, tt.decimal, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		in   string
+		want error
+	}{
+		{"", ErrEmpty},
+		{"Bs.", ErrEmpty},
+		{"sin tasa", ErrEmpty},
+		{"36a50", ErrInvalid},
+		{"1,2,3", ErrInvalid},
+		{"1.23.456", ErrInvalid},
+		{"1234.567.890", ErrInvalid},
+		{"1,234.567,8", ErrInvalid},
+		{"1.234,56,7", ErrInvalid},
+		{"1..2", ErrInvalid},
+		{"36 5a0", ErrInvalid},
+		{"1" + strings.Repeat("0", 400), ErrInvalid},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) = %v, %v, want error %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"Bs. 36,50", "36.50", "1.234,56", "1,234,567.89", "(45,82)",
+		"-Bs. 1.234.567,89", "1 234,56", "0,001", "1.234", "",
+	} {
+		f.Add(seed, false)
+	}
+
+	f.Fuzz(func(t *testing.T, s string, dot bool) {
+		opts, locale := Options{Decimal: ','}, "es-VE"
+		if dot {
+			opts, locale = Options{Decimal: '.'}, "en-US"
+		}
+
+		v, err := ParseWithOptions(s, opts)
+		if err != nil {
+			return
+		}
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			t.Fatalf("ParseWithOptions(%q) = %v, not finite", s, v)
+		}
+
+		// Formatting with every decimal the value has parses back exactly
+		decimals := 0
+		if _, frac, ok := strings.Cut(strconv.FormatFloat(v, 'f', -1, 64), "."); ok {
+			decimals = len(frac)
+		}
+		formatted, err := format.Number(v, decimals, locale)
+		if err != nil {
+			t.Fatalf("format.Number(%v) failed: %v", v, err)
+		}
+		back, err := ParseWithOptions(formatted, opts)
+		if err != nil {
+			t.Fatalf("ParseWithOptions(%q) of %q failed: %v", formatted, s, err)
+		}
+		if back != v {
+			t.Fatalf("ParseWithOptions(%q) = %v, parsed from %q as %v", formatted, back, s, v)
+		}
+	})
+}